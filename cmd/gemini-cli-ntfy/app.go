@@ -1,15 +1,28 @@
 package main
 
 import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/control"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/interfaces"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/monitor"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/process"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/session"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/systemd"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/terminal"
 )
 
 // Dependencies holds all the dependencies for the application
@@ -18,54 +31,1008 @@ type Dependencies struct {
 	Notifier       notification.Notifier
 	OutputMonitor  interfaces.DataHandler
 	ProcessManager *process.Manager
+	QuietNotifier  *notification.QuietNotifier
+	Messages       notification.Messages
+	History        *notification.HistoryNotifier
+	Subscriber     *notification.Subscriber
+	ControlSocket  *control.SocketServer
+	Receiver       *control.Receiver
+	StuckWatcher   *monitor.StuckWatcher
+	FileWatcher    *monitor.FileWatcher
+	CommitWatcher  *monitor.CommitWatcher
+	Resources      *process.ResourceWatchdog
+	ContextWatcher *monitor.ContextWatcher
+	ToolWatcher    *monitor.ToolWatcher
+	Progress       *monitor.ProgressWatcher
+	Budget         *monitor.Budget
+	Escalation     *notification.EscalatingNotifier
+	Heartbeat      *monitor.Heartbeat
+	MessageWatcher *monitor.MessageWatcher
+	Responses      *monitor.ResponseTracker
+	Sessions       *session.Registry
+	TerminalOutput *terminal.Output
+	StatusLine     *terminal.StatusLine
+	TitleMarker    *terminal.TitleMarker
+	Flash          *terminal.Flash
+	Overlay        *terminal.Overlay
+	EventHooks     *notification.EventHooks
+	EventStream    *notification.EventStream
+	replyNotifier  notification.Notifier
+	journal        *systemd.JournalWriter
+	eventsFile     *os.File
 	stopChan       chan struct{}
+	// Set once a Gemini CLI hook has reported an event
+	hookEvents atomic.Bool
+	// Fires when the session has run for max_session_duration
+	durationTimer *time.Timer
+	// Set when Gemini was stopped for running too long, which isn't a crash
+	stoppedForDuration atomic.Bool
+	// Set when the input just submitted was a slash command that changes
+	// the session, until the input handler sees it
+	slashCommand atomic.Bool
+	// Set once the user asked Gemini to quit
+	quitRequested atomic.Bool
+	// Log of the session's output, if configured
+	outputLog *session.OutputLog
+	tee       *session.OutputLog
+	// Follows what the user types, if capture_prompt is set
+	promptCapture *monitor.PromptCapture
+	// Model asked for in Gemini's arguments or settings, if any
+	requestedModel string
+	// When each class of API error was last reported
+	apiErrorsMu   sync.Mutex
+	apiErrorsSent map[monitor.APIErrorClass]time.Time
+	// When a build error was last reported
+	buildErrorMu   sync.Mutex
+	buildErrorSent time.Time
+	// The CLI's message that a newer version is available, once seen
+	update atomic.Pointer[string]
+	// Responses Gemini has completed, for heartbeats
+	turns atomic.Int64
+	// When the wrapped process started, for the daily digest
+	started time.Time
+	// The working tree before the wrapped process started, if in a repository
+	workTree *notification.GitWorkTree
+
+	// Short identifier of the session, e.g. the project directory's name
+	SessionID string
+	// ntfy topic notifications go to, which may be specific to the session
+	topic string
+	// Scope that notification buttons are signed for, so they only work
+	// for this run of the session
+	controlScope string
 }
 
 // NewDependencies creates all dependencies with the given configuration
 func NewDependencies(cfg *config.Config) (*Dependencies, error) {
+	return newDependencies(cfg, nil)
+}
+
+// newDependencies creates all dependencies, sending notifications to backend
+// instead of the configured services if it is set
+func newDependencies(cfg *config.Config, backend notification.Notifier) (*Dependencies, error) {
 	deps := &Dependencies{
 		Config:   cfg,
 		stopChan: make(chan struct{}),
 	}
 
+	// Serialize the child's output with the wrapper's own terminal UI
+	deps.TerminalOutput = terminal.NewOutput(os.Stdout)
+	deps.Flash = terminal.NewFlash()
+
+	// Built-in notification text in the user's language
+	locale := cfg.Locale
+	if locale == "" {
+		locale = notification.DetectLocale()
+	}
+	deps.Messages = notification.LoadMessages(locale, cfg.Messages)
+
+	// Write machine-readable events for other tools if asked to
+	if cfg.EventsFile != "" {
+		// #nosec G304 -- The path is given by the user, and may be a FIFO
+		f, err := os.OpenFile(cfg.EventsFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open events file: %w", err)
+		}
+		deps.eventsFile = f
+		deps.EventStream = notification.NewEventStream(f)
+	}
+
+	// Identify the session so its notifications can be told apart
+	cwd, _ := os.Getwd()
+	deps.SessionID = session.ID(cfg.SessionName, cwd)
+	deps.topic = cfg.NtfyTopic
+	if cfg.SessionTopic == "suffix" && deps.topic != "" {
+		deps.topic = session.Topic(deps.topic, deps.SessionID)
+	}
+	if cfg.ControlSecret != "" {
+		deps.controlScope = controlScope(deps.SessionID)
+	}
+
 	// Create notification components
-	baseNotifier := notification.NewNtfyClient(cfg.NtfyServer, cfg.NtfyTopic)
+	baseNotifier := notification.NewNtfyClient(cfg.NtfyServer, deps.topic)
+	baseNotifier.SetTags(cfg.Tags)
+	baseNotifier.SetTimeouts(cfg.NtfyTimeout, cfg.NtfyConnectTimeout)
+
+	// Authenticate to self-hosted servers with a client certificate if configured
+	var tlsConfig *tls.Config
+	if cfg.NtfyClientCert != "" || cfg.NtfyCACert != "" {
+		var err error
+		tlsConfig, err = notification.NewTLSConfig(cfg.NtfyClientCert, cfg.NtfyClientKey, cfg.NtfyCACert)
+		if err != nil {
+			return nil, err
+		}
+		baseNotifier.SetTLSConfig(tlsConfig)
+	}
+
+	// Heuristics for the wrapped CLI
+	profile := cfg.ActiveProfile(cfg.GeminiPath)
+	promptPatterns, err := profile.CompilePromptPatterns()
+	if err != nil {
+		return nil, err
+	}
+	busyPatterns, err := profile.CompileBusyPatterns()
+	if err != nil {
+		return nil, err
+	}
+	modelPatterns, err := profile.CompileModelPatterns()
+	if err != nil {
+		return nil, err
+	}
+	compressionPatterns, err := profile.CompileCompressionPatterns()
+	if err != nil {
+		return nil, err
+	}
+	contextLeftPattern, err := profile.CompileContextLeftPattern()
+	if err != nil {
+		return nil, err
+	}
+	mcpErrorPatterns, err := profile.CompileMCPErrorPatterns()
+	if err != nil {
+		return nil, err
+	}
+	apiErrorPatterns, err := profile.CompileAPIErrorPatterns()
+	if err != nil {
+		return nil, err
+	}
+	toolRunningPattern, toolDonePattern, err := profile.CompileToolPatterns()
+	if err != nil {
+		return nil, err
+	}
+	updatePatterns, err := profile.CompileUpdatePatterns()
+	if err != nil {
+		return nil, err
+	}
+	fileEditPattern, err := profile.CompileFileEditPattern()
+	if err != nil {
+		return nil, err
+	}
+	tokenUsagePattern, err := profile.CompileTokenUsagePattern()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create output monitor with stdout notifier temporarily
 	outputMonitor := monitor.NewOutputMonitor(cfg, notification.NewStdoutNotifier())
+	outputMonitor.SetPromptPatterns(promptPatterns)
+	outputMonitor.SetBusyPatterns(busyPatterns)
+	outputMonitor.SetModelPatterns(modelPatterns)
+
+	// Push notifications go to ntfy and any other configured services
+	var pushNotifiers []notification.Notifier
+	if cfg.NtfyTopic != "" {
+		pushNotifiers = append(pushNotifiers, baseNotifier)
+	}
+	if cfg.WebhookURL != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret))
+	}
+	if cfg.HomeAssistantURL != "" {
+		if cfg.HomeAssistantWebhookID != "" {
+			pushNotifiers = append(pushNotifiers, notification.NewHomeAssistantWebhookNotifier(cfg.HomeAssistantURL, cfg.HomeAssistantWebhookID))
+		} else {
+			homeAssistantNotifier := notification.NewHomeAssistantNotifier(cfg.HomeAssistantURL, cfg.HomeAssistantToken, cfg.HomeAssistantService)
+			homeAssistantNotifier.SetGroupBySession(cfg.GroupBySession)
+			pushNotifiers = append(pushNotifiers, homeAssistantNotifier)
+		}
+	}
+	if cfg.TeamsWebhookURL != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewTeamsNotifier(cfg.TeamsWebhookURL))
+	}
+	if cfg.BarkDeviceKey != "" {
+		barkNotifier := notification.NewBarkNotifier(cfg.BarkServer, cfg.BarkDeviceKey)
+		barkNotifier.SetSound(cfg.BarkSound)
+		barkNotifier.SetGroup(cfg.BarkGroup)
+		barkNotifier.SetGroupBySession(cfg.GroupBySession)
+		pushNotifiers = append(pushNotifiers, barkNotifier)
+	}
+	if cfg.SignalRPC != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewSignalNotifier(cfg.SignalRPC, cfg.SignalAccount, cfg.SignalRecipients, cfg.SignalGroupID))
+	}
+	if cfg.ZulipSite != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewZulipNotifier(cfg.ZulipSite, cfg.ZulipEmail, cfg.ZulipAPIKey, cfg.ZulipStream, cfg.ZulipTopic))
+	}
+	if cfg.MattermostWebhookURL != "" {
+		mattermostNotifier := notification.NewMattermostNotifier(cfg.MattermostWebhookURL)
+		mattermostNotifier.SetChannel(cfg.MattermostChannel)
+		mattermostNotifier.SetUsername(cfg.MattermostUsername)
+		pushNotifiers = append(pushNotifiers, mattermostNotifier)
+	}
+	if cfg.TwilioAccountSID != "" {
+		minPriority, err := notification.ParsePriority(cfg.TwilioMinPriority)
+		if err != nil {
+			return nil, err
+		}
+		twilioNotifier := notification.NewTwilioNotifier(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFrom, cfg.TwilioTo)
+		pushNotifiers = append(pushNotifiers, notification.NewMinPriorityNotifier(twilioNotifier, minPriority))
+	}
+	// On-call services only get the most urgent types, e.g. crashes
+	if cfg.PagerDutyRoutingKey != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewTypeAllowNotifier(notification.NewPagerDutyNotifier(cfg.PagerDutyRoutingKey), cfg.IncidentTypes))
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		pushNotifiers = append(pushNotifiers, notification.NewTypeAllowNotifier(notification.NewOpsgenieNotifier(cfg.OpsgenieURL, cfg.OpsgenieAPIKey), cfg.IncidentTypes))
+	}
+	var pushNotifier notification.Notifier = baseNotifier
+	if len(pushNotifiers) == 1 {
+		pushNotifier = pushNotifiers[0]
+	} else if len(pushNotifiers) > 1 {
+		pushNotifier = notification.NewMultiNotifier(pushNotifiers...)
+	}
+
+	// Route between push and desktop notifications if configured
+	backendNotifier := pushNotifier
+	if cfg.Routing != notification.RoutePush {
+		idleDetector := monitor.NewSystemIdleDetector()
+		backendNotifier = notification.NewRoutingNotifier(pushNotifier, notification.NewDesktopNotifier(), cfg.Routing, func() bool {
+			if !outputMonitor.IsFocused() {
+				return false
+			}
+			// If the machine's idle time is unknown, trust the focus state alone
+			idle, err := idleDetector.IsUserIdle(cfg.DesktopIdleThreshold)
+			return err != nil || !idle
+		})
+	}
+
+	// A replayed session's notifications only go to the replay
+	if backend != nil {
+		backendNotifier = backend
+	}
+
+	// Report every notification that goes out on the event stream
+	if deps.EventStream != nil {
+		backendNotifier = notification.NewSentNotifier(backendNotifier, deps.EventStream)
+	}
+
+	// Clean up and enforce length limits on the final text, whichever
+	// backend sends it
+	truncatingNotifier := notification.NewTruncatingNotifier(backendNotifier, cfg.MaxTitleLength, cfg.MaxMessageLength)
+	sanitizingNotifier := notification.NewSanitizingNotifier(truncatingNotifier)
+
+	// Attach the screen to the types that are hard to make sense of without it
+	var screenNotifier notification.Notifier = sanitizingNotifier
+	if len(cfg.AttachScreen) > 0 {
+		screenNotifier = notification.NewScreenNotifier(sanitizingNotifier, cfg.AttachScreen, func() []string {
+			return outputMonitor.GetScreenTail(defaultScreenLines)
+		})
+	}
 
 	// Wrap with context notifier
-	contextNotifier := notification.NewContextNotifier(baseNotifier, func() string {
+	contextNotifier := notification.NewContextNotifier(screenNotifier, func() string {
 		return outputMonitor.GetTerminalTitle()
 	})
+	contextNotifier.SetTitleRules(profile.AppName, profile.IgnoredTitles)
+	contextNotifier.SetTitleAffixes(cfg.TitlePrefix, cfg.TitleSuffix)
+	contextNotifier.SetTmuxClickURL(cfg.TmuxClickURL)
+	contextNotifier.SetShowUser(cfg.SSHShowUser)
+	contextNotifier.SetSession(deps.SessionID)
+
+	// Control replies bypass quiet mode so status requests are always answered
+	deps.replyNotifier = contextNotifier
 
-	// Wrap with backstop notifier if configured
-	var finalNotifier notification.Notifier = contextNotifier
-	if cfg.BackstopTimeout > 0 {
-		finalNotifier = notification.NewBackstopNotifier(contextNotifier, cfg.BackstopTimeout)
+	// Follow what the user types, for slash commands and to say what the
+	// session is working on if configured; a CI job's input doesn't pass
+	// through the wrapper
+	var promptNotifier notification.Notifier = contextNotifier
+	if !cfg.CI {
+		deps.promptCapture = monitor.NewPromptCapture()
+		if cfg.CapturePrompt {
+			capturedPrompt := notification.NewPromptNotifier(contextNotifier, deps.promptCapture.LastPrompt)
+			capturedPrompt.SetMessages(deps.Messages)
+			promptNotifier = capturedPrompt
+		}
+	}
+
+	// Attach remote control buttons to notifications
+	actionNotifier := notification.NewActionNotifier(promptNotifier, func(n notification.Notification) []notification.Action {
+		return controlActions(cfg, deps.controlScope, n)
+	})
+
+	// Alert the local terminal too if configured
+	var localNotifier notification.Notifier = actionNotifier
+	if cfg.LocalBell || cfg.LocalUrgency {
+		localNotifier = notification.NewBellNotifier(actionNotifier, func(seq []byte) {
+			deps.TerminalOutput.Do(func(w io.Writer) { _, _ = w.Write(seq) })
+		}, cfg.LocalBell, cfg.LocalUrgency)
+	}
+
+	// Remember what was actually sent, for the settings overlay
+	deps.History = notification.NewHistoryNotifier(localNotifier, 50)
+
+	// Render user-defined notification text if configured
+	var textNotifier notification.Notifier = deps.History
+	if len(cfg.Templates) > 0 {
+		templates := make(map[string]notification.MessageTemplate, len(cfg.Templates))
+		for pattern, t := range cfg.Templates {
+			templates[pattern] = notification.MessageTemplate{Title: t.Title, Message: t.Message}
+		}
+		templateNotifier, err := notification.NewTemplateNotifier(deps.History, templates, deps.templateData)
+		if err != nil {
+			return nil, err
+		}
+		textNotifier = templateNotifier
+	}
+
+	// Limit how many notifications are sent, overall and by type, so a
+	// chatty type can't use up the budget of the others
+	if cfg.RateLimit != "" || len(cfg.RateLimits) > 0 {
+		var overall notification.RateLimit
+		if cfg.RateLimit != "" {
+			limit, err := notification.ParseRateLimit(cfg.RateLimit)
+			if err != nil {
+				return nil, err
+			}
+			overall = limit
+		}
+		limits := make(map[string]notification.RateLimit, len(cfg.RateLimits))
+		for pattern, value := range cfg.RateLimits {
+			limit, err := notification.ParseRateLimit(value)
+			if err != nil {
+				return nil, err
+			}
+			limits[pattern] = limit
+		}
+		textNotifier = notification.NewRateLimitNotifier(textNotifier, overall, limits)
+	}
+
+	// Drop notification types that have been turned off
+	if len(cfg.Notify) > 0 {
+		textNotifier = notification.NewTypeFilterNotifier(textNotifier, cfg.Notify)
+	}
+
+	// Give each type of notification its priority
+	priorities := make(map[string]int, len(notification.DefaultPriorities)+len(cfg.Priorities))
+	for pattern, priority := range notification.DefaultPriorities {
+		priorities[pattern] = priority
+	}
+	for pattern, name := range cfg.Priorities {
+		priority, err := notification.ParsePriority(name)
+		if err != nil {
+			return nil, err
+		}
+		priorities[pattern] = priority
+	}
+
+	// Remind of critical notifications, louder each time, until they are
+	// acknowledged; a CI job has no one at the terminal to wait for
+	if cfg.EscalateAfter > 0 && !cfg.CI {
+		deps.Escalation = notification.NewEscalatingNotifier(textNotifier, cfg.EscalateTypes, cfg.EscalateAfter, cfg.EscalateMax)
+		deps.Escalation.SetMessages(deps.Messages)
+		textNotifier = deps.Escalation
+	}
+	textNotifier = notification.NewPriorityNotifier(textNotifier, priorities)
+
+	// Wrap with quiet notifier so quiet mode can be toggled at runtime
+	deps.QuietNotifier = notification.NewQuietNotifier(textNotifier, cfg.Quiet)
+	// Turning quiet mode on also ends reminders
+	if deps.Escalation != nil {
+		deps.Escalation.SetSuppressedFunc(deps.QuietNotifier.IsSuppressed)
+	}
+
+	// Report idle and pattern_match events to the user's hooks and the
+	// event stream, even in quiet mode
+	var finalNotifier notification.Notifier = deps.QuietNotifier
+	if len(cfg.EventHooks) > 0 {
+		deps.EventHooks = notification.NewEventHooks(cfg.EventHooks)
+	}
+	if deps.EventHooks != nil || deps.EventStream != nil {
+		finalNotifier = notification.NewLifecycleNotifier(deps.QuietNotifier, deps.reportLifecycle)
+	}
+
+	// Say how long both sides have been idle when Gemini waits for the user
+	idleNotifier := notification.NewIdleNotifier(finalNotifier, notification.IdlePatterns, func() (time.Duration, time.Duration) {
+		now := time.Now()
+		return now.Sub(outputMonitor.LastInputTime()), now.Sub(outputMonitor.LastOutputTime())
+	})
+	idleNotifier.SetMessages(deps.Messages)
+	finalNotifier = idleNotifier
+
+	// Wrap with backstop notifier if configured; with hotkeys it is always
+	// present so the timeout can be turned on from the settings overlay
+	if cfg.BackstopTimeout > 0 || cfg.Hotkeys {
+		backstopNotifier := notification.NewBackstopNotifier(finalNotifier, cfg.BackstopTimeout)
+		backstopNotifier.SetMessages(deps.Messages)
+		backstopNotifier.SetInteractionInputs(cfg.InteractionInputKinds())
+		// Show what the CLI is asking, if it recognizably asks something
+		backstopNotifier.SetPromptFunc(outputMonitor.WaitingPrompt)
+		// and whether it stopped in the middle of a task
+		backstopNotifier.SetStalledFunc(outputMonitor.Busy)
+		// and whether it got going again by itself
+		backstopNotifier.SetResumeNotify(cfg.ResumeNotify)
+		finalNotifier = backstopNotifier
 	}
 	deps.Notifier = finalNotifier
 
 	// Update the output monitor with the final notifier
 	outputMonitor.SetNotifier(deps.Notifier)
 	deps.OutputMonitor = outputMonitor
+	if deps.EventStream != nil {
+		deps.OutputMonitor = &activityReporter{OutputMonitor: outputMonitor, stream: deps.EventStream}
+	}
 
-	// Create input handler that disables backstop timer
-	inputHandler := func() {
+	// Reset or finalize the session on slash commands that start another
+	// conversation or quit, which the screen alone doesn't reliably show
+	if deps.promptCapture != nil {
+		commands := monitor.NewSlashCommands(profile.NewConversationCommands, profile.QuitCommands)
+		deps.promptCapture.SetSubmitHook(func(line string) {
+			switch commands.Match(line) {
+			case monitor.SlashNewConversation:
+				deps.slashCommand.Store(true)
+				outputMonitor.ResetConversation()
+				deps.newConversation()
+			case monitor.SlashQuit:
+				deps.slashCommand.Store(true)
+				deps.quitting()
+			}
+		})
+	}
+
+	// Create input handler that records input and disables backstop timer
+	inputHandler := func(kind notification.InputKind) {
+		outputMonitor.MarkInput()
+		// Any input shows the user is back
+		if deps.Escalation != nil && deps.Escalation.Acknowledge() && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: user input (%s) acknowledged the notification\n", kind)
+		}
+		// Enter, or a reply from the phone, submits a prompt; Gemini doesn't
+		// respond to a slash command that changes the session
+		slashCommand := kind == notification.InputEnter && deps.slashCommand.Swap(false)
+		if deps.Responses != nil && !slashCommand && (kind == notification.InputEnter || kind == notification.InputRemote) {
+			deps.Responses.Submit()
+		}
 		if backstopNotifier, ok := deps.Notifier.(*notification.BackstopNotifier); ok {
-			backstopNotifier.DisableBackstopTimer()
-			if os.Getenv("GEMINI_NOTIFY_DEBUG") == "true" {
-				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: user input detected, disabling backstop timer\n")
+			if backstopNotifier.HandleInput(kind) && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: user input (%s) detected, disabling backstop timer\n", kind)
 			}
 		}
 	}
 
-	// Create process manager
-	deps.ProcessManager = process.NewManager(cfg, deps.OutputMonitor, inputHandler)
+	// Log the output and copy it to the tee file if configured; a CI job's
+	// output doesn't pass through the wrapper
+	outputHandler := deps.OutputMonitor
+	if cfg.OutputLog != "" && !cfg.CI {
+		outputLog, err := session.OpenOutputLog(session.DefaultLogDir(), deps.SessionID, os.Getpid(), cfg.OutputLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: output log disabled: %v\n", err)
+		} else {
+			deps.outputLog = outputLog
+			outputHandler = &loggingHandler{DataHandler: deps.OutputMonitor, log: outputLog}
+		}
+	}
+	if cfg.Tee != "" && !cfg.CI {
+		tee, err := session.OpenTee(cfg.Tee, cfg.TeeRaw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: tee disabled: %v\n", err)
+		} else {
+			deps.tee = tee
+			outputHandler = &loggingHandler{DataHandler: outputHandler, log: tee}
+		}
+	}
+
+	// Notify from the events Gemini prints in non-interactive mode, which
+	// make the inactivity heuristics redundant; a CI job's output doesn't
+	// pass through the wrapper
+	if structuredOutput(cfg) && !cfg.CI {
+		parser := monitor.NewStructuredOutput(cfg.OutputFormat, deps.structuredEvent)
+		outputHandler = &structuredHandler{DataHandler: outputHandler, parser: parser}
+		if bn, ok := deps.Notifier.(*notification.BackstopNotifier); ok {
+			bn.SetTimeout(0)
+		}
+	}
+
+	// Create process manager; CI jobs have no terminal to give a PTY
+	if cfg.CI {
+		deps.ProcessManager = process.NewDirectManager(cfg)
+	} else {
+		deps.ProcessManager = process.NewManager(cfg, outputHandler, inputHandler)
+	}
+
+	// Output can bypass the wrapper entirely unless something else draws on
+	// the terminal while Gemini runs. Under systemd there is no terminal, and
+	// output is logged as plain lines instead.
+	if cfg.Supervised {
+		deps.journal = systemd.NewJournalWriter(os.Stdout)
+		deps.ProcessManager.SetOutput(deps.journal)
+		deps.ProcessManager.SetHeadless(true)
+	} else if cfg.ZeroCopyOutput && !drawsOnTerminal(cfg) {
+		deps.ProcessManager.SetOutput(os.Stdout)
+		deps.ProcessManager.SetZeroCopyOutput(true)
+	} else {
+		if cfg.ZeroCopyOutput && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: zero-copy output disabled by terminal UI options\n")
+		}
+		deps.ProcessManager.SetOutput(deps.TerminalOutput)
+	}
+	if cfg.Headless {
+		deps.ProcessManager.SetHeadless(true)
+	}
+
+	// Focus routing and response_ready need the terminal's focus events,
+	// which arrive on stdin
+	if tracksFocus(cfg) {
+		deps.ProcessManager.AddInputFilter(outputMonitor.FilterInput)
+	}
+
+	// Create status line if configured; it is started once the process runs
+	if cfg.StatusLine {
+		deps.StatusLine = terminal.NewStatusLine(deps.TerminalOutput, os.Stdout, deps.statusText)
+		outputMonitor.SetScreenClearHook(deps.StatusLine.Invalidate)
+		deps.Flash.OnShow(deps.StatusLine.Redraw)
+	}
+
+	controller := NewController(deps)
+
+	// Intercept hotkeys if configured; pressing the prefix twice opens the
+	// settings overlay
+	if cfg.Hotkeys {
+		prefix, err := terminal.ParseKey(cfg.HotkeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hotkey prefix: %w", err)
+		}
+
+		deps.Overlay = terminal.NewOverlay(deps.TerminalOutput, deps.overlayLines, func(key byte) bool {
+			return key == prefix || deps.handleOverlayKey(key)
+		})
+		if deps.StatusLine != nil {
+			deps.Overlay.OnClose(deps.StatusLine.Redraw)
+		}
+
+		snapshotKey, err := terminal.ParseKey(cfg.HotkeySnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot hotkey: %w", err)
+		}
+		quietKey, err := terminal.ParseKey(cfg.HotkeyQuiet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hotkey: %w", err)
+		}
+
+		chords := terminal.NewKeyChords(prefix)
+		chords.Bind(prefix, deps.Overlay.Open)
+		// Send in the background so typing is never held up by the network
+		chords.Bind(snapshotKey, func() { go controller.Snapshot() })
+		chords.Bind(quietKey, func() { controller.Execute("quiet toggle") })
+
+		// The overlay sees keys first so chords are not interpreted while it is open
+		deps.ProcessManager.AddInputFilter(deps.Overlay.FilterInput)
+		deps.ProcessManager.AddInputFilter(chords.FilterInput)
+	}
+
+	// Warn when the session has used up its budget, and hold back input
+	// then if asked to; hotkeys still work
+	if (cfg.BudgetTokens > 0 || cfg.BudgetCost > 0) && !cfg.CI {
+		deps.Budget = monitor.NewBudget(cfg.BudgetTokens, cfg.BudgetCost, cfg.CostPerMillionTokens, deps.budgetExceeded)
+		deps.Budget.SetPause(cfg.BudgetPause)
+		deps.ProcessManager.AddInputFilter(func(data []byte) []byte {
+			kept := deps.Budget.FilterInput(data)
+			if len(kept) < len(data) {
+				deps.Flash.Show(deps.Messages.Get("budget.paused"), flashDuration)
+			}
+			return kept
+		})
+	}
+
+	// Capture prompts last, so only what reaches Gemini counts
+	if deps.promptCapture != nil {
+		deps.ProcessManager.AddInputFilter(deps.promptCapture.FilterInput)
+	}
+
+	// Create title marker if configured; a warning shortly before the
+	// backstop fires takes precedence over the idle marker. Without a status
+	// line, hotkey confirmations are flashed in the title instead.
+	flashInTitle := cfg.Hotkeys && deps.StatusLine == nil
+	if cfg.IdleTitle || cfg.BackstopWarning > 0 || flashInTitle {
+		threshold := cfg.IdleTitleAfter
+		if threshold == 0 {
+			threshold = cfg.BackstopTimeout
+		}
+		if threshold == 0 {
+			threshold = config.DefaultConfig().BackstopTimeout
+		}
+		idleMarker := terminal.IdleMarker("⏳ ", outputMonitor.LastOutputTime, threshold)
+		deps.TitleMarker = terminal.NewTitleMarker(deps.TerminalOutput, outputMonitor.GetTerminalTitle, func() string {
+			if text := deps.Flash.Text(); text != "" && flashInTitle {
+				return "[" + text + "] "
+			}
+			if _, warn := deps.backstopWarning(); warn {
+				return "⚠ "
+			}
+			if cfg.IdleTitle {
+				return idleMarker()
+			}
+			return ""
+		})
+		if flashInTitle {
+			deps.Flash.OnShow(deps.TitleMarker.Update)
+		}
+	}
+
+	// Create control socket if configured; Gemini CLI hooks report their
+	// events over it too
+	if cfg.ControlSocket || cfg.GeminiHooks {
+		deps.ControlSocket = control.NewSocketServer(control.DefaultSocketPath(os.Getpid()), controller.ExecuteLocal)
+	}
+
+	// Create control topic subscriber if configured
+	if cfg.ControlTopic != "" {
+		deps.Subscriber = notification.NewSubscriber(cfg.NtfyServer, cfg.ControlTopic, controller.HandleMessage)
+		if tlsConfig != nil {
+			deps.Subscriber.SetTLSConfig(tlsConfig)
+		}
+		if cfg.ControlSecret != "" {
+			verifier := notification.NewControlVerifier(cfg.ControlSecret, cfg.ControlMaxAge)
+			verifier.SetScope(deps.controlScope)
+			deps.Subscriber.SetVerifier(verifier)
+		}
+	}
+
+	// Watch for sessions that have stalled for much longer than the backstop
+	if cfg.StuckAfter > 0 {
+		deps.StuckWatcher = monitor.NewStuckWatcher(outputMonitor.LastOutputTime, cfg.StuckAfter, func(idle time.Duration) {
+			_ = deps.QuietNotifier.Send(notification.Notification{
+				Title:   deps.Messages.Get("stuck.title"),
+				Message: deps.Messages.Get("stuck.message", idle.Round(time.Minute)),
+				Time:    time.Now(),
+				Pattern: "stuck",
+			})
+		})
+	}
+
+	// Tell the user about a tool call, e.g. a build or test run, that keeps
+	// Gemini busy for long
+	if cfg.ToolRunningAfter > 0 && !cfg.CI && !structuredOutput(cfg) && toolRunningPattern != nil {
+		deps.ToolWatcher = monitor.NewToolWatcher(outputMonitor.GetScreenTail, toolRunningPattern, toolDonePattern, cfg.ToolRunningAfter,
+			func(tool string, elapsed time.Duration) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("tool_running.title"),
+					Message: deps.Messages.Get("tool_running.message", tool, shortDuration(elapsed)),
+					Time:    time.Now(),
+					Pattern: "tool_running",
+				})
+			})
+	}
+
+	// Tell the user that a long stretch of work, e.g. a big refactoring, is
+	// going somewhere
+	if cfg.ProgressAfter > 0 && !cfg.CI && !structuredOutput(cfg) {
+		deps.Progress = monitor.NewProgressWatcher(outputMonitor.LastOutputTime, outputMonitor.GetScreenTail, fileEditPattern, cfg.ProgressAfter,
+			func(p monitor.Progress) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("progress.title"),
+					Message: deps.progressMessage(p),
+					Time:    time.Now(),
+					Pattern: "progress",
+				})
+			})
+	}
+
+	// Reassure the user now and then that a long unattended session is
+	// alive, whatever it is doing; CI jobs only report start, exit and errors
+	if cfg.HeartbeatInterval > 0 && !cfg.CI {
+		deps.Heartbeat = monitor.NewHeartbeat(cfg.HeartbeatInterval, func(running time.Duration) {
+			lastActivity := outputMonitor.LastOutputTime()
+			if input := outputMonitor.LastInputTime(); input.After(lastActivity) {
+				lastActivity = input
+			}
+			// Seconds only matter for activity under a minute ago
+			quiet := time.Since(lastActivity)
+			if quiet >= time.Minute {
+				quiet = quiet.Truncate(time.Minute)
+			}
+			_ = deps.QuietNotifier.Send(notification.Notification{
+				Title:   deps.Messages.Get("heartbeat.title"),
+				Message: deps.Messages.Get("heartbeat.message", shortDuration(running.Round(time.Minute)), deps.turns.Load(), shortDuration(quiet)),
+				Time:    time.Now(),
+				Pattern: "heartbeat",
+			})
+		})
+	}
+
+	// Tell the user when Gemini's context is compressed or nearly full,
+	// which often explains worse answers
+	if !cfg.CI && !structuredOutput(cfg) && (len(compressionPatterns) > 0 || contextLeftPattern != nil) {
+		deps.ContextWatcher = monitor.NewContextWatcher(outputMonitor.GetScreenTail, compressionPatterns, contextLeftPattern, cfg.ContextLowPercent,
+			func(message string) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("context.title"),
+					Message: message,
+					Time:    time.Now(),
+					Pattern: "context",
+				})
+			},
+			func(percent int) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("context.low_title"),
+					Message: deps.Messages.Get("context.low_message", percent),
+					Time:    time.Now(),
+					Pattern: "context",
+				})
+			})
+	}
+
+	// Pass on what Gemini copies, e.g. a generated command, to use on
+	// another device; a CI job's output doesn't pass through the wrapper
+	if cfg.CaptureClipboard && !cfg.CI {
+		outputMonitor.SetClipboardHook(func(text string) {
+			_ = deps.QuietNotifier.Send(notification.Notification{
+				Title:   deps.Messages.Get("clipboard.title"),
+				Message: text,
+				Time:    time.Now(),
+				Pattern: "clipboard",
+			})
+		})
+	}
+
+	// Notice what Gemini does to the project's files, which the screen may
+	// not show; a CI job runs unattended, with nobody to tell
+	if cfg.WatchFiles && !cfg.CI {
+		watcher, err := monitor.NewFileWatcher(cwd, cfg.FileBurstFiles, cfg.FileBurstWindow, cfg.SensitivePaths,
+			func(files []string) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("file_burst.title"),
+					Message: deps.Messages.Get("file_burst.message", len(files), shortDuration(cfg.FileBurstWindow), fileList(deps.Messages, files)),
+					Time:    time.Now(),
+					Pattern: "file_burst",
+				})
+			},
+			func(path string) {
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("sensitive_file.title"),
+					Message: deps.Messages.Get("sensitive_file.message", path),
+					Time:    time.Now(),
+					Pattern: "sensitive_file",
+				})
+			})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v\n", err)
+		} else {
+			deps.FileWatcher = watcher
+		}
+	}
+
+	// Commits an agent makes on its own are worth reviewing soon
+	if cfg.WatchCommits && !cfg.CI {
+		deps.CommitWatcher = monitor.NewCommitWatcher(func() string {
+			return notification.GitHead(cwd)
+		}, func(since string) []notification.GitCommit {
+			return notification.GitCommits(cwd, since)
+		}, func(commits []notification.GitCommit) {
+			_ = deps.QuietNotifier.Send(commitNotification(deps.Messages, notification.GitBranch(cwd), commits))
+		})
+	}
+
+	// Tell the user about errors Gemini prints, which may otherwise only
+	// be noticed much later
+	if !cfg.CI && !structuredOutput(cfg) {
+		deps.MessageWatcher = monitor.NewMessageWatcher(outputMonitor.GetScreenTail)
+		// A session that lost an MCP server's tools carries on without them
+		deps.MessageWatcher.Watch(mcpErrorPatterns, func(lines []string) {
+			_ = deps.QuietNotifier.Send(notification.Notification{
+				Title:   deps.Messages.Get("mcp_error.title"),
+				Message: strings.Join(lines, "\n") + "\n" + deps.Messages.Get("mcp_error.hint"),
+				Time:    time.Now(),
+				Pattern: "mcp_error",
+			})
+		})
+		deps.MessageWatcher.Watch(apiErrorPatterns, func(lines []string) {
+			for _, line := range lines {
+				deps.apiError(line)
+			}
+		})
+		// Sessions on unattended machines are the ones that fall behind
+		deps.MessageWatcher.Watch(updatePatterns, func(lines []string) {
+			deps.updateAvailable(lines[0])
+		})
+		// Test runs Gemini started, e.g. to check its changes
+		if cfg.TestResults {
+			deps.MessageWatcher.Watch(monitor.TestResultPatterns, func(lines []string) {
+				for _, result := range monitor.TestResults(lines) {
+					_ = deps.QuietNotifier.Send(testResultNotification(deps.Messages, result))
+				}
+			})
+		}
+		// A failed build, which Gemini may not mention on its own
+		if cfg.BuildErrors {
+			buildErrorPatterns, err := monitor.BuildErrorPatterns(cfg.BuildErrorPatterns)
+			if err != nil {
+				return nil, err
+			}
+			deps.MessageWatcher.Watch(buildErrorPatterns, deps.buildError)
+		}
+		// The CLI's running total of tokens, where it shows one
+		if deps.Budget != nil && tokenUsagePattern != nil {
+			deps.MessageWatcher.Watch([]*regexp.Regexp{tokenUsagePattern}, func(lines []string) {
+				if total, ok := monitor.TokenTotal(tokenUsagePattern, lines); ok {
+					deps.Budget.Observe(total)
+				}
+			})
+		}
+	}
+
+	// Time Gemini's responses to prompts if configured, or count them for
+	// heartbeats
+	if cfg.ResponseTimeout > 0 || cfg.ResponseReadyAfter > 0 || cfg.HeartbeatInterval > 0 {
+		var onTimeout func(time.Duration)
+		if cfg.ResponseTimeout > 0 {
+			timeout := shortDuration(cfg.ResponseTimeout)
+			onTimeout = func(elapsed time.Duration) {
+				submitted := time.Now().Add(-elapsed).Format("15:04")
+				_ = deps.QuietNotifier.Send(notification.Notification{
+					Title:   deps.Messages.Get("no_response.title", timeout),
+					Message: deps.Messages.Get("no_response.message", timeout, submitted),
+					Time:    time.Now(),
+					Pattern: "no_response",
+				})
+			}
+		}
+		deps.Responses = monitor.NewResponseTracker(outputMonitor.LastOutputTime, cfg.ResponseTimeout, onTimeout)
+		deps.Responses.SetCompleteHook(func(elapsed time.Duration) {
+			deps.turns.Add(1)
+			if cfg.ResponseReadyAfter > 0 {
+				deps.responseReady(elapsed)
+			}
+		})
+	}
+
+	// Accept notifications from other programs if configured
+	if cfg.ReceiverAddr != "" {
+		deps.Receiver = control.NewReceiver(cfg.ReceiverAddr, deps.sendExternal)
+		deps.Receiver.SetToken(cfg.ReceiverToken)
+		deps.Receiver.SetRateLimit(cfg.ReceiverRateLimit)
+	}
+
+	// List interactive sessions for "sessions list"; CI jobs aren't sessions
+	if !cfg.CI {
+		deps.Sessions = session.NewRegistry(session.DefaultDir())
+	}
 
 	return deps, nil
 }
 
+// budgetExceeded reports that the session has used up its budget
+func (d *Dependencies) budgetExceeded(usage monitor.Usage) {
+	message := d.budgetUsage(usage)
+	if d.Config.BudgetPause {
+		message += "\n" + d.Messages.Get("budget.paused")
+	}
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("budget.title"),
+		Message: message,
+		Time:    time.Now(),
+		Pattern: "budget",
+	})
+}
+
+// budgetUsage describes what the session has used of its budget
+func (d *Dependencies) budgetUsage(usage monitor.Usage) string {
+	var lines []string
+	if d.Config.BudgetTokens > 0 {
+		lines = append(lines, d.Messages.Get("budget.tokens", usage.Tokens, d.Config.BudgetTokens))
+	}
+	if d.Config.BudgetCost > 0 {
+		lines = append(lines, d.Messages.Get("budget.cost", usage.Cost, d.Config.BudgetCost))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxProgressFiles is how many edited files a progress notification names
+const maxProgressFiles = 5
+
+// progressMessage describes a stretch of work, naming the files edited
+func (d *Dependencies) progressMessage(p monitor.Progress) string {
+	message := d.Messages.Get("progress.message", shortDuration(p.Elapsed.Round(time.Minute)), p.Lines, len(p.Files))
+	if len(p.Files) == 0 {
+		return message
+	}
+	files := strings.Join(p.Files[:min(len(p.Files), maxProgressFiles)], ", ")
+	if len(p.Files) > maxProgressFiles {
+		files += " " + d.Messages.Get("progress.more", len(p.Files)-maxProgressFiles)
+	}
+	return message + "\n" + d.Messages.Get("progress.files", files)
+}
+
+// sendExternal sends an event received from another program. It skips the
+// backstop and lifecycle events, which are about the wrapped process, but is
+// templated, filtered and silenced like any other notification.
+func (d *Dependencies) sendExternal(event control.Event) error {
+	// The sender's title is kept; without one, the session context is used
+	return d.QuietNotifier.Send(notification.Notification{
+		Title:     event.Title,
+		Message:   event.Message,
+		Time:      time.Now(),
+		Pattern:   event.Type,
+		Click:     event.Click,
+		KeepTitle: event.Title != "",
+	})
+}
+
+// controlButton is a notification button that publishes a control command
+type controlButton struct {
+	label   string
+	command string
+}
+
+// controlScope returns a scope for signing the notification buttons of a
+// session that differs from that of any other session or run: anyone who
+// can read the notification topic sees the signed buttons
+func controlScope(sessionID string) string {
+	random := make([]byte, 8)
+	_, _ = rand.Read(random)
+	return sessionID + "/" + hex.EncodeToString(random)
+}
+
+// controlActions returns the remote control buttons for a notification,
+// signed for scope if a control secret is set
+func controlActions(cfg *config.Config, scope string, n notification.Notification) []notification.Action {
+	waiting := n.Pattern == "backstop" || n.Pattern == "stalled"
+	escalated := escalates(cfg, n.Pattern)
+	if cfg.ControlTopic == "" || (!waiting && !escalated) {
+		return nil
+	}
+
+	// A stalled task has no question to answer, only to interrupt
+	var buttons []controlButton
+	if cfg.RemoteInput && n.Pattern == "backstop" {
+		buttons = append(buttons, controlButton{"Yes", "reply y"}, controlButton{"No", "reply n"})
+	}
+	// Answering acknowledges too; ntfy shows only the first three buttons
+	if escalated {
+		buttons = append(buttons, controlButton{"Acknowledge", "ack"})
+	}
+	if cfg.RemoteSignals && waiting {
+		buttons = append(buttons, controlButton{"Interrupt", "interrupt"}, controlButton{"Kill", "kill"})
+	}
+
+	controlURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(cfg.NtfyServer, "/"), cfg.ControlTopic)
+
+	var actions []notification.Action
+	for _, b := range buttons {
+		body := b.command
+		// Buttons are signed when sent, so they stay valid for control_max_age
+		if cfg.ControlSecret != "" {
+			signed, err := notification.SignScopedControlMessage(cfg.ControlSecret, scope, b.command, time.Now())
+			if err != nil {
+				continue
+			}
+			body = signed
+		}
+		actions = append(actions, notification.Action{Label: b.label, URL: controlURL, Body: body})
+	}
+	return actions
+}
+
+// escalates reports whether notifications of a type are reminded of until
+// they are acknowledged
+func escalates(cfg *config.Config, pattern string) bool {
+	if cfg.EscalateAfter <= 0 {
+		return false
+	}
+	for _, t := range cfg.EscalateTypes {
+		if t == pattern {
+			return true
+		}
+	}
+	return false
+}
+
 // Close cleans up all dependencies
 func (d *Dependencies) Close() {
 	// Stop status indicator refresh
@@ -79,6 +1046,37 @@ func (d *Dependencies) Close() {
 		d.stopChan = nil
 	}
 
+	if d.EventStream != nil {
+		d.EventStream.Close(eventStreamGrace)
+		_ = d.eventsFile.Close()
+	}
+
+	// Stop listening for remote commands
+	if d.Subscriber != nil {
+		_ = d.Subscriber.Close()
+	}
+	if d.ControlSocket != nil {
+		_ = d.ControlSocket.Close()
+	}
+	if d.Receiver != nil {
+		_ = d.Receiver.Close()
+	}
+	if d.durationTimer != nil {
+		d.durationTimer.Stop()
+	}
+	if d.Escalation != nil {
+		_ = d.Escalation.Close()
+	}
+	if d.outputLog != nil {
+		_ = d.outputLog.Close()
+	}
+	if d.tee != nil {
+		_ = d.tee.Close()
+	}
+	if d.Sessions != nil {
+		_ = d.Sessions.Remove(os.Getpid())
+	}
+
 	// Close notifiers
 	// First try to close as backstop notifier
 	if backstopNotifier, ok := d.Notifier.(*notification.BackstopNotifier); ok {
@@ -100,31 +1098,782 @@ func NewApplication(deps *Dependencies) *Application {
 
 // Run starts the application with the given command and arguments
 func (a *Application) Run(command string, args []string) error {
+	a.deps.requestedModel = a.deps.Config.ActiveProfile(command).RequestedModel(args)
+	if a.deps.Config.CI {
+		return a.runCI(command, args)
+	}
+
 	// Send startup notification if configured
-	if a.deps.Config.StartupNotify && !a.deps.Config.Quiet {
+	if a.deps.Config.NotifyEnabled("startup") && !a.deps.Config.Quiet {
 		pwd, _ := os.Getwd()
 		startupNotification := notification.Notification{
-			Title:   "Gemini CLI Session Started",
-			Message: fmt.Sprintf("Working directory: %s", pwd),
+			Title:   a.deps.Messages.Get("startup.title"),
+			Message: a.deps.withModel(a.deps.Messages.Get("startup.message", pwd)),
 			Time:    time.Now(),
 			Pattern: "startup",
 		}
 		_ = a.deps.Notifier.Send(startupNotification)
 	}
 
+	// Start the local control socket and advertise it to the child process
+	if a.deps.ControlSocket != nil {
+		if err := a.deps.ControlSocket.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to start control socket: %v\n", err)
+		} else {
+			_ = os.Setenv("GEMINI_NOTIFY_SOCKET", a.deps.ControlSocket.Path())
+		}
+	}
+
+	// Start accepting notifications from other programs
+	if a.deps.Receiver != nil {
+		if err := a.deps.Receiver.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to start receiver: %v\n", err)
+		}
+	}
+
+	// Start listening for remote commands
+	if a.deps.Subscriber != nil {
+		if err := a.deps.Subscriber.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to start control subscriber: %v\n", err)
+		}
+	}
+
+	// Reserve the bottom row for the status line before the child sizes its terminal
+	if a.deps.StatusLine != nil {
+		if err := a.deps.StatusLine.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: status line disabled: %v\n", err)
+		} else {
+			a.deps.ProcessManager.ReserveRows(1)
+		}
+	}
+
+	if a.deps.TitleMarker != nil {
+		a.deps.TitleMarker.Start()
+	}
+
+	// Ask the terminal to report focus changes
+	if tracksFocus(a.deps.Config) {
+		a.deps.TerminalOutput.Do(func(w io.Writer) { _, _ = w.Write(monitor.EnableFocusReporting()) })
+	}
+
+	a.deps.snapshotWorkTree()
 	if err := a.deps.ProcessManager.Start(command, args); err != nil {
+		a.closeTerminalUI()
 		return err
 	}
+	a.deps.started = time.Now()
+	if err := a.deps.ProcessManager.PTYError(); err != nil {
+		a.deps.ptyFallback(err)
+	}
+	a.deps.reportLifecycle(notification.LifecycleEvent{Event: notification.EventSessionStart})
+	if a.deps.Config.Supervised {
+		if err := systemd.Notify(systemd.Ready); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to notify systemd: %v\n", err)
+		}
+		go systemd.RunWatchdog(a.deps.stopChan)
+	}
+	a.startWatchers()
+	if a.deps.Sessions != nil {
+		a.deps.registerSession(a.deps.stopChan)
+	}
+
+	err := a.deps.ProcessManager.Wait()
+	if a.deps.Sessions != nil {
+		_ = a.deps.Sessions.Remove(os.Getpid())
+	}
+	a.closeTerminalUI()
+	if a.deps.journal != nil {
+		_ = a.deps.journal.Flush()
+	}
+	if a.deps.Config.Supervised {
+		_ = systemd.Notify(systemd.Stopping)
+	}
+
+	if a.deps.durationTimer != nil {
+		a.deps.durationTimer.Stop()
+	}
+	if a.deps.outputLog != nil {
+		if err := a.deps.outputLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v\n", err)
+		} else if a.deps.Config.CompressLogs {
+			// Before the exit notifications, so they point at the .gz file
+			if err := a.deps.outputLog.Compress(); err != nil {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v\n", err)
+			}
+		}
+	}
+	a.deps.tidyLogs()
+	if a.deps.tee != nil {
+		if err := a.deps.tee.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v\n", err)
+		}
+	}
+
+	code := a.deps.ProcessManager.ExitCode()
+	if !a.deps.stoppedForDuration.Load() && a.deps.Config.NotifyExit(code, crashed(code)) {
+		if crashed(code) {
+			a.deps.sendCrash(code)
+		} else {
+			a.deps.sendExit(code)
+		}
+	}
+	a.deps.sessionEnded(code)
+	a.waitForAcknowledgement()
+	return err
+}
+
+// tidyLogs compresses the logs other sessions left behind and keeps the
+// state directory under its size cap, if configured
+func (d *Dependencies) tidyLogs() {
+	cfg := d.Config
+	if !cfg.CompressLogs && cfg.StateMaxMB == 0 {
+		return
+	}
+	maxBytes := int64(cfg.StateMaxMB) * 1024 * 1024
+	if err := session.TidyLogs(session.DefaultLogDir(), session.StateDir(), cfg.CompressLogs, maxBytes); err != nil {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v\n", err)
+	}
+}
+
+// waitForAcknowledgement keeps the wrapper running after a crash while its
+// notification is being escalated, since reminders stop when the wrapper
+// exits. Enter, "ack" on the control topic or socket, or Ctrl-C ends it.
+func (a *Application) waitForAcknowledgement() {
+	escalation := a.deps.Escalation
+	if escalation == nil || escalation.Pending() != "crash" {
+		return
+	}
+
+	// Gemini is gone, so it isn't waiting for the user either
+	if backstopNotifier, ok := a.deps.Notifier.(*notification.BackstopNotifier); ok {
+		_ = backstopNotifier.Close()
+	}
+
+	fmt.Fprintln(os.Stderr, "gemini-cli-ntfy: reminding of the crash until it is acknowledged; press Enter to stop")
+	if isInteractive() {
+		go func() {
+			_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+			escalation.Acknowledge()
+		}()
+	}
+	<-escalation.Done()
+}
+
+// testResultNotification reports the counts of a test run
+func testResultNotification(messages notification.Messages, result monitor.TestResult) notification.Notification {
+	pattern := "tests_passed"
+	if result.Failed > 0 {
+		pattern = "tests_failed"
+	}
+	key := "tests.message"
+	if result.Packages {
+		key = "tests.packages"
+	}
+	message := messages.Get(key, result.Runner, result.Failed, result.Passed)
+	if result.Skipped > 0 {
+		message += ", " + messages.Get("tests.skipped", result.Skipped)
+	}
+	return notification.Notification{
+		Title:   messages.Get(pattern + ".title"),
+		Message: message,
+		Time:    time.Now(),
+		Pattern: pattern,
+	}
+}
+
+// updateAvailable reports the CLI's message that a newer version is
+// available, once per session
+func (d *Dependencies) updateAvailable(message string) {
+	if !d.update.CompareAndSwap(nil, &message) {
+		return
+	}
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("update.title"),
+		Message: message,
+		Time:    time.Now(),
+		Pattern: "update",
+	})
+}
+
+// availableUpdate returns the CLI's message that a newer version is
+// available, or "" if it hasn't shown one
+func (d *Dependencies) availableUpdate() string {
+	if message := d.update.Load(); message != nil {
+		return *message
+	}
+	return ""
+}
+
+// newConversation resets what the watchers remember of the session when the
+// user starts another conversation, e.g. with /clear
+func (d *Dependencies) newConversation() {
+	if d.Responses != nil {
+		d.Responses.Cancel()
+	}
+	if d.ContextWatcher != nil {
+		d.ContextWatcher.Reset()
+	}
+	if d.MessageWatcher != nil {
+		d.MessageWatcher.Reset()
+	}
+}
+
+// quitting finalizes the session when the user asks Gemini to quit, e.g.
+// with /quit: nothing is pending any more, so no backstop or max duration
+// alert is sent while Gemini exits
+func (d *Dependencies) quitting() {
+	d.quitRequested.Store(true)
+	if d.Responses != nil {
+		d.Responses.Cancel()
+	}
+	if backstop, ok := d.Notifier.(*notification.BackstopNotifier); ok {
+		backstop.SetBackstopSent(true)
+	}
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: quit requested - finalizing session\n")
+	}
+}
+
+// startWatchers starts following the session's output and timing it once
+// the session has started
+func (a *Application) startWatchers() {
+	if a.deps.StuckWatcher != nil {
+		go a.deps.StuckWatcher.Run(a.deps.stopChan)
+	}
+	if a.deps.FileWatcher != nil {
+		go a.deps.FileWatcher.Run(a.deps.stopChan)
+	}
+	if a.deps.CommitWatcher != nil {
+		go a.deps.CommitWatcher.Run(a.deps.stopChan)
+	}
+	if cfg := a.deps.Config; cfg.MemoryWarnMB > 0 || cfg.CPUWarnAfter > 0 {
+		a.deps.Resources = a.deps.newResourceWatchdog()
+		go a.deps.Resources.Run(a.deps.stopChan)
+	}
+	if a.deps.ContextWatcher != nil {
+		go a.deps.ContextWatcher.Run(a.deps.stopChan)
+	}
+	if a.deps.ToolWatcher != nil {
+		go a.deps.ToolWatcher.Run(a.deps.stopChan)
+	}
+	if a.deps.Progress != nil {
+		go a.deps.Progress.Run(a.deps.stopChan)
+	}
+	if a.deps.Heartbeat != nil {
+		go a.deps.Heartbeat.Run(a.deps.stopChan)
+	}
+	if a.deps.MessageWatcher != nil && a.deps.MessageWatcher.Watching() {
+		go a.deps.MessageWatcher.Run(a.deps.stopChan)
+	}
+	if a.deps.Responses != nil {
+		go a.deps.Responses.Run(a.deps.stopChan)
+	}
+	if a.deps.Config.DigestTime != "" {
+		go a.deps.runDigestSchedule(a.deps.stopChan)
+	}
+	if limit := a.deps.Config.MaxSessionDuration; limit > 0 {
+		started := time.Now()
+		a.deps.durationTimer = time.AfterFunc(limit, func() { a.deps.maxDurationReached(started) })
+	}
+}
+
+// crashed reports whether an exit code means the wrapped process died rather
+// than being quit; -1 means it was killed by a signal
+func crashed(code int) bool {
+	// 130 is the shell convention for quitting with Ctrl-C
+	return code != 0 && code != 130
+}
+
+// sendCrash reports that the wrapped process died. Like the stuck
+// notification, it bypasses the backstop, which only concerns a running
+// session.
+func (d *Dependencies) sendCrash(code int) {
+	title, pattern := d.Messages.Get("crash.title"), "crash"
+	message := d.Messages.Get("crash.message", code)
+	if code < 0 {
+		message = d.killedMessage()
+	}
+	// Running out of memory needs another fix than a crash
+	if d.ProcessManager.OOMKilled() {
+		title, pattern = d.Messages.Get("oom.title"), "oom"
+	}
+	_ = d.QuietNotifier.Send(d.withExitStyle(notification.Notification{
+		Title:   title,
+		Message: d.withOutputLog(d.withWorkTree(d.withModel(message))),
+		Time:    time.Now(),
+		Pattern: pattern,
+	}, code))
+}
+
+// ptyFallback explains that Gemini runs with its output piped because no
+// PTY could be opened, on the terminal and in a notification, since the
+// session may not behave as usual
+func (d *Dependencies) ptyFallback(err error) {
+	fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: warning: %v; running Gemini with its output piped instead\n", err)
+	fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: Gemini may not be interactive, and the status line, hotkeys and remote input are unavailable\n")
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("pty_fallback.title"),
+		Message: d.Messages.Get("pty_fallback.message", err),
+		Time:    time.Now(),
+		Pattern: "pty_fallback",
+	})
+}
+
+// killedMessage says how the wrapped process was killed: by the OOM
+// killer, or by which signal
+func (d *Dependencies) killedMessage() string {
+	if d.ProcessManager.OOMKilled() {
+		return d.Messages.Get("oom.message")
+	}
+	if sig := d.ProcessManager.ExitSignal(); sig != 0 {
+		return d.Messages.Get("crash.signal", process.SignalName(sig))
+	}
+	return d.Messages.Get("crash.killed")
+}
+
+// sendExit reports that the wrapped process exited normally or was quit,
+// for exit codes exit_codes asks to notify
+func (d *Dependencies) sendExit(code int) {
+	_ = d.QuietNotifier.Send(d.withExitStyle(notification.Notification{
+		Title:   d.Messages.Get("exit.title"),
+		Message: d.withOutputLog(d.withWorkTree(d.withModel(d.Messages.Get("exit.message", code)))),
+		Time:    time.Now(),
+		Pattern: "exit",
+	}, code))
+}
+
+// withExitStyle gives an exit notification the priority and tags
+// exit_styles sets for its exit code
+func (d *Dependencies) withExitStyle(n notification.Notification, code int) notification.Notification {
+	style, ok := d.Config.ExitStyleFor(code)
+	if !ok {
+		return n
+	}
+	if style.Priority != "" {
+		// Validation rejects invalid priorities
+		n.Priority, _ = notification.ParsePriority(style.Priority)
+	}
+	n.Tags = style.Tags
+	return n
+}
+
+// maxDurationReached alerts that the session has run for
+// max_session_duration, and stops Gemini if configured to. Like the stuck
+// notification, it bypasses the backstop.
+func (d *Dependencies) maxDurationReached(started time.Time) {
+	// A session the user is quitting is not forgotten
+	if d.quitRequested.Load() {
+		return
+	}
+	duration := shortDuration(d.Config.MaxSessionDuration)
+	message := d.Messages.Get("max_duration.message", duration, started.Format("15:04"))
+	if d.Config.MaxSessionKill {
+		message = d.withOutputLog(d.withWorkTree(d.Messages.Get("max_duration.stopping", duration, started.Format("15:04"))))
+	}
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:     d.Messages.Get("max_duration.title", duration),
+		Message:   message,
+		Time:      time.Now(),
+		Pattern:   "max_duration",
+		KeepTitle: true,
+	})
+
+	if d.Config.MaxSessionKill {
+		d.stoppedForDuration.Store(true)
+		if err := d.ProcessManager.Terminate(); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to stop Gemini: %v\n", err)
+		}
+	}
+}
+
+// withOutputLog adds the output log's path to a message about the end of
+// the session, so the user can review what happened
+func (d *Dependencies) withOutputLog(message string) string {
+	if d.outputLog == nil {
+		return message
+	}
+	return message + "\n" + d.Messages.Get("output_log.message", d.outputLog.Path())
+}
+
+// model returns the model the session uses: the one Gemini last showed, or
+// else the one it was asked to use, or "" if neither is known
+func (d *Dependencies) model() string {
+	if om, ok := d.OutputMonitor.(interface{ Model() string }); ok {
+		if model := om.Model(); model != "" {
+			return model
+		}
+	}
+	return d.requestedModel
+}
+
+// snapshotWorkTree remembers the state of the working tree before the wrapped
+// process starts, to tell the files it changed
+func (d *Dependencies) snapshotWorkTree() {
+	dir, _ := os.Getwd()
+	d.workTree = notification.GitStatus(dir)
+}
+
+// withWorkTree adds whether the working tree has uncommitted changes, and how
+// many files changed during the session, to a message about the end of the
+// session, so the user knows whether there is something to review
+func (d *Dependencies) withWorkTree(message string) string {
+	if d.workTree == nil {
+		return message
+	}
+	now := notification.GitStatus(d.workTree.Root)
+	if now == nil {
+		return message
+	}
+
+	status := d.Messages.Get("git.clean")
+	if len(now.Changes) > 0 {
+		status = d.Messages.Get("git.dirty", len(now.Changes))
+	}
+	if changed := now.ChangedSince(d.workTree, d.started); changed > 0 {
+		status += ", " + d.Messages.Get("git.changed", changed)
+	}
+	return message + "\n" + status
+}
+
+// withModel adds the model to a message about the start or end of the
+// session, since sessions on different models cost differently
+func (d *Dependencies) withModel(message string) string {
+	model := d.model()
+	if model == "" {
+		return message
+	}
+	return message + "\n" + d.Messages.Get("model.message", model)
+}
+
+// responseReady reports a response that took long enough for the user to
+// have looked away, unless they are watching the terminal
+func (d *Dependencies) responseReady(elapsed time.Duration) {
+	if elapsed < d.Config.ResponseReadyAfter {
+		return
+	}
+	if om, ok := d.OutputMonitor.(interface{ IsFocused() bool }); ok && om.IsFocused() {
+		return
+	}
+
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("response_ready.title"),
+		Message: d.Messages.Get("response_ready.message", shortDuration(elapsed)),
+		Time:    time.Now(),
+		Pattern: "response_ready",
+	})
+	// The backstop would only repeat this for the same quiet period
+	if bn, ok := d.Notifier.(*notification.BackstopNotifier); ok {
+		bn.SetBackstopSent(true)
+	}
+}
+
+// structuredEvent notifies about an event from Gemini's structured output
+func (d *Dependencies) structuredEvent(event monitor.StructuredEvent) {
+	if d.Budget != nil && event.Tokens > 0 {
+		d.Budget.Add(event.Tokens)
+	}
+
+	var n notification.Notification
+	switch event.Kind {
+	case monitor.StructuredTurn:
+		// The message starts with the answer, which says more than a fixed text
+		message := d.Messages.Get("turn.message")
+		if event.Text != "" {
+			message = event.Text
+		}
+		n = notification.Notification{
+			Title:   d.Messages.Get("turn.title"),
+			Message: message + "\n" + d.Messages.Get("turn.stats", event.ToolCalls, shortDuration(event.Duration)),
+			Pattern: "turn",
+		}
+	case monitor.StructuredToolError:
+		n = notification.Notification{
+			Title:   d.Messages.Get("tool_error.title"),
+			Message: d.Messages.Get("tool_error.message", event.Tool, event.Text),
+			Pattern: "tool_error",
+		}
+	case monitor.StructuredError:
+		if monitor.ClassifyAPIError(event.Text) != monitor.APIErrorOther {
+			d.apiError(event.Text)
+			return
+		}
+		message := d.Messages.Get("error.message")
+		if event.Text != "" {
+			message = event.Text
+		}
+		n = notification.Notification{
+			Title:   d.Messages.Get("error.title"),
+			Message: message,
+			Pattern: "error",
+		}
+	default:
+		return
+	}
+	n.Time = time.Now()
+	_ = d.Notifier.Send(n)
+}
+
+// apiErrorRepeat is how long another API error of the same class isn't
+// reported, since the CLI prints a message for each retry
+const apiErrorRepeat = 15 * time.Minute
+
+// apiError reports an API error by its class, with the fix it suggests. Like
+// the stuck notification, it bypasses the backstop, which would otherwise
+// only report an idle session much later.
+func (d *Dependencies) apiError(message string) {
+	class := monitor.ClassifyAPIError(message)
+	now := time.Now()
+	d.apiErrorsMu.Lock()
+	if sent, ok := d.apiErrorsSent[class]; ok && now.Sub(sent) < apiErrorRepeat {
+		d.apiErrorsMu.Unlock()
+		return
+	}
+	if d.apiErrorsSent == nil {
+		d.apiErrorsSent = make(map[monitor.APIErrorClass]time.Time)
+	}
+	d.apiErrorsSent[class] = now
+	d.apiErrorsMu.Unlock()
+
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get(string(class) + ".title"),
+		Message: message + "\n" + d.Messages.Get(string(class)+".hint"),
+		Time:    now,
+		Pattern: string(class),
+	})
+	if bn, ok := d.Notifier.(*notification.BackstopNotifier); ok {
+		bn.SetBackstopSent(true)
+	}
+}
+
+// buildErrorRepeat is how long another build error isn't reported, since a
+// build prints an error per problem, and they come into view over time
+const buildErrorRepeat = time.Minute
+
+// buildError reports the first of the compiler errors of a failed build
+func (d *Dependencies) buildError(lines []string) {
+	now := time.Now()
+	d.buildErrorMu.Lock()
+	if now.Sub(d.buildErrorSent) < buildErrorRepeat {
+		d.buildErrorMu.Unlock()
+		return
+	}
+	d.buildErrorSent = now
+	d.buildErrorMu.Unlock()
+
+	message := lines[0]
+	if len(lines) > 1 {
+		message += "\n" + d.Messages.Get("build_error.more", len(lines)-1)
+	}
+	_ = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("build_error.title"),
+		Message: message,
+		Time:    now,
+		Pattern: "build_error",
+	})
+}
+
+// shortDuration formats a duration without zero minutes and seconds, e.g.
+// "6h" or "1h30m"
+func shortDuration(d time.Duration) string {
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// maxListedFiles bounds the files named in a file_burst notification
+const maxListedFiles = 5
+
+// fileList names the first few files, one per line, and how many more there
+// are
+func fileList(messages notification.Messages, files []string) string {
+	if len(files) <= maxListedFiles {
+		return strings.Join(files, "\n")
+	}
+	return strings.Join(files[:maxListedFiles], "\n") + "\n" + messages.Get("file_burst.more", len(files)-maxListedFiles)
+}
+
+// newResourceWatchdog creates the watchdog for the wrapped process once it
+// has started
+func (d *Dependencies) newResourceWatchdog() *process.ResourceWatchdog {
+	cfg := d.Config
+	return process.NewResourceWatchdog(d.ProcessManager.Pid(), uint64(cfg.MemoryWarnMB)<<20, cfg.CPUWarnPercent, cfg.CPUWarnAfter,
+		func(rss uint64) {
+			_ = d.QuietNotifier.Send(notification.Notification{
+				Title:   d.Messages.Get("high_memory.title"),
+				Message: d.Messages.Get("high_memory.message", formatBytes(rss), formatBytes(uint64(cfg.MemoryWarnMB)<<20)),
+				Time:    time.Now(),
+				Pattern: "high_memory",
+			})
+		},
+		func(percent float64, busy time.Duration) {
+			_ = d.QuietNotifier.Send(notification.Notification{
+				Title:   d.Messages.Get("high_cpu.title"),
+				Message: d.Messages.Get("high_cpu.message", percent, shortDuration(busy.Round(time.Minute))),
+				Time:    time.Now(),
+				Pattern: "high_cpu",
+			})
+		})
+}
+
+// formatBytes formats a size in MiB, or GiB from 1 GiB, e.g. "4.5 GiB"
+func formatBytes(n uint64) string {
+	if n >= 1<<30 {
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	}
+	return fmt.Sprintf("%d MiB", n>>20)
+}
+
+// commitNotification lists new commits on a branch, one per line
+func commitNotification(messages notification.Messages, branch string, commits []notification.GitCommit) notification.Notification {
+	title := messages.Get("commit.title", branch)
+	if len(commits) > 1 {
+		title = messages.Get("commits.title", len(commits), branch)
+	}
+	lines := make([]string, len(commits))
+	for i, commit := range commits {
+		lines[i] = commit.Hash + " " + commit.Subject
+	}
+	return notification.Notification{
+		Title:   title,
+		Message: strings.Join(lines, "\n"),
+		Time:    time.Now(),
+		Pattern: "commit",
+	}
+}
+
+// eventHookGrace bounds how long the wrapper waits for hooks when exiting
+const eventHookGrace = 5 * time.Second
+
+// eventStreamGrace bounds how long the wrapper waits for the event stream's
+// reader when exiting
+const eventStreamGrace = time.Second
 
-	return a.deps.ProcessManager.Wait()
+// reportLifecycle passes a lifecycle event to the user's hooks and the event
+// stream
+func (d *Dependencies) reportLifecycle(event notification.LifecycleEvent) {
+	if d.EventHooks != nil {
+		d.EventHooks.Run(event)
+	}
+	if d.EventStream != nil {
+		d.EventStream.Emit(event)
+	}
+}
+
+// sessionEnded reports that the wrapped process has exited, giving the hooks
+// and the event stream a moment to finish before the wrapper exits
+func (d *Dependencies) sessionEnded(code int) {
+	event := notification.LifecycleEvent{Event: notification.EventSessionEnd, ExitCode: &code}
+	if d.outputLog != nil {
+		event.OutputLog = d.outputLog.Path()
+	}
+	event.UpdateAvailable = d.availableUpdate()
+	if d.Resources != nil {
+		event.PeakRSS = d.Resources.PeakRSS()
+		event.CPUSeconds = d.Resources.CPUTime().Seconds()
+	}
+	d.recordSession(code)
+	d.reportLifecycle(event)
+	if d.EventHooks != nil {
+		d.EventHooks.Wait(eventHookGrace)
+	}
+	if d.EventStream != nil {
+		d.EventStream.Close(eventStreamGrace)
+	}
+}
+
+// activityReporter reports output activity on the event stream while the
+// monitor handles the output as usual
+type activityReporter struct {
+	*monitor.OutputMonitor
+	stream *notification.EventStream
+}
+
+// HandleData implements interfaces.DataHandler
+func (ar *activityReporter) HandleData(data []byte) {
+	ar.stream.Activity()
+	ar.OutputMonitor.HandleData(data)
+}
+
+// loggingHandler writes output to the session's output log while the
+// monitor handles it as usual
+type loggingHandler struct {
+	interfaces.DataHandler
+	log *session.OutputLog
+}
+
+// HandleData implements interfaces.DataHandler
+func (lh *loggingHandler) HandleData(data []byte) {
+	_, _ = lh.log.Write(data)
+	lh.DataHandler.HandleData(data)
+}
+
+// structuredHandler parses Gemini's structured events while the monitor
+// handles the output as usual
+type structuredHandler struct {
+	interfaces.DataHandler
+	parser *monitor.StructuredOutput
+}
+
+// HandleData implements interfaces.DataHandler
+func (sh *structuredHandler) HandleData(data []byte) {
+	_, _ = sh.parser.Write(data)
+	sh.DataHandler.HandleData(data)
+}
+
+// closeTerminalUI removes the wrapper's own terminal UI and restores the
+// terminal for the shell
+func (a *Application) closeTerminalUI() {
+	if a.deps.Overlay != nil {
+		a.deps.Overlay.Close()
+	}
+	if tracksFocus(a.deps.Config) {
+		a.deps.TerminalOutput.Do(func(w io.Writer) { _, _ = w.Write(monitor.DisableFocusReporting()) })
+	}
+	if a.deps.TitleMarker != nil {
+		_ = a.deps.TitleMarker.Close()
+	}
+	if a.deps.StatusLine != nil {
+		_ = a.deps.StatusLine.Close()
+	}
 }
 
 // Stop gracefully stops the application
 func (a *Application) Stop() error {
+	if a.deps.Config.Supervised {
+		_ = systemd.Notify(systemd.Stopping)
+	}
+	a.closeTerminalUI()
+	if a.deps.Sessions != nil {
+		_ = a.deps.Sessions.Remove(os.Getpid())
+	}
 	return a.deps.ProcessManager.Stop()
 }
 
 // ExitCode returns the exit code of the wrapped process
 func (a *Application) ExitCode() int {
 	return a.deps.ProcessManager.ExitCode()
+}
+
+// tracksFocus reports whether the wrapper needs the terminal's focus events,
+// which a headless terminal doesn't send
+func tracksFocus(cfg *config.Config) bool {
+	return !cfg.Headless && (cfg.Routing == notification.RouteFocus || cfg.ResponseReadyAfter > 0)
+}
+
+// structuredOutput reports whether Gemini prints structured events rather
+// than its terminal UI
+func structuredOutput(cfg *config.Config) bool {
+	return cfg.OutputFormat == monitor.FormatJSON || cfg.OutputFormat == monitor.FormatStreamJSON
+}
+
+// drawsOnTerminal reports whether the wrapper writes to the terminal while
+// Gemini runs, which requires Gemini's output to pass through the wrapper
+func drawsOnTerminal(cfg *config.Config) bool {
+	return cfg.StatusLine || cfg.Hotkeys || cfg.IdleTitle || cfg.BackstopWarning > 0 ||
+		cfg.LocalBell || cfg.LocalUrgency
 }
\ No newline at end of file
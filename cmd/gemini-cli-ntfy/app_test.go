@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/control"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/process"
+)
+
+// recordingBackend records the notifications that reach the backend
+type recordingBackend struct {
+	mu   sync.Mutex
+	sent []notification.Notification
+}
+
+func (rb *recordingBackend) Send(n notification.Notification) error {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.sent = append(rb.sent, n)
+	return nil
+}
+
+// notifications returns the notifications sent so far
+func (rb *recordingBackend) notifications() []notification.Notification {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return append([]notification.Notification(nil), rb.sent...)
+}
+
+// testDependencies creates the dependencies of a session sending to a
+// recording backend, with the user's state kept out of the test
+func testDependencies(t *testing.T, cfg *config.Config) (*Dependencies, *recordingBackend) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("TMUX", "")
+
+	backend := &recordingBackend{}
+	deps, err := newDependencies(cfg, backend)
+	if err != nil {
+		t.Fatalf("newDependencies failed: %v", err)
+	}
+	t.Cleanup(deps.Close)
+	return deps, backend
+}
+
+func TestTemplatedTitleReachesBackend(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.NtfyTopic = "test"
+	cfg.BackstopTimeout = 0
+	cfg.Templates = map[string]config.NotificationTemplate{
+		"backstop": {Title: "waiting: {{.Title}}"},
+	}
+	deps, backend := testDependencies(t, cfg)
+
+	_ = deps.QuietNotifier.Send(notification.Notification{Title: "Gemini needs attention", Message: "m", Time: time.Now(), Pattern: "backstop"})
+	_ = deps.QuietNotifier.Send(notification.Notification{Title: "Gemini CLI Session Started", Message: "m", Time: time.Now(), Pattern: "startup"})
+
+	sent := backend.notifications()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(sent))
+	}
+	if sent[0].Title != "waiting: Gemini needs attention" {
+		t.Errorf("expected the templated title, got %q", sent[0].Title)
+	}
+	if sent[1].Title == "Gemini CLI Session Started" {
+		t.Errorf("expected the session context as the title of other types, got %q", sent[1].Title)
+	}
+}
+
+func TestExternalEventTitle(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.NtfyTopic = "test"
+	cfg.BackstopTimeout = 0
+	deps, backend := testDependencies(t, cfg)
+
+	_ = deps.sendExternal(control.Event{Title: "Build", Message: "make finished", Type: "build"})
+	_ = deps.sendExternal(control.Event{Message: "make finished", Type: "build"})
+
+	sent := backend.notifications()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(sent))
+	}
+	if sent[0].Title != "Build" || sent[0].Message != "make finished" {
+		t.Errorf("expected the event's title and message, got %q, %q", sent[0].Title, sent[0].Message)
+	}
+	if sent[1].Title == "" {
+		t.Error("expected the session context as the title of an event without one")
+	}
+}
+
+func TestControlActionsScoped(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ControlTopic = "control"
+	cfg.ControlSecret = "s3cret"
+	cfg.RemoteSignals = true
+	scope := controlScope("my-api")
+	if scope == controlScope("my-api") {
+		t.Fatal("expected every run to get its own scope")
+	}
+
+	actions := controlActions(cfg, scope, notification.Notification{Pattern: "backstop"})
+	if len(actions) == 0 {
+		t.Fatal("expected buttons")
+	}
+	own := notification.NewControlVerifier(cfg.ControlSecret, cfg.ControlMaxAge)
+	own.SetScope(scope)
+	if command, err := own.Verify(actions[0].Body); err != nil || command != "interrupt" {
+		t.Errorf("expected the session to accept its button, got %q, %v", command, err)
+	}
+	other := notification.NewControlVerifier(cfg.ControlSecret, cfg.ControlMaxAge)
+	other.SetScope(controlScope("my-api"))
+	if _, err := other.Verify(actions[1].Body); err == nil {
+		t.Error("expected another run of the session to reject the button")
+	}
+}
+
+func TestControlActions(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		controlTopic  string
+		remoteInput   bool
+		remoteSignals bool
+		escalateAfter time.Duration
+		want          []string
+	}{
+		{"no control topic", "backstop", "", true, true, time.Minute, nil},
+		{"backstop without remote control", "backstop", "control", false, false, 0, nil},
+		{"backstop with remote input", "backstop", "control", true, false, 0, []string{"Yes", "No"}},
+		{"backstop with remote signals", "backstop", "control", false, true, 0, []string{"Interrupt", "Kill"}},
+		{"backstop with both", "backstop", "control", true, true, 0, []string{"Yes", "No", "Interrupt", "Kill"}},
+		{"escalated backstop", "backstop", "control", true, false, time.Minute, []string{"Yes", "No", "Acknowledge"}},
+		{"stalled with remote input", "stalled", "control", true, false, 0, nil},
+		{"stalled with remote signals", "stalled", "control", true, true, 0, []string{"Interrupt", "Kill"}},
+		{"escalated crash", "crash", "control", true, true, time.Minute, []string{"Acknowledge"}},
+		{"crash without escalation", "crash", "control", true, true, 0, nil},
+		{"other types", "startup", "control", true, true, time.Minute, nil},
+	}
+
+	for _, tt := range tests {
+		for _, secret := range []string{"", "s3cret"} {
+			t.Run(fmt.Sprintf("%s/secret=%v", tt.name, secret != ""), func(t *testing.T) {
+				cfg := config.DefaultConfig()
+				cfg.NtfyServer = "https://ntfy.example.com/"
+				cfg.ControlTopic = tt.controlTopic
+				cfg.ControlSecret = secret
+				cfg.RemoteInput = tt.remoteInput
+				cfg.RemoteSignals = tt.remoteSignals
+				cfg.EscalateAfter = tt.escalateAfter
+				scope := controlScope("my-api")
+
+				actions := controlActions(cfg, scope, notification.Notification{Pattern: tt.pattern})
+				var labels []string
+				for _, action := range actions {
+					labels = append(labels, action.Label)
+				}
+				if strings.Join(labels, " ") != strings.Join(tt.want, " ") {
+					t.Fatalf("expected buttons %q, got %q", tt.want, labels)
+				}
+
+				verifier := notification.NewControlVerifier(secret, cfg.ControlMaxAge)
+				verifier.SetScope(scope)
+				for _, action := range actions {
+					if action.URL != "https://ntfy.example.com/control" {
+						t.Errorf("%s: unexpected URL %q", action.Label, action.URL)
+					}
+					command := action.Body
+					if secret != "" {
+						var err error
+						if command, err = verifier.Verify(action.Body); err != nil {
+							t.Errorf("%s: expected a signed body, got %q: %v", action.Label, action.Body, err)
+						}
+					}
+					if want := buttonCommands[action.Label]; command != want {
+						t.Errorf("%s: expected command %q, got %q", action.Label, want, command)
+					}
+				}
+			})
+		}
+	}
+}
+
+// buttonCommands are the commands the control buttons send
+var buttonCommands = map[string]string{
+	"Yes":         "reply y",
+	"No":          "reply n",
+	"Acknowledge": "ack",
+	"Interrupt":   "interrupt",
+	"Kill":        "kill",
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		6 * time.Hour:                     "6h",
+		90 * time.Minute:                  "1h30m",
+		90 * time.Second:                  "1m30s",
+		2*time.Hour + 30*time.Second:      "2h0m30s",
+		45*time.Second + time.Millisecond: "45s",
+	}
+	for d, want := range tests {
+		if got := shortDuration(d); got != want {
+			t.Errorf("shortDuration(%v) = %q, expected %q", d, got, want)
+		}
+	}
+}
+
+func TestMaxDurationReached(t *testing.T) {
+	started := time.Date(2026, 10, 16, 9, 12, 0, 0, time.Local)
+	newDeps := func(t *testing.T, kill bool) (*Dependencies, *recordingBackend) {
+		cfg := config.DefaultConfig()
+		cfg.NtfyTopic = "test"
+		cfg.BackstopTimeout = 0
+		cfg.MaxSessionDuration = 6 * time.Hour
+		cfg.MaxSessionKill = kill
+		return testDependencies(t, cfg)
+	}
+
+	t.Run("alert", func(t *testing.T) {
+		deps, backend := newDeps(t, false)
+		deps.maxDurationReached(started)
+
+		sent := backend.notifications()
+		if len(sent) != 1 {
+			t.Fatalf("expected 1 notification, got %d", len(sent))
+		}
+		if sent[0].Title != "Gemini has been running for 6h" || sent[0].Message != "Running for 6h since 09:12" {
+			t.Errorf("unexpected notification %q: %q", sent[0].Title, sent[0].Message)
+		}
+		if deps.stoppedForDuration.Load() {
+			t.Error("expected Gemini to be left running")
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		deps, backend := newDeps(t, true)
+		deps.ProcessManager = process.NewDirectManager(deps.Config)
+		if err := deps.ProcessManager.Start("/bin/sleep", []string{"10"}); err != nil {
+			t.Fatalf("failed to start sleep: %v", err)
+		}
+		deps.maxDurationReached(started)
+		_ = deps.ProcessManager.Wait()
+		if deps.ProcessManager.ExitSignal() != syscall.SIGTERM {
+			t.Errorf("expected Gemini to be stopped, got %v", deps.ProcessManager.ExitSignal())
+		}
+
+		sent := backend.notifications()
+		if len(sent) != 1 || !strings.HasPrefix(sent[0].Message, "Running for 6h since 09:12, stopping it now") {
+			t.Fatalf("expected the stopping notification, got %+v", sent)
+		}
+		if !deps.stoppedForDuration.Load() {
+			t.Error("expected the exit not to be reported as a crash")
+		}
+	})
+
+	t.Run("quitting", func(t *testing.T) {
+		deps, backend := newDeps(t, true)
+		deps.quitRequested.Store(true)
+		deps.maxDurationReached(started)
+
+		if sent := backend.notifications(); len(sent) != 0 {
+			t.Errorf("expected no notification while quitting, got %+v", sent)
+		}
+		if deps.stoppedForDuration.Load() {
+			t.Error("expected Gemini not to be stopped")
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// runCI runs the command without a PTY and reports only its start, its exit
+// code and duration if exit_codes includes it, or its failure to start
+func (a *Application) runCI(command string, args []string) error {
+	line := strings.Join(append([]string{filepath.Base(command)}, args...), " ")
+	messages := a.deps.Messages
+	notifier := a.deps.Notifier
+
+	start := commandStartNotification(messages, line)
+	start.Message = a.deps.withModel(start.Message)
+	_ = notifier.Send(start)
+
+	a.deps.snapshotWorkTree()
+	a.deps.started = time.Now()
+	if err := a.deps.ProcessManager.Start(command, args); err != nil {
+		n := commandEndNotification(messages, line, -1, 0)
+		n.Message = err.Error()
+		_ = notifier.Send(n)
+		return err
+	}
+
+	a.deps.reportLifecycle(notification.LifecycleEvent{Event: notification.EventSessionStart})
+
+	err := a.deps.ProcessManager.Wait()
+	code := a.deps.ProcessManager.ExitCode()
+	duration := time.Since(a.deps.started).Round(time.Second)
+	if a.deps.Config.NotifyExit(code, true) {
+		end := commandEndNotification(messages, line, code, duration)
+		if code < 0 {
+			end.Message += "\n" + a.deps.killedMessage()
+		}
+		end.Message = a.deps.withWorkTree(a.deps.withModel(end.Message))
+		_ = notifier.Send(a.deps.withExitStyle(end, code))
+	}
+	a.deps.sessionEnded(code)
+	return err
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// flashDuration is how long local confirmations stay on screen
+const flashDuration = 3 * time.Second
+
+// Controller routes remote control commands to the running wrapper
+type Controller struct {
+	deps *Dependencies
+}
+
+// NewController creates a new controller for the given dependencies
+func NewController(deps *Dependencies) *Controller {
+	return &Controller{
+		deps: deps,
+	}
+}
+
+// HandleMessage executes a message received on the control topic and
+// sends the reply back as a notification
+func (c *Controller) HandleMessage(msg notification.ControlMessage) {
+	// Screen snapshots are delivered as an attachment rather than inline text
+	if commandName(msg.Message) == "screen" {
+		c.sendScreen(commandArgument(msg.Message))
+		return
+	}
+
+	reply := c.Execute(msg.Message)
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: control command %q: %s\n", msg.Message, reply)
+	}
+
+	if c.deps.replyNotifier == nil {
+		return
+	}
+
+	_ = c.deps.replyNotifier.Send(notification.Notification{
+		Title:   "Gemini CLI Control",
+		Message: reply,
+		Time:    time.Now(),
+		Pattern: "control",
+	})
+}
+
+// Execute runs a single command and returns a human readable reply
+func (c *Controller) Execute(command string) string {
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(command)))
+	if len(fields) == 0 {
+		return "Empty command. Send \"help\" for a list of commands."
+	}
+
+	// reply keeps its argument verbatim, including case and spacing
+	if fields[0] == "reply" || fields[0] == "input" {
+		return c.reply(commandArgument(command))
+	}
+
+	switch fields[0] {
+	case "quiet":
+		return c.quiet(fields[1:])
+	case "pause":
+		return c.quiet([]string{"on"})
+	case "resume":
+		return c.quiet([]string{"off"})
+	case "snooze":
+		return c.snooze(fields[1:])
+	case "test":
+		return c.test()
+	case "status":
+		return c.status()
+	case "budget":
+		return c.budget(fields[1:])
+	case "ack":
+		return c.ack()
+	case "screen":
+		lines, problem := c.screenLines(commandArgument(command))
+		if problem != "" {
+			return problem
+		}
+		return strings.Join(lines, "\n")
+	case "interrupt":
+		return c.signal(syscall.SIGINT, "Interrupt")
+	case "kill":
+		return c.signal(syscall.SIGTERM, "Terminate")
+	case "help":
+		return "Commands: quiet [on|off|toggle], pause, resume, snooze <duration>|off, status, ack, budget [ack], test, reply <text>, screen [lines], interrupt, kill, help"
+	default:
+		return fmt.Sprintf("Unknown command %q. Send \"help\" for a list of commands.", fields[0])
+	}
+}
+
+// quiet handles "quiet on", "quiet off" and "quiet toggle"; a bare "quiet" toggles
+func (c *Controller) quiet(args []string) string {
+	if len(args) > 1 {
+		return "Usage: quiet on|off|toggle"
+	}
+
+	quiet := !c.deps.QuietNotifier.IsQuiet()
+	if len(args) == 1 {
+		switch args[0] {
+		case "on":
+			quiet = true
+		case "off":
+			quiet = false
+		case "toggle":
+		default:
+			return "Usage: quiet on|off|toggle"
+		}
+	}
+
+	c.deps.QuietNotifier.SetQuiet(quiet)
+	reply := "Quiet mode disabled"
+	if quiet {
+		reply = "Quiet mode enabled"
+	}
+
+	// Confirm locally too, since the change may have come from elsewhere
+	if c.deps.Flash != nil {
+		c.deps.Flash.Show(reply, flashDuration)
+	}
+	return reply
+}
+
+// snooze handles "snooze <duration>" and "snooze off"
+func (c *Controller) snooze(args []string) string {
+	if len(args) != 1 {
+		return "Usage: snooze <duration>|off"
+	}
+
+	if args[0] == "off" {
+		c.deps.QuietNotifier.Snooze(0)
+		return "Snooze cancelled"
+	}
+
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		return fmt.Sprintf("Invalid duration %q (e.g. 30m, 1h)", args[0])
+	}
+
+	c.deps.QuietNotifier.Snooze(d)
+	return fmt.Sprintf("Notifications snoozed until %s", time.Now().Add(d).Format("15:04"))
+}
+
+// test sends a test notification, bypassing quiet mode
+func (c *Controller) test() string {
+	if c.deps.replyNotifier == nil {
+		return "Notifications are not configured"
+	}
+
+	err := c.deps.replyNotifier.Send(notification.Notification{
+		Title:   "Gemini CLI Test",
+		Message: "Test notification from gemini-cli-ntfy",
+		Time:    time.Now(),
+		Pattern: "test",
+	})
+	if err != nil {
+		return fmt.Sprintf("Test notification failed: %v", err)
+	}
+	return "Test notification sent"
+}
+
+// reply types text followed by Enter into the wrapped process
+func (c *Controller) reply(text string) string {
+	if text == "" {
+		return "Usage: reply <text>"
+	}
+	if c.deps.Budget != nil && c.deps.Budget.Paused() {
+		return "Input is paused because the budget is used up; send \"budget ack\" first"
+	}
+
+	if err := c.deps.ProcessManager.InjectInput(text); err != nil {
+		return fmt.Sprintf("Reply failed: %v", err)
+	}
+	return fmt.Sprintf("Sent %q", text)
+}
+
+// budget handles "budget", which reports what the session has used, and
+// "budget ack", which lets input through again after the budget ran out
+func (c *Controller) budget(args []string) string {
+	if c.deps.Budget == nil {
+		return "No budget is configured"
+	}
+	if len(args) > 1 || (len(args) == 1 && args[0] != "ack") {
+		return "Usage: budget [ack]"
+	}
+
+	if len(args) == 1 {
+		if !c.deps.Budget.Acknowledge() {
+			return "Input is not paused"
+		}
+		return "Input resumed"
+	}
+	return c.deps.budgetUsage(c.deps.Budget.Usage())
+}
+
+// ack stops the reminders of a notification being escalated
+func (c *Controller) ack() string {
+	if c.deps.Escalation == nil || !c.deps.Escalation.Acknowledge() {
+		return "Nothing to acknowledge"
+	}
+	return "Acknowledged"
+}
+
+// signal sends a signal to the wrapped process
+func (c *Controller) signal(sig syscall.Signal, name string) string {
+	if err := c.deps.ProcessManager.SignalRemote(sig); err != nil {
+		return fmt.Sprintf("%s failed: %v", name, err)
+	}
+	return fmt.Sprintf("%s signal sent to Gemini", name)
+}
+
+// defaultScreenLines is how many output lines a screen snapshot contains
+const defaultScreenLines = 40
+
+// screenLines returns the most recent output lines for a screen snapshot,
+// or a reply explaining why no snapshot could be taken
+func (c *Controller) screenLines(arg string) ([]string, string) {
+	n := defaultScreenLines
+	if arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed <= 0 {
+			return nil, "Usage: screen [lines]"
+		}
+		n = parsed
+	}
+
+	om, ok := c.deps.OutputMonitor.(interface{ GetScreenTail(n int) []string })
+	if !ok {
+		return nil, "Screen capture is not available"
+	}
+	return om.GetScreenTail(n), ""
+}
+
+// sendScreen sends the current screen contents as a notification attachment
+func (c *Controller) sendScreen(arg string) {
+	c.sendSnapshot("Gemini CLI Control", "screen", arg)
+}
+
+// Snapshot sends the current screen contents on demand, e.g. from a hotkey.
+// Like control replies it bypasses quiet mode, since it was asked for.
+func (c *Controller) Snapshot() {
+	c.sendSnapshot("Gemini CLI Snapshot", "snapshot", "")
+}
+
+// sendSnapshot sends the most recent output lines as a notification, with
+// the full text attached
+func (c *Controller) sendSnapshot(title, pattern, arg string) {
+	if c.deps.replyNotifier == nil {
+		return
+	}
+
+	n := notification.Notification{
+		Title:   title,
+		Time:    time.Now(),
+		Pattern: pattern,
+	}
+
+	lines, problem := c.screenLines(arg)
+	switch {
+	case problem != "":
+		n.Message = problem
+	case len(lines) == 0:
+		n.Message = "No output captured yet"
+	default:
+		// Show the last line inline so the snapshot is useful without opening it
+		n.Message = fmt.Sprintf("Screen snapshot (%d lines): %s", len(lines), lines[len(lines)-1])
+		n.Attachment = []byte(strings.Join(lines, "\n") + "\n")
+		n.AttachmentName = "screen.txt"
+	}
+
+	_ = c.deps.replyNotifier.Send(n)
+}
+
+// commandName returns the lowercased first word of a command
+func commandName(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// commandArgument returns everything after the first word of a command
+func commandArgument(command string) string {
+	command = strings.TrimSpace(command)
+	if i := strings.IndexAny(command, " \t"); i >= 0 {
+		return strings.TrimSpace(command[i+1:])
+	}
+	return ""
+}
+
+// status reports the current state of the wrapper
+func (c *Controller) status() string {
+	var lines []string
+
+	if c.deps.QuietNotifier.IsQuiet() {
+		lines = append(lines, "Quiet: on")
+	} else {
+		lines = append(lines, "Quiet: off")
+	}
+
+	if until := c.deps.QuietNotifier.SnoozedUntil(); !until.IsZero() {
+		lines = append(lines, fmt.Sprintf("Snoozed until: %s", until.Format("15:04")))
+	}
+
+	if om, ok := c.deps.OutputMonitor.(interface{ LastOutputTime() time.Time }); ok {
+		idle := time.Since(om.LastOutputTime()).Round(time.Second)
+		lines = append(lines, fmt.Sprintf("Last output: %s ago", idle))
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		lines = append(lines, fmt.Sprintf("Working directory: %s", cwd))
+	}
+
+	lines = append(lines, fmt.Sprintf("Session: %s", c.deps.SessionID))
+	if c.deps.Config.SessionTopic == "suffix" && c.deps.topic != "" {
+		lines = append(lines, fmt.Sprintf("Topic: %s", c.deps.topic))
+	}
+
+	if c.deps.ControlSocket != nil {
+		lines = append(lines, fmt.Sprintf("Control socket: %s", c.deps.ControlSocket.Path()))
+	}
+
+	if c.deps.Receiver != nil {
+		lines = append(lines, fmt.Sprintf("Receiver: http://%s/event", c.deps.Receiver.Addr()))
+	}
+
+	if c.deps.outputLog != nil {
+		lines = append(lines, fmt.Sprintf("Output log: %s", c.deps.outputLog.Path()))
+	}
+
+	if c.deps.Escalation != nil {
+		if pending := c.deps.Escalation.Pending(); pending != "" {
+			lines = append(lines, fmt.Sprintf("Awaiting acknowledgement: %s", pending))
+		}
+	}
+
+	if c.deps.Budget != nil {
+		usage := c.deps.Budget.Usage()
+		if c.deps.Config.BudgetTokens > 0 {
+			lines = append(lines, fmt.Sprintf("Budget: %d of %d tokens", usage.Tokens, c.deps.Config.BudgetTokens))
+		}
+		if c.deps.Config.BudgetCost > 0 {
+			lines = append(lines, fmt.Sprintf("Budget: %.2f of %.2f", usage.Cost, c.deps.Config.BudgetCost))
+		}
+	}
+
+	if update := c.deps.availableUpdate(); update != "" {
+		lines = append(lines, fmt.Sprintf("Update available: %s", update))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/session"
+)
+
+// isDigest reports whether the arguments are the "digest" command rather
+// than arguments for Gemini
+func isDigest(args []string) bool {
+	return len(args) == 1 && args[0] == "digest"
+}
+
+// digestWindow is the stretch of time a digest summarizes
+const digestWindow = 24 * time.Hour
+
+// recordsKept is how long finished sessions stay in the records
+const recordsKept = 7 * 24 * time.Hour
+
+// digestErrorTypes are the notification types the digest counts as errors
+var digestErrorTypes = map[string]bool{
+	"crash":         true,
+	"oom":           true,
+	"stuck":         true,
+	"error":         true,
+	"command_error": true,
+	"api_auth":      true,
+	"api_quota":     true,
+	"api_server":    true,
+	"api_error":     true,
+	"mcp_error":     true,
+}
+
+// runDigest sends the digest of the last day now. Once the day's digest
+// time has passed it counts as that day's digest, so it isn't sent twice
+// when run from cron while sessions are running.
+func runDigest(cfg *config.Config) error {
+	if cfg.DigestTime == "" {
+		return fmt.Errorf("digest_time is not set, so sessions are not recorded")
+	}
+
+	// Nothing is wrapped, so only the notifiers are needed
+	cfg.NotifiersOnly()
+	deps, err := NewDependencies(cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	now := time.Now()
+	if !now.Before(digestAt(cfg.DigestTime, now)) {
+		claimed, err := session.ClaimDigest(session.DefaultDigestDir(), now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			fmt.Println("Today's digest was already sent")
+			return nil
+		}
+	}
+	return deps.sendDigest(now)
+}
+
+// digestAt returns the digest time on the day of t
+func digestAt(clock string, t time.Time) time.Time {
+	at, _ := time.Parse("15:04", clock)
+	return time.Date(t.Year(), t.Month(), t.Day(), at.Hour(), at.Minute(), 0, 0, t.Location())
+}
+
+// runDigestSchedule sends the digest at the digest time each day while the
+// session runs, unless another session or cron sent it already
+func (d *Dependencies) runDigestSchedule(stop <-chan struct{}) {
+	for {
+		now := time.Now()
+		next := digestAt(d.Config.DigestTime, now)
+		if !next.After(now) {
+			next = digestAt(d.Config.DigestTime, now.AddDate(0, 0, 1))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+
+		claimed, err := session.ClaimDigest(session.DefaultDigestDir(), next)
+		if err == nil && claimed {
+			err = d.sendDigest(next)
+		}
+		if err != nil && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to send digest: %v\n", err)
+		}
+	}
+}
+
+// sendDigest sends the summary of the sessions of the day before now, and
+// forgets sessions too old for any digest
+func (d *Dependencies) sendDigest(now time.Time) error {
+	path := session.DefaultRecordsPath()
+	records, err := session.ReadRecords(path, now.Add(-digestWindow))
+	if err != nil {
+		return err
+	}
+	running, _ := session.NewRegistry(session.DefaultDir()).List()
+
+	err = d.QuietNotifier.Send(notification.Notification{
+		Title:   d.Messages.Get("digest.title"),
+		Message: d.digestMessage(records, len(running)),
+		Time:    now,
+		Pattern: "digest",
+	})
+	if pruneErr := session.PruneRecords(path, now.Add(-recordsKept)); err == nil {
+		err = pruneErr
+	}
+	return err
+}
+
+// digestMessage summarizes finished sessions: how many ran and for how
+// long, the notifications they sent by type, and how many were errors
+func (d *Dependencies) digestMessage(records []session.Record, running int) string {
+	var active time.Duration
+	counts := make(map[string]int)
+	errors := 0
+	for _, r := range records {
+		active += r.Ended.Sub(r.Started)
+		for pattern, n := range r.Notifications {
+			// Earlier digests aren't part of the day's work
+			if pattern == "digest" {
+				continue
+			}
+			counts[pattern] += n
+			if digestErrorTypes[pattern] {
+				errors += n
+			}
+		}
+	}
+
+	var lines []string
+	if len(records) == 0 {
+		lines = append(lines, d.Messages.Get("digest.none"))
+	} else {
+		lines = append(lines, d.Messages.Get("digest.sessions", len(records), shortDuration(active.Round(time.Minute))))
+	}
+	if running > 0 {
+		lines = append(lines, d.Messages.Get("digest.running", running))
+	}
+
+	if len(counts) > 0 {
+		patterns := make([]string, 0, len(counts))
+		for pattern := range counts {
+			patterns = append(patterns, pattern)
+		}
+		// Most frequent first
+		sort.Slice(patterns, func(i, j int) bool {
+			if counts[patterns[i]] != counts[patterns[j]] {
+				return counts[patterns[i]] > counts[patterns[j]]
+			}
+			return patterns[i] < patterns[j]
+		})
+		parts := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			parts[i] = fmt.Sprintf("%s %d", pattern, counts[pattern])
+		}
+		lines = append(lines, d.Messages.Get("digest.notifications", strings.Join(parts, ", ")))
+	}
+	lines = append(lines, d.Messages.Get("digest.errors", errors))
+
+	return strings.Join(lines, "\n")
+}
+
+// recordSession adds the finished session to the records the daily digest
+// is compiled from
+func (d *Dependencies) recordSession(code int) {
+	if d.Config.DigestTime == "" || d.started.IsZero() {
+		return
+	}
+
+	dir, _ := os.Getwd()
+	record := session.Record{
+		ID:            d.SessionID,
+		Dir:           dir,
+		Started:       d.started,
+		Ended:         time.Now(),
+		ExitCode:      code,
+		Notifications: d.History.Counts(),
+	}
+	if err := session.AppendRecord(session.DefaultRecordsPath(), record); err != nil {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to record session: %v\n", err)
+	}
+}
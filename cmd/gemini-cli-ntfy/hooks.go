@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// Events reported by the hook command, and the Gemini CLI hook events they
+// are installed for
+const (
+	hookEventApproval = "approval"
+	hookEventTurn     = "turn"
+)
+
+var geminiHookEvents = map[string]string{
+	"Notification": hookEventApproval,
+	"AfterAgent":   hookEventTurn,
+}
+
+// maxHookInput bounds how much of the hook's event JSON is read
+const maxHookInput = 64 * 1024
+
+// hookTimeout bounds how long a hook can hold up Gemini
+const hookTimeout = 2 * time.Second
+
+// isHookCommand reports whether the arguments are the "hook <event>" command
+// that Gemini CLI runs, rather than arguments for Gemini
+func isHookCommand(args []string) bool {
+	return len(args) == 2 && args[0] == "hook"
+}
+
+// isHooksInstall reports whether the arguments are the "hooks install" command
+func isHooksInstall(args []string) bool {
+	return len(args) == 2 && args[0] == "hooks" && args[1] == "install"
+}
+
+// runHook forwards a Gemini CLI hook event, read as JSON from stdin, to the
+// wrapper session Gemini runs in. Outside a wrapper session it does nothing,
+// and it never fails the hook, so Gemini is not disturbed either way.
+func runHook(event string) {
+	input, _ := io.ReadAll(io.LimitReader(os.Stdin, maxHookInput))
+
+	socketPath := os.Getenv("GEMINI_NOTIFY_SOCKET")
+	if socketPath == "" {
+		return
+	}
+
+	// The command is a single line, so the payload is compacted
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, bytes.TrimSpace(input)); err != nil {
+		payload.Reset()
+		payload.WriteString("{}")
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, hookTimeout)
+	if err != nil {
+		if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: hook %s: %v\n", event, err)
+		}
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(hookTimeout))
+
+	if _, err := fmt.Fprintf(conn, "event %s %s\n", event, payload.String()); err != nil {
+		return
+	}
+	// Wait for the reply so the event is handled before Gemini carries on
+	_, _ = bufio.NewReader(conn).ReadString('\n')
+}
+
+// installHooks adds hooks that report approval requests and finished turns
+// to Gemini CLI's user settings
+func installHooks() error {
+	path := config.GeminiSettingsPath()
+	if path == "" {
+		return fmt.Errorf("cannot determine Gemini CLI settings location")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get our executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	hooks := make(map[string]string, len(geminiHookEvents))
+	for geminiEvent, event := range geminiHookEvents {
+		hooks[geminiEvent] = shellQuote(exe) + " hook " + event
+	}
+
+	added, err := config.InstallGeminiHooks(path, hooks)
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		fmt.Printf("Hooks are already installed in %s\n", path)
+	} else {
+		fmt.Printf("Installed %d hooks in %s\n", added, path)
+	}
+	fmt.Println("Set gemini_hooks: true in the config file to use them.")
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell if it needs it
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookPayload holds the fields of a hook event that notifications use
+type hookPayload struct {
+	Message string `json:"message"`
+}
+
+// hookEvent handles "event <name> <json>" sent by the hook command
+func (c *Controller) hookEvent(args string) string {
+	name, data, _ := strings.Cut(args, " ")
+
+	var payload hookPayload
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), &payload)
+	}
+
+	var n notification.Notification
+	switch name {
+	case hookEventApproval:
+		n = notification.Notification{
+			Title:   c.deps.Messages.Get("approval.title"),
+			Message: c.deps.Messages.Get("approval.message"),
+			Pattern: hookEventApproval,
+		}
+		if payload.Message != "" {
+			n.Message = payload.Message
+		}
+	case hookEventTurn:
+		n = notification.Notification{
+			Title:   c.deps.Messages.Get("turn.title"),
+			Message: c.deps.Messages.Get("turn.message"),
+			Pattern: hookEventTurn,
+		}
+	default:
+		return fmt.Sprintf("Unknown event %q", name)
+	}
+
+	// Exact signals from Gemini make the inactivity heuristics redundant
+	if c.deps.hookEvents.CompareAndSwap(false, true) {
+		if bn, ok := c.deps.Notifier.(*notification.BackstopNotifier); ok {
+			bn.SetTimeout(0)
+		}
+		if c.deps.Responses != nil {
+			c.deps.Responses.UseTurnEvents()
+		}
+		if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: hook events received, disabling backstop timer\n")
+		}
+	}
+
+	// Gemini either answered or waits for the user, so it isn't late
+	if c.deps.Responses != nil {
+		if name == hookEventTurn {
+			c.deps.Responses.Complete()
+		} else {
+			c.deps.Responses.Cancel()
+		}
+	}
+
+	n.Time = time.Now()
+	if err := c.deps.Notifier.Send(n); err != nil {
+		return fmt.Sprintf("Notification failed: %v", err)
+	}
+	return "OK"
+}
+
+// ExecuteLocal runs a command received on the control socket. Hook events
+// are only accepted there; other commands need control_socket.
+func (c *Controller) ExecuteLocal(command string) string {
+	if commandName(command) == "event" {
+		return c.hookEvent(commandArgument(command))
+	}
+	if !c.deps.Config.ControlSocket {
+		return "Control commands are disabled (control_socket is off)"
+	}
+	return c.Execute(command)
+}
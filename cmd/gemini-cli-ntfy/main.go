@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,15 +11,27 @@ import (
 	"syscall"
 
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/monitor"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/process"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/terminal"
 	flag "github.com/spf13/pflag"
 )
 
 func main() {
 	// Parse our flags and separate Gemini's flags
 	var (
-		configPath string
-		quiet      bool
-		help       bool
+		configPath    string
+		quiet         bool
+		help          bool
+		allowInsecure bool
+		pprofAddr     string
+		pprofRemote   bool
+		profileName   string
+		supervised    bool
+		ci            bool
+		eventsFile    string
+		eventsFD      int
+		teePath       string
 	)
 
 	// Manually parse arguments to separate our flags from Gemini's
@@ -37,13 +50,23 @@ func main() {
 				ourArgs = append(ourArgs, os.Args[i+1])
 				i++
 			}
+		case "--pprof", "--profile", "--events-file", "--events-fd", "--tee":
+			ourArgs = append(ourArgs, arg)
+			if i+1 < len(os.Args) && !strings.HasPrefix(os.Args[i+1], "-") {
+				ourArgs = append(ourArgs, os.Args[i+1])
+				i++
+			}
 		case "--quiet", "-quiet":
 			ourArgs = append(ourArgs, arg)
+		case "--allow-insecure-config", "--supervised", "--ci", "--pprof-allow-remote":
+			ourArgs = append(ourArgs, arg)
 		case "--help", "-help":
 			ourArgs = append(ourArgs, arg)
 		default:
 			// Handle --flag=value format for our flags
-			if strings.HasPrefix(arg, "--config=") || strings.HasPrefix(arg, "-config=") {
+			if strings.HasPrefix(arg, "--config=") || strings.HasPrefix(arg, "-config=") || strings.HasPrefix(arg, "--pprof=") ||
+				strings.HasPrefix(arg, "--profile=") || strings.HasPrefix(arg, "--events-file=") || strings.HasPrefix(arg, "--events-fd=") ||
+				strings.HasPrefix(arg, "--tee=") {
 				ourArgs = append(ourArgs, arg)
 			} else {
 				// Everything else goes to Gemini
@@ -59,8 +82,9 @@ func main() {
 			// Only show our help if no gemini args were provided
 			hasGeminiArgs := false
 			for _, a := range os.Args[1:] {
-				if a != "-help" && a != "--help" && a != "-h" && a != "--quiet" && a != "-quiet" &&
-					!strings.HasPrefix(a, "--config") && !strings.HasPrefix(a, "-config") {
+				if a != "-help" && a != "--help" && a != "-h" && a != "--quiet" && a != "-quiet" && a != "--allow-insecure-config" && a != "--supervised" && a != "--ci" &&
+					!strings.HasPrefix(a, "--config") && !strings.HasPrefix(a, "-config") && !strings.HasPrefix(a, "--pprof") && !strings.HasPrefix(a, "--profile") &&
+					!strings.HasPrefix(a, "--events-") && !strings.HasPrefix(a, "--tee") {
 					hasGeminiArgs = true
 					break
 				}
@@ -77,6 +101,15 @@ func main() {
 	flag.StringVar(&configPath, "config", "", "Path to config file")
 	flag.BoolVar(&quiet, "quiet", false, "Disable all notifications")
 	flag.BoolVar(&help, "help", false, "Show help message")
+	flag.BoolVar(&allowInsecure, "allow-insecure-config", false, "Load a config file with credentials even if other users can read it")
+	flag.StringVar(&pprofAddr, "pprof", "", "Serve net/http/pprof profiling endpoints on this address")
+	flag.BoolVar(&pprofRemote, "pprof-allow-remote", false, "Serve the pprof endpoints on addresses other machines can reach")
+	flag.StringVar(&profileName, "profile", "", "Profile of the wrapped CLI (gemini, claude, aider, codex)")
+	flag.BoolVar(&supervised, "supervised", false, "Run as a systemd service without a terminal")
+	flag.BoolVar(&ci, "ci", false, "Run without a PTY and only report start, exit and errors")
+	flag.StringVar(&eventsFile, "events-file", "", "Write events as JSON lines to this file or FIFO")
+	flag.IntVar(&eventsFD, "events-fd", -1, "Write events as JSON lines to this file descriptor")
+	flag.StringVar(&teePath, "tee", "", "Write a plain text copy of Gemini's output to this file")
 
 	// Parse only our flags
 	if err := flag.CommandLine.Parse(ourArgs); err != nil {
@@ -89,23 +122,188 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Hook commands run by Gemini CLI and the shell need no configuration of
+	// their own
+	if isHookCommand(geminiArgs) && !isShellHookInstall(geminiArgs) {
+		runHook(geminiArgs[1])
+		os.Exit(0)
+	}
+	if isShellHookInstall(geminiArgs) {
+		if err := installShellHook(geminiArgs, profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if isSessionsList(geminiArgs) {
+		if err := listSessions(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if isHooksInstall(geminiArgs) {
+		if err := installHooks(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error installing hooks: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Setup writes the config, so it can't depend on a complete one
+	if isSetup(geminiArgs) {
+		if configPath != "" {
+			_ = os.Setenv("GEMINI_NOTIFY_CONFIG", configPath)
+		}
+		if err := runSetup(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// A replay sends nothing, so it needs no notification service
+	if isSimulate(geminiArgs) {
+		if err := os.Setenv("GEMINI_NOTIFY_QUIET", "true"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting config option: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Point the loader at the config file given on the command line
+	if configPath != "" {
+		if err := os.Setenv("GEMINI_NOTIFY_CONFIG", configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting config path: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if allowInsecure {
+		if err := os.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "true"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting config option: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
+	if errors.Is(err, config.ErrNoNotificationService) && !ci && !supervised && isInteractive() &&
+		!isNotifyEvent(geminiArgs) && !isTopicRotate(geminiArgs) && !isSubscribe(geminiArgs) && !isDigest(geminiArgs) {
+		configured, onboardErr := onboard()
+		if onboardErr != nil {
+			fmt.Fprintf(os.Stderr, "Error setting up a topic: %v\n", onboardErr)
+			os.Exit(1)
+		}
+		if configured {
+			cfg, err = config.Load()
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Override config with command line flags
-	if configPath != "" {
-		if err := os.Setenv("GEMINI_NOTIFY_CONFIG", configPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error setting config path: %v\n", err)
+	// Our own subcommands don't start Gemini
+	if isNotifyEvent(geminiArgs) {
+		if err := runNotifyEvent(cfg, geminiArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending notification: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if isTopicRotate(geminiArgs) {
+		if err := rotateTopic(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rotating topic: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if isSubscribe(geminiArgs) {
+		if err := runSubscribe(cfg, geminiArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error subscribing: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if isDigest(geminiArgs) {
+		if err := runDigest(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending digest: %v\n", err)
 			os.Exit(1)
 		}
+		os.Exit(0)
 	}
+
+	// Override config with command line flags
 	if quiet {
 		cfg.Quiet = true
 	}
+	if profileName != "" {
+		if _, err := config.LookupProfile(profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Profile = profileName
+	}
+	if supervised {
+		cfg.Supervised = true
+	}
+	if ci {
+		cfg.CI = true
+	}
+	if eventsFile != "" {
+		cfg.EventsFile = eventsFile
+	}
+	if eventsFD >= 0 {
+		cfg.EventsFile = fmt.Sprintf("/dev/fd/%d", eventsFD)
+	}
+	if teePath != "" {
+		cfg.Tee = teePath
+	}
+	// Nothing can be drawn on or read from a terminal under systemd or in CI
+	if cfg.Supervised || cfg.CI {
+		cfg.DisableTerminalUI()
+	}
+	// Escape sequences show up as garbage on a dumb terminal, e.g. under
+	// script or an IDE task runner, and without a terminal on stdin there is
+	// no raw mode or size to follow; output is still followed as usual
+	if !cfg.Supervised && !cfg.CI {
+		if limitation, limited := terminal.DetectLimitation(os.Getenv, process.IsTerminal(int(os.Stdin.Fd()))); limited {
+			if drawsOnTerminal(cfg) {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %s, turning off the terminal UI\n", limitation.Reason)
+			}
+			cfg.DisableTerminalUI()
+			cfg.Headless = cfg.Headless || limitation.Headless
+		}
+	}
+	// CI jobs only report start, exit and errors, and can't be controlled
+	if cfg.CI {
+		cfg.BackstopTimeout = 0
+		cfg.ControlTopic = ""
+		cfg.ControlSocket = false
+		cfg.GeminiHooks = false
+	}
+
+	// Replay a recorded session with the final configuration
+	if isSimulate(geminiArgs) {
+		if err := runSimulate(cfg, geminiArgs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error simulating session: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Remote input without signing lets anyone who knows the topic type into the terminal
+	if cfg.ControlTopic != "" && cfg.ControlSecret == "" && (cfg.RemoteInput || cfg.RemoteSignals) {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: warning: remote input/signals enabled without control_secret\n")
+	}
+
+	// Profiling endpoints for diagnosing throughput problems
+	if pprofAddr != "" {
+		if err := startPprof(pprofAddr, pprofRemote); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting pprof: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Use the manually parsed Gemini args
 	userArgs := geminiArgs
@@ -117,7 +315,16 @@ func main() {
 
 	var command string
 
-	// Determine gemini path
+	// Pick the profile of the wrapped CLI: the one asked for, or the one
+	// named by the configured path or the name we were installed under
+	detectFrom := cfg.GeminiPath
+	if detectFrom == "" {
+		detectFrom = os.Args[0]
+	}
+	profile := cfg.ActiveProfile(detectFrom)
+	cfg.Profile = profile.Name
+
+	// Determine the path of the wrapped CLI
 	if cfg.GeminiPath != "" {
 		// Use configured path directly - don't validate, let it fail at execution if wrong
 		command = cfg.GeminiPath
@@ -125,29 +332,35 @@ func main() {
 			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: Using configured gemini path: %s\n", command)
 		}
 	} else {
-		// Try to find gemini in PATH, excluding ourselves
-		geminiPath, err := findGemini()
+		// Try to find the CLI in PATH, excluding ourselves
+		binaryPath, err := findBinary(profile.Binary)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			fmt.Fprintf(os.Stderr, "\nYou can fix this by:\n")
 			fmt.Fprintf(os.Stderr, "1. Setting gemini_path in your config file (~/.config/gemini-cli-ntfy/config.yaml)\n")
 			fmt.Fprintf(os.Stderr, "2. Setting GEMINI_NOTIFY_GEMINI_PATH environment variable\n")
-			fmt.Fprintf(os.Stderr, "3. Ensuring the real gemini is in your PATH\n")
+			fmt.Fprintf(os.Stderr, "3. Ensuring the real %s is in your PATH\n", profile.Binary)
 			os.Exit(1)
 		}
-		command = geminiPath
+		command = binaryPath
 		if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
-			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: Found gemini in PATH at: %s\n", command)
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: Found %s in PATH at: %s\n", profile.Binary, command)
 		}
 	}
 
-	// Merge default args with user args
+	// Merge default args with user args; the defaults are Gemini's flags
 	var args []string
-	if len(cfg.DefaultGeminiArgs) > 0 {
+	if len(cfg.DefaultGeminiArgs) > 0 && profile.Name == config.DefaultProfile {
 		args = append(args, cfg.DefaultGeminiArgs...)
 	}
 	args = append(args, userArgs...)
 
+	// Notify from Gemini's structured events if its arguments ask for them;
+	// other CLIs print formats of their own
+	if cfg.OutputFormat == "" && profile.Name == config.DefaultProfile {
+		cfg.OutputFormat = monitor.DetectOutputFormat(args)
+	}
+
 	// Create dependencies
 	deps, err := NewDependencies(cfg)
 	if err != nil {
@@ -193,6 +406,10 @@ func main() {
 		if _, ok := err.(*exec.ExitError); !ok {
 			// Only log if it's not an expected exit error
 			fmt.Fprintf(os.Stderr, "Error running gemini: %v\n", err)
+			// Gemini never ran, so there is no exit code to pass on
+			if app.ExitCode() == 0 {
+				os.Exit(1)
+			}
 		}
 	}
 
@@ -204,11 +421,28 @@ func printUsage() {
 	fmt.Println("gemini-cli-ntfy - Gemini CLI wrapper with notifications")
 	fmt.Println()
 	fmt.Println("Usage: gemini-cli-ntfy [OPTIONS] [GEMINI_ARGS...]")
+	fmt.Println("       gemini-cli-ntfy [OPTIONS] setup")
+	fmt.Println("       gemini-cli-ntfy [OPTIONS] topic rotate")
+	fmt.Println("       gemini-cli-ntfy [OPTIONS] subscribe [--since 1h] [--topic TOPIC]")
+	fmt.Println("       gemini-cli-ntfy [OPTIONS] digest")
+	fmt.Println("       gemini-cli-ntfy hooks install")
+	fmt.Println("       gemini-cli-ntfy sessions list")
+	fmt.Println("       gemini-cli-ntfy [OPTIONS] simulate --input recording.cast [--speed 10x]")
+	fmt.Println("       gemini-cli-ntfy [--profile name] hook install [zsh|bash]")
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("      --config string   Path to config file")
 	fmt.Println("      --help            Show help message")
 	fmt.Println("      --quiet           Disable all notifications")
+	fmt.Println("      --allow-insecure-config  Load a config file with credentials even if other users can read it")
+	fmt.Println("      --pprof addr      Serve pprof profiling endpoints, e.g. localhost:6060")
+	fmt.Println("      --pprof-allow-remote  Allow a --pprof address other machines can reach")
+	fmt.Println("      --profile name    Wrap another CLI: " + strings.Join(config.ProfileNames(), ", "))
+	fmt.Println("      --supervised      Run as a systemd service (Type=notify, watchdog, plain log output)")
+	fmt.Println("      --ci              Run without a PTY (CI, cron); only notify on start, exit and errors")
+	fmt.Println("      --events-file path  Write events as JSON lines to a file or FIFO")
+	fmt.Println("      --events-fd n     Write events as JSON lines to an inherited file descriptor")
+	fmt.Println("      --tee path        Write a plain text copy of Gemini's output to a file")
 	fmt.Println()
 	fmt.Println("All unknown flags are passed through to Gemini CLI")
 	fmt.Println()
@@ -221,12 +455,29 @@ func printUsage() {
 	fmt.Println("  GEMINI_NOTIFY_DEFAULT_ARGS  Default Gemini args (comma-separated)")
 	fmt.Println("  GEMINI_NOTIFY_CONFIG      Path to config file")
 	fmt.Println("  GEMINI_NOTIFY_GEMINI_PATH  Path to the real gemini binary")
+	fmt.Println("  GEMINI_NOTIFY_PROFILE     Profile of the wrapped CLI (default: detected)")
+	fmt.Println("  GEMINI_NOTIFY_CONTROL_TOPIC  Ntfy topic for remote commands")
+	fmt.Println("  GEMINI_NOTIFY_REMOTE_INPUT  Allow remote replies to type into Gemini (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_REMOTE_SIGNALS  Allow remote interrupt/kill of Gemini (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_CONTROL_SECRET  Shared secret for signed remote commands")
+	fmt.Println("  GEMINI_NOTIFY_CONTROL_SOCKET  Serve control commands on a unix socket (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_GEMINI_HOOKS  Use events from installed Gemini CLI hooks (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_OUTPUT_FORMAT  Gemini's output format: text, json, stream-json (default: detected)")
+	fmt.Println("  GEMINI_NOTIFY_STATUS_LINE  Show a status bar on the bottom row (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_IDLE_TITLE  Mark the terminal title while Gemini is idle (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_LOCAL_BELL  Ring the terminal bell on notifications (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_ROUTING     Notification routing: push, desktop, both, focus")
+	fmt.Println("  GEMINI_NOTIFY_HOTKEYS     Enable wrapper hotkeys and the settings overlay (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_SUPERVISED  Run as a systemd service (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_CI          Run in CI mode without a PTY (true/false)")
+	fmt.Println("  GEMINI_NOTIFY_EVENTS_FILE  Write events as JSON lines to this file")
+	fmt.Println("  GEMINI_NOTIFY_RECEIVER_ADDR  Accept notifications posted to http://ADDR/event")
 	fmt.Println()
 	fmt.Println("Configuration file: ~/.config/gemini-cli-ntfy/config.yaml")
 }
 
-// findGemini searches for the real gemini binary in PATH, excluding ourselves
-func findGemini() (string, error) {
+// findBinary searches for the named binary in PATH, excluding ourselves
+func findBinary(name string) (string, error) {
 	// Get our own executable path to exclude it
 	ourPath, err := os.Executable()
 	if err != nil {
@@ -237,24 +488,24 @@ func findGemini() (string, error) {
 		return "", fmt.Errorf("failed to resolve our executable path: %w", err)
 	}
 
-	// Search PATH for gemini
+	// Search PATH for the binary
 	pathEnv := os.Getenv("PATH")
 	if pathEnv == "" {
 		return "", fmt.Errorf("PATH environment variable is empty")
 	}
 
 	for _, dir := range filepath.SplitList(pathEnv) {
-		geminiPath := filepath.Join(dir, "gemini")
+		binaryPath := filepath.Join(dir, name)
 
 		// Check if file exists and is executable
-		info, err := os.Stat(geminiPath)
+		info, err := os.Stat(binaryPath)
 		if err != nil {
 			continue // Not found in this directory
 		}
 
 		if info.Mode().IsRegular() && info.Mode()&0111 != 0 {
 			// Resolve symlinks to check if it's us
-			resolvedPath, err := filepath.EvalSymlinks(geminiPath)
+			resolvedPath, err := filepath.EvalSymlinks(binaryPath)
 			if err != nil {
 				continue
 			}
@@ -264,10 +515,10 @@ func findGemini() (string, error) {
 				continue
 			}
 
-			// Found a different gemini binary
-			return geminiPath, nil
+			// Found a different binary of that name
+			return binaryPath, nil
 		}
 	}
 
-	return "", fmt.Errorf("gemini not found in PATH (excluding gemini-cli-ntfy wrapper)")
+	return "", fmt.Errorf("%s not found in PATH (excluding gemini-cli-ntfy wrapper)", name)
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/terminal"
+)
+
+// isInteractive reports whether a person is at the terminal to answer
+// questions, rather than a script or service manager
+func isInteractive() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// onboard offers a first-time user a new topic, writes it to the config
+// file and shows a QR code to subscribe to it with a phone. It reports
+// whether a topic was configured, after which the config can be loaded.
+func onboard() (bool, error) {
+	path := config.Path()
+	if path == "" {
+		return false, nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Println("No ntfy topic is configured, so gemini-cli-ntfy has nowhere to send notifications.")
+	fmt.Print("Generate a private topic now? [Y/n] ")
+	answer, err := in.ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return false, nil
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+		return false, nil
+	}
+
+	topic, err := generateTopic()
+	if err != nil {
+		return false, err
+	}
+	cfg, err := config.LoadUnvalidated()
+	if err != nil {
+		return false, err
+	}
+	server := strings.TrimSuffix(cfg.NtfyServer, "/")
+	if err := config.SetFileValue(path, "ntfy_topic", topic); err != nil {
+		return false, err
+	}
+	subscribeURL := fmt.Sprintf("%s/%s", server, topic)
+
+	fmt.Printf("\nWrote ntfy_topic to %s\n", path)
+	fmt.Println("Scan the code with your phone's camera, or subscribe to the topic in the ntfy app:")
+	fmt.Println()
+	if code, err := terminal.QRCode(subscribeURL); err == nil {
+		fmt.Print(code)
+	}
+	fmt.Printf("\n  %s\n\n", subscribeURL)
+	fmt.Println("Run gemini-cli-ntfy setup to choose priorities and send a test notification.")
+	fmt.Print("Press Enter to start Gemini.")
+	_, _ = in.ReadString('\n')
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// backstopStep is how much the settings overlay changes the backstop timeout per key press
+const backstopStep = 15 * time.Second
+
+// overlayHistoryLines is how many recent notifications the settings overlay lists
+const overlayHistoryLines = 10
+
+// overlayLines renders the runtime settings overlay
+func (d *Dependencies) overlayLines() []string {
+	quiet := "off"
+	if d.QuietNotifier.IsQuiet() {
+		quiet = "on"
+	}
+
+	backstop := "off"
+	if bn, ok := d.Notifier.(*notification.BackstopNotifier); ok && bn.Timeout() > 0 {
+		backstop = bn.Timeout().String()
+	}
+
+	lines := []string{
+		"\033[1mgemini-cli-ntfy settings\033[0m",
+		"",
+		fmt.Sprintf("  q      Quiet mode        %s", quiet),
+		fmt.Sprintf("  + / -  Backstop timeout  %s", backstop),
+		"",
+		"\033[1mRecent notifications\033[0m",
+		"",
+	}
+
+	recent := d.History.Recent()
+	if len(recent) > overlayHistoryLines {
+		recent = recent[:overlayHistoryLines]
+	}
+	if len(recent) == 0 {
+		lines = append(lines, "  (none yet)")
+	}
+	for _, n := range recent {
+		lines = append(lines, "  "+clip(fmt.Sprintf("%s  %s: %s", n.Time.Format("15:04:05"), n.Title, n.Message), 76))
+	}
+
+	return append(lines, "", "  Esc or Enter to return to Gemini")
+}
+
+// handleOverlayKey applies a key pressed in the settings overlay and returns
+// true when the overlay should close
+func (d *Dependencies) handleOverlayKey(key byte) bool {
+	switch key {
+	case 'q', 'Q':
+		d.QuietNotifier.SetQuiet(!d.QuietNotifier.IsQuiet())
+	case '+', '=':
+		d.adjustBackstop(backstopStep)
+	case '-', '_':
+		d.adjustBackstop(-backstopStep)
+	case '\033', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// adjustBackstop changes the backstop timeout by delta, rounding to whole steps
+func (d *Dependencies) adjustBackstop(delta time.Duration) {
+	bn, ok := d.Notifier.(*notification.BackstopNotifier)
+	if !ok {
+		return
+	}
+	timeout := (bn.Timeout() + delta).Truncate(backstopStep)
+	if timeout < 0 {
+		timeout = 0
+	}
+	bn.SetTimeout(timeout)
+}
+
+// clip shortens s to at most width runes, flattening newlines
+func clip(s string, width int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+)
+
+// startPprof serves the runtime profiling endpoints on addr, e.g.
+// "localhost:6060". It returns once the listener is open, so a bad address
+// is reported before Gemini starts. The endpoints are unauthenticated, so
+// addresses other machines can reach are refused unless allowRemote is set.
+func startPprof(addr string, allowRemote bool) error {
+	if !loopbackAddr(addr) {
+		if !allowRemote {
+			return fmt.Errorf("%s is reachable from other machines and the endpoints are unauthenticated; use localhost, or --pprof-allow-remote to serve them anyway", addr)
+		}
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: warning: serving unauthenticated pprof endpoints on %s\n", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	// Use a private mux rather than http.DefaultServeMux
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: pprof server stopped: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// loopbackAddr reports whether a host:port address only listens on the
+// loopback interface
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestLoopbackAddr(t *testing.T) {
+	tests := map[string]bool{
+		"localhost:6060":   true,
+		"127.0.0.1:6060":   true,
+		"127.0.0.2:6060":   true,
+		"[::1]:6060":       true,
+		":6060":            false,
+		"0.0.0.0:6060":     false,
+		"[::]:6060":        false,
+		"192.168.1.5:6060": false,
+		"devbox:6060":      false,
+		"localhost":        false,
+	}
+	for addr, want := range tests {
+		if got := loopbackAddr(addr); got != want {
+			t.Errorf("loopbackAddr(%q) = %v, expected %v", addr, got, want)
+		}
+	}
+}
+
+func TestStartPprofRefusesRemoteAddresses(t *testing.T) {
+	if err := startPprof(":0", false); err == nil {
+		t.Error("expected all interfaces to be refused")
+	}
+	if err := startPprof("127.0.0.1:0", false); err != nil {
+		t.Errorf("expected localhost to be served, got %v", err)
+	}
+	if err := startPprof("0.0.0.0:0", true); err != nil {
+		t.Errorf("expected all interfaces to be served when allowed, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/session"
+)
+
+// sessionRefreshInterval is how often a running session updates its entry
+// in the registry
+const sessionRefreshInterval = 15 * time.Second
+
+// isSessionsList reports whether the arguments are the "sessions list"
+// command rather than arguments for Gemini
+func isSessionsList(args []string) bool {
+	return len(args) == 2 && args[0] == "sessions" && args[1] == "list"
+}
+
+// listSessions prints the running sessions of the current user
+func listSessions(w io.Writer) error {
+	sessions, err := session.NewRegistry(session.DefaultDir()).List()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		_, _ = fmt.Fprintln(w, "No running sessions")
+		return nil
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PID\tSESSION\tSTARTED\tSTATE\tTOPIC\tDIRECTORY")
+	for _, s := range sessions {
+		state := "idle " + formatIdle(s.IdleFor(now))
+		if s.Quiet {
+			state += ", quiet"
+		}
+		topic := s.Topic
+		if topic == "" {
+			topic = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", s.PID, s.ID, formatStarted(s.Started, now), state, topic, shortenHome(s.Dir))
+	}
+	return tw.Flush()
+}
+
+// formatStarted formats a start time as the time of day, with the date if
+// it was not today
+func formatStarted(started, now time.Time) string {
+	started = started.Local()
+	if y, m, d := started.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return started.Format("15:04")
+	}
+	return started.Format("Jan 2 15:04")
+}
+
+// shortenHome replaces the home directory at the start of a path with ~
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// registerSession records the session in the registry and keeps its entry
+// current until stop is closed
+func (d *Dependencies) registerSession(stop <-chan struct{}) {
+	dir, _ := os.Getwd()
+	info := session.Info{
+		PID:     os.Getpid(),
+		ID:      d.SessionID,
+		Started: time.Now(),
+		Dir:     dir,
+		Topic:   d.topic,
+		Profile: d.Config.Profile,
+	}
+	if d.ControlSocket != nil {
+		info.Socket = d.ControlSocket.Path()
+	}
+	if d.outputLog != nil {
+		info.OutputLog = d.outputLog.Path()
+	}
+	d.sessionInfo(&info)
+	if err := d.Sessions.Save(info); err != nil {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to register session: %v\n", err)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(sessionRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				previous := info
+				d.sessionInfo(&info)
+				if info != previous {
+					_ = d.Sessions.Save(info)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// sessionInfo fills in the parts of a session's entry that change while it
+// runs
+func (d *Dependencies) sessionInfo(info *session.Info) {
+	if om, ok := d.OutputMonitor.(interface{ LastOutputTime() time.Time }); ok {
+		info.LastOutput = om.LastOutputTime()
+	}
+	info.Quiet = d.QuietNotifier.IsQuiet()
+}
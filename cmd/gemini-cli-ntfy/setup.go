@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/terminal"
+)
+
+// isSetup reports whether the arguments are the "setup" command rather than
+// arguments for Gemini
+func isSetup(args []string) bool {
+	return len(args) == 1 && args[0] == "setup"
+}
+
+// errSetupCancelled is returned when the user leaves the wizard early
+var errSetupCancelled = errors.New("setup cancelled; the config file was not changed")
+
+// setupWizard asks for the settings needed to receive notifications and
+// writes them to the config file once a test notification has arrived
+type setupWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	// Sends the test notification
+	send func(server, topic string, n notification.Notification) error
+}
+
+// runSetup walks the user through choosing a server and topic and their
+// priorities, and writes the config file after they confirm receiving a
+// test notification
+func runSetup() error {
+	wizard := &setupWizard{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stdout,
+		send: func(server, topic string, n notification.Notification) error {
+			client := notification.NewNtfyClient(server, topic)
+			return client.Send(n)
+		},
+	}
+	return wizard.run()
+}
+
+// run asks the questions, verifies delivery and writes the config file
+func (w *setupWizard) run() error {
+	path := config.Path()
+	if path == "" {
+		return fmt.Errorf("cannot determine config file location")
+	}
+
+	// Settings already in place are offered as the answers, so running
+	// setup again doesn't move notifications to another topic
+	defaults, err := config.LoadUnvalidated()
+	if err != nil {
+		return err
+	}
+	topic := defaults.NtfyTopic
+	topicHint := ""
+	if topic == "" {
+		generated, err := generateTopic()
+		if err != nil {
+			return err
+		}
+		topic, topicHint = generated, " (new and hard to guess)"
+	}
+
+	fmt.Fprintf(w.out, "This sets up notifications and writes %s.\n\n", path)
+	server, err := w.ask("ntfy server", defaults.NtfyServer, validServer)
+	if err != nil {
+		return err
+	}
+	server = strings.TrimSuffix(server, "/")
+	fmt.Fprintln(w.out, "Anyone who knows the topic can read your notifications, so keep it secret.")
+	topic, err = w.ask("Topic"+topicHint, topic, validTopic)
+	if err != nil {
+		return err
+	}
+	subscribeURL := fmt.Sprintf("%s/%s", server, topic)
+	fmt.Fprintln(w.out, "Scan the code with your phone's camera, or subscribe to the topic in the ntfy app:")
+	if code, err := terminal.QRCode(subscribeURL); err == nil {
+		fmt.Fprintf(w.out, "\n%s", code)
+	}
+	fmt.Fprintf(w.out, "\n  %s\n\n", subscribeURL)
+
+	fmt.Fprintln(w.out, "Priorities decide how loudly your phone announces a notification: min, low, default, high or urgent.")
+	waiting, err := w.ask("Priority when Gemini waits for you", w.priorityDefault(defaults, "backstop"), validPriority)
+	if err != nil {
+		return err
+	}
+	failing, err := w.ask("Priority when Gemini crashes or is stuck", w.priorityDefault(defaults, "crash"), validPriority)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w.out)
+
+	if err := w.verify(server, topic, waiting); err != nil {
+		return err
+	}
+
+	values := map[string]string{"ntfy_server": server, "ntfy_topic": topic}
+	for _, key := range []string{"ntfy_server", "ntfy_topic"} {
+		if err := config.SetFileValue(path, key, values[key]); err != nil {
+			return err
+		}
+	}
+	priorities := []struct{ pattern, priority string }{
+		{"backstop", waiting},
+		{"crash", failing},
+		{"oom", failing},
+		{"stuck", failing},
+	}
+	for _, p := range priorities {
+		if err := config.SetFileMapValue(path, "priorities", p.pattern, strings.ToLower(p.priority)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w.out, "Wrote the settings to %s\n", path)
+
+	if os.Getenv("GEMINI_NOTIFY_TOPIC") != "" || os.Getenv("GEMINI_NOTIFY_SERVER") != "" {
+		fmt.Fprintln(w.out, "Note: GEMINI_NOTIFY_TOPIC or GEMINI_NOTIFY_SERVER is set and overrides the config file.")
+	}
+	return nil
+}
+
+// verify sends a test notification with a code until the user types the
+// code back, showing the notification arrived
+func (w *setupWizard) verify(server, topic, priority string) error {
+	code, err := setupCode()
+	if err != nil {
+		return err
+	}
+	level, _ := notification.ParsePriority(priority)
+
+	for {
+		fmt.Fprintln(w.out, "Sending a test notification...")
+		err := w.send(server, topic, notification.Notification{
+			Title:    "gemini-cli-ntfy setup",
+			Message:  fmt.Sprintf("Your setup code is %s", code),
+			Time:     time.Now(),
+			Pattern:  "setup",
+			Priority: level,
+		})
+		if err != nil {
+			fmt.Fprintf(w.out, "Sending failed: %v\n", err)
+		}
+
+		for {
+			answer, err := w.prompt("Code from the notification (r to resend, q to quit)")
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(answer) {
+			case code:
+				fmt.Fprintln(w.out, "Received.")
+				return nil
+			case "q":
+				return errSetupCancelled
+			case "r", "":
+			default:
+				fmt.Fprintln(w.out, "That is not the code. Check that you subscribed to the topic above.")
+				continue
+			}
+			break
+		}
+	}
+}
+
+// priorityDefault returns the priority the config gives a type, by name
+func (w *setupWizard) priorityDefault(cfg *config.Config, pattern string) string {
+	if priority, ok := cfg.Priorities[pattern]; ok {
+		return priority
+	}
+	if priority, ok := notification.DefaultPriorities[pattern]; ok && priority == notification.PriorityUrgent {
+		return "urgent"
+	}
+	return "default"
+}
+
+// ask prompts for a value, offering def when the user just presses Enter,
+// until check accepts it
+func (w *setupWizard) ask(question, def string, check func(string) error) (string, error) {
+	for {
+		label := question
+		if def != "" {
+			label = fmt.Sprintf("%s [%s]", question, def)
+		}
+		answer, err := w.prompt(label)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintln(w.out, err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// prompt shows a question and reads one line of answer
+func (w *setupWizard) prompt(question string) (string, error) {
+	fmt.Fprintf(w.out, "%s: ", question)
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Fprintln(w.out)
+		return "", errSetupCancelled
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// setupCode returns a random four-digit code
+func setupCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
+
+// validServer accepts http and https URLs
+func validServer(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("enter a URL such as https://ntfy.sh")
+	}
+	return nil
+}
+
+// validTopic accepts the characters ntfy allows in topic names
+func validTopic(s string) error {
+	if s == "" || len(s) > 64 || strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+		return fmt.Errorf("a topic is up to 64 letters, digits, - and _")
+	}
+	return nil
+}
+
+// validPriority accepts the priority names and numbers
+func validPriority(s string) error {
+	_, err := notification.ParsePriority(s)
+	return err
+}
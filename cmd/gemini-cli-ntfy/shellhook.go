@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// Notification types sent for commands seen by the shell hooks, and in CI mode
+const (
+	commandStart  = "command_start"
+	commandFinish = "command_finish"
+	commandError  = "command_error"
+)
+
+// isShellHookInstall reports whether the arguments are the
+// "hook install [zsh|bash]" command
+func isShellHookInstall(args []string) bool {
+	return len(args) >= 2 && len(args) <= 3 && args[0] == "hook" && args[1] == "install"
+}
+
+// isNotifyEvent reports whether the arguments are the "notify-event" command
+// run by the shell hooks
+func isNotifyEvent(args []string) bool {
+	return len(args) > 0 && args[0] == "notify-event"
+}
+
+// zshHook reports commands run from an interactive zsh. %[1]s is our
+// executable and %[2]s the name of the watched binary. preexec's third
+// argument is the command line with aliases expanded, so an alias for the
+// binary is reported too.
+const zshHook = `# gemini-cli-ntfy shell hooks for zsh
+_gemini_ntfy_preexec() {
+  local cmd=${3%%%%[[:space:]]*}
+  [[ ${cmd:t} == %[2]s ]] || return 0
+  _gemini_ntfy_cmd=$3
+  _gemini_ntfy_start=$SECONDS
+  %[1]s notify-event start "$3" >/dev/null 2>&1 &!
+}
+_gemini_ntfy_precmd() {
+  local code=$?
+  [[ -n $_gemini_ntfy_cmd ]] || return 0
+  %[1]s notify-event finish "$code" "$(( SECONDS - _gemini_ntfy_start ))" "$_gemini_ntfy_cmd" >/dev/null 2>&1 &!
+  unset _gemini_ntfy_cmd
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec _gemini_ntfy_preexec
+add-zsh-hook precmd _gemini_ntfy_precmd
+`
+
+// bashHook does the same for bash, which has no preexec hook: the DEBUG trap
+// stands in for it, armed only for the first command after each prompt.
+// It replaces any DEBUG trap already set.
+const bashHook = `# gemini-cli-ntfy shell hooks for bash
+_gemini_ntfy_preexec() {
+  [[ -n $_gemini_ntfy_armed ]] || return 0
+  _gemini_ntfy_armed=
+  local cmd=${1%%%%[[:space:]]*}
+  [[ ${cmd##*/} == %[2]s ]] || return 0
+  _gemini_ntfy_cmd=$1
+  _gemini_ntfy_start=$SECONDS
+  (%[1]s notify-event start "$1" >/dev/null 2>&1 &)
+}
+_gemini_ntfy_precmd() {
+  local code=$?
+  if [[ -n $_gemini_ntfy_cmd ]]; then
+    (%[1]s notify-event finish "$code" "$(( SECONDS - _gemini_ntfy_start ))" "$_gemini_ntfy_cmd" >/dev/null 2>&1 &)
+    _gemini_ntfy_cmd=
+  fi
+  return $code
+}
+_gemini_ntfy_arm() {
+  _gemini_ntfy_armed=1
+}
+if [[ $PROMPT_COMMAND != *_gemini_ntfy_precmd* ]]; then
+  PROMPT_COMMAND="_gemini_ntfy_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND};_gemini_ntfy_arm"
+fi
+trap '_gemini_ntfy_preexec "$BASH_COMMAND"' DEBUG
+`
+
+// installShellHook prints hooks for the given shell (default: $SHELL) that
+// report when the profile's binary starts and finishes, for use as
+// eval "$(gemini-cli-ntfy hook install zsh)" in the shell's startup file
+func installShellHook(args []string, profileName string) error {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if len(args) == 3 {
+		shell = args[2]
+	}
+
+	if profileName == "" {
+		profileName = config.DefaultProfile
+	}
+	profile, err := config.LookupProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get our executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	var script string
+	switch shell {
+	case "zsh":
+		script = zshHook
+	case "bash":
+		script = bashHook
+	default:
+		return fmt.Errorf("unsupported shell %q (use zsh or bash)", shell)
+	}
+	fmt.Printf(script, shellQuote(exe), shellQuote(profile.Binary))
+	return nil
+}
+
+// runNotifyEvent sends a notification for a command seen by the shell hooks:
+//
+//	notify-event start <command line>
+//	notify-event finish <exit code> <seconds> <command line>
+func runNotifyEvent(cfg *config.Config, args []string) error {
+	// Nothing is wrapped, so only the notifiers are needed
+	cfg.NotifiersOnly()
+	deps, err := NewDependencies(cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	n, err := commandNotification(deps.Messages, args)
+	if err != nil {
+		return err
+	}
+
+	err = deps.Notifier.Send(n)
+	if deps.EventHooks != nil {
+		deps.EventHooks.Wait(eventHookGrace)
+	}
+	return err
+}
+
+// commandNotification builds the notification for notify-event's arguments
+func commandNotification(messages notification.Messages, args []string) (notification.Notification, error) {
+	switch {
+	case len(args) == 3 && args[1] == "start":
+		return commandStartNotification(messages, args[2]), nil
+	case len(args) == 5 && args[1] == "finish":
+		code, err := strconv.Atoi(args[2])
+		if err != nil {
+			return notification.Notification{}, fmt.Errorf("invalid exit code %q", args[2])
+		}
+		seconds, err := strconv.Atoi(args[3])
+		if err != nil || seconds < 0 {
+			return notification.Notification{}, fmt.Errorf("invalid duration %q", args[3])
+		}
+		return commandEndNotification(messages, args[4], code, time.Duration(seconds)*time.Second), nil
+	default:
+		return notification.Notification{}, fmt.Errorf("usage: notify-event start <command> | notify-event finish <exit code> <seconds> <command>")
+	}
+}
+
+// commandStartNotification reports that a command line has started
+func commandStartNotification(messages notification.Messages, line string) notification.Notification {
+	return notification.Notification{
+		Title:   messages.Get("command_start.title", programName(line)),
+		Message: messages.Get("command_start.message", line),
+		Time:    time.Now(),
+		Pattern: commandStart,
+	}
+}
+
+// commandEndNotification reports that a command line has exited, as an
+// error if the exit code isn't zero
+func commandEndNotification(messages notification.Messages, line string, code int, duration time.Duration) notification.Notification {
+	kind := commandFinish
+	if code != 0 {
+		kind = commandError
+	}
+	program := programName(line)
+	return notification.Notification{
+		Title:   messages.Get(kind+".title", program),
+		Message: messages.Get(kind+".message", program, code, duration),
+		Time:    time.Now(),
+		Pattern: kind,
+	}
+}
+
+// programName returns the name of the program a command line runs
+func programName(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+func TestNotifyEventLeavesSessionOutputAlone(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	tee := filepath.Join(dir, "gemini.txt")
+	if err := os.WriteFile(tee, []byte("a session's output\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Quiet = true
+	cfg.Tee = tee
+	cfg.OutputLog = "plain"
+	if err := runNotifyEvent(cfg, []string{"notify-event", "start", "make test"}); err != nil {
+		t.Fatalf("runNotifyEvent failed: %v", err)
+	}
+
+	data, err := os.ReadFile(tee)
+	if err != nil || string(data) != "a session's output\n" {
+		t.Errorf("expected the tee file to be left alone, got %q, %v", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "state", "gemini-cli-ntfy", "logs")); len(entries) > 0 {
+		t.Errorf("expected no output log, got %d files", len(entries))
+	}
+}
+
+func TestCommandNotification(t *testing.T) {
+	messages := notification.DefaultMessages()
+	tests := []struct {
+		name    string
+		args    []string
+		pattern string
+		title   string
+		wantErr bool
+	}{
+		{"start", []string{"notify-event", "start", "gemini -p 'fix it'"}, commandStart, "gemini started", false},
+		{"finish", []string{"notify-event", "finish", "0", "90", "/usr/local/bin/gemini"}, commandFinish, "gemini finished", false},
+		{"error", []string{"notify-event", "finish", "130", "5", "gemini"}, commandError, "gemini failed", false},
+		{"bad exit code", []string{"notify-event", "finish", "x", "5", "gemini"}, "", "", true},
+		{"bad duration", []string{"notify-event", "finish", "0", "5s", "gemini"}, "", "", true},
+		{"negative duration", []string{"notify-event", "finish", "0", "-5", "gemini"}, "", "", true},
+		{"start without command", []string{"notify-event", "start"}, "", "", true},
+		{"finish without command", []string{"notify-event", "finish", "0", "5"}, "", "", true},
+		{"unknown event", []string{"notify-event", "stop", "gemini"}, "", "", true},
+		{"no event", []string{"notify-event"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := commandNotification(messages, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("commandNotification failed: %v", err)
+			}
+			if n.Pattern != tt.pattern || n.Title != tt.title {
+				t.Errorf("expected %s %q, got %s %q", tt.pattern, tt.title, n.Pattern, n.Title)
+			}
+		})
+	}
+}
+
+func TestProgramName(t *testing.T) {
+	tests := map[string]string{
+		"gemini":                     "gemini",
+		"  gemini --yolo":            "gemini",
+		"/usr/local/bin/gemini -p x": "gemini",
+		"./gemini":                   "gemini",
+		"":                           "",
+		"   ":                        "",
+	}
+	for line, want := range tests {
+		if got := programName(line); got != want {
+			t.Errorf("programName(%q) = %q, expected %q", line, got, want)
+		}
+	}
+}
+
+func TestInstallShellHookUnknownShell(t *testing.T) {
+	if err := installShellHook([]string{"hook", "install", "fish"}, ""); err == nil {
+		t.Error("expected an error for fish")
+	}
+	t.Setenv("SHELL", "/usr/bin/fish")
+	if err := installShellHook([]string{"hook", "install"}, ""); err == nil {
+		t.Error("expected an error for fish from $SHELL")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/session"
+	flag "github.com/spf13/pflag"
+)
+
+// isSimulate reports whether the arguments are the "simulate" command
+// rather than arguments for Gemini
+func isSimulate(args []string) bool {
+	return len(args) > 0 && args[0] == "simulate"
+}
+
+// runSimulate replays a recorded session through the monitors and
+// notifiers, printing the notifications it would have sent instead of
+// sending them, so timeouts and patterns can be tuned against real sessions
+func runSimulate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	input := flags.String("input", "", "asciinema recording (.cast) to replay")
+	speedArg := flags.String("speed", "1x", "Replay speed, e.g. 10x")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("usage: simulate --input recording.cast [--speed 10x]")
+	}
+	speed, err := parseSpeed(*speedArg)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G304 -- The path is given by the user
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	events, err := session.ReadCast(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	simulationConfig(cfg, speed)
+	printer := &replayPrinter{out: os.Stdout, speed: speed}
+	deps, err := newDependencies(cfg, printer)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	printer.start = time.Now()
+	NewApplication(deps).replay(events, speed)
+	var length time.Duration
+	if len(events) > 0 {
+		length = events[len(events)-1].Time
+	}
+	fmt.Printf("Replayed %s of %s in %s: %d notifications\n",
+		formatIdle(length), *input, formatIdle(time.Since(printer.start)), printer.sent())
+	return nil
+}
+
+// parseSpeed parses a replay speed such as "10x", "10" or "0.5x"
+func parseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %q (e.g. 10x)", s)
+	}
+	return speed, nil
+}
+
+// simulationConfig keeps a replay from touching anything outside it, and
+// shortens the configured timeouts by the replay speed so they fire at the
+// same point in the recording
+func simulationConfig(cfg *config.Config, speed float64) {
+	cfg.DisableTerminalUI()
+	cfg.Quiet = false
+	cfg.Supervised = false
+	cfg.CI = false
+	cfg.ControlTopic = ""
+	cfg.ControlSocket = false
+	cfg.GeminiHooks = false
+	cfg.ReceiverAddr = ""
+	cfg.EventHooks = nil
+	cfg.EventsFile = ""
+	cfg.OutputLog = ""
+	cfg.CompressLogs = false
+	cfg.StateMaxMB = 0
+	cfg.Tee = ""
+	cfg.DigestTime = ""
+	cfg.WatchFiles = false
+	cfg.WatchCommits = false
+
+	for _, d := range []*time.Duration{
+		&cfg.BackstopTimeout,
+		&cfg.StuckAfter,
+		&cfg.ToolRunningAfter,
+		&cfg.ProgressAfter,
+		&cfg.HeartbeatInterval,
+		&cfg.EscalateAfter,
+		&cfg.ResponseTimeout,
+		&cfg.ResponseReadyAfter,
+		&cfg.MaxSessionDuration,
+	} {
+		*d = time.Duration(float64(*d) / speed)
+	}
+}
+
+// replay feeds a recording's output and input to the session in its
+// original rhythm, sped up by speed
+func (a *Application) replay(events []session.CastEvent, speed float64) {
+	a.startWatchers()
+
+	start := time.Now()
+	for _, event := range events {
+		time.Sleep(time.Until(start.Add(time.Duration(float64(event.Time) / speed))))
+		switch event.Kind {
+		case session.CastOutput:
+			a.deps.ProcessManager.ReplayOutput([]byte(event.Data))
+		case session.CastInput:
+			a.deps.ProcessManager.ReplayInput([]byte(event.Data))
+		}
+	}
+
+	if a.deps.durationTimer != nil {
+		a.deps.durationTimer.Stop()
+	}
+}
+
+// replayPrinter prints each notification of a replay with the time in the
+// recording it was sent at
+type replayPrinter struct {
+	out   io.Writer
+	speed float64
+	start time.Time
+
+	mu    sync.Mutex
+	count int
+}
+
+// Send implements notification.Notifier
+func (rp *replayPrinter) Send(n notification.Notification) error {
+	at := time.Duration(float64(time.Since(rp.start)) * rp.speed)
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.count++
+	priority := ""
+	if n.Priority != 0 {
+		priority = fmt.Sprintf(" (priority %d)", n.Priority)
+	}
+	fmt.Fprintf(rp.out, "%8s  %s%s: %s\n", formatIdle(at), n.Pattern, priority, n.Title)
+	for _, line := range strings.Split(n.Message, "\n") {
+		fmt.Fprintf(rp.out, "%8s  %s\n", "", line)
+	}
+	return nil
+}
+
+// sent returns how many notifications were printed
+func (rp *replayPrinter) sent() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.count
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// statusText returns the one-line summary shown in the status line
+func (d *Dependencies) statusText() string {
+	parts := []string{"gemini-cli-ntfy"}
+
+	// A fresh confirmation replaces the rest of the bar until it expires
+	if text := d.Flash.Text(); text != "" {
+		return strings.Join(append(parts, text), " │ ")
+	}
+
+	switch {
+	case d.QuietNotifier.IsQuiet():
+		parts = append(parts, "notify: quiet")
+	case !d.QuietNotifier.SnoozedUntil().IsZero():
+		parts = append(parts, "notify: snoozed until "+d.QuietNotifier.SnoozedUntil().Format("15:04"))
+	default:
+		parts = append(parts, "notify: on")
+	}
+
+	if om, ok := d.OutputMonitor.(interface{ LastOutputTime() time.Time }); ok {
+		parts = append(parts, "idle "+formatIdle(time.Since(om.LastOutputTime())))
+	}
+
+	if backstop, ok := d.Notifier.(*notification.BackstopNotifier); ok {
+		if remaining, warn := d.backstopWarning(); warn {
+			parts = append(parts, fmt.Sprintf("⚠ backstop in %ds - press a key to cancel", int(remaining.Round(time.Second).Seconds())))
+		} else if remaining, pending := backstop.TimeUntilBackstop(); pending {
+			parts = append(parts, fmt.Sprintf("backstop in %ds", int(remaining.Round(time.Second).Seconds())))
+		} else if backstop.BackstopSent() {
+			parts = append(parts, "backstop sent")
+		}
+	}
+
+	return strings.Join(parts, " │ ")
+}
+
+// backstopWarning returns the time left before the backstop notification
+// and whether it is close enough to warn about locally
+func (d *Dependencies) backstopWarning() (time.Duration, bool) {
+	if d.Config.BackstopWarning <= 0 {
+		return 0, false
+	}
+	backstop, ok := d.Notifier.(*notification.BackstopNotifier)
+	if !ok {
+		return 0, false
+	}
+	remaining, pending := backstop.TimeUntilBackstop()
+	return remaining, pending && remaining <= d.Config.BackstopWarning
+}
+
+// templateDataTailLines is how many output lines notification templates can see
+const templateDataTailLines = 20
+
+// templateData returns the context fields available to notification templates
+func (d *Dependencies) templateData() notification.TemplateData {
+	var data notification.TemplateData
+
+	if cwd, err := os.Getwd(); err == nil {
+		data.Cwd = cwd
+		data.GitBranch = notification.GitBranch(cwd)
+	}
+	data.Hostname, _ = os.Hostname()
+
+	if om, ok := d.OutputMonitor.(interface{ LastOutputTime() time.Time }); ok {
+		data.IdleDuration = time.Since(om.LastOutputTime()).Round(time.Second)
+	}
+	if om, ok := d.OutputMonitor.(interface{ LastInputTime() time.Time }); ok {
+		data.InputIdleDuration = time.Since(om.LastInputTime()).Round(time.Second)
+	}
+	if d.promptCapture != nil && d.Config.CapturePrompt {
+		data.Prompt = d.promptCapture.LastPrompt()
+	}
+	data.Model = d.model()
+	if om, ok := d.OutputMonitor.(interface{ GetScreenTail(n int) []string }); ok {
+		data.TailLines = om.GetScreenTail(templateDataTailLines)
+	}
+
+	return data
+}
+
+// formatIdle formats an idle duration as m:ss or h:mm:ss
+func formatIdle(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
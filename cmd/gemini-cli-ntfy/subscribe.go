@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	flag "github.com/spf13/pflag"
+)
+
+// isSubscribe reports whether the arguments are the "subscribe" command
+// rather than arguments for Gemini
+func isSubscribe(args []string) bool {
+	return len(args) > 0 && args[0] == "subscribe"
+}
+
+// priorityLabels names the priorities on ntfy's scale
+var priorityLabels = map[int]string{
+	notification.PriorityMin:     "min",
+	notification.PriorityLow:     "low",
+	notification.PriorityDefault: "default",
+	notification.PriorityHigh:    "high",
+	notification.PriorityUrgent:  "urgent",
+}
+
+// runSubscribe prints the notifications arriving on the topic until
+// interrupted, to check delivery end to end or to follow sessions on other
+// machines without a phone
+func runSubscribe(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	topic := flags.String("topic", cfg.NtfyTopic, "Topic to subscribe to")
+	since := flags.String("since", "", "Also show earlier notifications, e.g. 1h or all")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *topic == "" {
+		return fmt.Errorf("no topic to subscribe to; set ntfy_topic or pass --topic")
+	}
+
+	printer := &subscribePrinter{out: os.Stdout}
+	sub := notification.NewSubscriber(cfg.NtfyServer, *topic, printer.print)
+	if cfg.NtfyClientCert != "" || cfg.NtfyCACert != "" {
+		tlsConfig, err := notification.NewTLSConfig(cfg.NtfyClientCert, cfg.NtfyClientKey, cfg.NtfyCACert)
+		if err != nil {
+			return err
+		}
+		sub.SetTLSConfig(tlsConfig)
+	}
+	if *since != "" {
+		sub.SetSince(*since)
+	}
+	sub.SetErrorHandler(func(err error) {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %v; retrying\n", err)
+	})
+
+	fmt.Fprintf(os.Stderr, "Listening on %s/%s (Ctrl-C to stop)\n", strings.TrimSuffix(cfg.NtfyServer, "/"), *topic)
+	if err := sub.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+	signal.Stop(signals)
+	return sub.Close()
+}
+
+// subscribePrinter prints received notifications, one block per message
+type subscribePrinter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// print writes a message's time, title, priority and tags, followed by
+// its text
+func (p *subscribePrinter) print(msg notification.ControlMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	header := msg.Title
+	if header == "" {
+		header = "(no title)"
+	}
+	if msg.Priority != notification.PriorityDefault {
+		header += fmt.Sprintf("  [%s]", priorityLabels[msg.Priority])
+	}
+	if len(msg.Tags) > 0 {
+		header += "  #" + strings.Join(msg.Tags, " #")
+	}
+	// Earlier notifications asked for with --since may be from another day
+	stamp := msg.Time.Format("15:04:05")
+	if now := time.Now(); msg.Time.YearDay() != now.YearDay() || msg.Time.Year() != now.Year() {
+		stamp = msg.Time.Format("Jan 2 15:04:05")
+	}
+	fmt.Fprintf(p.out, "%s  %s\n", stamp, header)
+	for _, line := range strings.Split(msg.Message, "\n") {
+		fmt.Fprintf(p.out, "%8s  %s\n", "", line)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// isTopicRotate reports whether the arguments are the "topic rotate"
+// command rather than arguments for Gemini
+func isTopicRotate(args []string) bool {
+	return len(args) == 2 && args[0] == "topic" && args[1] == "rotate"
+}
+
+// rotateTopic moves notifications to a freshly generated topic: the config
+// file is updated, the old topic is told it is no longer used, and
+// subscribe instructions for the new topic are printed
+func rotateTopic(cfg *config.Config) error {
+	newTopic, err := generateTopic()
+	if err != nil {
+		return err
+	}
+
+	path := config.Path()
+	if path == "" {
+		return fmt.Errorf("cannot determine config file location")
+	}
+	if err := config.SetFileValue(path, "ntfy_topic", newTopic); err != nil {
+		return err
+	}
+	fmt.Printf("Updated ntfy_topic in %s\n", path)
+
+	// Don't reveal the new topic on the old one; anyone could be listening
+	if oldTopic := cfg.NtfyTopic; oldTopic != "" {
+		client := notification.NewNtfyClient(cfg.NtfyServer, oldTopic)
+		client.SetTimeouts(cfg.NtfyTimeout, cfg.NtfyConnectTimeout)
+		if cfg.NtfyClientCert != "" || cfg.NtfyCACert != "" {
+			tlsConfig, err := notification.NewTLSConfig(cfg.NtfyClientCert, cfg.NtfyClientKey, cfg.NtfyCACert)
+			if err != nil {
+				return err
+			}
+			client.SetTLSConfig(tlsConfig)
+		}
+
+		err := client.Send(notification.Notification{
+			Title:   "Gemini CLI topic moved",
+			Message: "Notifications now go to a new topic. You can unsubscribe from this one.",
+			Time:    time.Now(),
+			Pattern: "rotate",
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to notify old topic: %v\n", err)
+		} else {
+			fmt.Printf("Sent a \"topic moved\" notice to the old topic %q\n", oldTopic)
+		}
+	}
+
+	if os.Getenv("GEMINI_NOTIFY_TOPIC") != "" {
+		fmt.Println()
+		fmt.Println("Note: GEMINI_NOTIFY_TOPIC is set and overrides the config file.")
+		fmt.Printf("Update it to: export GEMINI_NOTIFY_TOPIC=%s\n", newTopic)
+	}
+
+	fmt.Println()
+	fmt.Printf("New topic: %s\n", newTopic)
+	fmt.Println("Subscribe to it in the ntfy app, or open:")
+	fmt.Printf("  %s/%s\n", strings.TrimSuffix(cfg.NtfyServer, "/"), newTopic)
+
+	return nil
+}
+
+// generateTopic returns a new random, hard to guess topic name
+func generateTopic() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate topic: %w", err)
+	}
+	return "gemini-" + hex.EncodeToString(b), nil
+}
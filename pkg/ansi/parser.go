@@ -0,0 +1,274 @@
+package ansi
+
+// SequenceKind identifies the type of an escape sequence
+type SequenceKind int
+
+const (
+	// KindEscape is a short escape sequence such as ESC c or ESC ( B
+	KindEscape SequenceKind = iota + 1
+	// KindCSI is a control sequence such as ESC [ 2 J
+	KindCSI
+	// KindOSC is an operating system command such as ESC ] 0 ; title BEL
+	KindOSC
+	// KindString is a DCS, APC or PM control string, whose contents are ignored
+	KindString
+)
+
+// Limits on buffered sequence contents. Longer sequences are still consumed
+// but not dispatched, so a runaway sequence cannot grow memory unbounded.
+const (
+	maxParamsLength = 64
+	maxDataLength   = 4096
+)
+
+// Sequence is a complete escape sequence found by a Parser. Its slices point
+// into the parser's buffers and are only valid during the Dispatch call.
+type Sequence struct {
+	Kind SequenceKind
+	// Params holds the CSI parameter bytes, including private markers such as '?'
+	Params []byte
+	// Intermediates holds intermediate bytes, e.g. '(' in ESC ( B
+	Intermediates []byte
+	// Final is the byte that ended an escape or CSI sequence
+	Final byte
+	// Data holds the payload of an OSC sequence, without its terminator
+	Data []byte
+}
+
+// Handler receives the contents of a byte stream as a Parser reads it
+type Handler interface {
+	// Print is called with a run of text outside escape sequences. The slice
+	// is only valid during the call.
+	Print(text []byte)
+	// Execute is called for a C0 control character outside escape sequences,
+	// such as BEL or a newline
+	Execute(c byte)
+	// Dispatch is called for every complete escape sequence
+	Dispatch(seq *Sequence)
+}
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCSI
+	stateString
+	stateStringEscape
+)
+
+// Parser is an incremental escape sequence parser. It keeps its state between
+// calls to Feed, so sequences split across chunks are handled without
+// rescanning earlier data.
+type Parser struct {
+	state parserState
+	seq   Sequence
+	// overflow is set when the current sequence exceeded a length limit
+	overflow bool
+	// Continuation bytes still expected for the current UTF-8 character, so
+	// they are never mistaken for 8-bit controls
+	utf8Pending int
+
+	params        []byte
+	intermediates []byte
+	data          []byte
+}
+
+// NewParser creates a parser in the ground state
+func NewParser() *Parser {
+	return &Parser{
+		params:        make([]byte, 0, maxParamsLength),
+		intermediates: make([]byte, 0, 4),
+	}
+}
+
+// Feed parses data, calling h for text, controls and sequences as they are
+// found. Incomplete sequences at the end of data are completed by later calls.
+func (p *Parser) Feed(data []byte, h Handler) {
+	i := 0
+	for i < len(data) {
+		if p.state == stateGround {
+			// Hand runs of text over in one call
+			start := i
+			for i < len(data) {
+				if b := data[i]; b >= 0x20 && b < 0x7F {
+					// Plain ASCII, the common case
+					p.utf8Pending = 0
+				} else if !p.isText(b) {
+					break
+				}
+				i++
+			}
+			if i > start {
+				h.Print(data[start:i])
+			}
+			if i == len(data) {
+				return
+			}
+		}
+
+		p.advance(data[i], h)
+		i++
+	}
+}
+
+// isText reports whether b, read in the ground state, is text, and tracks
+// multi-byte UTF-8 characters
+func (p *Parser) isText(b byte) bool {
+	switch {
+	case b < 0x20 || b == 0x7F:
+		return false
+	case b < 0x80:
+		p.utf8Pending = 0
+	case b < 0xC0:
+		if p.utf8Pending == 0 && b == 0x9B { // CSI (8-bit)
+			return false
+		}
+		if p.utf8Pending > 0 {
+			p.utf8Pending--
+		}
+	case b < 0xE0:
+		p.utf8Pending = 1
+	case b < 0xF0:
+		p.utf8Pending = 2
+	case b < 0xF8:
+		p.utf8Pending = 3
+	default:
+		p.utf8Pending = 0
+	}
+	return true
+}
+
+// advance processes a single byte that is not part of a text run
+func (p *Parser) advance(b byte, h Handler) {
+	// CAN and SUB abort a sequence anywhere, ESC starts a new one unless it
+	// may be the start of a string terminator
+	switch {
+	case b == 0x18 || b == 0x1A:
+		p.state = stateGround
+		return
+	case b == 0x1B && p.state != stateString:
+		p.begin(stateEscape)
+		return
+	}
+
+	switch p.state {
+	case stateGround:
+		p.utf8Pending = 0
+		switch b {
+		case 0x7F:
+		case 0x9B:
+			p.begin(stateCSI)
+		default:
+			h.Execute(b)
+		}
+
+	case stateEscape:
+		switch {
+		case b < 0x20:
+			h.Execute(b)
+		case b >= 0x20 && b <= 0x2F:
+			p.collectIntermediate(b)
+		case b == '[' && len(p.intermediates) == 0:
+			p.state = stateCSI
+		case b == ']' && len(p.intermediates) == 0:
+			p.seq.Kind = KindOSC
+			p.state = stateString
+		case (b == 'P' || b == '_' || b == '^') && len(p.intermediates) == 0:
+			p.seq.Kind = KindString
+			p.state = stateString
+		case b == 0x7F:
+		default:
+			p.seq.Kind = KindEscape
+			p.seq.Final = b
+			p.dispatch(h)
+		}
+
+	case stateCSI:
+		switch {
+		case b < 0x20:
+			h.Execute(b)
+		case b >= 0x20 && b <= 0x2F:
+			p.collectIntermediate(b)
+		case b >= 0x30 && b <= 0x3F:
+			if len(p.params) < maxParamsLength {
+				p.params = append(p.params, b)
+			} else {
+				p.overflow = true
+			}
+		case b >= 0x40 && b <= 0x7E:
+			p.seq.Kind = KindCSI
+			p.seq.Final = b
+			p.dispatch(h)
+		}
+
+	case stateString:
+		switch b {
+		case 0x07:
+			p.dispatch(h)
+		case 0x1B:
+			p.state = stateStringEscape
+		default:
+			p.collectData(b)
+		}
+
+	case stateStringEscape:
+		if b == '\\' {
+			p.dispatch(h)
+			return
+		}
+		// Not a terminator: the ESC starts a new sequence
+		p.begin(stateEscape)
+		p.advance(b, h)
+	}
+}
+
+// begin resets the sequence buffers and enters state
+func (p *Parser) begin(state parserState) {
+	p.state = state
+	p.seq = Sequence{}
+	p.overflow = false
+	p.params = p.params[:0]
+	p.intermediates = p.intermediates[:0]
+	p.data = p.data[:0]
+}
+
+// collectIntermediate records an intermediate byte of the current sequence
+func (p *Parser) collectIntermediate(b byte) {
+	if len(p.intermediates) < cap(p.intermediates) {
+		p.intermediates = append(p.intermediates, b)
+	} else {
+		p.overflow = true
+	}
+}
+
+// collectData records a byte of the current string payload, which only OSC
+// sequences keep
+func (p *Parser) collectData(b byte) {
+	if p.seq.Kind != KindOSC {
+		return
+	}
+	if len(p.data) < maxDataLength {
+		p.data = append(p.data, b)
+	} else {
+		p.overflow = true
+	}
+}
+
+// dispatch reports the completed sequence and returns to the ground state
+func (p *Parser) dispatch(h Handler) {
+	p.state = stateGround
+	if p.overflow {
+		return
+	}
+
+	p.seq.Params = p.params
+	p.seq.Intermediates = p.intermediates
+	p.seq.Data = p.data
+	h.Dispatch(&p.seq)
+}
+
+// Reset returns the parser to the ground state, discarding any partial sequence
+func (p *Parser) Reset() {
+	p.begin(stateGround)
+}
@@ -0,0 +1,164 @@
+package ansi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingHandler records everything a parser reports as readable events
+type recordingHandler struct {
+	events []string
+}
+
+func (r *recordingHandler) Print(text []byte) {
+	r.events = append(r.events, "print "+string(text))
+}
+
+func (r *recordingHandler) Execute(c byte) {
+	r.events = append(r.events, fmt.Sprintf("execute %#x", c))
+}
+
+func (r *recordingHandler) Dispatch(seq *Sequence) {
+	switch seq.Kind {
+	case KindEscape:
+		r.events = append(r.events, fmt.Sprintf("esc %s%c", seq.Intermediates, seq.Final))
+	case KindCSI:
+		r.events = append(r.events, fmt.Sprintf("csi %s%s%c", seq.Params, seq.Intermediates, seq.Final))
+	case KindOSC:
+		r.events = append(r.events, "osc "+string(seq.Data))
+	case KindString:
+		r.events = append(r.events, "string")
+	}
+}
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected []string
+	}{
+		{
+			name:     "text and controls",
+			chunks:   []string{"hi\n\x07there"},
+			expected: []string{"print hi", "execute 0xa", "execute 0x7", "print there"},
+		},
+		{
+			name:     "csi with private parameters",
+			chunks:   []string{"\033[?1049h\033[2J"},
+			expected: []string{"csi ?1049h", "csi 2J"},
+		},
+		{
+			name:     "escape with intermediate",
+			chunks:   []string{"\033(B\033c"},
+			expected: []string{"esc (B", "esc c"},
+		},
+		{
+			name:     "osc terminated by BEL and ST",
+			chunks:   []string{"\033]0;one\007\033]2;two\033\\"},
+			expected: []string{"osc 0;one", "osc 2;two"},
+		},
+		{
+			name:     "sequences split across chunks",
+			chunks:   []string{"a\033", "[2", "Jb\033]0;ti", "tle\033", "\\"},
+			expected: []string{"print a", "csi 2J", "print b", "osc 0;title"},
+		},
+		{
+			name:     "dcs contents ignored",
+			chunks:   []string{"\033Pq#0;1\033\\x"},
+			expected: []string{"string", "print x"},
+		},
+		{
+			name:     "escape interrupts a sequence",
+			chunks:   []string{"\033[12\033[K"},
+			expected: []string{"csi K"},
+		},
+		{
+			name:     "cancel aborts a sequence",
+			chunks:   []string{"\033[12\x18x"},
+			expected: []string{"print x"},
+		},
+		{
+			name:     "8-bit csi",
+			chunks:   []string{"a\x9b31mb"},
+			expected: []string{"print a", "csi 31m", "print b"},
+		},
+		{
+			name:     "utf-8 text passes through",
+			chunks:   []string{"caf\xc3\xa9 \xe2\x9b\x94"},
+			expected: []string{"print caf\xc3\xa9 \xe2\x9b\x94"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			h := &recordingHandler{}
+			for _, chunk := range tt.chunks {
+				p.Feed([]byte(chunk), h)
+			}
+
+			// Text split across chunks is reported per chunk; join it back up
+			var events []string
+			for _, e := range h.events {
+				if n := len(events); n > 0 && strings.HasPrefix(e, "print ") && strings.HasPrefix(events[n-1], "print ") {
+					events[n-1] += strings.TrimPrefix(e, "print ")
+					continue
+				}
+				events = append(events, e)
+			}
+
+			if strings.Join(events, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %q, got %q", tt.expected, events)
+			}
+		})
+	}
+}
+
+func TestParserOversizedSequence(t *testing.T) {
+	p := NewParser()
+	h := &recordingHandler{}
+
+	p.Feed([]byte("\033]0;"+strings.Repeat("x", maxDataLength+10)+"\007ok"), h)
+
+	if len(h.events) != 1 || h.events[0] != "print ok" {
+		t.Errorf("expected oversized OSC to be dropped, got %d events", len(h.events))
+	}
+}
+
+// nopHandler discards parser events
+type nopHandler struct{}
+
+func (nopHandler) Print([]byte)       {}
+func (nopHandler) Execute(byte)       {}
+func (nopHandler) Dispatch(*Sequence) {}
+
+// benchmarkOutput is colored, partly non-ASCII terminal output
+var benchmarkOutput = []byte(strings.Repeat("\033[32m+ added ✓ line\033[0m\r\n\033]0;Gemini\007\033[2K\033[1G> prompt\n", 64))
+
+func BenchmarkParserFeed(b *testing.B) {
+	p := NewParser()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkOutput)))
+	for i := 0; i < b.N; i++ {
+		p.Feed(benchmarkOutput, nopHandler{})
+	}
+}
+
+func BenchmarkStripAppend(b *testing.B) {
+	buf := make([]byte, 0, len(benchmarkOutput))
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkOutput)))
+	for i := 0; i < b.N; i++ {
+		buf = StripAppend(buf[:0], benchmarkOutput)
+	}
+}
+
+func BenchmarkSanitize(b *testing.B) {
+	s := string(benchmarkOutput)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(s)))
+	for i := 0; i < b.N; i++ {
+		_ = Sanitize(s)
+	}
+}
@@ -0,0 +1,39 @@
+package ansi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sanitize makes text from the terminal safe to include in a notification:
+// escape sequences, control characters and invalid UTF-8 are removed, and
+// only newlines and tabs are kept as whitespace controls
+func Sanitize(s string) string {
+	stripped := Strip([]byte(s))
+
+	var b strings.Builder
+	b.Grow(len(stripped))
+	for len(stripped) > 0 {
+		r, size := utf8.DecodeRune(stripped)
+		stripped = stripped[size:]
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// Invalid UTF-8
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case unicode.IsControl(r):
+			// Carriage returns and C1 controls
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SanitizeLine sanitizes s like Sanitize and folds it onto a single line,
+// for titles and other one-line fields
+func SanitizeLine(s string) string {
+	return strings.Join(strings.Fields(Sanitize(s)), " ")
+}
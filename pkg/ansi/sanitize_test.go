@@ -0,0 +1,34 @@
+package ansi
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"colors", "\033[1;31merror\033[0m: failed", "error: failed"},
+		{"title sequence", "\033]0;title\007done", "done"},
+		{"bell and backspace", "a\007b\bc", "abc"},
+		{"keeps newlines and tabs", "one\n\ttwo\r\n", "one\n\ttwo\n"},
+		{"C1 controls", "a\u0085b\u009bc", "abc"},
+		{"invalid UTF-8", "a\xffb", "ab"},
+		{"multi-byte characters", "ě ✛ 日本語", "ě ✛ 日本語"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.expected {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSanitizeLine(t *testing.T) {
+	if got := SanitizeLine("  \033[1mBuild\033[0m\n  failed\t(2)  "); got != "Build failed (2)" {
+		t.Errorf("unexpected line %q", got)
+	}
+}
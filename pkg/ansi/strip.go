@@ -0,0 +1,99 @@
+// Package ansi provides helpers for handling terminal escape sequences.
+package ansi
+
+import "unicode/utf8"
+
+// Strip removes ANSI escape sequences and non-printable control characters
+// from data. Newlines, carriage returns and tabs are kept so callers can
+// still reconstruct lines.
+func Strip(data []byte) []byte {
+	return StripAppend(make([]byte, 0, len(data)), data)
+}
+
+// StripAppend is like Strip but appends the result to dst and returns the
+// extended slice, so callers can reuse a buffer
+func StripAppend(dst, data []byte) []byte {
+	result := dst
+
+	i := 0
+	for i < len(data) {
+		b := data[i]
+
+		switch {
+		case b == 0x1B: // ESC
+			i = skipEscape(data, i+1)
+			continue
+		case b == 0x9B: // CSI (8-bit)
+			i = skipCSI(data, i+1)
+			continue
+		case b == '\n' || b == '\r' || b == '\t':
+			result = append(result, b)
+		case b < 0x20 || b == 0x7F:
+			// Drop other C0 control characters (bell, backspace, ...)
+		case b >= 0xC0:
+			// Copy multi-byte UTF-8 characters whole, so continuation bytes
+			// such as 0x9B are never mistaken for 8-bit controls
+			if r, size := utf8.DecodeRune(data[i:]); r != utf8.RuneError || size > 1 {
+				result = append(result, data[i:i+size]...)
+				i += size
+				continue
+			}
+			result = append(result, b)
+		default:
+			result = append(result, b)
+		}
+		i++
+	}
+
+	return result
+}
+
+// skipEscape skips the escape sequence starting after ESC at index i and
+// returns the index of the first byte following it
+func skipEscape(data []byte, i int) int {
+	if i >= len(data) {
+		return i
+	}
+
+	switch next := data[i]; {
+	case next == '[': // CSI sequence
+		return skipCSI(data, i+1)
+	case next == ']' || next == 'P' || next == '_' || next == '^': // OSC, DCS, APC, PM strings
+		return skipString(data, i+1)
+	case next == '(' || next == ')' || next == '*' || next == '+' || next == '#': // Character set designation
+		if i+1 < len(data) {
+			return i + 2
+		}
+		return i + 1
+	default: // Two-byte sequence such as ESC c or ESC M
+		return i + 1
+	}
+}
+
+// skipCSI skips CSI parameters up to and including the final byte
+func skipCSI(data []byte, i int) int {
+	for i < len(data) {
+		c := data[i]
+		i++
+		if c >= 0x40 && c <= 0x7E {
+			break
+		}
+	}
+	return i
+}
+
+// skipString skips a control string terminated by BEL or ST (ESC \)
+func skipString(data []byte, i int) int {
+	for i < len(data) {
+		c := data[i]
+		i++
+		if c == 0x07 {
+			break
+		}
+		if c == 0x1B && i < len(data) && data[i] == '\\' {
+			i++
+			break
+		}
+	}
+	return i
+}
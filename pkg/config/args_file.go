@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadArgsFile reads arguments from a file with one argument per line, taken
+// as is apart from surrounding whitespace, so arguments may contain spaces,
+// commas and quotes. Blank lines and lines starting with # are skipped, and a
+// leading ~/ in the path is the home directory.
+func loadArgsFile(path string) ([]string, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	// #nosec G304 - The path comes from the user's own configuration
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, line)
+	}
+	return args, nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadArgsFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "gemini-args")
+	content := "# Defaults for every session\n--model\ngemini-2.5-pro\n\n  --include-directories=../shared docs, notes  \r\n#--yolo\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"--model", "gemini-2.5-pro", "--include-directories=../shared docs, notes"}
+	for _, p := range []string{path, "~/gemini-args"} {
+		args, err := loadArgsFile(p)
+		if err != nil {
+			t.Fatalf("loadArgsFile(%q) failed: %v", p, err)
+		}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("loadArgsFile(%q) = %q, want %q", p, args, want)
+		}
+	}
+
+	if _, err := loadArgsFile(filepath.Join(home, "missing")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestLoadDefaultArgsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gemini-args")
+	if err := os.WriteFile(path, []byte("--prompt-interactive\nexplain this, briefly\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEMINI_NOTIFY_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("GEMINI_NOTIFY_DEFAULT_ARGS", "--sandbox")
+	t.Setenv("GEMINI_NOTIFY_DEFAULT_ARGS_FILE", path)
+
+	cfg, err := LoadUnvalidated()
+	if err != nil {
+		t.Fatalf("LoadUnvalidated failed: %v", err)
+	}
+	want := []string{"--sandbox", "--prompt-interactive", "explain this, briefly"}
+	if !reflect.DeepEqual(cfg.DefaultGeminiArgs, want) {
+		t.Errorf("DefaultGeminiArgs = %q, want %q", cfg.DefaultGeminiArgs, want)
+	}
+
+	t.Setenv("GEMINI_NOTIFY_DEFAULT_ARGS_FILE", filepath.Join(dir, "missing"))
+	if _, err := LoadUnvalidated(); err == nil {
+		t.Error("expected an error for a missing args file")
+	}
+}
@@ -1,12 +1,18 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/terminal"
 	"gopkg.in/yaml.v3"
 )
 
@@ -16,16 +22,331 @@ type Config struct {
 	NtfyTopic  string `yaml:"ntfy_topic" env:"GEMINI_NOTIFY_TOPIC"`
 	NtfyServer string `yaml:"ntfy_server" env:"GEMINI_NOTIFY_SERVER"`
 
+	// Sessions are identified by their name, or else the name of the
+	// working directory. Notifications are tagged with the identifier; it
+	// can also be appended to the topic ("suffix"), and services that group
+	// notifications can give each session its own group.
+	SessionName    string `yaml:"session_name" env:"GEMINI_NOTIFY_SESSION_NAME"`
+	SessionTopic   string `yaml:"session_topic" env:"GEMINI_NOTIFY_SESSION_TOPIC"`
+	GroupBySession bool   `yaml:"group_by_session" env:"GEMINI_NOTIFY_GROUP_BY_SESSION"`
+
 	// Behavior flags
 	Quiet             bool     `yaml:"quiet" env:"GEMINI_NOTIFY_QUIET"`
 	StartupNotify     bool     `yaml:"startup_notify" env:"GEMINI_NOTIFY_STARTUP"`
 	DefaultGeminiArgs []string `yaml:"default_gemini_args"`
 
+	// File with more default arguments, one per line, for arguments with
+	// spaces or commas that the comma-separated env var can't express
+	DefaultGeminiArgsFile string `yaml:"default_gemini_args_file" env:"GEMINI_NOTIFY_DEFAULT_ARGS_FILE"`
+
+	// Client certificate for ntfy servers behind a mutual TLS proxy, and an
+	// optional CA to trust for the server certificate
+	NtfyClientCert string `yaml:"ntfy_client_cert" env:"GEMINI_NOTIFY_CLIENT_CERT"`
+	NtfyClientKey  string `yaml:"ntfy_client_key" env:"GEMINI_NOTIFY_CLIENT_KEY"`
+	NtfyCACert     string `yaml:"ntfy_ca_cert" env:"GEMINI_NOTIFY_CA_CERT"`
+
+	// How long sending a notification may take in all, and how long
+	// connecting to the server may take within that
+	NtfyTimeout        time.Duration `yaml:"ntfy_timeout" env:"GEMINI_NOTIFY_NTFY_TIMEOUT"`
+	NtfyConnectTimeout time.Duration `yaml:"ntfy_connect_timeout" env:"GEMINI_NOTIFY_NTFY_CONNECT_TIMEOUT"`
+
+	// Generic webhook to POST notifications to, in addition to or instead of
+	// ntfy, and an optional secret to sign each request body with
+	WebhookURL    string `yaml:"webhook_url" env:"GEMINI_NOTIFY_WEBHOOK_URL"`
+	WebhookSecret string `yaml:"webhook_secret" env:"GEMINI_NOTIFY_WEBHOOK_SECRET"`
+
+	// Home Assistant instance to send notifications to, either by calling a
+	// service (e.g. "notify.mobile_app_pixel") with a long-lived access
+	// token or by triggering a webhook automation
+	HomeAssistantURL       string `yaml:"homeassistant_url" env:"GEMINI_NOTIFY_HOMEASSISTANT_URL"`
+	HomeAssistantToken     string `yaml:"homeassistant_token" env:"GEMINI_NOTIFY_HOMEASSISTANT_TOKEN"`
+	HomeAssistantService   string `yaml:"homeassistant_service" env:"GEMINI_NOTIFY_HOMEASSISTANT_SERVICE"`
+	HomeAssistantWebhookID string `yaml:"homeassistant_webhook_id" env:"GEMINI_NOTIFY_HOMEASSISTANT_WEBHOOK_ID"`
+
+	// Microsoft Teams incoming webhook or Workflows webhook URL
+	TeamsWebhookURL string `yaml:"teams_webhook_url" env:"GEMINI_NOTIFY_TEAMS_WEBHOOK_URL"`
+
+	// Bark iOS app: the device key, the Bark server, and the sound and
+	// Notification Center group to use
+	BarkDeviceKey string `yaml:"bark_device_key" env:"GEMINI_NOTIFY_BARK_DEVICE_KEY"`
+	BarkServer    string `yaml:"bark_server" env:"GEMINI_NOTIFY_BARK_SERVER"`
+	BarkSound     string `yaml:"bark_sound" env:"GEMINI_NOTIFY_BARK_SOUND"`
+	BarkGroup     string `yaml:"bark_group" env:"GEMINI_NOTIFY_BARK_GROUP"`
+
+	// Signal messages sent through a signal-cli daemon's JSON-RPC interface
+	// (an http URL or unix socket path), to phone numbers or a group ID
+	SignalRPC        string   `yaml:"signal_rpc" env:"GEMINI_NOTIFY_SIGNAL_RPC"`
+	SignalAccount    string   `yaml:"signal_account" env:"GEMINI_NOTIFY_SIGNAL_ACCOUNT"`
+	SignalRecipients []string `yaml:"signal_recipients" env:"GEMINI_NOTIFY_SIGNAL_RECIPIENTS"`
+	SignalGroupID    string   `yaml:"signal_group_id" env:"GEMINI_NOTIFY_SIGNAL_GROUP_ID"`
+
+	// Zulip stream and topic to post to as a bot, identified by its email
+	// address and API key
+	ZulipSite   string `yaml:"zulip_site" env:"GEMINI_NOTIFY_ZULIP_SITE"`
+	ZulipEmail  string `yaml:"zulip_email" env:"GEMINI_NOTIFY_ZULIP_EMAIL"`
+	ZulipAPIKey string `yaml:"zulip_api_key" env:"GEMINI_NOTIFY_ZULIP_API_KEY"`
+	ZulipStream string `yaml:"zulip_stream" env:"GEMINI_NOTIFY_ZULIP_STREAM"`
+	ZulipTopic  string `yaml:"zulip_topic" env:"GEMINI_NOTIFY_ZULIP_TOPIC"`
+
+	// Mattermost incoming webhook URL, with an optional channel and username
+	// to post as instead of the webhook's own
+	MattermostWebhookURL string `yaml:"mattermost_webhook_url" env:"GEMINI_NOTIFY_MATTERMOST_WEBHOOK_URL"`
+	MattermostChannel    string `yaml:"mattermost_channel" env:"GEMINI_NOTIFY_MATTERMOST_CHANNEL"`
+	MattermostUsername   string `yaml:"mattermost_username" env:"GEMINI_NOTIFY_MATTERMOST_USERNAME"`
+
+	// SMS through Twilio, only for notifications of at least
+	// twilio_min_priority
+	TwilioAccountSID  string   `yaml:"twilio_account_sid" env:"GEMINI_NOTIFY_TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken   string   `yaml:"twilio_auth_token" env:"GEMINI_NOTIFY_TWILIO_AUTH_TOKEN"`
+	TwilioFrom        string   `yaml:"twilio_from" env:"GEMINI_NOTIFY_TWILIO_FROM"`
+	TwilioTo          []string `yaml:"twilio_to" env:"GEMINI_NOTIFY_TWILIO_TO"`
+	TwilioMinPriority string   `yaml:"twilio_min_priority" env:"GEMINI_NOTIFY_TWILIO_MIN_PRIORITY"`
+
+	// On-call services that only receive the most urgent notification types
+	// (incident_types), as PagerDuty incidents or Opsgenie P1 alerts
+	PagerDutyRoutingKey string   `yaml:"pagerduty_routing_key" env:"GEMINI_NOTIFY_PAGERDUTY_ROUTING_KEY"`
+	OpsgenieAPIKey      string   `yaml:"opsgenie_api_key" env:"GEMINI_NOTIFY_OPSGENIE_API_KEY"`
+	OpsgenieURL         string   `yaml:"opsgenie_url" env:"GEMINI_NOTIFY_OPSGENIE_URL"`
+	IncidentTypes       []string `yaml:"incident_types"`
+	// Send a stuck notification once Gemini has produced no output for this
+	// long (0 disables)
+	StuckAfter time.Duration `yaml:"stuck_after" env:"GEMINI_NOTIFY_STUCK_AFTER"`
+	// Send a tool_running notification once a tool call, e.g. a shell
+	// command, has been running for this long (0 disables)
+	ToolRunningAfter time.Duration `yaml:"tool_running_after" env:"GEMINI_NOTIFY_TOOL_RUNNING_AFTER"`
+	// Send a progress notification once Gemini has produced output without
+	// a pause for this long, and again as often while it goes on (0 disables)
+	ProgressAfter time.Duration `yaml:"progress_after" env:"GEMINI_NOTIFY_PROGRESS_AFTER"`
+	// Send a heartbeat notification this often while the session runs, busy
+	// or idle (0 disables)
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" env:"GEMINI_NOTIFY_HEARTBEAT_INTERVAL"`
+	// Send a high_memory notification once Gemini and the processes it
+	// started use more than this many MiB of memory (0 disables), and a
+	// high_cpu notification once they have used cpu_warn_percent of a core
+	// or more for cpu_warn_after (0 disables); Linux only
+	MemoryWarnMB   int           `yaml:"memory_warn_mb" env:"GEMINI_NOTIFY_MEMORY_WARN_MB"`
+	CPUWarnAfter   time.Duration `yaml:"cpu_warn_after" env:"GEMINI_NOTIFY_CPU_WARN_AFTER"`
+	CPUWarnPercent int           `yaml:"cpu_warn_percent" env:"GEMINI_NOTIFY_CPU_WARN_PERCENT"`
+	// Send a no_response notification when Gemini has not finished
+	// responding this long after a prompt was submitted (0 disables)
+	ResponseTimeout time.Duration `yaml:"response_timeout" env:"GEMINI_NOTIFY_RESPONSE_TIMEOUT"`
+	// Send a response_ready notification when a response that took longer
+	// than this completes while the terminal is unfocused (0 disables)
+	ResponseReadyAfter time.Duration `yaml:"response_ready_after" env:"GEMINI_NOTIFY_RESPONSE_READY_AFTER"`
+	// Send a context notification when Gemini shows this little of its
+	// context window left, in percent (0 disables); compressions of the
+	// context are reported either way
+	ContextLowPercent int `yaml:"context_low_percent" env:"GEMINI_NOTIFY_CONTEXT_LOW_PERCENT"`
+	// Alert once a session has run for this long (0 disables), and stop
+	// Gemini then if max_session_kill is set
+	MaxSessionDuration time.Duration `yaml:"max_session_duration" env:"GEMINI_NOTIFY_MAX_SESSION_DURATION"`
+	MaxSessionKill     bool          `yaml:"max_session_kill" env:"GEMINI_NOTIFY_MAX_SESSION_KILL"`
+	// Warn once the session has used this many tokens (0 disables), as
+	// structured output reports them or the CLI shows them on screen
+	BudgetTokens int `yaml:"budget_tokens" env:"GEMINI_NOTIFY_BUDGET_TOKENS"`
+	// Warn once the estimated cost of the session's tokens, priced at
+	// cost_per_million_tokens, reaches this (0 disables)
+	BudgetCost           float64 `yaml:"budget_cost" env:"GEMINI_NOTIFY_BUDGET_COST"`
+	CostPerMillionTokens float64 `yaml:"cost_per_million_tokens" env:"GEMINI_NOTIFY_COST_PER_MILLION_TOKENS"`
+	// Hold back input once the budget is used up, until "budget ack" is
+	// sent on the control topic or socket
+	BudgetPause bool `yaml:"budget_pause" env:"GEMINI_NOTIFY_BUDGET_PAUSE"`
+	// Send notifications of escalate_types again until they are
+	// acknowledged, first this long after and then twice as long after each
+	// reminder, at rising priority and at most escalate_max times (0
+	// disables)
+	EscalateAfter time.Duration `yaml:"escalate_after" env:"GEMINI_NOTIFY_ESCALATE_AFTER"`
+	EscalateMax   int           `yaml:"escalate_max" env:"GEMINI_NOTIFY_ESCALATE_MAX"`
+	EscalateTypes []string      `yaml:"escalate_types"`
+	// Exit codes, ranges of them ("1-127"), "nonzero" or "killed" that send
+	// an exit notification; by default, only a crash does, or every exit in
+	// CI mode
+	ExitCodes []string `yaml:"exit_codes" env:"GEMINI_NOTIFY_EXIT_CODES"`
+	// Priority and tags of exit notifications by the exit codes they are
+	// sent for, given like exit_codes, e.g. "0", "nonzero" or "137"
+	ExitStyles map[string]ExitStyle `yaml:"exit_styles"`
+	// Record finished sessions and send a summary of the last day at this
+	// time of day, e.g. "18:00" (empty disables)
+	DigestTime string `yaml:"digest_time" env:"GEMINI_NOTIFY_DIGEST_TIME"`
+	// Log each session's output to a file in the state directory: "raw",
+	// "plain" (without escape sequences) or "both"
+	OutputLog string `yaml:"output_log" env:"GEMINI_NOTIFY_OUTPUT_LOG"`
+	// Gzip output logs once their session ends, and remove the oldest logs
+	// while the state directory takes up more than StateMaxMB (0 disables)
+	CompressLogs bool `yaml:"compress_logs" env:"GEMINI_NOTIFY_COMPRESS_LOGS"`
+	StateMaxMB   int  `yaml:"state_max_mb" env:"GEMINI_NOTIFY_STATE_MAX_MB"`
+	// Write a plain text copy of the output, without escape sequences, to
+	// this file, replacing it; TeeRaw keeps the escape sequences instead
+	Tee    string `yaml:"tee" env:"GEMINI_NOTIFY_TEE"`
+	TeeRaw bool   `yaml:"tee_raw" env:"GEMINI_NOTIFY_TEE_RAW"`
+
 	// Backstop notification - send notification after inactivity
 	BackstopTimeout time.Duration `yaml:"backstop_timeout" env:"GEMINI_NOTIFY_BACKSTOP_TIMEOUT"`
+	// Send a resumed notification when output resumes after a backstop
+	// notification without the user's input
+	ResumeNotify bool `yaml:"resume_notify" env:"GEMINI_NOTIFY_RESUME_NOTIFY"`
 
 	// Gemini path configuration
 	GeminiPath string `yaml:"gemini_path" env:"GEMINI_NOTIFY_GEMINI_PATH"`
+	// Built-in profile of the wrapped CLI (gemini, claude, aider, codex);
+	// detected from the binary name when empty
+	Profile string `yaml:"profile" env:"GEMINI_NOTIFY_PROFILE"`
+
+	// Remote control - ntfy topic to receive commands on
+	ControlTopic string `yaml:"control_topic" env:"GEMINI_NOTIFY_CONTROL_TOPIC"`
+	// Allow remote commands to type into the wrapped process (opt-in)
+	RemoteInput bool `yaml:"remote_input" env:"GEMINI_NOTIFY_REMOTE_INPUT"`
+	// Allow remote commands to interrupt or terminate the wrapped process (opt-in)
+	RemoteSignals bool `yaml:"remote_signals" env:"GEMINI_NOTIFY_REMOTE_SIGNALS"`
+	// Shared secret for HMAC-signed control messages (unsigned messages are rejected when set)
+	ControlSecret string `yaml:"control_secret" env:"GEMINI_NOTIFY_CONTROL_SECRET"`
+	// Maximum age of a signed control message
+	ControlMaxAge time.Duration `yaml:"control_max_age" env:"GEMINI_NOTIFY_CONTROL_MAX_AGE"`
+	// Serve the control API on a per-session unix socket
+	ControlSocket bool `yaml:"control_socket" env:"GEMINI_NOTIFY_CONTROL_SOCKET"`
+	// Receive events from Gemini CLI hooks installed with `hooks install`
+	// instead of relying on the inactivity backstop alone
+	GeminiHooks bool `yaml:"gemini_hooks" env:"GEMINI_NOTIFY_GEMINI_HOOKS"`
+	// Output format of Gemini's non-interactive mode: "json" or
+	// "stream-json" to notify from its structured events, "text" for the
+	// terminal heuristics; detected from Gemini's arguments when empty
+	OutputFormat string `yaml:"output_format" env:"GEMINI_NOTIFY_OUTPUT_FORMAT"`
+
+	// URL template opened when tapping a notification sent from inside tmux
+	TmuxClickURL string `yaml:"tmux_click_url" env:"GEMINI_NOTIFY_TMUX_CLICK_URL"`
+	// Show user@host instead of just the hostname when running over SSH
+	SSHShowUser bool `yaml:"ssh_show_user" env:"GEMINI_NOTIFY_SSH_SHOW_USER"`
+	// Text before and after the session context in notification titles;
+	// "{app}" is replaced with the name of the wrapped CLI
+	TitlePrefix string `yaml:"title_prefix" env:"GEMINI_NOTIFY_TITLE_PREFIX"`
+	TitleSuffix string `yaml:"title_suffix" env:"GEMINI_NOTIFY_TITLE_SUFFIX"`
+	// Add the last prompt typed at the CLI to notifications; off by default
+	// since the text is sent to the notification services
+	CapturePrompt bool `yaml:"capture_prompt" env:"GEMINI_NOTIFY_CAPTURE_PROMPT"`
+	// Send text the CLI copies to the clipboard (OSC 52) in a notification;
+	// off by default since the text is sent to the notification services
+	CaptureClipboard bool `yaml:"capture_clipboard" env:"GEMINI_NOTIFY_CAPTURE_CLIPBOARD"`
+	// Notification types that get the most recent lines of the screen
+	// attached, e.g. crash or api_error
+	AttachScreen []string `yaml:"attach_screen" env:"GEMINI_NOTIFY_ATTACH_SCREEN"`
+	// Watch the project directory for changes to files, whatever the
+	// terminal shows: file_burst_files files changed within
+	// file_burst_window sends a file_burst notification, and a change to a
+	// path matching sensitive_paths a sensitive_file notification
+	WatchFiles      bool          `yaml:"watch_files" env:"GEMINI_NOTIFY_WATCH_FILES"`
+	FileBurstFiles  int           `yaml:"file_burst_files" env:"GEMINI_NOTIFY_FILE_BURST_FILES"`
+	FileBurstWindow time.Duration `yaml:"file_burst_window" env:"GEMINI_NOTIFY_FILE_BURST_WINDOW"`
+	SensitivePaths  []string      `yaml:"sensitive_paths" env:"GEMINI_NOTIFY_SENSITIVE_PATHS"`
+	// Send a commit notification with the subject of each commit made in
+	// the project's repository while the session runs
+	WatchCommits bool `yaml:"watch_commits" env:"GEMINI_NOTIFY_WATCH_COMMITS"`
+	// Send a tests_passed or tests_failed notification with the counts of
+	// the summaries test runners print, e.g. go test or pytest
+	TestResults bool `yaml:"test_results" env:"GEMINI_NOTIFY_TEST_RESULTS"`
+	// Send a build_error notification with the first compiler error the
+	// session shows, recognized for Go, TypeScript and Rust, and by the
+	// regular expressions in build_error_patterns
+	BuildErrors        bool     `yaml:"build_errors" env:"GEMINI_NOTIFY_BUILD_ERRORS"`
+	BuildErrorPatterns []string `yaml:"build_error_patterns"`
+
+	// Show a status bar on the bottom terminal row
+	StatusLine bool `yaml:"status_line" env:"GEMINI_NOTIFY_STATUS_LINE"`
+	// Prefix the terminal title with an hourglass while Gemini is idle
+	IdleTitle bool `yaml:"idle_title" env:"GEMINI_NOTIFY_IDLE_TITLE"`
+	// Idle time before the title is marked (defaults to backstop_timeout)
+	IdleTitleAfter time.Duration `yaml:"idle_title_after" env:"GEMINI_NOTIFY_IDLE_TITLE_AFTER"`
+	// Warn locally this long before the backstop notification fires (0 disables)
+	BackstopWarning time.Duration `yaml:"backstop_warning" env:"GEMINI_NOTIFY_BACKSTOP_WARNING"`
+	// Kinds of input that count as user interaction and disable the
+	// backstop: keystroke, paste, bracketed_paste, remote (default all)
+	InteractionInputs []string `yaml:"interaction_inputs" env:"GEMINI_NOTIFY_INTERACTION_INPUTS"`
+
+	// Alert the local terminal whenever a notification is sent
+	LocalBell    bool `yaml:"local_bell" env:"GEMINI_NOTIFY_LOCAL_BELL"`
+	LocalUrgency bool `yaml:"local_urgency" env:"GEMINI_NOTIFY_LOCAL_URGENCY"`
+
+	// Where notifications go: push, desktop, both, or focus (desktop while
+	// the terminal is focused and the machine is in use, push otherwise)
+	Routing string `yaml:"routing" env:"GEMINI_NOTIFY_ROUTING"`
+	// Machine idle time after which the focus policy switches to push
+	DesktopIdleThreshold time.Duration `yaml:"desktop_idle_threshold" env:"GEMINI_NOTIFY_DESKTOP_IDLE_THRESHOLD"`
+
+	// Maximum title and message lengths in characters; longer text is cut in
+	// the middle (0 disables the limit)
+	MaxTitleLength   int `yaml:"max_title_length" env:"GEMINI_NOTIFY_MAX_TITLE_LENGTH"`
+	MaxMessageLength int `yaml:"max_message_length" env:"GEMINI_NOTIFY_MAX_MESSAGE_LENGTH"`
+
+	// Language of built-in notification text, e.g. "de" (defaults to the
+	// locale from LC_ALL, LC_MESSAGES or LANG)
+	Locale string `yaml:"locale" env:"GEMINI_NOTIFY_LOCALE"`
+	// Additional message catalogs by locale, overriding the built-in ones
+	Messages map[string]map[string]string `yaml:"messages"`
+
+	// Turn individual notification types (startup, backstop, ...) on or off
+	Notify map[string]bool `yaml:"notify"`
+
+	// Priority per notification type: min, low, default, high, urgent or
+	// 1-5 (crash and stuck are urgent unless set here)
+	Priorities map[string]string `yaml:"priorities"`
+
+	// Most notifications sent in any interval, as count/interval, e.g.
+	// "20/1h" (empty for no limit), and limits of their own for notification
+	// types; notifications dropped by their type's limit don't count towards
+	// the overall one
+	RateLimit  string            `yaml:"rate_limit" env:"GEMINI_NOTIFY_RATE_LIMIT"`
+	RateLimits map[string]string `yaml:"rate_limits"`
+
+	// ntfy tags or emoji short codes per notification type, replacing the
+	// default "gemini-cli" and type tags
+	Tags map[string][]string `yaml:"tags"`
+
+	// Go templates for notification titles and messages, keyed by
+	// notification type (startup, backstop, ...)
+	Templates map[string]NotificationTemplate `yaml:"templates"`
+
+	// Shell commands run on lifecycle events (session_start, idle,
+	// pattern_match, session_end) with the event as JSON on stdin
+	EventHooks map[string][]string `yaml:"event_hooks"`
+	// File, FIFO or /dev/fd/N to write lifecycle events to as
+	// newline-delimited JSON
+	EventsFile string `yaml:"events_file" env:"GEMINI_NOTIFY_EVENTS_FILE"`
+
+	// Address to receive notifications from other programs on, as JSON
+	// posted to /event, e.g. "127.0.0.1:8787" (empty disables)
+	ReceiverAddr string `yaml:"receiver_addr" env:"GEMINI_NOTIFY_RECEIVER_ADDR"`
+	// Bearer token required by the receiver; needed to listen beyond localhost
+	ReceiverToken string `yaml:"receiver_token" env:"GEMINI_NOTIFY_RECEIVER_TOKEN"`
+	// Events the receiver accepts per minute
+	ReceiverRateLimit int `yaml:"receiver_rate_limit" env:"GEMINI_NOTIFY_RECEIVER_RATE_LIMIT"`
+
+	// Intercept wrapper hotkeys (prefix key followed by a command key)
+	Hotkeys bool `yaml:"hotkeys" env:"GEMINI_NOTIFY_HOTKEYS"`
+	// Prefix key for hotkeys, e.g. "ctrl-\\" or "ctrl-g"
+	HotkeyPrefix string `yaml:"hotkey_prefix" env:"GEMINI_NOTIFY_HOTKEY_PREFIX"`
+	// Key pressed after the prefix to send a screen snapshot notification
+	HotkeySnapshot string `yaml:"hotkey_snapshot" env:"GEMINI_NOTIFY_HOTKEY_SNAPSHOT"`
+	// Key pressed after the prefix to toggle quiet mode
+	HotkeyQuiet string `yaml:"hotkey_quiet" env:"GEMINI_NOTIFY_HOTKEY_QUIET"`
+
+	// Relay output with splice(2) on Linux when no wrapper UI draws on the terminal
+	ZeroCopyOutput bool `yaml:"zero_copy_output" env:"GEMINI_NOTIFY_ZERO_COPY_OUTPUT"`
+
+	// Run as a systemd service: report readiness and watchdog pings, log
+	// output as plain lines and make no assumptions about a terminal
+	Supervised bool `yaml:"supervised" env:"GEMINI_NOTIFY_SUPERVISED"`
+	// Run without a PTY, e.g. in CI jobs or cron, sending only start, exit
+	// and error notifications
+	CI bool `yaml:"ci" env:"GEMINI_NOTIFY_CI"`
+	// Leave the terminal as it is, without raw mode or following its size;
+	// set when stdin is not a terminal or TERM is dumb
+	Headless bool `yaml:"headless" env:"GEMINI_NOTIFY_HEADLESS"`
+}
+
+// NotificationTemplate holds the title and message templates for one type
+// of notification
+type NotificationTemplate struct {
+	Title   string `yaml:"title"`
+	Message string `yaml:"message"`
 }
 
 // DefaultConfig returns the default configuration
@@ -34,11 +355,62 @@ func DefaultConfig() *Config {
 		NtfyServer:      "https://ntfy.sh",
 		BackstopTimeout: 30 * time.Second,
 		StartupNotify:   true, // Default to true so users know notifications are working
+		ControlMaxAge:   15 * time.Minute,
+		Routing:         "push",
+		// Away from the keyboard for two minutes means not at the terminal
+		DesktopIdleThreshold: 2 * time.Minute,
+		HotkeyPrefix:         "ctrl-\\",
+		HotkeySnapshot:       "s",
+		HotkeyQuiet:          "q",
+		// A slow network shouldn't hold up the next notification for long
+		NtfyTimeout:        10 * time.Second,
+		NtfyConnectTimeout: 5 * time.Second,
+		// ntfy turns messages over 4096 bytes into attachments
+		MaxTitleLength:   250,
+		TitlePrefix:      "{app}: ",
+		MaxMessageLength: 4000,
+		// Late enough that answers may already suffer
+		ContextLowPercent: 10,
+		// Only page someone when a session has died or stalled
+		IncidentTypes: []string{"crash", "oom", "stuck"},
+		// Gemini waiting for an answer, or dead
+		EscalateMax:   3,
+		EscalateTypes: []string{"approval", "backstop", "crash"},
+		OpsgenieURL:   "https://api.opsgenie.com",
+		BarkServer:    "https://api.day.app",
+		BarkGroup:     "gemini-cli",
+		ZulipTopic:    "gemini-cli",
+		// Texts cost money and wake people up
+		TwilioMinPriority: "urgent",
+		// Enough for a build script, not enough to flood the phone
+		ReceiverRateLimit: 30,
+		// A single core flat out
+		CPUWarnPercent: 90,
+		// More files than a single edit touches
+		FileBurstFiles:  20,
+		FileBurstWindow: 10 * time.Second,
+		SensitivePaths:  []string{".env", ".env.*", "~/.ssh/authorized_keys", "~/.ssh/config", "~/.ssh/id_*"},
 	}
 }
 
 // Load loads configuration from file and environment
 func Load() (*Config, error) {
+	cfg, err := LoadUnvalidated()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadUnvalidated loads configuration like Load but accepts an incomplete
+// one, for commands that help to complete it
+func LoadUnvalidated() (*Config, error) {
 	cfg := DefaultConfig()
 
 	// Try to load from config file
@@ -47,6 +419,14 @@ func Load() (*Config, error) {
 		if err := loadFromFile(cfg, configPath); err != nil && !os.IsNotExist(err) {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
+
+		// Secrets in a file other users can read are not secret
+		if err := checkPermissions(configPath, cfg); err != nil {
+			if os.Getenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG") != "true" {
+				return nil, fmt.Errorf("%w; run chmod 600 on it, or use --allow-insecure-config to continue anyway", err)
+			}
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: warning: %v\n", err)
+		}
 	}
 
 	// Override with environment variables
@@ -54,9 +434,12 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load from environment: %w", err)
 	}
 
-	// Validate configuration
-	if err := validate(cfg); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	if cfg.DefaultGeminiArgsFile != "" {
+		args, err := loadArgsFile(cfg.DefaultGeminiArgsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load default_gemini_args_file: %w", err)
+		}
+		cfg.DefaultGeminiArgs = append(cfg.DefaultGeminiArgs, args...)
 	}
 
 	return cfg, nil
@@ -103,6 +486,266 @@ func loadFromEnv(cfg *Config) error {
 		cfg.NtfyServer = server
 	}
 
+	if webhookURL := os.Getenv("GEMINI_NOTIFY_WEBHOOK_URL"); webhookURL != "" {
+		cfg.WebhookURL = webhookURL
+	}
+
+	if webhookSecret := os.Getenv("GEMINI_NOTIFY_WEBHOOK_SECRET"); webhookSecret != "" {
+		cfg.WebhookSecret = webhookSecret
+	}
+
+	if haURL := os.Getenv("GEMINI_NOTIFY_HOMEASSISTANT_URL"); haURL != "" {
+		cfg.HomeAssistantURL = haURL
+	}
+
+	if haToken := os.Getenv("GEMINI_NOTIFY_HOMEASSISTANT_TOKEN"); haToken != "" {
+		cfg.HomeAssistantToken = haToken
+	}
+
+	if haService := os.Getenv("GEMINI_NOTIFY_HOMEASSISTANT_SERVICE"); haService != "" {
+		cfg.HomeAssistantService = haService
+	}
+
+	if haWebhook := os.Getenv("GEMINI_NOTIFY_HOMEASSISTANT_WEBHOOK_ID"); haWebhook != "" {
+		cfg.HomeAssistantWebhookID = haWebhook
+	}
+
+	if teamsURL := os.Getenv("GEMINI_NOTIFY_TEAMS_WEBHOOK_URL"); teamsURL != "" {
+		cfg.TeamsWebhookURL = teamsURL
+	}
+
+	if barkKey := os.Getenv("GEMINI_NOTIFY_BARK_DEVICE_KEY"); barkKey != "" {
+		cfg.BarkDeviceKey = barkKey
+	}
+
+	if barkServer := os.Getenv("GEMINI_NOTIFY_BARK_SERVER"); barkServer != "" {
+		cfg.BarkServer = barkServer
+	}
+
+	if barkSound := os.Getenv("GEMINI_NOTIFY_BARK_SOUND"); barkSound != "" {
+		cfg.BarkSound = barkSound
+	}
+
+	if barkGroup := os.Getenv("GEMINI_NOTIFY_BARK_GROUP"); barkGroup != "" {
+		cfg.BarkGroup = barkGroup
+	}
+
+	if signalRPC := os.Getenv("GEMINI_NOTIFY_SIGNAL_RPC"); signalRPC != "" {
+		cfg.SignalRPC = signalRPC
+	}
+
+	if signalAccount := os.Getenv("GEMINI_NOTIFY_SIGNAL_ACCOUNT"); signalAccount != "" {
+		cfg.SignalAccount = signalAccount
+	}
+
+	if recipients := os.Getenv("GEMINI_NOTIFY_SIGNAL_RECIPIENTS"); recipients != "" {
+		cfg.SignalRecipients = splitList(recipients)
+	}
+
+	if groupID := os.Getenv("GEMINI_NOTIFY_SIGNAL_GROUP_ID"); groupID != "" {
+		cfg.SignalGroupID = groupID
+	}
+
+	if zulipSite := os.Getenv("GEMINI_NOTIFY_ZULIP_SITE"); zulipSite != "" {
+		cfg.ZulipSite = zulipSite
+	}
+
+	if zulipEmail := os.Getenv("GEMINI_NOTIFY_ZULIP_EMAIL"); zulipEmail != "" {
+		cfg.ZulipEmail = zulipEmail
+	}
+
+	if zulipKey := os.Getenv("GEMINI_NOTIFY_ZULIP_API_KEY"); zulipKey != "" {
+		cfg.ZulipAPIKey = zulipKey
+	}
+
+	if zulipStream := os.Getenv("GEMINI_NOTIFY_ZULIP_STREAM"); zulipStream != "" {
+		cfg.ZulipStream = zulipStream
+	}
+
+	if zulipTopic := os.Getenv("GEMINI_NOTIFY_ZULIP_TOPIC"); zulipTopic != "" {
+		cfg.ZulipTopic = zulipTopic
+	}
+
+	if mattermostURL := os.Getenv("GEMINI_NOTIFY_MATTERMOST_WEBHOOK_URL"); mattermostURL != "" {
+		cfg.MattermostWebhookURL = mattermostURL
+	}
+
+	if mattermostChannel := os.Getenv("GEMINI_NOTIFY_MATTERMOST_CHANNEL"); mattermostChannel != "" {
+		cfg.MattermostChannel = mattermostChannel
+	}
+
+	if mattermostUsername := os.Getenv("GEMINI_NOTIFY_MATTERMOST_USERNAME"); mattermostUsername != "" {
+		cfg.MattermostUsername = mattermostUsername
+	}
+
+	if sid := os.Getenv("GEMINI_NOTIFY_TWILIO_ACCOUNT_SID"); sid != "" {
+		cfg.TwilioAccountSID = sid
+	}
+
+	if token := os.Getenv("GEMINI_NOTIFY_TWILIO_AUTH_TOKEN"); token != "" {
+		cfg.TwilioAuthToken = token
+	}
+
+	if from := os.Getenv("GEMINI_NOTIFY_TWILIO_FROM"); from != "" {
+		cfg.TwilioFrom = from
+	}
+
+	if to := os.Getenv("GEMINI_NOTIFY_TWILIO_TO"); to != "" {
+		cfg.TwilioTo = splitList(to)
+	}
+
+	if minPriority := os.Getenv("GEMINI_NOTIFY_TWILIO_MIN_PRIORITY"); minPriority != "" {
+		cfg.TwilioMinPriority = minPriority
+	}
+
+	if routingKey := os.Getenv("GEMINI_NOTIFY_PAGERDUTY_ROUTING_KEY"); routingKey != "" {
+		cfg.PagerDutyRoutingKey = routingKey
+	}
+
+	if apiKey := os.Getenv("GEMINI_NOTIFY_OPSGENIE_API_KEY"); apiKey != "" {
+		cfg.OpsgenieAPIKey = apiKey
+	}
+
+	if opsgenieURL := os.Getenv("GEMINI_NOTIFY_OPSGENIE_URL"); opsgenieURL != "" {
+		cfg.OpsgenieURL = opsgenieURL
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_STUCK_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_STUCK_AFTER: %w", err)
+		}
+		cfg.StuckAfter = d
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_TOOL_RUNNING_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_TOOL_RUNNING_AFTER: %w", err)
+		}
+		cfg.ToolRunningAfter = d
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_PROGRESS_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_PROGRESS_AFTER: %w", err)
+		}
+		cfg.ProgressAfter = d
+	}
+
+	if interval := os.Getenv("GEMINI_NOTIFY_HEARTBEAT_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_HEARTBEAT_INTERVAL: %w", err)
+		}
+		cfg.HeartbeatInterval = d
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_MEMORY_WARN_MB", &cfg.MemoryWarnMB); err != nil {
+		return err
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_CPU_WARN_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_CPU_WARN_AFTER: %w", err)
+		}
+		cfg.CPUWarnAfter = d
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_CPU_WARN_PERCENT", &cfg.CPUWarnPercent); err != nil {
+		return err
+	}
+
+	if timeout := os.Getenv("GEMINI_NOTIFY_RESPONSE_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_RESPONSE_TIMEOUT: %w", err)
+		}
+		cfg.ResponseTimeout = d
+	}
+
+	if readyAfter := os.Getenv("GEMINI_NOTIFY_RESPONSE_READY_AFTER"); readyAfter != "" {
+		d, err := time.ParseDuration(readyAfter)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_RESPONSE_READY_AFTER: %w", err)
+		}
+		cfg.ResponseReadyAfter = d
+	}
+
+	if maxDuration := os.Getenv("GEMINI_NOTIFY_MAX_SESSION_DURATION"); maxDuration != "" {
+		d, err := time.ParseDuration(maxDuration)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_MAX_SESSION_DURATION: %w", err)
+		}
+		cfg.MaxSessionDuration = d
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_MAX_SESSION_KILL", &cfg.MaxSessionKill); err != nil {
+		return err
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_BUDGET_TOKENS", &cfg.BudgetTokens); err != nil {
+		return err
+	}
+
+	if err := loadFloatFromEnv("GEMINI_NOTIFY_BUDGET_COST", &cfg.BudgetCost); err != nil {
+		return err
+	}
+
+	if err := loadFloatFromEnv("GEMINI_NOTIFY_COST_PER_MILLION_TOKENS", &cfg.CostPerMillionTokens); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_BUDGET_PAUSE", &cfg.BudgetPause); err != nil {
+		return err
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_ESCALATE_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_ESCALATE_AFTER: %w", err)
+		}
+		cfg.EscalateAfter = d
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_ESCALATE_MAX", &cfg.EscalateMax); err != nil {
+		return err
+	}
+
+	if exitCodes := os.Getenv("GEMINI_NOTIFY_EXIT_CODES"); exitCodes != "" {
+		cfg.ExitCodes = splitList(exitCodes)
+	}
+
+	if digestTime := os.Getenv("GEMINI_NOTIFY_DIGEST_TIME"); digestTime != "" {
+		cfg.DigestTime = digestTime
+	}
+
+	if outputLog := os.Getenv("GEMINI_NOTIFY_OUTPUT_LOG"); outputLog != "" {
+		cfg.OutputLog = outputLog
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_COMPRESS_LOGS", &cfg.CompressLogs); err != nil {
+		return err
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_STATE_MAX_MB", &cfg.StateMaxMB); err != nil {
+		return err
+	}
+
+	if rateLimit := os.Getenv("GEMINI_NOTIFY_RATE_LIMIT"); rateLimit != "" {
+		cfg.RateLimit = rateLimit
+	}
+
+	if tee := os.Getenv("GEMINI_NOTIFY_TEE"); tee != "" {
+		cfg.Tee = tee
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_TEE_RAW", &cfg.TeeRaw); err != nil {
+		return err
+	}
+
 	if timeout := os.Getenv("GEMINI_NOTIFY_BACKSTOP_TIMEOUT"); timeout != "" {
 		d, err := time.ParseDuration(timeout)
 		if err != nil {
@@ -111,60 +754,742 @@ func loadFromEnv(cfg *Config) error {
 		cfg.BackstopTimeout = d
 	}
 
-	if quiet := os.Getenv("GEMINI_NOTIFY_QUIET"); quiet != "" {
-		switch quiet {
-		case "true", "1", "yes":
-			cfg.Quiet = true
-		case "false", "0", "no":
-			cfg.Quiet = false
-		default:
-			return fmt.Errorf("invalid GEMINI_NOTIFY_QUIET value: %q (use true/false)", quiet)
-		}
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_RESUME_NOTIFY", &cfg.ResumeNotify); err != nil {
+		return err
 	}
 
-	if startup := os.Getenv("GEMINI_NOTIFY_STARTUP"); startup != "" {
-		switch startup {
-		case "true", "1", "yes":
-			cfg.StartupNotify = true
-		case "false", "0", "no":
-			cfg.StartupNotify = false
-		default:
-			return fmt.Errorf("invalid GEMINI_NOTIFY_STARTUP value: %q (use true/false)", startup)
-		}
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_QUIET", &cfg.Quiet); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_STARTUP", &cfg.StartupNotify); err != nil {
+		return err
 	}
 
 	if geminiPath := os.Getenv("GEMINI_NOTIFY_GEMINI_PATH"); geminiPath != "" {
 		cfg.GeminiPath = geminiPath
 	}
 
-	if defaultArgs := os.Getenv("GEMINI_NOTIFY_DEFAULT_ARGS"); defaultArgs != "" {
-		// Split by comma and trim whitespace
-		args := strings.Split(defaultArgs, ",")
-		for i, arg := range args {
-			args[i] = strings.TrimSpace(arg)
-		}
-		// Filter out empty strings
-		var filteredArgs []string
-		for _, arg := range args {
-			if arg != "" {
-				filteredArgs = append(filteredArgs, arg)
-			}
+	if profile := os.Getenv("GEMINI_NOTIFY_PROFILE"); profile != "" {
+		cfg.Profile = profile
+	}
+
+	if controlTopic := os.Getenv("GEMINI_NOTIFY_CONTROL_TOPIC"); controlTopic != "" {
+		cfg.ControlTopic = controlTopic
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_REMOTE_INPUT", &cfg.RemoteInput); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_REMOTE_SIGNALS", &cfg.RemoteSignals); err != nil {
+		return err
+	}
+
+	if secret := os.Getenv("GEMINI_NOTIFY_CONTROL_SECRET"); secret != "" {
+		cfg.ControlSecret = secret
+	}
+
+	if maxAge := os.Getenv("GEMINI_NOTIFY_CONTROL_MAX_AGE"); maxAge != "" {
+		d, err := time.ParseDuration(maxAge)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_CONTROL_MAX_AGE: %w", err)
 		}
-		cfg.DefaultGeminiArgs = filteredArgs
+		cfg.ControlMaxAge = d
 	}
 
-	return nil
-}
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_CONTROL_SOCKET", &cfg.ControlSocket); err != nil {
+		return err
+	}
 
-// validate validates the configuration
-func validate(cfg *Config) error {
-	if cfg.NtfyTopic == "" && !cfg.Quiet {
-		return fmt.Errorf("ntfy_topic is required when not in quiet mode")
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_GEMINI_HOOKS", &cfg.GeminiHooks); err != nil {
+		return err
 	}
 
-	if cfg.BackstopTimeout < 0 {
-		return fmt.Errorf("backstop_timeout must be non-negative")
+	if outputFormat := os.Getenv("GEMINI_NOTIFY_OUTPUT_FORMAT"); outputFormat != "" {
+		cfg.OutputFormat = outputFormat
 	}
 
-	return nil
+	if clickURL := os.Getenv("GEMINI_NOTIFY_TMUX_CLICK_URL"); clickURL != "" {
+		cfg.TmuxClickURL = clickURL
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_SSH_SHOW_USER", &cfg.SSHShowUser); err != nil {
+		return err
+	}
+
+	if prefix := os.Getenv("GEMINI_NOTIFY_TITLE_PREFIX"); prefix != "" {
+		cfg.TitlePrefix = prefix
+	}
+
+	if suffix := os.Getenv("GEMINI_NOTIFY_TITLE_SUFFIX"); suffix != "" {
+		cfg.TitleSuffix = suffix
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_CAPTURE_PROMPT", &cfg.CapturePrompt); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_CAPTURE_CLIPBOARD", &cfg.CaptureClipboard); err != nil {
+		return err
+	}
+
+	if attachScreen := os.Getenv("GEMINI_NOTIFY_ATTACH_SCREEN"); attachScreen != "" {
+		cfg.AttachScreen = splitList(attachScreen)
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_WATCH_FILES", &cfg.WatchFiles); err != nil {
+		return err
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_FILE_BURST_FILES", &cfg.FileBurstFiles); err != nil {
+		return err
+	}
+
+	if window := os.Getenv("GEMINI_NOTIFY_FILE_BURST_WINDOW"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_FILE_BURST_WINDOW: %w", err)
+		}
+		cfg.FileBurstWindow = d
+	}
+
+	if paths := os.Getenv("GEMINI_NOTIFY_SENSITIVE_PATHS"); paths != "" {
+		cfg.SensitivePaths = splitList(paths)
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_WATCH_COMMITS", &cfg.WatchCommits); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_TEST_RESULTS", &cfg.TestResults); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_BUILD_ERRORS", &cfg.BuildErrors); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_STATUS_LINE", &cfg.StatusLine); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_ZERO_COPY_OUTPUT", &cfg.ZeroCopyOutput); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_SUPERVISED", &cfg.Supervised); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_CI", &cfg.CI); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_HEADLESS", &cfg.Headless); err != nil {
+		return err
+	}
+
+	if eventsFile := os.Getenv("GEMINI_NOTIFY_EVENTS_FILE"); eventsFile != "" {
+		cfg.EventsFile = eventsFile
+	}
+
+	if addr := os.Getenv("GEMINI_NOTIFY_RECEIVER_ADDR"); addr != "" {
+		cfg.ReceiverAddr = addr
+	}
+
+	if token := os.Getenv("GEMINI_NOTIFY_RECEIVER_TOKEN"); token != "" {
+		cfg.ReceiverToken = token
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_RECEIVER_RATE_LIMIT", &cfg.ReceiverRateLimit); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_IDLE_TITLE", &cfg.IdleTitle); err != nil {
+		return err
+	}
+
+	if after := os.Getenv("GEMINI_NOTIFY_IDLE_TITLE_AFTER"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_IDLE_TITLE_AFTER: %w", err)
+		}
+		cfg.IdleTitleAfter = d
+	}
+
+	if warning := os.Getenv("GEMINI_NOTIFY_BACKSTOP_WARNING"); warning != "" {
+		d, err := time.ParseDuration(warning)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_BACKSTOP_WARNING: %w", err)
+		}
+		cfg.BackstopWarning = d
+	}
+
+	if inputs := os.Getenv("GEMINI_NOTIFY_INTERACTION_INPUTS"); inputs != "" {
+		cfg.InteractionInputs = splitList(inputs)
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_LOCAL_BELL", &cfg.LocalBell); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_LOCAL_URGENCY", &cfg.LocalUrgency); err != nil {
+		return err
+	}
+
+	if sessionTopic := os.Getenv("GEMINI_NOTIFY_SESSION_TOPIC"); sessionTopic != "" {
+		cfg.SessionTopic = sessionTopic
+	}
+
+	if sessionName := os.Getenv("GEMINI_NOTIFY_SESSION_NAME"); sessionName != "" {
+		cfg.SessionName = sessionName
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_GROUP_BY_SESSION", &cfg.GroupBySession); err != nil {
+		return err
+	}
+
+	if routing := os.Getenv("GEMINI_NOTIFY_ROUTING"); routing != "" {
+		cfg.Routing = routing
+	}
+
+	if threshold := os.Getenv("GEMINI_NOTIFY_DESKTOP_IDLE_THRESHOLD"); threshold != "" {
+		d, err := time.ParseDuration(threshold)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_DESKTOP_IDLE_THRESHOLD: %w", err)
+		}
+		cfg.DesktopIdleThreshold = d
+	}
+
+	if cert := os.Getenv("GEMINI_NOTIFY_CLIENT_CERT"); cert != "" {
+		cfg.NtfyClientCert = cert
+	}
+
+	if key := os.Getenv("GEMINI_NOTIFY_CLIENT_KEY"); key != "" {
+		cfg.NtfyClientKey = key
+	}
+
+	if ca := os.Getenv("GEMINI_NOTIFY_CA_CERT"); ca != "" {
+		cfg.NtfyCACert = ca
+	}
+
+	if timeout := os.Getenv("GEMINI_NOTIFY_NTFY_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_NTFY_TIMEOUT: %w", err)
+		}
+		cfg.NtfyTimeout = d
+	}
+
+	if timeout := os.Getenv("GEMINI_NOTIFY_NTFY_CONNECT_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid GEMINI_NOTIFY_NTFY_CONNECT_TIMEOUT: %w", err)
+		}
+		cfg.NtfyConnectTimeout = d
+	}
+
+	if locale := os.Getenv("GEMINI_NOTIFY_LOCALE"); locale != "" {
+		cfg.Locale = locale
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_CONTEXT_LOW_PERCENT", &cfg.ContextLowPercent); err != nil {
+		return err
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_MAX_TITLE_LENGTH", &cfg.MaxTitleLength); err != nil {
+		return err
+	}
+
+	if err := loadIntFromEnv("GEMINI_NOTIFY_MAX_MESSAGE_LENGTH", &cfg.MaxMessageLength); err != nil {
+		return err
+	}
+
+	if err := loadBoolFromEnv("GEMINI_NOTIFY_HOTKEYS", &cfg.Hotkeys); err != nil {
+		return err
+	}
+
+	if prefix := os.Getenv("GEMINI_NOTIFY_HOTKEY_PREFIX"); prefix != "" {
+		cfg.HotkeyPrefix = prefix
+	}
+
+	if key := os.Getenv("GEMINI_NOTIFY_HOTKEY_SNAPSHOT"); key != "" {
+		cfg.HotkeySnapshot = key
+	}
+
+	if key := os.Getenv("GEMINI_NOTIFY_HOTKEY_QUIET"); key != "" {
+		cfg.HotkeyQuiet = key
+	}
+
+	if defaultArgs := os.Getenv("GEMINI_NOTIFY_DEFAULT_ARGS"); defaultArgs != "" {
+		cfg.DefaultGeminiArgs = splitList(defaultArgs)
+	}
+
+	if argsFile := os.Getenv("GEMINI_NOTIFY_DEFAULT_ARGS_FILE"); argsFile != "" {
+		cfg.DefaultGeminiArgsFile = argsFile
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated environment variable, dropping
+// whitespace and empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// NotifyEnabled returns whether notifications of the given type are sent.
+// An entry under notify wins; startup notifications otherwise follow
+// startup_notify, and every other type is on.
+func (c *Config) NotifyEnabled(kind string) bool {
+	if enabled, ok := c.Notify[kind]; ok {
+		return enabled
+	}
+	if kind == "startup" {
+		return c.StartupNotify
+	}
+	return true
+}
+
+// InteractionInputKinds returns the kinds of input that count as user
+// interaction, or nil if all of them do
+func (c *Config) InteractionInputKinds() []notification.InputKind {
+	if c.InteractionInputs == nil {
+		return nil
+	}
+	kinds := make([]notification.InputKind, 0, len(c.InteractionInputs))
+	for _, name := range c.InteractionInputs {
+		// Validation rejects unknown kinds
+		if kind, err := notification.ParseInputKind(name); err == nil {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// DisableTerminalUI turns off the features that draw on or read from a
+// terminal, for running without one
+func (c *Config) DisableTerminalUI() {
+	c.StatusLine = false
+	c.IdleTitle = false
+	c.BackstopWarning = 0
+	c.LocalBell = false
+	c.LocalUrgency = false
+	c.Hotkeys = false
+	c.ZeroCopyOutput = false
+	if c.Routing == "focus" {
+		c.Routing = "push"
+	}
+}
+
+// NotifiersOnly turns off everything but the notifiers, for commands that
+// send notifications without wrapping a session
+func (c *Config) NotifiersOnly() {
+	c.DisableTerminalUI()
+	c.BackstopTimeout = 0
+	// Nothing is printed whose output could be kept, and the tee file of
+	// a session must not be emptied
+	c.OutputLog = ""
+	c.Tee = ""
+}
+
+// ActiveProfile returns the selected profile, or the one matching the name
+// of command when none is selected
+func (c *Config) ActiveProfile(command string) Profile {
+	if c.Profile != "" {
+		if profile, err := LookupProfile(c.Profile); err == nil {
+			return profile
+		}
+	}
+	return DetectProfile(command)
+}
+
+// loadBoolFromEnv sets dst from a boolean environment variable if it is set
+func loadBoolFromEnv(name string, dst *bool) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	switch value {
+	case "true", "1", "yes":
+		*dst = true
+	case "false", "0", "no":
+		*dst = false
+	default:
+		return fmt.Errorf("invalid %s value: %q (use true/false)", name, value)
+	}
+
+	return nil
+}
+
+// loadIntFromEnv sets dst from an integer environment variable if it is set
+func loadIntFromEnv(name string, dst *int) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*dst = n
+
+	return nil
+}
+
+// loadFloatFromEnv sets dst from a decimal environment variable if it is set
+func loadFloatFromEnv(name string, dst *float64) error {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	*dst = f
+
+	return nil
+}
+
+// minHeartbeatInterval is the shortest heartbeat_interval allowed
+const minHeartbeatInterval = 5 * time.Minute
+
+// minEscalateAfter is the shortest escalate_after allowed
+const minEscalateAfter = 30 * time.Second
+
+// ErrNoNotificationService means notifications have nowhere to go, as on
+// the first run before a topic is configured
+var ErrNoNotificationService = errors.New("ntfy_topic or another notification service (e.g. webhook_url, teams_webhook_url or bark_device_key) is required when not in quiet mode")
+
+// validate validates the configuration
+func validate(cfg *Config) error {
+	if cfg.SessionTopic != "" && cfg.SessionTopic != "suffix" {
+		return fmt.Errorf("session_topic must be suffix (got %q)", cfg.SessionTopic)
+	}
+
+	switch cfg.Routing {
+	case "push", "desktop", "both", "focus":
+	default:
+		return fmt.Errorf("routing must be one of push, desktop, both, focus (got %q)", cfg.Routing)
+	}
+
+	// Desktop-only routing never talks to ntfy, and other services can stand in for it
+	if !cfg.HasPushBackend() && !cfg.Quiet && cfg.Routing != "desktop" {
+		return ErrNoNotificationService
+	}
+
+	if cfg.WebhookSecret != "" && cfg.WebhookURL == "" {
+		return fmt.Errorf("webhook_secret requires webhook_url")
+	}
+
+	if cfg.HomeAssistantURL != "" {
+		if (cfg.HomeAssistantService == "") == (cfg.HomeAssistantWebhookID == "") {
+			return fmt.Errorf("homeassistant_url requires either homeassistant_service or homeassistant_webhook_id")
+		}
+		if cfg.HomeAssistantService != "" && cfg.HomeAssistantToken == "" {
+			return fmt.Errorf("homeassistant_service requires homeassistant_token")
+		}
+	}
+
+	if cfg.BackstopTimeout < 0 {
+		return fmt.Errorf("backstop_timeout must be non-negative")
+	}
+
+	if cfg.StuckAfter < 0 {
+		return fmt.Errorf("stuck_after must be non-negative")
+	}
+
+	if cfg.ToolRunningAfter < 0 {
+		return fmt.Errorf("tool_running_after must be non-negative")
+	}
+
+	if cfg.FileBurstFiles < 0 {
+		return fmt.Errorf("file_burst_files must be non-negative")
+	}
+
+	if cfg.FileBurstWindow < 0 {
+		return fmt.Errorf("file_burst_window must be non-negative")
+	}
+
+	for _, pattern := range cfg.BuildErrorPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid build_error_patterns entry %q: %w", pattern, err)
+		}
+	}
+
+	if cfg.ProgressAfter < 0 {
+		return fmt.Errorf("progress_after must be non-negative")
+	}
+
+	// Heartbeats are reassurance, not alerts; more often would be noise
+	if cfg.HeartbeatInterval < 0 || (cfg.HeartbeatInterval > 0 && cfg.HeartbeatInterval < minHeartbeatInterval) {
+		return fmt.Errorf("heartbeat_interval must be 0 or at least %.0fm", minHeartbeatInterval.Minutes())
+	}
+
+	if cfg.MemoryWarnMB < 0 {
+		return fmt.Errorf("memory_warn_mb must be non-negative")
+	}
+
+	if cfg.CPUWarnAfter < 0 {
+		return fmt.Errorf("cpu_warn_after must be non-negative")
+	}
+
+	if cfg.CPUWarnAfter > 0 && cfg.CPUWarnPercent < 1 {
+		return fmt.Errorf("cpu_warn_percent must be at least 1")
+	}
+
+	if cfg.ResponseTimeout < 0 {
+		return fmt.Errorf("response_timeout must be non-negative")
+	}
+
+	if cfg.ResponseReadyAfter < 0 {
+		return fmt.Errorf("response_ready_after must be non-negative")
+	}
+
+	if cfg.ContextLowPercent < 0 || cfg.ContextLowPercent > 100 {
+		return fmt.Errorf("context_low_percent must be between 0 and 100")
+	}
+
+	if cfg.MaxSessionDuration < 0 {
+		return fmt.Errorf("max_session_duration must be non-negative")
+	}
+
+	if cfg.MaxSessionKill && cfg.MaxSessionDuration == 0 {
+		return fmt.Errorf("max_session_kill requires max_session_duration")
+	}
+
+	if cfg.BudgetTokens < 0 || cfg.BudgetCost < 0 || cfg.CostPerMillionTokens < 0 {
+		return fmt.Errorf("budget_tokens, budget_cost and cost_per_million_tokens must be non-negative")
+	}
+
+	if cfg.BudgetCost > 0 && cfg.CostPerMillionTokens == 0 {
+		return fmt.Errorf("budget_cost requires cost_per_million_tokens")
+	}
+
+	// Input held back can only be let through by a control command
+	if cfg.BudgetPause && cfg.BudgetTokens == 0 && cfg.BudgetCost == 0 {
+		return fmt.Errorf("budget_pause requires budget_tokens or budget_cost")
+	}
+	if cfg.BudgetPause && cfg.ControlTopic == "" && !cfg.ControlSocket {
+		return fmt.Errorf("budget_pause requires control_topic or control_socket")
+	}
+
+	// Reminders sooner than this would be answered by the first one
+	if cfg.EscalateAfter < 0 || (cfg.EscalateAfter > 0 && cfg.EscalateAfter < minEscalateAfter) {
+		return fmt.Errorf("escalate_after must be 0 or at least %.0fs", minEscalateAfter.Seconds())
+	}
+	if cfg.EscalateAfter > 0 && cfg.EscalateMax < 1 {
+		return fmt.Errorf("escalate_max must be at least 1")
+	}
+
+	if _, err := parseExitCodes(cfg.ExitCodes); err != nil {
+		return err
+	}
+
+	for codes, style := range cfg.ExitStyles {
+		if _, err := parseExitCodes([]string{codes}); err != nil {
+			return fmt.Errorf("invalid exit code %q in exit_styles", codes)
+		}
+		if style.Priority != "" {
+			if _, err := notification.ParsePriority(style.Priority); err != nil {
+				return fmt.Errorf("invalid priority for exit code %s: %w", codes, err)
+			}
+		}
+	}
+
+	if cfg.DigestTime != "" {
+		if _, err := time.Parse("15:04", cfg.DigestTime); err != nil {
+			return fmt.Errorf("digest_time must be a time of day such as 18:00 (got %q)", cfg.DigestTime)
+		}
+	}
+
+	switch cfg.OutputLog {
+	case "", "raw", "plain", "both":
+	default:
+		return fmt.Errorf("output_log must be one of raw, plain, both (got %q)", cfg.OutputLog)
+	}
+
+	if cfg.StateMaxMB < 0 {
+		return fmt.Errorf("state_max_mb must be non-negative")
+	}
+
+	switch cfg.OutputFormat {
+	case "", "text", "json", "stream-json":
+	default:
+		return fmt.Errorf("output_format must be one of text, json, stream-json (got %q)", cfg.OutputFormat)
+	}
+
+	if cfg.BarkDeviceKey != "" && cfg.BarkServer == "" {
+		return fmt.Errorf("bark_device_key requires bark_server")
+	}
+
+	if cfg.SignalRPC != "" && len(cfg.SignalRecipients) == 0 && cfg.SignalGroupID == "" {
+		return fmt.Errorf("signal_rpc requires signal_recipients or signal_group_id")
+	}
+
+	if cfg.ZulipSite != "" {
+		if cfg.ZulipEmail == "" || cfg.ZulipAPIKey == "" || cfg.ZulipStream == "" {
+			return fmt.Errorf("zulip_site requires zulip_email, zulip_api_key and zulip_stream")
+		}
+		if cfg.ZulipTopic == "" {
+			return fmt.Errorf("zulip_topic must not be empty")
+		}
+	}
+
+	if cfg.TwilioAccountSID != "" {
+		if cfg.TwilioAuthToken == "" || cfg.TwilioFrom == "" || len(cfg.TwilioTo) == 0 {
+			return fmt.Errorf("twilio_account_sid requires twilio_auth_token, twilio_from and twilio_to")
+		}
+		if _, err := notification.ParsePriority(cfg.TwilioMinPriority); err != nil {
+			return fmt.Errorf("invalid twilio_min_priority: %w", err)
+		}
+	}
+
+	for pattern, priority := range cfg.Priorities {
+		if _, err := notification.ParsePriority(priority); err != nil {
+			return fmt.Errorf("invalid priority for %s: %w", pattern, err)
+		}
+	}
+
+	if cfg.RateLimit != "" {
+		if _, err := notification.ParseRateLimit(cfg.RateLimit); err != nil {
+			return fmt.Errorf("invalid rate_limit: %w", err)
+		}
+	}
+	for pattern, limit := range cfg.RateLimits {
+		if _, err := notification.ParseRateLimit(limit); err != nil {
+			return fmt.Errorf("invalid rate limit for %s: %w", pattern, err)
+		}
+	}
+
+	if cfg.OpsgenieAPIKey != "" && cfg.OpsgenieURL == "" {
+		return fmt.Errorf("opsgenie_api_key requires opsgenie_url")
+	}
+
+	if cfg.Profile != "" {
+		if _, err := LookupProfile(cfg.Profile); err != nil {
+			return err
+		}
+	}
+
+	if cfg.NtfyTimeout <= 0 || cfg.NtfyConnectTimeout <= 0 {
+		return fmt.Errorf("ntfy_timeout and ntfy_connect_timeout must be positive")
+	}
+
+	if (cfg.NtfyClientCert == "") != (cfg.NtfyClientKey == "") {
+		return fmt.Errorf("ntfy_client_cert and ntfy_client_key must be set together")
+	}
+
+	if cfg.ControlTopic != "" && cfg.ControlTopic == cfg.NtfyTopic {
+		return fmt.Errorf("control_topic must differ from ntfy_topic")
+	}
+
+	if cfg.IdleTitleAfter < 0 {
+		return fmt.Errorf("idle_title_after must be non-negative")
+	}
+
+	if cfg.MaxTitleLength < 0 || cfg.MaxMessageLength < 0 {
+		return fmt.Errorf("max_title_length and max_message_length must be non-negative")
+	}
+
+	if cfg.BackstopWarning < 0 {
+		return fmt.Errorf("backstop_warning must be non-negative")
+	}
+
+	for _, input := range cfg.InteractionInputs {
+		if _, err := notification.ParseInputKind(input); err != nil {
+			return fmt.Errorf("invalid interaction_inputs: %w", err)
+		}
+	}
+
+	for event := range cfg.EventHooks {
+		if !isLifecycleEvent(event) {
+			return fmt.Errorf("unknown event %q in event_hooks (use session_start, idle, pattern_match or session_end)", event)
+		}
+	}
+
+	if cfg.ReceiverAddr != "" {
+		host, _, err := net.SplitHostPort(cfg.ReceiverAddr)
+		if err != nil {
+			return fmt.Errorf("invalid receiver_addr: %w", err)
+		}
+		// Anyone who can reach the port can notify the phone
+		if !isLoopback(host) && cfg.ReceiverToken == "" {
+			return fmt.Errorf("receiver_token is required when receiver_addr is not on localhost")
+		}
+		if cfg.ReceiverRateLimit <= 0 {
+			return fmt.Errorf("receiver_rate_limit must be positive")
+		}
+	}
+
+	if cfg.ControlSecret != "" && cfg.ControlMaxAge <= 0 {
+		return fmt.Errorf("control_max_age must be positive")
+	}
+
+	if cfg.Hotkeys {
+		prefix, err := terminal.ParseKey(cfg.HotkeyPrefix)
+		if err != nil {
+			return fmt.Errorf("invalid hotkey_prefix: %w", err)
+		}
+		// Pressing the prefix twice opens the settings overlay, so every
+		// command key must differ from it and from each other
+		used := map[byte]string{prefix: "hotkey_prefix"}
+		for _, hotkey := range []struct{ name, key string }{
+			{"hotkey_snapshot", cfg.HotkeySnapshot},
+			{"hotkey_quiet", cfg.HotkeyQuiet},
+		} {
+			key, err := terminal.ParseKey(hotkey.key)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", hotkey.name, err)
+			}
+			if other, ok := used[key]; ok {
+				return fmt.Errorf("%s must differ from %s", hotkey.name, other)
+			}
+			used[key] = hotkey.name
+		}
+	}
+
+	return nil
+}
+
+// HasPushBackend reports whether a service to send push notifications to is
+// configured
+func (c *Config) HasPushBackend() bool {
+	return c.NtfyTopic != "" || c.WebhookURL != "" || c.HomeAssistantURL != "" || c.TeamsWebhookURL != "" ||
+		c.BarkDeviceKey != "" || c.SignalRPC != "" || c.ZulipSite != "" || c.MattermostWebhookURL != "" ||
+		c.TwilioAccountSID != "" || c.HasIncidentBackend()
+}
+
+// HasIncidentBackend reports whether an on-call service is configured
+func (c *Config) HasIncidentBackend() bool {
+	return c.PagerDutyRoutingKey != "" || c.OpsgenieAPIKey != ""
+}
+
+// isLoopback reports whether host only accepts connections from this machine
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLifecycleEvent reports whether event_hooks can name the event
+func isLifecycleEvent(event string) bool {
+	switch event {
+	case "session_start", "idle", "pattern_match", "session_end":
+		return true
+	}
+	return false
 }
\ No newline at end of file
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Path returns the path of the config file, whether or not it exists
+func Path() string {
+	return getConfigPath()
+}
+
+// SetFileValue sets a top-level key in the YAML config file at path to
+// value, keeping the rest of the file including comments. The file is
+// created if it does not exist.
+func SetFileValue(path, key, value string) error {
+	return editFile(path, func(root *yaml.Node) {
+		setMappingValue(root, key, value)
+	})
+}
+
+// SetFileMapValue sets key in the top-level mapping named section, e.g.
+// backstop under priorities, like SetFileValue. The mapping is created if
+// it does not exist.
+func SetFileMapValue(path, section, key, value string) error {
+	return editFile(path, func(root *yaml.Node) {
+		setMappingValue(mappingValue(root, section), key, value)
+	})
+}
+
+// editFile applies edit to the top-level mapping of the YAML config file at
+// path and writes it back, keeping comments. The file is created if it does
+// not exist.
+func editFile(path string, edit func(root *yaml.Node)) error {
+	var doc yaml.Node
+
+	// #nosec G304 - The config file path comes from trusted sources (env var or standard locations)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	case os.IsNotExist(err):
+	default:
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// An empty file parses to no document at all
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file is not a mapping")
+	}
+
+	edit(root)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	_ = encoder.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// The config can hold secrets such as control_secret, so it is only
+	// readable by the user, even if it already existed with a looser mode
+	if err := os.WriteFile(path, out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file permissions: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the mapping stored under key in a YAML mapping node,
+// replacing anything else stored there and appending it if missing
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			node := mapping.Content[i+1]
+			if node.Kind != yaml.MappingNode {
+				node.Kind, node.Tag, node.Value, node.Content = yaml.MappingNode, "!!map", "", nil
+				node.Style = 0
+			}
+			return node
+		}
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, node)
+	return node
+}
+
+// setMappingValue sets key in a YAML mapping node, appending it if missing
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			node := mapping.Content[i+1]
+			node.Kind, node.Tag, node.Value, node.Content = yaml.ScalarNode, "!!str", value, nil
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetFileValue(t *testing.T) {
+	t.Run("replaces value and keeps comments", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		original := "# my settings\nntfy_topic: \"old-topic\" # where to send\nquiet: false\n"
+		if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := SetFileValue(path, "ntfy_topic", "new-topic"); err != nil {
+			t.Fatalf("SetFileValue failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		out := string(data)
+		if !strings.Contains(out, "new-topic") || strings.Contains(out, "old-topic") {
+			t.Errorf("topic not replaced: %q", out)
+		}
+		if !strings.Contains(out, "# my settings") || !strings.Contains(out, "# where to send") || !strings.Contains(out, "quiet: false") {
+			t.Errorf("rest of file not kept: %q", out)
+		}
+	})
+
+	t.Run("creates file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "sub", "config.yaml")
+		if err := SetFileValue(path, "ntfy_topic", "new-topic"); err != nil {
+			t.Fatalf("SetFileValue failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if strings.TrimSpace(string(data)) != "ntfy_topic: new-topic" {
+			t.Errorf("unexpected file %q", data)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("appends missing key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		_ = os.WriteFile(path, []byte("quiet: true\n"), 0600)
+
+		if err := SetFileValue(path, "ntfy_topic", "new-topic"); err != nil {
+			t.Fatalf("SetFileValue failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if string(data) != "quiet: true\nntfy_topic: new-topic\n" {
+			t.Errorf("unexpected file %q", data)
+		}
+	})
+}
+
+func TestSetFileMapValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	_ = os.WriteFile(path, []byte("ntfy_topic: my-topic\npriorities:\n  crash: urgent # loud\n"), 0600)
+
+	if err := SetFileMapValue(path, "priorities", "backstop", "high"); err != nil {
+		t.Fatalf("SetFileMapValue failed: %v", err)
+	}
+	if err := SetFileMapValue(path, "priorities", "crash", "max"); err != nil {
+		t.Fatalf("SetFileMapValue failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "ntfy_topic: my-topic\npriorities:\n  crash: max # loud\n  backstop: high\n"
+	if string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exitCodeRange is a range of exit codes, both ends included
+type exitCodeRange struct {
+	min, max int
+}
+
+// killedExitCode is the exit code of a process killed by a signal
+const killedExitCode = -1
+
+// parseExitCodes parses exit_codes: codes such as "130", ranges such as
+// "1-127", "nonzero" for every code but 0, and "killed" for a process
+// killed by a signal
+func parseExitCodes(specs []string) ([]exitCodeRange, error) {
+	ranges := make([]exitCodeRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		switch spec {
+		case "nonzero":
+			ranges = append(ranges, exitCodeRange{killedExitCode, killedExitCode}, exitCodeRange{1, 255})
+			continue
+		case "killed":
+			ranges = append(ranges, exitCodeRange{killedExitCode, killedExitCode})
+			continue
+		}
+
+		low, high, isRange := strings.Cut(spec, "-")
+		if !isRange {
+			high = low
+		}
+		min, err := strconv.Atoi(low)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code %q in exit_codes", spec)
+		}
+		max, err := strconv.Atoi(high)
+		if err != nil || min < 0 || max > 255 || min > max {
+			return nil, fmt.Errorf("invalid exit code %q in exit_codes", spec)
+		}
+		ranges = append(ranges, exitCodeRange{min, max})
+	}
+	return ranges, nil
+}
+
+// NotifyExit reports whether the wrapped process exiting with code is
+// notified: whether exit_codes includes it, or byDefault if exit_codes is
+// not set
+func (c *Config) NotifyExit(code int, byDefault bool) bool {
+	if len(c.ExitCodes) == 0 {
+		return byDefault
+	}
+	// Validation rejects invalid codes
+	ranges, _ := parseExitCodes(c.ExitCodes)
+	for _, r := range ranges {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitStyle is how an exit notification is sent for some exit codes
+type ExitStyle struct {
+	// Priority by name or number, as under priorities
+	Priority string `yaml:"priority"`
+	// ntfy tags or emoji, instead of those of the notification type
+	Tags []string `yaml:"tags"`
+}
+
+// ExitStyleFor returns the exit_styles entry for code. Of the entries that
+// include it, the one with the fewest codes applies, e.g. "137" before
+// "nonzero".
+func (c *Config) ExitStyleFor(code int) (ExitStyle, bool) {
+	var style ExitStyle
+	found, fewest := false, 0
+	// Keys in order, so entries of the same size pick the same one each time
+	keys := make([]string, 0, len(c.ExitStyles))
+	for key := range c.ExitStyles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Validation rejects invalid keys
+		ranges, err := parseExitCodes([]string{key})
+		if err != nil {
+			continue
+		}
+		included, size := false, 0
+		for _, r := range ranges {
+			included = included || (code >= r.min && code <= r.max)
+			size += r.max - r.min + 1
+		}
+		if included && (!found || size < fewest) {
+			style, found, fewest = c.ExitStyles[key], true, size
+		}
+	}
+	return style, found
+}
@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestNotifyExit(t *testing.T) {
+	t.Run("default without exit_codes", func(t *testing.T) {
+		cfg := &Config{}
+		if !cfg.NotifyExit(1, true) || cfg.NotifyExit(0, false) {
+			t.Error("expected the default to decide")
+		}
+	})
+
+	t.Run("codes and ranges", func(t *testing.T) {
+		cfg := &Config{ExitCodes: []string{"130", "2-5"}}
+		for code, expected := range map[int]bool{0: false, 1: false, 2: true, 5: true, 6: false, 130: true, -1: false} {
+			if got := cfg.NotifyExit(code, false); got != expected {
+				t.Errorf("code %d: expected %v, got %v", code, expected, got)
+			}
+		}
+	})
+
+	t.Run("nonzero and killed", func(t *testing.T) {
+		cfg := &Config{ExitCodes: []string{"nonzero"}}
+		if cfg.NotifyExit(0, true) || !cfg.NotifyExit(130, false) || !cfg.NotifyExit(-1, false) {
+			t.Error("expected every code but 0 to be notified")
+		}
+		cfg = &Config{ExitCodes: []string{"Killed"}}
+		if !cfg.NotifyExit(-1, false) || cfg.NotifyExit(137, true) {
+			t.Error("expected only a kill by a signal to be notified")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []string{"abc", "5-2", "256", "-3", "1-"} {
+			if _, err := parseExitCodes([]string{spec}); err == nil {
+				t.Errorf("expected %q to be rejected", spec)
+			}
+		}
+	})
+}
+
+func TestExitStyleFor(t *testing.T) {
+	cfg := &Config{ExitStyles: map[string]ExitStyle{
+		"0":       {Priority: "low", Tags: []string{"white_check_mark"}},
+		"nonzero": {Priority: "high", Tags: []string{"x"}},
+		"128-159": {Tags: []string{"zap"}},
+		"137":     {Priority: "urgent", Tags: []string{"skull"}},
+	}}
+	for code, expected := range map[int]string{0: "white_check_mark", 1: "x", -1: "x", 130: "zap", 137: "skull"} {
+		style, ok := cfg.ExitStyleFor(code)
+		if !ok || len(style.Tags) != 1 || style.Tags[0] != expected {
+			t.Errorf("code %d: expected %s, got %+v", code, expected, style)
+		}
+	}
+
+	cfg = &Config{ExitStyles: map[string]ExitStyle{"killed": {Priority: "urgent"}}}
+	if _, ok := cfg.ExitStyleFor(1); ok {
+		t.Error("expected no style for a code without an entry")
+	}
+}
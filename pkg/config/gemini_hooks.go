@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GeminiSettingsPath returns the path of Gemini CLI's user settings file
+func GeminiSettingsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gemini", "settings.json")
+}
+
+// InstallGeminiHooks adds a command hook to Gemini CLI's settings file at
+// path for each entry of hooks, which maps a Gemini hook event such as
+// "AfterAgent" to the command to run. Other settings are kept, and hooks
+// that are already installed are not added again. It returns how many hooks
+// were added.
+func InstallGeminiHooks(path string, hooks map[string]string) (int, error) {
+	settings := map[string]interface{}{}
+
+	// #nosec G304 - The settings path is Gemini CLI's well-known location or given by the user
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &settings); err != nil {
+				return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+		}
+	case os.IsNotExist(err):
+	default:
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	section, ok := settings["hooks"].(map[string]interface{})
+	if !ok {
+		if settings["hooks"] != nil {
+			return 0, fmt.Errorf("hooks in %s is not an object", path)
+		}
+		section = map[string]interface{}{}
+		settings["hooks"] = section
+	}
+
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	added := 0
+	for _, event := range events {
+		command := hooks[event]
+		entries, _ := section[event].([]interface{})
+		if hasHookCommand(entries, command) {
+			continue
+		}
+		section[event] = append(entries, map[string]interface{}{
+			"hooks": []interface{}{
+				map[string]interface{}{"type": "command", "command": command},
+			},
+		})
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0600); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return added, nil
+}
+
+// hasHookCommand reports whether a list of hook matcher entries already
+// runs command
+func hasHookCommand(entries []interface{}, command string) bool {
+	for _, entry := range entries {
+		matcher, _ := entry.(map[string]interface{})
+		list, _ := matcher["hooks"].([]interface{})
+		for _, hook := range list {
+			if h, _ := hook.(map[string]interface{}); h["command"] == command {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallGeminiHooks(t *testing.T) {
+	hooks := map[string]string{
+		"Notification": "/usr/bin/gemini-cli-ntfy hook approval",
+		"AfterAgent":   "/usr/bin/gemini-cli-ntfy hook turn",
+	}
+
+	t.Run("keeps other settings and is idempotent", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gemini", "settings.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		original := `{"theme": "Dracula", "hooks": {"AfterAgent": [{"hooks": [{"type": "command", "command": "say done"}]}]}}`
+		if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		added, err := InstallGeminiHooks(path, hooks)
+		if err != nil {
+			t.Fatalf("InstallGeminiHooks failed: %v", err)
+		}
+		if added != 2 {
+			t.Errorf("expected 2 hooks added, got %d", added)
+		}
+
+		added, err = InstallGeminiHooks(path, hooks)
+		if err != nil || added != 0 {
+			t.Errorf("expected nothing added the second time, got %d, %v", added, err)
+		}
+
+		data, _ := os.ReadFile(path)
+		var settings struct {
+			Theme string `json:"theme"`
+			Hooks map[string][]struct {
+				Hooks []struct {
+					Command string `json:"command"`
+				} `json:"hooks"`
+			} `json:"hooks"`
+		}
+		if err := json.Unmarshal(data, &settings); err != nil {
+			t.Fatal(err)
+		}
+		if settings.Theme != "Dracula" {
+			t.Errorf("theme not kept: %s", data)
+		}
+		if got := settings.Hooks["AfterAgent"]; len(got) != 2 || got[0].Hooks[0].Command != "say done" {
+			t.Errorf("existing hook not kept: %s", data)
+		}
+		if got := settings.Hooks["Notification"]; len(got) != 1 || got[0].Hooks[0].Command != hooks["Notification"] {
+			t.Errorf("notification hook not installed: %s", data)
+		}
+	})
+
+	t.Run("creates the settings file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gemini", "settings.json")
+		if added, err := InstallGeminiHooks(path, hooks); err != nil || added != 2 {
+			t.Fatalf("expected 2 hooks added, got %d, %v", added, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+		}
+	})
+
+	t.Run("rejects malformed settings", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		if err := os.WriteFile(path, []byte(`{"hooks": []}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := InstallGeminiHooks(path, hooks); err == nil {
+			t.Error("expected an error for a hooks list")
+		}
+	})
+}
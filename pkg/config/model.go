@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RequestedModel returns the model the wrapped CLI was asked to use with -m
+// or --model, or "" if the arguments don't say. For Gemini CLI, the
+// GEMINI_MODEL environment variable and the model in its project and user
+// settings are used too.
+func (p Profile) RequestedModel(args []string) string {
+	if model := modelFromArgs(args); model != "" {
+		return model
+	}
+	if p.Name != DefaultProfile {
+		return ""
+	}
+	if model := os.Getenv("GEMINI_MODEL"); model != "" {
+		return model
+	}
+	// Project settings take precedence over the user's
+	if model := geminiSettingsModel(filepath.Join(".gemini", "settings.json")); model != "" {
+		return model
+	}
+	if path := GeminiSettingsPath(); path != "" {
+		return geminiSettingsModel(path)
+	}
+	return ""
+}
+
+// modelFromArgs returns the value of the last -m or --model argument
+func modelFromArgs(args []string) string {
+	model := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return model
+		case arg == "-m" || arg == "--model":
+			if i+1 < len(args) {
+				i++
+				model = args[i]
+			}
+		case strings.HasPrefix(arg, "--model="):
+			model = strings.TrimPrefix(arg, "--model=")
+		}
+	}
+	return model
+}
+
+// geminiSettingsModel returns the model set in a Gemini CLI settings file,
+// either as model.name or, in older versions, as model itself
+func geminiSettingsModel(path string) string {
+	// #nosec G304 - The settings path is Gemini CLI's well-known location
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var settings struct {
+		Model json.RawMessage `json:"model"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil || len(settings.Model) == 0 {
+		return ""
+	}
+
+	var name string
+	if err := json.Unmarshal(settings.Model, &name); err == nil {
+		return name
+	}
+	var model struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(settings.Model, &model); err == nil {
+		return model.Name
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestedModel(t *testing.T) {
+	// Keep the real settings and environment out of the test
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GEMINI_MODEL", "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	gemini, _ := LookupProfile("gemini")
+	claude, _ := LookupProfile("claude")
+
+	t.Run("from arguments", func(t *testing.T) {
+		tests := []struct {
+			args []string
+			want string
+		}{
+			{[]string{"-m", "gemini-2.5-flash"}, "gemini-2.5-flash"},
+			{[]string{"--model=gemini-2.5-pro", "-p", "hi"}, "gemini-2.5-pro"},
+			{[]string{"-p", "hi", "--", "-m", "x"}, ""},
+			{nil, ""},
+		}
+		for _, tt := range tests {
+			if got := gemini.RequestedModel(tt.args); got != tt.want {
+				t.Errorf("RequestedModel(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("from Gemini settings", func(t *testing.T) {
+		path := filepath.Join(home, ".gemini", "settings.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		for settings, want := range map[string]string{
+			`{"model": {"name": "gemini-2.5-flash"}}`: "gemini-2.5-flash",
+			`{"model": "gemini-2.5-pro"}`:             "gemini-2.5-pro",
+			`{"theme": "Dracula"}`:                    "",
+		} {
+			if err := os.WriteFile(path, []byte(settings), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := gemini.RequestedModel(nil); got != want {
+				t.Errorf("settings %s: got %q, want %q", settings, got, want)
+			}
+		}
+
+		t.Setenv("GEMINI_MODEL", "gemini-2.0-flash")
+		if got := gemini.RequestedModel(nil); got != "gemini-2.0-flash" {
+			t.Errorf("expected GEMINI_MODEL to win over settings, got %q", got)
+		}
+		if got := claude.RequestedModel(nil); got != "" {
+			t.Errorf("expected Gemini's settings not to apply to claude, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// hasCredentials reports whether the configuration holds secrets that other
+// users must not be able to read
+func hasCredentials(cfg *Config) bool {
+	// Webhook URLs, e.g. Slack's or Discord's, often embed their token
+	return cfg.ControlSecret != "" || cfg.WebhookURL != "" || cfg.WebhookSecret != "" || cfg.ReceiverToken != "" ||
+		cfg.HomeAssistantToken != "" || cfg.HomeAssistantWebhookID != "" ||
+		cfg.TeamsWebhookURL != "" || cfg.BarkDeviceKey != "" || cfg.ZulipAPIKey != "" ||
+		cfg.MattermostWebhookURL != "" || cfg.TwilioAuthToken != "" ||
+		cfg.PagerDutyRoutingKey != "" || cfg.OpsgenieAPIKey != ""
+}
+
+// checkPermissions returns an error if the config file at path holds
+// credentials and can be read by other users
+func checkPermissions(path string, cfg *Config) error {
+	if !hasCredentials(cfg) {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	if mode := info.Mode().Perm(); mode&0077 != 0 {
+		return fmt.Errorf("config file %s contains credentials but is accessible by other users (mode %04o)", path, mode)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPermissions(t *testing.T) {
+	writeConfig := func(t *testing.T, content string, mode os.FileMode) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	const withSecret = "ntfy_topic: test\ncontrol_topic: control\ncontrol_secret: hunter2\n"
+
+	t.Run("refuses readable file with credentials", func(t *testing.T) {
+		t.Setenv("GEMINI_NOTIFY_CONFIG", writeConfig(t, withSecret, 0644))
+		t.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "")
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "accessible by other users") {
+			t.Errorf("expected permission error, got %v", err)
+		}
+	})
+
+	t.Run("override allows loading", func(t *testing.T) {
+		t.Setenv("GEMINI_NOTIFY_CONFIG", writeConfig(t, withSecret, 0644))
+		t.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "true")
+
+		if _, err := Load(); err != nil {
+			t.Errorf("expected load to succeed, got %v", err)
+		}
+	})
+
+	t.Run("private file with credentials", func(t *testing.T) {
+		t.Setenv("GEMINI_NOTIFY_CONFIG", writeConfig(t, withSecret, 0600))
+		t.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "")
+
+		if _, err := Load(); err != nil {
+			t.Errorf("expected load to succeed, got %v", err)
+		}
+	})
+
+	t.Run("refuses readable file with a webhook URL", func(t *testing.T) {
+		t.Setenv("GEMINI_NOTIFY_CONFIG", writeConfig(t, "webhook_url: https://hooks.slack.com/services/T0/B0/token\n", 0644))
+		t.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "")
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "accessible by other users") {
+			t.Errorf("expected permission error, got %v", err)
+		}
+	})
+
+	t.Run("readable file without credentials", func(t *testing.T) {
+		t.Setenv("GEMINI_NOTIFY_CONFIG", writeConfig(t, "ntfy_topic: test\n", 0644))
+		t.Setenv("GEMINI_NOTIFY_ALLOW_INSECURE_CONFIG", "")
+
+		if _, err := Load(); err != nil {
+			t.Errorf("expected load to succeed, got %v", err)
+		}
+	})
+}
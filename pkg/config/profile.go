@@ -0,0 +1,330 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile is the profile used when none is selected or detected
+const DefaultProfile = "gemini"
+
+// Profile describes an AI CLI the wrapper can run and the heuristics used to
+// follow it
+type Profile struct {
+	// Name selects the profile with --profile or the profile option
+	Name string
+	// Binary is the executable looked up in PATH
+	Binary string
+	// AppName prefixes notification titles, e.g. "Gemini CLI"
+	AppName string
+	// IgnoredTitles are terminal titles that only name the CLI, compared
+	// case-insensitively, and add nothing to a notification
+	IgnoredTitles []string
+	// PromptPatterns are regular expressions matching a recent output line
+	// when the CLI is waiting for an answer, questions before answer choices
+	PromptPatterns []string
+	// BusyPatterns are regular expressions matching a recent output line
+	// while the CLI works on a task, e.g. the hint next to its spinner
+	BusyPatterns []string
+	// ModelPatterns are regular expressions matching the name of the model
+	// in the CLI's output, e.g. in its footer; the first group is the name
+	// if there is one
+	ModelPatterns []string
+	// CompressionPatterns are regular expressions matching the CLI's
+	// message that it compressed or is about to overflow its context
+	CompressionPatterns []string
+	// ContextLeftPattern matches the percentage of the context window left,
+	// as the CLI shows it, in its first group
+	ContextLeftPattern string
+	// MCPErrorPatterns are regular expressions matching the CLI's message
+	// that an MCP server failed to start or lost its connection
+	MCPErrorPatterns []string
+	// APIErrorPatterns are regular expressions matching the CLI's message
+	// that a request to the model's API failed
+	APIErrorPatterns []string
+	// ToolRunningPattern matches the line of a tool call in progress, e.g.
+	// a shell command, with the tool and its arguments in the first group;
+	// ToolDonePattern matches the line of one that ended
+	ToolRunningPattern string
+	ToolDonePattern    string
+	// FileEditPattern matches the line of a file edit the CLI made, with
+	// the file in the first group
+	FileEditPattern string
+	// TokenUsagePattern matches the tokens the session has used so far, as
+	// the CLI shows them, in its first group, e.g. "12.4K"
+	TokenUsagePattern string
+	// UpdatePatterns are regular expressions matching the CLI's message
+	// that a newer version is available
+	UpdatePatterns []string
+	// NewConversationCommands are the commands, typed at the CLI's prompt,
+	// that start over with another conversation, e.g. "/clear"; a command
+	// of several words matches lines that start with them
+	NewConversationCommands []string
+	// QuitCommands are the commands that end the session, e.g. "/quit"
+	QuitCommands []string
+}
+
+// builtinProfiles holds the profiles for the supported CLIs by name
+var builtinProfiles = map[string]Profile{
+	"gemini": {
+		Name:          "gemini",
+		Binary:        "gemini",
+		AppName:       "Gemini CLI",
+		IgnoredTitles: []string{"gemini", "gemini cli"},
+		PromptPatterns: []string{
+			`(?i)allow execution`,
+			`(?i)apply this change\?`,
+			`(?i)waiting for user confirmation`,
+		},
+		BusyPatterns: []string{
+			`(?i)esc to cancel`,
+		},
+		ModelPatterns: []string{
+			`\bgemini-\d[\w.-]*[\w]`,
+		},
+		CompressionPatterns: []string{
+			`(?i)chat history compressed`,
+			`(?i)compressed context will be sent`,
+			`(?i)exceed the remaining context window`,
+		},
+		ContextLeftPattern: `(\d+)% context left`,
+		MCPErrorPatterns: []string{
+			`(?i)error connecting to mcp server`,
+			`(?i)failed to (start|connect to|discover tools from) mcp server`,
+			`(?i)mcp (error|issues detected)`,
+			`(?i)mcp server .*\b(disconnected|failed)\b`,
+		},
+		APIErrorPatterns: []string{
+			`(?i)\bapi error\b`,
+			`(?i)failed with status \d{3}`,
+		},
+		UpdatePatterns: []string{
+			`(?i)gemini cli update available`,
+			`(?i)new version of gemini cli is available`,
+		},
+		ToolRunningPattern:      `^[│|]?\s*⊷\s+(.*?)\s*[│|]?\s*$`,
+		ToolDonePattern:         `^[│|]?\s*[✓✔✗x?-]\s+\S`,
+		FileEditPattern:         `^[│|]?\s*[✓✔]\s+(?:WriteFile|Edit)\s+(?:Writing to\s+)?(\S+?):?(?:\s|$)`,
+		NewConversationCommands: []string{"/clear", "/chat resume", "/resume"},
+		QuitCommands:            []string{"/quit", "/exit"},
+	},
+	"claude": {
+		Name:          "claude",
+		Binary:        "claude",
+		AppName:       "Claude Code",
+		IgnoredTitles: []string{"claude", "claude code"},
+		PromptPatterns: []string{
+			`(?i)do you want to (proceed|make this edit|create)`,
+			`^\s*❯\s*1\.\s*Yes`,
+		},
+		BusyPatterns: []string{
+			`(?i)esc to interrupt`,
+		},
+		ModelPatterns: []string{
+			`\bclaude-(?:opus|sonnet|haiku)[\w.-]*[\w]`,
+			`\b(?:Opus|Sonnet|Haiku) \d+(?:\.\d+)?`,
+		},
+		CompressionPatterns: []string{
+			`(?i)conversation compacted`,
+		},
+		ContextLeftPattern: `(?i)context left until auto-compact: (\d+)%`,
+		MCPErrorPatterns: []string{
+			`(?i)mcp servers? .*\bfailed\b`,
+			`(?i)\d+ mcp servers? failed`,
+		},
+		APIErrorPatterns: []string{
+			`(?i)\bapi error\b`,
+		},
+		UpdatePatterns: []string{
+			`(?i)\bupdate available\b`,
+			`(?i)new version (of claude code )?(is )?available`,
+		},
+		FileEditPattern:         `^[⏺●]\s*(?:Update|Write|Edit|MultiEdit)\((.+?)\)`,
+		NewConversationCommands: []string{"/clear", "/resume"},
+		QuitCommands:            []string{"/exit", "/quit"},
+	},
+	"aider": {
+		Name:          "aider",
+		Binary:        "aider",
+		AppName:       "Aider",
+		IgnoredTitles: []string{"aider"},
+		PromptPatterns: []string{
+			`\(Y\)es/\(N\)o`,
+		},
+		APIErrorPatterns: []string{
+			`\blitellm\.\w*Error\b`,
+		},
+		ModelPatterns: []string{
+			`(?i)\bmain model: (\S+)`,
+		},
+		UpdatePatterns: []string{
+			`(?i)newer aider version .* is available`,
+		},
+		FileEditPattern:         `^Applied edit to (.+?)\s*$`,
+		NewConversationCommands: []string{"/clear", "/reset"},
+		QuitCommands:            []string{"/exit", "/quit"},
+	},
+	"codex": {
+		Name:          "codex",
+		Binary:        "codex",
+		AppName:       "Codex CLI",
+		IgnoredTitles: []string{"codex", "codex cli"},
+		PromptPatterns: []string{
+			`(?i)allow command\?`,
+			`(?i)approve this (command|change)`,
+		},
+		BusyPatterns: []string{
+			`(?i)esc to interrupt`,
+		},
+		ModelPatterns: []string{
+			`\bgpt-\d[\w.-]*[\w]`,
+		},
+		ContextLeftPattern: `(\d+)% context left`,
+		MCPErrorPatterns: []string{
+			`(?i)mcp (client|server) .*\bfailed\b`,
+		},
+		APIErrorPatterns: []string{
+			`(?i)\b(stream|api) error\b`,
+		},
+		UpdatePatterns: []string{
+			`(?i)\bupdate available\b`,
+		},
+		TokenUsagePattern:       `(?i)([\d.,]+\s?[KM]?)\s+tokens used`,
+		NewConversationCommands: []string{"/new"},
+		QuitCommands:            []string{"/quit", "/exit"},
+	},
+}
+
+// LookupProfile returns the built-in profile with the given name
+func LookupProfile(name string) (Profile, error) {
+	profile, ok := builtinProfiles[strings.ToLower(name)]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// DetectProfile returns the profile whose binary matches the name of the
+// wrapped command, falling back to the default profile
+func DetectProfile(command string) Profile {
+	base := strings.ToLower(filepath.Base(command))
+	for _, profile := range builtinProfiles {
+		if base == profile.Binary {
+			return profile
+		}
+	}
+	return builtinProfiles[DefaultProfile]
+}
+
+// ProfileNames returns the names of the built-in profiles in sorted order
+func ProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompilePromptPatterns compiles the profile's prompt patterns
+func (p Profile) CompilePromptPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("prompt", p.PromptPatterns)
+}
+
+// CompileBusyPatterns compiles the profile's busy patterns
+func (p Profile) CompileBusyPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("busy", p.BusyPatterns)
+}
+
+// CompileModelPatterns compiles the profile's model patterns
+func (p Profile) CompileModelPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("model", p.ModelPatterns)
+}
+
+// CompileCompressionPatterns compiles the profile's compression patterns
+func (p Profile) CompileCompressionPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("compression", p.CompressionPatterns)
+}
+
+// CompileMCPErrorPatterns compiles the profile's MCP error patterns
+func (p Profile) CompileMCPErrorPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("MCP error", p.MCPErrorPatterns)
+}
+
+// CompileAPIErrorPatterns compiles the profile's API error patterns
+func (p Profile) CompileAPIErrorPatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("API error", p.APIErrorPatterns)
+}
+
+// CompileContextLeftPattern compiles the profile's context left pattern, or
+// returns nil if it has none
+func (p Profile) CompileContextLeftPattern() (*regexp.Regexp, error) {
+	if p.ContextLeftPattern == "" {
+		return nil, nil
+	}
+	patterns, err := p.compilePatterns("context left", []string{p.ContextLeftPattern})
+	if err != nil {
+		return nil, err
+	}
+	return patterns[0], nil
+}
+
+// CompileUpdatePatterns compiles the profile's patterns for update messages
+func (p Profile) CompileUpdatePatterns() ([]*regexp.Regexp, error) {
+	return p.compilePatterns("update", p.UpdatePatterns)
+}
+
+// CompileToolPatterns compiles the profile's patterns for running and
+// ended tool calls, or returns nil if it has none
+func (p Profile) CompileToolPatterns() (running, done *regexp.Regexp, err error) {
+	if p.ToolRunningPattern == "" {
+		return nil, nil, nil
+	}
+	patterns, err := p.compilePatterns("tool", []string{p.ToolRunningPattern, p.ToolDonePattern})
+	if err != nil {
+		return nil, nil, err
+	}
+	return patterns[0], patterns[1], nil
+}
+
+// CompileFileEditPattern compiles the profile's pattern for file edits, or
+// returns nil if it has none
+func (p Profile) CompileFileEditPattern() (*regexp.Regexp, error) {
+	if p.FileEditPattern == "" {
+		return nil, nil
+	}
+	patterns, err := p.compilePatterns("file edit", []string{p.FileEditPattern})
+	if err != nil {
+		return nil, err
+	}
+	return patterns[0], nil
+}
+
+// CompileTokenUsagePattern compiles the profile's pattern for the tokens
+// used, or returns nil if it has none
+func (p Profile) CompileTokenUsagePattern() (*regexp.Regexp, error) {
+	if p.TokenUsagePattern == "" {
+		return nil, nil
+	}
+	patterns, err := p.compilePatterns("token usage", []string{p.TokenUsagePattern})
+	if err != nil {
+		return nil, err
+	}
+	return patterns[0], nil
+}
+
+// compilePatterns compiles one kind of the profile's patterns
+func (p Profile) compilePatterns(kind string, sources []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(sources))
+	for _, pattern := range sources {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q in profile %s: %w", kind, pattern, p.Name, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Run("detects profile from binary name", func(t *testing.T) {
+		tests := []struct {
+			command  string
+			expected string
+		}{
+			{"/usr/local/bin/claude", "claude"},
+			{"aider", "aider"},
+			{"/home/user/.local/bin/Codex", "codex"},
+			{"/opt/gemini/bin/gemini", "gemini"},
+			{"gemini-cli-ntfy", DefaultProfile},
+			{"", DefaultProfile},
+		}
+		for _, tt := range tests {
+			if got := DetectProfile(tt.command).Name; got != tt.expected {
+				t.Errorf("DetectProfile(%q) = %q, want %q", tt.command, got, tt.expected)
+			}
+		}
+	})
+
+	t.Run("selected profile wins over detection", func(t *testing.T) {
+		cfg := &Config{Profile: "aider"}
+		if got := cfg.ActiveProfile("/usr/bin/claude").Name; got != "aider" {
+			t.Errorf("expected aider, got %q", got)
+		}
+	})
+
+	t.Run("unknown profile is rejected", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Quiet = true
+		cfg.Profile = "cursor"
+		err := validate(cfg)
+		if err == nil || !strings.Contains(err.Error(), "unknown profile") {
+			t.Errorf("expected unknown profile error, got %v", err)
+		}
+	})
+
+	t.Run("patterns compile", func(t *testing.T) {
+		for _, name := range ProfileNames() {
+			profile, err := LookupProfile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := profile.CompilePromptPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileBusyPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileModelPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileCompressionPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileContextLeftPattern(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileMCPErrorPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileAPIErrorPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, _, err := profile.CompileToolPatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileUpdatePatterns(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileFileEditPattern(); err != nil {
+				t.Error(err)
+			}
+			if _, err := profile.CompileTokenUsagePattern(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("file edits match", func(t *testing.T) {
+		edits := map[string]map[string]string{
+			"gemini": {
+				"│ ✓  Edit pkg/parser.go: func old() => func new()  │":   "pkg/parser.go",
+				"│ ✓  WriteFile Writing to README.md                  │": "README.md",
+			},
+			"claude": {
+				"⏺ Update(src/app.ts)":   "src/app.ts",
+				"⏺ Write(notes/todo.md)": "notes/todo.md",
+			},
+			"aider": {
+				"Applied edit to aider/main.py": "aider/main.py",
+			},
+		}
+		for name, lines := range edits {
+			profile, err := LookupProfile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pattern, err := profile.CompileFileEditPattern()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for line, file := range lines {
+				if match := pattern.FindStringSubmatch(line); len(match) < 2 || match[1] != file {
+					t.Errorf("%s: expected %q to match %q, got %q", name, line, file, match)
+				}
+			}
+		}
+	})
+
+	t.Run("update messages match", func(t *testing.T) {
+		messages := map[string]string{
+			"gemini": "Gemini CLI update available! 0.1.13 → 0.1.14",
+			"claude": "✗ Update available! Run: npm i -g @anthropic-ai/claude-code",
+			"aider":  "Newer aider version v0.86.1 is available.",
+			"codex":  "✨⬆️ Update available! 0.20.0 -> 0.21.0.",
+		}
+		for name, message := range messages {
+			profile, err := LookupProfile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patterns, err := profile.CompileUpdatePatterns()
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched := false
+			for _, pattern := range patterns {
+				matched = matched || pattern.MatchString(message)
+			}
+			if !matched {
+				t.Errorf("%s: expected %q to match", name, message)
+			}
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEventSize bounds the size of a posted event
+const maxEventSize = 64 * 1024
+
+// DefaultEventType is the type of events posted without one
+const DefaultEventType = "external"
+
+// eventTypePattern restricts event types to names usable as notification
+// types in templates, tags and notify settings
+var eventTypePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Event is a notification posted to the receiver by another program
+type Event struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	// Type is used as the notification type (default "external")
+	Type string `json:"type"`
+	// URL opened when the notification is tapped
+	Click string `json:"click"`
+}
+
+// EventHandler passes a received event on, e.g. to the notification chain
+type EventHandler func(Event) error
+
+// Receiver accepts events from other programs, such as build scripts, as
+// JSON posted to /event over HTTP. Requests must be sent as
+// application/json, which browsers cannot do cross-origin without asking
+// first, and must name the receiver's own address or a loopback name as
+// their Host, so a web page can't post events by rebinding its name to
+// this machine either.
+type Receiver struct {
+	addr    string
+	handler EventHandler
+	token   string
+	limiter *rateLimiter
+
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewReceiver creates a receiver that listens on addr, e.g. "127.0.0.1:8787"
+func NewReceiver(addr string, handler EventHandler) *Receiver {
+	return &Receiver{
+		addr:    addr,
+		handler: handler,
+	}
+}
+
+// SetToken requires requests to carry "Authorization: Bearer <token>"
+func (r *Receiver) SetToken(token string) {
+	r.token = token
+}
+
+// SetRateLimit limits how many events are accepted per minute; further
+// events are refused until the limit allows more (0 disables the limit)
+func (r *Receiver) SetRateLimit(perMinute int) {
+	if perMinute <= 0 {
+		r.limiter = nil
+		return
+	}
+	r.limiter = newRateLimiter(perMinute, time.Minute)
+}
+
+// Start begins accepting requests
+func (r *Receiver) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.listener != nil {
+		return fmt.Errorf("receiver already started")
+	}
+
+	listener, err := net.Listen("tcp", r.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", r.addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/event", r.handleEvent)
+	r.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+	r.listener = listener
+
+	go func() { _ = r.server.Serve(listener) }()
+	return nil
+}
+
+// Addr returns the address the receiver listens on, once started
+func (r *Receiver) Addr() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listener == nil {
+		return r.addr
+	}
+	return r.listener.Addr().String()
+}
+
+// Close stops the receiver
+func (r *Receiver) Close() error {
+	r.mu.Lock()
+	server := r.server
+	r.server = nil
+	r.listener = nil
+	r.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Close()
+}
+
+// handleEvent handles POST /event
+func (r *Receiver) handleEvent(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !r.allowedHost(req.Host) {
+		http.Error(w, "unexpected host", http.StatusForbidden)
+		return
+	}
+
+	if r.token != "" {
+		want := "Bearer " + r.token
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	event, err := decodeEvent(http.MaxBytesReader(w, req.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.limiter != nil && !r.limiter.Allow() {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	if err := r.handler(event); err != nil {
+		http.Error(w, fmt.Sprintf("notification failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// allowedHost reports whether a request's Host names the receiver: a
+// loopback name or address, the host it was told to listen on, or any
+// address when it listens on all of them. Other names are what a web page
+// rebinding its own name to this machine would send.
+func (r *Receiver) allowedHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	listenHost, _, err := net.SplitHostPort(r.addr)
+	if err != nil {
+		return false
+	}
+	if listenHost != "" && strings.EqualFold(host, listenHost) {
+		return true
+	}
+	if listenIP := net.ParseIP(listenHost); listenHost == "" || (listenIP != nil && listenIP.IsUnspecified()) {
+		return ip != nil
+	}
+	return false
+}
+
+// decodeEvent reads and checks an event
+func decodeEvent(body io.Reader) (Event, error) {
+	var event Event
+	if err := json.NewDecoder(body).Decode(&event); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return event, fmt.Errorf("event too large")
+		}
+		return event, fmt.Errorf("invalid event JSON: %v", err)
+	}
+
+	if event.Title == "" && event.Message == "" {
+		return event, fmt.Errorf("event needs a title or message")
+	}
+	if event.Type == "" {
+		event.Type = DefaultEventType
+	}
+	if !eventTypePattern.MatchString(event.Type) {
+		return event, fmt.Errorf("invalid event type %q", event.Type)
+	}
+	return event, nil
+}
+
+// rateLimiter is a token bucket allowing a number of events per interval,
+// in bursts of up to that number
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+	now      func() time.Time
+}
+
+// newRateLimiter allows n events per interval
+func newRateLimiter(n int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(n),
+		tokens:   float64(n),
+		rate:     float64(n) / interval.Seconds(),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether another event may pass, using up a token if so
+func (rl *rateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.capacity {
+		rl.tokens = rl.capacity
+	}
+	rl.last = now
+
+	if rl.tokens < 1 {
+		return false
+	}
+	rl.tokens--
+	return true
+}
+
+// Reset refills the bucket
+func (rl *rateLimiter) Reset() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens = rl.capacity
+	rl.last = rl.now()
+}
@@ -0,0 +1,172 @@
+package control
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReceiver(t *testing.T) {
+	var got []Event
+	receiver := NewReceiver("127.0.0.1:0", func(event Event) error {
+		got = append(got, event)
+		return nil
+	})
+	receiver.SetToken("secret")
+	receiver.SetRateLimit(2)
+	if err := receiver.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = receiver.Close() }()
+
+	url := "http://" + receiver.Addr() + "/event"
+	post := func(body, contentType, token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		token       string
+		want        int
+	}{
+		{"wrong token", `{"title":"t"}`, "application/json", "nope", http.StatusUnauthorized},
+		{"form post", `{"title":"t"}`, "text/plain", "secret", http.StatusUnsupportedMediaType},
+		{"invalid JSON", `{`, "application/json", "secret", http.StatusBadRequest},
+		{"empty event", `{"type":"build"}`, "application/json", "secret", http.StatusBadRequest},
+		{"invalid type", `{"title":"t","type":"Build Done"}`, "application/json", "secret", http.StatusBadRequest},
+		{"event", `{"title":"Build","message":"done","type":"build"}`, "application/json; charset=utf-8", "secret", http.StatusAccepted},
+		{"default type", `{"message":"hi"}`, "application/json", "secret", http.StatusAccepted},
+		{"rate limited", `{"message":"again"}`, "application/json", "secret", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := post(tt.body, tt.contentType, tt.token); status != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, status)
+			}
+		})
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+	if got[0].Title != "Build" || got[0].Message != "done" || got[0].Type != "build" {
+		t.Errorf("unexpected event: %+v", got[0])
+	}
+	if got[1].Type != DefaultEventType {
+		t.Errorf("expected default type %q, got %q", DefaultEventType, got[1].Type)
+	}
+
+	t.Run("GET not allowed", func(t *testing.T) {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("expected 405, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rebound host", func(t *testing.T) {
+		receiver.limiter.Reset()
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"message":"x"}`))
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Host = "attacker.example:8787"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		receiver.limiter.Reset()
+		receiver.handler = func(Event) error { return fmt.Errorf("offline") }
+		if status := post(`{"message":"x"}`, "application/json", "secret"); status != http.StatusBadGateway {
+			t.Errorf("expected 502, got %d", status)
+		}
+	})
+}
+
+func TestReceiverAllowedHost(t *testing.T) {
+	tests := []struct {
+		addr string
+		host string
+		want bool
+	}{
+		{"127.0.0.1:8787", "127.0.0.1:8787", true},
+		{"127.0.0.1:8787", "localhost:8787", true},
+		{"127.0.0.1:8787", "LOCALHOST.", true},
+		{"127.0.0.1:8787", "[::1]:8787", true},
+		{"127.0.0.1:8787", "attacker.example:8787", false},
+		{"127.0.0.1:8787", "192.168.1.5:8787", false},
+		{"devbox.lan:8787", "devbox.lan:8787", true},
+		{"devbox.lan:8787", "attacker.example", false},
+		{"0.0.0.0:8787", "192.168.1.5:8787", true},
+		{":8787", "192.168.1.5:8787", true},
+		{":8787", "attacker.example:8787", false},
+	}
+	for _, tt := range tests {
+		r := NewReceiver(tt.addr, nil)
+		if got := r.allowedHost(tt.host); got != tt.want {
+			t.Errorf("allowedHost(%q) listening on %q = %v, want %v", tt.host, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	rl := newRateLimiter(2, time.Minute)
+	rl.now = func() time.Time { return now }
+	rl.last = now
+
+	if !rl.Allow() || !rl.Allow() {
+		t.Fatal("expected a burst of 2 to be allowed")
+	}
+	if rl.Allow() {
+		t.Error("expected third event to be refused")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !rl.Allow() {
+		t.Error("expected an event to be allowed after half the interval")
+	}
+	if rl.Allow() {
+		t.Error("expected only one token to have been refilled")
+	}
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !rl.Allow() {
+			t.Errorf("expected event %d to be allowed after a long wait", i+1)
+		}
+	}
+	if rl.Allow() {
+		t.Error("expected tokens to be capped at the burst size")
+	}
+}
@@ -0,0 +1,144 @@
+// Package control provides a local control API for a running wrapper.
+package control
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler executes a command and returns the reply
+type Handler func(command string) string
+
+// SocketServer serves control commands over a unix socket. Each connection
+// sends a single command line and receives the reply before the server
+// closes the connection, so `echo status | nc -U <socket>` works.
+type SocketServer struct {
+	path    string
+	handler Handler
+
+	mu       sync.Mutex
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// NewSocketServer creates a new control socket server
+func NewSocketServer(path string, handler Handler) *SocketServer {
+	return &SocketServer{
+		path:    path,
+		handler: handler,
+	}
+}
+
+// DefaultSocketPath returns the per-session socket path for the given pid
+func DefaultSocketPath(pid int) string {
+	return filepath.Join(SocketDir(), fmt.Sprintf("%d.sock", pid))
+}
+
+// SocketDir returns the directory holding per-session control sockets
+func SocketDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "gemini-cli-ntfy")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gemini-cli-ntfy-%d", os.Getuid()))
+}
+
+// Path returns the socket path
+func (s *SocketServer) Path() string {
+	return s.path
+}
+
+// Start creates the socket and begins accepting connections
+func (s *SocketServer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return fmt.Errorf("control socket already started")
+	}
+
+	// Only the owning user may reach the socket
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a crashed session with the same pid
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.path, err)
+	}
+	if err := os.Chmod(s.path, 0600); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+
+	return nil
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (s *SocketServer) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// serve handles a single connection
+func (s *SocketServer) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return
+	}
+
+	command := strings.TrimSpace(line)
+	if command == "" {
+		return
+	}
+
+	reply := s.handler(command)
+	_, _ = fmt.Fprintln(conn, reply)
+}
+
+// Close stops accepting connections and removes the socket
+func (s *SocketServer) Close() error {
+	s.mu.Lock()
+	listener := s.listener
+	s.listener = nil
+	s.mu.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	err := listener.Close()
+	s.wg.Wait()
+	_ = os.Remove(s.path)
+
+	return err
+}
@@ -0,0 +1,49 @@
+package control
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSocketServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+	server := NewSocketServer(path, func(command string) string {
+		return "got: " + command
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Errorf("socket should only be accessible by owner, got %v", info.Mode().Perm())
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	fmt.Fprintln(conn, "  status  ")
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	_ = conn.Close()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if reply != "got: status\n" {
+		t.Errorf("expected 'got: status', got %q", reply)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected socket to be removed after Close")
+	}
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import "regexp"
+
+// APIErrorClass is the kind of an API error, which decides how it is fixed.
+// The classes are also the patterns of their notifications.
+type APIErrorClass string
+
+const (
+	// APIErrorAuth is rejected credentials, fixed by signing in again
+	APIErrorAuth APIErrorClass = "api_auth"
+	// APIErrorQuota is an exhausted quota or rate limit, fixed by waiting
+	APIErrorQuota APIErrorClass = "api_quota"
+	// APIErrorServer is a failure on the API's side, fixed by retrying
+	APIErrorServer APIErrorClass = "api_server"
+	// APIErrorOther is any other API error
+	APIErrorOther APIErrorClass = "api_error"
+)
+
+// apiErrorPatterns recognize the classes by status code or wording, in
+// order; a status code in the message is the most reliable sign
+var apiErrorPatterns = []struct {
+	class   APIErrorClass
+	pattern *regexp.Regexp
+}{
+	{APIErrorAuth, regexp.MustCompile(`\b(401|403)\b`)},
+	{APIErrorQuota, regexp.MustCompile(`\b429\b`)},
+	{APIErrorServer, regexp.MustCompile(`\b5\d\d\b`)},
+	{APIErrorAuth, regexp.MustCompile(`(?i)unauthenticated|permission.denied|api.key not valid|invalid.(api.)?key|invalid.authentication|authentication.?error|credentials|expired.token|token.expired`)},
+	{APIErrorQuota, regexp.MustCompile(`(?i)resource.exhausted|quota|rate.?limit|too many requests`)},
+	{APIErrorServer, regexp.MustCompile(`(?i)internal.error|unavailable|overloaded|bad gateway|deadline.exceeded`)},
+}
+
+// ClassifyAPIError returns the class of an API error message
+func ClassifyAPIError(message string) APIErrorClass {
+	for _, p := range apiErrorPatterns {
+		if p.pattern.MatchString(message) {
+			return p.class
+		}
+	}
+	return APIErrorOther
+}
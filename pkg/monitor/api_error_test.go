@@ -0,0 +1,24 @@
+package monitor
+
+import "testing"
+
+func TestClassifyAPIError(t *testing.T) {
+	tests := []struct {
+		message string
+		want    APIErrorClass
+	}{
+		{"✕ [API Error: got status: 401 Unauthorized. Request had invalid authentication credentials.]", APIErrorAuth},
+		{"[API Error: API key not valid. Please pass a valid API key.]", APIErrorAuth},
+		{"✕ [API Error: got status: 429 Too Many Requests. Quota exceeded for quota metric]", APIErrorQuota},
+		{"[API Error: RESOURCE_EXHAUSTED]", APIErrorQuota},
+		{"✕ [API Error: got status: 503 Service Unavailable. The model is overloaded.]", APIErrorServer},
+		{"API Error: 529 {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\"}}", APIErrorServer},
+		{"[API Error: exception TypeError: fetch failed]", APIErrorOther},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyAPIError(tt.message); got != tt.want {
+			t.Errorf("ClassifyAPIError(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
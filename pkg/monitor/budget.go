@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Usage is what a session has used of its budget
+type Usage struct {
+	Tokens int
+	// Estimated cost of the tokens, in the currency prices are given in
+	Cost float64
+}
+
+// Budget tracks the tokens a session uses against a budget of tokens or of
+// their estimated cost. Once the budget is used up it is reported, and the
+// user's input can be held back until the overrun is acknowledged, so an
+// unattended session doesn't keep spending.
+type Budget struct {
+	tokens          int
+	cost            float64
+	pricePerMillion float64
+	onExceeded      func(Usage)
+
+	mu       sync.Mutex
+	used     int
+	exceeded bool
+	pause    bool
+	paused   bool
+}
+
+// NewBudget creates a budget of tokens, cost or both (0 leaves either
+// out), with tokens priced at pricePerMillion per million. onExceeded is
+// called once when the budget is used up.
+func NewBudget(tokens int, cost, pricePerMillion float64, onExceeded func(Usage)) *Budget {
+	return &Budget{
+		tokens:          tokens,
+		cost:            cost,
+		pricePerMillion: pricePerMillion,
+		onExceeded:      onExceeded,
+	}
+}
+
+// SetPause makes the budget hold back input once it is used up, until
+// Acknowledge is called
+func (b *Budget) SetPause(pause bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pause = pause
+}
+
+// Add counts the tokens of one turn, as reported in structured output
+func (b *Budget) Add(tokens int) {
+	b.mu.Lock()
+	b.used += tokens
+	b.mu.Unlock()
+	b.check()
+}
+
+// Observe records a running total for the session, as a CLI shows it on
+// screen; totals lower than the one already seen are ignored
+func (b *Budget) Observe(total int) {
+	b.mu.Lock()
+	b.used = max(b.used, total)
+	b.mu.Unlock()
+	b.check()
+}
+
+// check reports the budget once it is used up
+func (b *Budget) check() {
+	b.mu.Lock()
+	usage := b.usageLocked()
+	over := (b.tokens > 0 && usage.Tokens >= b.tokens) || (b.cost > 0 && usage.Cost >= b.cost)
+	if !over || b.exceeded {
+		b.mu.Unlock()
+		return
+	}
+	b.exceeded = true
+	b.paused = b.pause
+	b.mu.Unlock()
+
+	b.onExceeded(usage)
+}
+
+// Usage returns what the session has used so far
+func (b *Budget) Usage() Usage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usageLocked()
+}
+
+// usageLocked returns the usage. Caller must hold b.mu.
+func (b *Budget) usageLocked() Usage {
+	return Usage{Tokens: b.used, Cost: float64(b.used) * b.pricePerMillion / 1e6}
+}
+
+// Exceeded reports whether the budget has been used up
+func (b *Budget) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// Paused reports whether input is held back
+func (b *Budget) Paused() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.paused
+}
+
+// Acknowledge lets input through again after the budget was used up, and
+// reports whether it was held back. The budget isn't reported again.
+func (b *Budget) Acknowledge() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	paused := b.paused
+	b.paused = false
+	return paused
+}
+
+// FilterInput drops the user's input while it is held back. Ctrl-C still
+// gets through, so Gemini can be stopped.
+func (b *Budget) FilterInput(data []byte) []byte {
+	if !b.Paused() {
+		return data
+	}
+	var kept []byte
+	for _, c := range data {
+		if c == 0x03 {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// ParseTokenCount parses a token count as CLIs show it, e.g. "12,345",
+// "1.2K" or "3M"
+func ParseTokenCount(s string) (int, bool) {
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), ",", ""))
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1e3
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1e6
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimRight(s, "KM")), 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return int(n * multiplier), true
+}
+
+// TokenTotal returns the largest token count in lines matched by pattern,
+// in its first group
+func TokenTotal(pattern *regexp.Regexp, lines []string) (int, bool) {
+	total, found := 0, false
+	for _, line := range lines {
+		match := pattern.FindStringSubmatch(line)
+		if len(match) < 2 {
+			continue
+		}
+		if n, ok := ParseTokenCount(match[1]); ok {
+			total, found = max(total, n), true
+		}
+	}
+	return total, found
+}
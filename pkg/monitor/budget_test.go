@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	t.Run("reports tokens once", func(t *testing.T) {
+		var reports []Usage
+		b := NewBudget(1000, 0, 2.5, func(u Usage) { reports = append(reports, u) })
+		b.Add(600)
+		if len(reports) != 0 {
+			t.Fatalf("expected no report under the budget, got %v", reports)
+		}
+		b.Add(500)
+		b.Add(100)
+		if len(reports) != 1 || reports[0].Tokens != 1100 || reports[0].Cost != 0.00275 {
+			t.Errorf("expected one report at 1100 tokens, got %v", reports)
+		}
+		if b.Paused() {
+			t.Error("expected input to flow without pause")
+		}
+	})
+
+	t.Run("cost budget with observed totals", func(t *testing.T) {
+		var reports []Usage
+		b := NewBudget(0, 1, 10, func(u Usage) { reports = append(reports, u) })
+		b.Observe(90000)
+		b.Observe(50000)
+		if got := b.Usage().Tokens; got != 90000 {
+			t.Errorf("expected a lower total to be ignored, got %d", got)
+		}
+		b.Observe(100000)
+		if len(reports) != 1 {
+			t.Errorf("expected a report once the cost reached 1, got %v", reports)
+		}
+	})
+
+	t.Run("holds back input until acknowledged", func(t *testing.T) {
+		b := NewBudget(10, 0, 0, func(Usage) {})
+		b.SetPause(true)
+		if got := b.FilterInput([]byte("hi\r")); string(got) != "hi\r" {
+			t.Errorf("expected input before the budget is used up, got %q", got)
+		}
+		b.Add(10)
+		if got := b.FilterInput([]byte("hi\x03\r")); string(got) != "\x03" {
+			t.Errorf("expected only Ctrl-C while paused, got %q", got)
+		}
+		if !b.Acknowledge() || b.Acknowledge() {
+			t.Error("expected the first acknowledgement to lift the pause")
+		}
+		b.Add(10)
+		if got := b.FilterInput([]byte("ok\r")); string(got) != "ok\r" {
+			t.Errorf("expected input after acknowledging, got %q", got)
+		}
+	})
+}
+
+func TestTokenTotal(t *testing.T) {
+	counts := map[string]int{"12,345": 12345, "1.5K": 1500, "3M": 3000000, "42": 42}
+	for s, want := range counts {
+		if got, ok := ParseTokenCount(s); !ok || got != want {
+			t.Errorf("ParseTokenCount(%q) = %d, %v; expected %d", s, got, ok, want)
+		}
+	}
+	if _, ok := ParseTokenCount("many"); ok {
+		t.Error("expected an error for a word")
+	}
+
+	pattern := regexp.MustCompile(`([\d.,]+[KM]?) tokens used`)
+	total, ok := TokenTotal(pattern, []string{"> fix it", "8.1K tokens used", "12.4K tokens used · 80% context left"})
+	if !ok || total != 12400 {
+		t.Errorf("expected 12400 tokens, got %d (%v)", total, ok)
+	}
+}
@@ -0,0 +1,35 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// buildErrorSources recognize the error lines of common compilers
+var buildErrorSources = []string{
+	// Go: "./main.go:12:5: undefined: foo"
+	`(?:^|\s)[\w./-]+\.go:\d+:\d+: `,
+	// TypeScript: "src/app.ts(3,7): error TS2322: ..." or, pretty printed,
+	// "src/app.ts:3:7 - error TS2322: ..."
+	`[\w./-]+\.tsx?(?:\(\d+,\d+\):|:\d+:\d+ -) error TS\d+:`,
+	// Rust: "error[E0425]: cannot find value `x` in this scope"
+	`\berror\[E\d+\]: `,
+	`\berror: could not compile\b`,
+}
+
+// BuildErrorPatterns compiles the bundled patterns for compiler errors
+// followed by extra ones, e.g. from the configuration
+func BuildErrorPatterns(extra []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(buildErrorSources)+len(extra))
+	for _, source := range buildErrorSources {
+		patterns = append(patterns, regexp.MustCompile(source))
+	}
+	for _, source := range extra {
+		pattern, err := regexp.Compile(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build error pattern %q: %w", source, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
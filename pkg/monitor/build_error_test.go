@@ -0,0 +1,38 @@
+package monitor
+
+import "testing"
+
+func TestBuildErrorPatterns(t *testing.T) {
+	patterns, err := BuildErrorPatterns([]string{`^ERROR in `})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		line string
+		want bool
+	}{
+		{"./main.go:12:5: undefined: foo", true},
+		{"│ pkg/server/server.go:40:2: declared and not used: x │", true},
+		{"src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.", true},
+		{"src/app.tsx:3:7 - error TS2304: Cannot find name 'foo'.", true},
+		{"error[E0425]: cannot find value `x` in this scope", true},
+		{"error: could not compile `app` (bin \"app\") due to 2 previous errors", true},
+		{"ERROR in ./src/index.js 5:0", true},
+		{"Edited main.go", false},
+		{"main.go:12: TODO fix this", false},
+		{"the error handling in app.ts looks fine", false},
+	} {
+		matched := false
+		for _, pattern := range patterns {
+			matched = matched || pattern.MatchString(tt.line)
+		}
+		if matched != tt.want {
+			t.Errorf("expected match %v for %q", tt.want, tt.line)
+		}
+	}
+
+	if _, err := BuildErrorPatterns([]string{"("}); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+}
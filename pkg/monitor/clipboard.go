@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/base64"
+)
+
+// clipboardPrefix starts an OSC 52 sequence, which sets or queries the
+// terminal's clipboard: ESC ] 52 ; targets ; base64 text BEL
+var clipboardPrefix = []byte("52;")
+
+// clipboardWrite returns the text an OSC 52 payload copies to the
+// clipboard, and false if the payload is not a clipboard write. Queries
+// ("?") and clearing the clipboard are not writes.
+func clipboardWrite(data []byte) (string, bool) {
+	if !bytes.HasPrefix(data, clipboardPrefix) {
+		return "", false
+	}
+	_, encoded, ok := bytes.Cut(data[len(clipboardPrefix):], []byte(";"))
+	if !ok || len(encoded) == 0 || bytes.Equal(encoded, []byte("?")) {
+		return "", false
+	}
+
+	text, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		// Some programs leave out the padding
+		text, err = base64.RawStdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return "", false
+		}
+	}
+	if len(text) == 0 {
+		return "", false
+	}
+	return string(text), true
+}
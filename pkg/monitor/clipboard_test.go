@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+func TestClipboardWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		text string
+		ok   bool
+	}{
+		{"write", "52;c;Z2l0IHB1c2g=", "git push", true},
+		{"without padding", "52;c;Z2l0IHB1c2g", "git push", true},
+		{"no targets", "52;;dG9rZW4=", "token", true},
+		{"query", "52;c;?", "", false},
+		{"clear", "52;c;", "", false},
+		{"invalid", "52;c;!!!", "", false},
+		{"title", "0;gemini", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := clipboardWrite([]byte(tt.data))
+			if text != tt.text || ok != tt.ok {
+				t.Errorf("expected %q, %v, got %q, %v", tt.text, tt.ok, text, ok)
+			}
+		})
+	}
+}
+
+func TestOutputMonitorClipboardHook(t *testing.T) {
+	om := NewOutputMonitor(&config.Config{}, notification.NewStdoutNotifier())
+
+	var mu sync.Mutex
+	var copied []string
+	om.SetClipboardHook(func(text string) {
+		mu.Lock()
+		defer mu.Unlock()
+		copied = append(copied, text)
+	})
+
+	// Split across chunks, terminated by ST
+	om.HandleData([]byte("done\x1b]52;c;Z2l0"))
+	om.HandleData([]byte("IHB1c2g=\x1b\\"))
+	om.HandleData([]byte("\x1b]52;c;?\x07"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(copied) != 1 || copied[0] != "git push" {
+		t.Errorf("expected one copy of \"git push\", got %q", copied)
+	}
+}
@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// commitCheckInterval is how often a CommitWatcher looks for new commits
+const commitCheckInterval = 5 * time.Second
+
+// CommitWatcher reports commits made in the project's repository while the
+// session runs, e.g. by an agent committing on its own. Commits that only
+// became reachable, e.g. by checking out another branch, are not reported.
+type CommitWatcher struct {
+	head     func() string
+	commits  func(since string) []notification.GitCommit
+	started  time.Time
+	onCommit func(commits []notification.GitCommit)
+
+	mu sync.Mutex
+	// Commit checked out when last checked
+	last string
+}
+
+// NewCommitWatcher creates a watcher that calls onCommit with the commits
+// made since it started running each time the checked out commit changes.
+// head returns the checked out commit, and commits those reachable from it
+// but not from since.
+func NewCommitWatcher(head func() string, commits func(since string) []notification.GitCommit,
+	onCommit func(commits []notification.GitCommit)) *CommitWatcher {
+	return &CommitWatcher{
+		head:     head,
+		commits:  commits,
+		onCommit: onCommit,
+	}
+}
+
+// Run checks the repository until stop is closed
+func (cw *CommitWatcher) Run(stop <-chan struct{}) {
+	cw.start(time.Now())
+	ticker := time.NewTicker(commitCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cw.check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// start notes where the session started
+func (cw *CommitWatcher) start(now time.Time) {
+	// Commit times have a resolution of seconds
+	cw.started = now.Truncate(time.Second)
+	cw.last = cw.head()
+}
+
+// check reports the commits made since the last check
+func (cw *CommitWatcher) check() {
+	head := cw.head()
+
+	cw.mu.Lock()
+	since := cw.last
+	cw.last = head
+	cw.mu.Unlock()
+	if head == since || head == "" {
+		return
+	}
+
+	var made []notification.GitCommit
+	for _, commit := range cw.commits(since) {
+		if !commit.Time.Before(cw.started) {
+			made = append(made, commit)
+		}
+	}
+	if len(made) > 0 {
+		cw.onCommit(made)
+	}
+}
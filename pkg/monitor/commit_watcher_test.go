@@ -0,0 +1,53 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+func TestCommitWatcher(t *testing.T) {
+	start := time.Now()
+	head := "aaa"
+	history := map[string][]notification.GitCommit{
+		"aaa": {{Hash: "bbb", Subject: "Fix the parser", Time: start.Add(time.Minute)}},
+		"bbb": {
+			{Hash: "ccc", Subject: "Add tests", Time: start.Add(2 * time.Minute)},
+			{Hash: "ddd", Subject: "Update docs", Time: start.Add(3 * time.Minute)},
+		},
+		// Another branch, committed to before the session
+		"ddd": {{Hash: "eee", Subject: "Old work", Time: start.Add(-time.Hour)}},
+	}
+	var reports [][]notification.GitCommit
+	cw := NewCommitWatcher(func() string { return head }, func(since string) []notification.GitCommit {
+		return history[since]
+	}, func(commits []notification.GitCommit) {
+		reports = append(reports, commits)
+	})
+	cw.start(start)
+
+	cw.check()
+	if len(reports) != 0 {
+		t.Fatalf("expected no report without a new commit, got %v", reports)
+	}
+
+	head = "bbb"
+	cw.check()
+	cw.check()
+	if len(reports) != 1 || len(reports[0]) != 1 || reports[0][0].Subject != "Fix the parser" {
+		t.Fatalf("expected the new commit once, got %v", reports)
+	}
+
+	head = "ddd"
+	cw.check()
+	if len(reports) != 2 || len(reports[1]) != 2 {
+		t.Fatalf("expected both new commits, got %v", reports)
+	}
+
+	head = "eee"
+	cw.check()
+	if len(reports) != 2 {
+		t.Errorf("expected no report for checking out older commits, got %v", reports)
+	}
+}
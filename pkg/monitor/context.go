@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contextSearchLines is how many recent output lines are searched for
+// messages about the context window
+const contextSearchLines = 20
+
+// contextCheckInterval is how often the recent output is searched
+const contextCheckInterval = 2 * time.Second
+
+// maxReportedCompressions bounds how many compression messages are
+// remembered, so redraws of the same message aren't reported again
+const maxReportedCompressions = 32
+
+// ContextWatcher reports what the wrapped CLI says about its context window:
+// that it compressed the conversation, or that little of the window is
+// left. Either often explains answers getting worse. The CLI redraws its
+// screen, so each message is reported once, and a low context once until
+// more is free again.
+type ContextWatcher struct {
+	lines       func(n int) []string
+	compression []*regexp.Regexp
+	contextLeft *regexp.Regexp
+	lowPercent  int
+
+	onCompressed func(message string)
+	onLow        func(percent int)
+
+	mu sync.Mutex
+	// Compression messages already reported, oldest first
+	reported []string
+	// Whether the low context has been reported
+	low bool
+}
+
+// NewContextWatcher creates a watcher that searches the recent output lines
+// returned by lines. onCompressed is called with a line matching one of
+// the compression patterns, and onLow when contextLeft shows lowPercent or
+// less of the context window left (0 disables it).
+func NewContextWatcher(lines func(n int) []string, compression []*regexp.Regexp, contextLeft *regexp.Regexp, lowPercent int,
+	onCompressed func(message string), onLow func(percent int)) *ContextWatcher {
+	return &ContextWatcher{
+		lines:        lines,
+		compression:  compression,
+		contextLeft:  contextLeft,
+		lowPercent:   lowPercent,
+		onCompressed: onCompressed,
+		onLow:        onLow,
+	}
+}
+
+// Run checks the output until stop is closed
+func (cw *ContextWatcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(contextCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cw.check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check searches the recent output and reports what is new
+func (cw *ContextWatcher) check() {
+	lines := cw.lines(contextSearchLines)
+
+	var compressed []string
+	percent := -1
+	cw.mu.Lock()
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		for _, pattern := range cw.compression {
+			if pattern.MatchString(line) && !cw.wasReported(line) {
+				cw.reported = append(cw.reported, line)
+				if len(cw.reported) > maxReportedCompressions {
+					cw.reported = cw.reported[1:]
+				}
+				compressed = append(compressed, line)
+				break
+			}
+		}
+		// The latest figure counts
+		if cw.contextLeft != nil {
+			if match := cw.contextLeft.FindStringSubmatch(line); len(match) > 1 {
+				if p, err := strconv.Atoi(match[1]); err == nil {
+					percent = p
+				}
+			}
+		}
+	}
+
+	reportLow := false
+	if percent >= 0 && cw.lowPercent > 0 {
+		if percent <= cw.lowPercent {
+			reportLow = !cw.low
+			cw.low = true
+		} else {
+			// A compression freed the window; report the next low again
+			cw.low = false
+		}
+	}
+	cw.mu.Unlock()
+
+	for _, message := range compressed {
+		cw.onCompressed(message)
+	}
+	if reportLow {
+		cw.onLow(percent)
+	}
+}
+
+// Reset forgets what was reported, for a new conversation
+func (cw *ContextWatcher) Reset() {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.reported = nil
+	cw.low = false
+}
+
+// wasReported reports whether a compression message was reported already.
+// Caller must hold cw.mu.
+func (cw *ContextWatcher) wasReported(line string) bool {
+	for _, reported := range cw.reported {
+		if reported == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+)
+
+func TestContextWatcher(t *testing.T) {
+	profile, err := config.LookupProfile("gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compression, err := profile.CompileCompressionPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contextLeft, err := profile.CompileContextLeftPattern()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var screen []string
+	var compressed []string
+	var low []int
+	cw := NewContextWatcher(func(n int) []string { return screen }, compression, contextLeft, 10,
+		func(message string) { compressed = append(compressed, message) },
+		func(percent int) { low = append(low, percent) })
+
+	screen = []string{"~/src/app  gemini-2.5-pro (42% context left)"}
+	cw.check()
+	if len(compressed) != 0 || len(low) != 0 {
+		t.Fatalf("expected nothing to report, got %v %v", compressed, low)
+	}
+
+	t.Run("low context is reported once", func(t *testing.T) {
+		screen = []string{"~/src/app  gemini-2.5-pro (9% context left)"}
+		cw.check()
+		screen = []string{"~/src/app  gemini-2.5-pro (7% context left)"}
+		cw.check()
+		if len(low) != 1 || low[0] != 9 {
+			t.Errorf("expected one report at 9%%, got %v", low)
+		}
+	})
+
+	t.Run("compression is reported once and re-arms low", func(t *testing.T) {
+		message := "ℹ Chat history compressed from 980000 to 41000 tokens."
+		screen = []string{"  " + message, "~/src/app  gemini-2.5-pro (96% context left)"}
+		cw.check()
+		// The same message redrawn
+		cw.check()
+		if len(compressed) != 1 || compressed[0] != message {
+			t.Errorf("expected one compression report, got %q", compressed)
+		}
+
+		screen = []string{"~/src/app  gemini-2.5-pro (5% context left)"}
+		cw.check()
+		if len(low) != 2 || low[1] != 5 {
+			t.Errorf("expected a second low report after compression, got %v", low)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package monitor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// maxWatchedDirs bounds how many directories a FileWatcher watches, since
+// each takes an inotify watch, of which there are few by default
+const maxWatchedDirs = 8192
+
+// skippedDirs are directories whose files are not the session's work
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+// FileWatcher watches the project directory, and the sensitive paths
+// outside it, for changes to files, whatever the terminal shows. It reports
+// a burst of changes to many files in a short time, and each change to a
+// sensitive path once.
+type FileWatcher struct {
+	root        string
+	burstFiles  int
+	burstWindow time.Duration
+	// Patterns of sensitive paths, with "~" expanded
+	sensitive   []string
+	onBurst     func(files []string)
+	onSensitive func(path string)
+
+	watcher *fsnotify.Watcher
+	dirs    int
+
+	mu sync.Mutex
+	// Time of the latest change to each file changed within burstWindow
+	recent     map[string]time.Time
+	lastChange time.Time
+	// Set once the current burst was reported, until changes pause
+	inBurst bool
+	// Sensitive paths already reported
+	reported map[string]bool
+}
+
+// NewFileWatcher creates a watcher for the directory tree at root. onBurst
+// is called with the changed files once burstFiles files changed within
+// burstWindow (0 files disables this), and onSensitive with a path matching
+// one of the sensitive patterns when it first changes. A pattern without a
+// slash matches file names, e.g. ".env*"; others match paths relative to
+// root, or absolute paths, e.g. "~/.ssh".
+func NewFileWatcher(root string, burstFiles int, burstWindow time.Duration, sensitive []string,
+	onBurst func(files []string), onSensitive func(path string)) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch files: %w", err)
+	}
+
+	fw := &FileWatcher{
+		root:        root,
+		burstFiles:  burstFiles,
+		burstWindow: burstWindow,
+		onBurst:     onBurst,
+		onSensitive: onSensitive,
+		watcher:     watcher,
+		recent:      make(map[string]time.Time),
+		reported:    make(map[string]bool),
+	}
+	home, _ := os.UserHomeDir()
+	for _, pattern := range sensitive {
+		if strings.HasPrefix(pattern, "~/") && home != "" {
+			pattern = filepath.Join(home, pattern[2:])
+		}
+		fw.sensitive = append(fw.sensitive, pattern)
+	}
+
+	fw.addTree(root)
+	// Sensitive paths outside the project are watched on their own
+	for _, pattern := range fw.sensitive {
+		if !filepath.IsAbs(pattern) || fw.inRoot(pattern) {
+			continue
+		}
+		dir := pattern
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		_ = fw.watcher.Add(dir)
+	}
+	return fw, nil
+}
+
+// addTree watches a directory and the directories below it
+func (fw *FileWatcher) addTree(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if skippedDirs[entry.Name()] || fw.dirs >= maxWatchedDirs {
+			return filepath.SkipDir
+		}
+		if fw.watcher.Add(path) == nil {
+			fw.dirs++
+		}
+		return nil
+	})
+}
+
+// Run reports changes until stop is closed
+func (fw *FileWatcher) Run(stop <-chan struct{}) {
+	defer func() { _ = fw.watcher.Close() }()
+	for {
+		select {
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			// Permissions alone don't change what a file holds
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// Files may be written to a new directory right away
+			if event.Has(fsnotify.Create) && fw.inRoot(event.Name) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !skippedDirs[info.Name()] {
+					fw.addTree(event.Name)
+					continue
+				}
+			}
+			fw.changed(event.Name, time.Now())
+		case _, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// changed records a change to a file, reporting a burst or a sensitive path
+func (fw *FileWatcher) changed(path string, now time.Time) {
+	name := fw.displayPath(path)
+
+	fw.mu.Lock()
+	sensitive := fw.isSensitive(path) && !fw.reported[name]
+	if sensitive {
+		fw.reported[name] = true
+	}
+
+	var burst []string
+	if fw.burstFiles > 0 && fw.inRoot(path) {
+		// A pause in the changes ends the burst
+		if now.Sub(fw.lastChange) > fw.burstWindow {
+			fw.inBurst = false
+		}
+		fw.lastChange = now
+		fw.recent[name] = now
+		for file, changed := range fw.recent {
+			if now.Sub(changed) > fw.burstWindow {
+				delete(fw.recent, file)
+			}
+		}
+		if len(fw.recent) >= fw.burstFiles && !fw.inBurst {
+			fw.inBurst = true
+			for file := range fw.recent {
+				burst = append(burst, file)
+			}
+			sort.Strings(burst)
+		}
+	}
+	fw.mu.Unlock()
+
+	if sensitive {
+		fw.onSensitive(name)
+	}
+	if burst != nil {
+		fw.onBurst(burst)
+	}
+}
+
+// inRoot reports whether path is in the project directory
+func (fw *FileWatcher) inRoot(path string) bool {
+	rel, err := filepath.Rel(fw.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// displayPath returns path relative to the project directory, or with the
+// home directory shortened to "~" outside it
+func (fw *FileWatcher) displayPath(path string) string {
+	if fw.inRoot(path) {
+		if rel, err := filepath.Rel(fw.root, path); err == nil {
+			return rel
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+// isSensitive reports whether path matches one of the sensitive patterns
+func (fw *FileWatcher) isSensitive(path string) bool {
+	for _, pattern := range fw.sensitive {
+		switch {
+		case filepath.IsAbs(pattern):
+			if matchPathOrParent(pattern, path) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if fw.inRoot(path) && matchPathOrParent(filepath.Join(fw.root, pattern), path) {
+				return true
+			}
+		default:
+			if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPathOrParent reports whether path, or a directory it is in, matches
+// pattern
+func matchPathOrParent(pattern, path string) bool {
+	for p := path; ; p = filepath.Dir(p) {
+		if matched, _ := filepath.Match(pattern, p); matched {
+			return true
+		}
+		if parent := filepath.Dir(p); parent == p {
+			return false
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherBurst(t *testing.T) {
+	root := t.TempDir()
+	var bursts [][]string
+	fw, err := NewFileWatcher(root, 3, 10*time.Second, nil, func(files []string) {
+		bursts = append(bursts, files)
+	}, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fw.watcher.Close()
+
+	start := time.Now()
+	fw.changed(filepath.Join(root, "a.go"), start)
+	fw.changed(filepath.Join(root, "a.go"), start.Add(time.Second))
+	fw.changed(filepath.Join(root, "b.go"), start.Add(2*time.Second))
+	if len(bursts) != 0 {
+		t.Fatalf("expected no burst for two files, got %v", bursts)
+	}
+
+	fw.changed(filepath.Join(root, "src", "c.go"), start.Add(3*time.Second))
+	fw.changed(filepath.Join(root, "d.go"), start.Add(4*time.Second))
+	if len(bursts) != 1 {
+		t.Fatalf("expected one burst, got %v", bursts)
+	}
+	want := []string{"a.go", "b.go", filepath.Join("src", "c.go")}
+	for i, file := range want {
+		if bursts[0][i] != file {
+			t.Fatalf("expected %v, got %v", want, bursts[0])
+		}
+	}
+
+	// Only a pause in the changes ends the burst
+	fw.changed(filepath.Join(root, "e.go"), start.Add(12*time.Second))
+	fw.changed(filepath.Join(root, "f.go"), start.Add(13*time.Second))
+	if len(bursts) != 1 {
+		t.Fatalf("expected the burst to go on, got %v", bursts)
+	}
+	later := start.Add(time.Minute)
+	for _, file := range []string{"g.go", "h.go", "i.go"} {
+		fw.changed(filepath.Join(root, file), later)
+	}
+	if len(bursts) != 2 || len(bursts[1]) != 3 {
+		t.Errorf("expected a second burst after the pause, got %v", bursts)
+	}
+}
+
+func TestFileWatcherSensitive(t *testing.T) {
+	root := t.TempDir()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	var reports []string
+	fw, err := NewFileWatcher(root, 0, time.Second, []string{".env*", "secrets/", "config/*.key", "~/.ssh"}, func([]string) {
+		t.Error("expected no burst with file_burst_files 0")
+	}, func(path string) {
+		reports = append(reports, path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fw.watcher.Close()
+
+	now := time.Now()
+	for _, path := range []string{
+		filepath.Join(root, ".env"),
+		filepath.Join(root, "web", ".env.local"),
+		filepath.Join(root, ".env"),
+		filepath.Join(root, "config", "tls.key"),
+		filepath.Join(root, "config", "app.yaml"),
+		filepath.Join(root, "main.go"),
+		filepath.Join(home, ".ssh", "id_ed25519"),
+		filepath.Join(home, ".sshd"),
+	} {
+		fw.changed(path, now)
+	}
+
+	want := []string{".env", filepath.Join("web", ".env.local"), filepath.Join("config", "tls.key"), "~/.ssh/id_ed25519"}
+	if len(reports) != len(want) {
+		t.Fatalf("expected %v, got %v", want, reports)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, reports)
+		}
+	}
+}
+
+func TestFileWatcherRun(t *testing.T) {
+	root := t.TempDir()
+	reports := make(chan string, 10)
+	fw, err := NewFileWatcher(root, 0, time.Second, []string{"*.pem"}, func([]string) {}, func(path string) {
+		reports <- path
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go fw.Run(stop)
+
+	// Directories created after the start are watched too
+	dir := filepath.Join(root, "certs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "server.pem"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case path := <-reports:
+		if path != filepath.Join("certs", "server.pem") {
+			t.Errorf("expected certs/server.pem, got %q", path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the new file to be reported")
+	}
+}
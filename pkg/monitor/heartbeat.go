@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// Heartbeat reports at a fixed interval that a session is still running,
+// whether Gemini is busy or idle, so a long unattended run that stays
+// quiet can be told apart from one that died with the machine
+type Heartbeat struct {
+	interval time.Duration
+	onBeat   func(running time.Duration)
+
+	mu      sync.Mutex
+	started time.Time
+	// When the last beat was sent, or the session started
+	last time.Time
+}
+
+// NewHeartbeat creates a heartbeat that calls onBeat with the time the
+// session has been running every interval
+func NewHeartbeat(interval time.Duration, onBeat func(running time.Duration)) *Heartbeat {
+	now := time.Now()
+	return &Heartbeat{
+		interval: interval,
+		onBeat:   onBeat,
+		started:  now,
+		last:     now,
+	}
+}
+
+// Run sends beats until stop is closed
+func (h *Heartbeat) Run(stop <-chan struct{}) {
+	// Check often enough that a beat is not much later than due
+	interval := min(h.interval/10, time.Minute)
+	ticker := time.NewTicker(max(interval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			h.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check sends a beat if the interval has passed since the last one. Beats
+// missed while the machine slept are not made up.
+func (h *Heartbeat) check(now time.Time) {
+	h.mu.Lock()
+	if now.Sub(h.last) < h.interval {
+		h.mu.Unlock()
+		return
+	}
+	h.last = now
+	running := now.Sub(h.started)
+	h.mu.Unlock()
+
+	h.onBeat(running)
+}
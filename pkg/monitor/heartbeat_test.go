@@ -0,0 +1,32 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var beats []time.Duration
+	h := NewHeartbeat(time.Hour, func(running time.Duration) {
+		beats = append(beats, running)
+	})
+	start := h.started
+
+	h.check(start.Add(59 * time.Minute))
+	if len(beats) != 0 {
+		t.Fatalf("expected no beat before the interval, got %v", beats)
+	}
+
+	h.check(start.Add(time.Hour))
+	h.check(start.Add(90 * time.Minute))
+	h.check(start.Add(2 * time.Hour))
+	// After a long sleep, one beat rather than one per missed interval
+	h.check(start.Add(6 * time.Hour))
+	h.check(start.Add(6*time.Hour + time.Minute))
+
+	want := []time.Duration{time.Hour, 2 * time.Hour, 6 * time.Hour}
+	if !reflect.DeepEqual(beats, want) {
+		t.Errorf("expected beats at %v, got %v", want, beats)
+	}
+}
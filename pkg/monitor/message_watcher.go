@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// messageSearchLines is how many recent output lines are searched for
+// messages
+const messageSearchLines = 30
+
+// messageCheckInterval is how often the recent output is searched
+const messageCheckInterval = 2 * time.Second
+
+// maxReportedMessages bounds how many messages of a kind are remembered
+const maxReportedMessages = 64
+
+// MessageWatcher reports messages of the wrapped CLI in its recent output,
+// e.g. errors, by the patterns of their kind. The CLI redraws its screen, so
+// each distinct line is reported once.
+type MessageWatcher struct {
+	lines func(n int) []string
+
+	mu    sync.Mutex
+	kinds []*messageKind
+}
+
+// messageKind is one kind of message the watcher looks for
+type messageKind struct {
+	patterns []*regexp.Regexp
+	onMatch  func(lines []string)
+	// Lines already reported, oldest first
+	reported []string
+}
+
+// NewMessageWatcher creates a watcher that searches the recent output lines
+// returned by lines
+func NewMessageWatcher(lines func(n int) []string) *MessageWatcher {
+	return &MessageWatcher{lines: lines}
+}
+
+// Watch adds a kind of message. onMatch is called with the new lines that
+// match any of the patterns, at most once per check.
+func (mw *MessageWatcher) Watch(patterns []*regexp.Regexp, onMatch func(lines []string)) {
+	if len(patterns) == 0 {
+		return
+	}
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	mw.kinds = append(mw.kinds, &messageKind{patterns: patterns, onMatch: onMatch})
+}
+
+// Watching reports whether any kind of message is watched for
+func (mw *MessageWatcher) Watching() bool {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	return len(mw.kinds) > 0
+}
+
+// Reset forgets the messages reported, for a new conversation
+func (mw *MessageWatcher) Reset() {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+	for _, kind := range mw.kinds {
+		kind.reported = nil
+	}
+}
+
+// Run checks the output until stop is closed
+func (mw *MessageWatcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(messageCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			mw.check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check searches the recent output and reports new messages
+func (mw *MessageWatcher) check() {
+	lines := mw.lines(messageSearchLines)
+
+	type report struct {
+		onMatch func([]string)
+		lines   []string
+	}
+	var reports []report
+	mw.mu.Lock()
+	for _, kind := range mw.kinds {
+		if found := kind.match(lines); len(found) > 0 {
+			reports = append(reports, report{kind.onMatch, found})
+		}
+	}
+	mw.mu.Unlock()
+
+	// Reported without the lock, so a slow notifier can't hold up Watch
+	for _, r := range reports {
+		r.onMatch(r.lines)
+	}
+}
+
+// match returns the lines that match the kind's patterns and were not
+// reported before, and remembers them. Caller must hold mw.mu.
+func (mk *messageKind) match(lines []string) []string {
+	var found []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || mk.wasReported(line) {
+			continue
+		}
+		for _, pattern := range mk.patterns {
+			if pattern.MatchString(line) {
+				found = append(found, line)
+				mk.reported = append(mk.reported, line)
+				if len(mk.reported) > maxReportedMessages {
+					mk.reported = mk.reported[1:]
+				}
+				break
+			}
+		}
+	}
+	return found
+}
+
+// wasReported reports whether a line was reported already. Caller must
+// hold mw.mu.
+func (mk *messageKind) wasReported(line string) bool {
+	for _, reported := range mk.reported {
+		if reported == line {
+			return true
+		}
+	}
+	return false
+}
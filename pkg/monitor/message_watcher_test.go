@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+)
+
+func TestMessageWatcher(t *testing.T) {
+	profile, err := config.LookupProfile("gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcpErrors, err := profile.CompileMCPErrorPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var screen []string
+	var reports [][]string
+	mw := NewMessageWatcher(func(n int) []string { return screen })
+	if mw.Watching() {
+		t.Error("expected nothing to be watched yet")
+	}
+	mw.Watch(mcpErrors, func(lines []string) { reports = append(reports, lines) })
+	if !mw.Watching() {
+		t.Error("expected MCP errors to be watched")
+	}
+
+	screen = []string{"Loaded cached credentials.", "> fix the build"}
+	mw.check()
+	if len(reports) != 0 {
+		t.Fatalf("expected no reports, got %q", reports)
+	}
+
+	screen = append(screen,
+		"  Error connecting to MCP server 'github': spawn npx ENOENT",
+		"✕ MCP ERROR (github): Error: connection closed",
+		"~/src/app  gemini-2.5-pro (98% context left)")
+	mw.check()
+	// The screen is redrawn with the same messages
+	mw.check()
+	if len(reports) != 1 || len(reports[0]) != 2 {
+		t.Fatalf("expected one report of both lines, got %q", reports)
+	}
+	if reports[0][0] != "Error connecting to MCP server 'github': spawn npx ENOENT" {
+		t.Errorf("expected the trimmed line, got %q", reports[0][0])
+	}
+
+	screen = append(screen, "Error connecting to MCP server 'linear': timed out")
+	mw.check()
+	if len(reports) != 2 || len(reports[1]) != 1 {
+		t.Errorf("expected a second report of the new line, got %q", reports)
+	}
+}
@@ -4,27 +4,64 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/interfaces"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
 )
 
+// defaultTailLines is the number of output lines kept for screen snapshots
+const defaultTailLines = 200
+
 // OutputMonitor monitors output and tracks activity
 type OutputMonitor struct {
-	config   *config.Config
-	notifier notification.Notifier
+	config *config.Config
+
+	// The PTY read path touches these on every chunk, so they are atomic
+	// rather than guarded by a mutex that readers would contend on
+	notifier atomic.Pointer[notification.Notifier]
+	// Times of the last output and the last user input as offsets from
+	// start, which keeps the monotonic clock reading
+	start      time.Time
+	lastOutput atomic.Int64
+	lastInput  atomic.Int64
+
+	mu sync.Mutex
 
-	mu             sync.Mutex
-	lastOutputTime time.Time
-	lineBuffer     bytes.Buffer
+	// Output is analyzed in a single pass of the parser; parseMu guards the
+	// parser and the analysis, which carry state between chunks
+	parseMu  sync.Mutex
+	parser   *ansi.Parser
+	analysis outputAnalysis
 
 	// Terminal sequence detection
-	sequenceDetector   interfaces.TerminalSequenceDetector
+	sequenceDetector   *TerminalSequenceDetector
 	screenEventHandler interfaces.ScreenEventHandler
 	terminalState      *TerminalState
+
+	// Recent output for screen snapshots
+	tailBuffer *TailBuffer
+
+	// Called after a screen clear is detected, guarded by mu
+	screenClearHook func()
+	// Called after a bell is detected, guarded by mu
+	bellHook func()
+	// Called with text the CLI copies to the clipboard, guarded by mu
+	clipboardHook func(text string)
+	// Patterns matching the wrapped CLI's questions, guarded by mu
+	promptPatterns []*regexp.Regexp
+	// Patterns matching the wrapped CLI's working indicator, guarded by mu
+	busyPatterns []*regexp.Regexp
+	// Patterns matching the name of the model in use, and the last name
+	// found, guarded by mu
+	modelPatterns []*regexp.Regexp
+	model         string
 }
 
 // NewOutputMonitor creates a new output monitor
@@ -32,175 +69,221 @@ func NewOutputMonitor(cfg *config.Config, notifier notification.Notifier) *Outpu
 	now := time.Now()
 	om := &OutputMonitor{
 		config:           cfg,
-		notifier:         notifier,
-		lastOutputTime:   now,
-		sequenceDetector: NewTerminalSequenceDetector(),
+		start:            now,
+		parser:           ansi.NewParser(),
+		sequenceDetector: newTerminalSequenceDetector(),
 		terminalState:    NewTerminalState(),
+		tailBuffer:       NewTailBuffer(defaultTailLines),
 	}
 	// Set self as the screen event handler
 	om.screenEventHandler = om
+	om.analysis.detector = om.sequenceDetector
+	om.notifier.Store(&notifier)
 	return om
 }
 
 // SetScreenEventHandler sets the handler for screen events
 func (om *OutputMonitor) SetScreenEventHandler(handler interfaces.ScreenEventHandler) {
+	om.parseMu.Lock()
+	defer om.parseMu.Unlock()
+	om.screenEventHandler = handler
+}
+
+// SetScreenClearHook sets a function called whenever a screen clear (or a
+// sequence that may disturb the bottom line) is detected
+func (om *OutputMonitor) SetScreenClearHook(hook func()) {
 	om.mu.Lock()
 	defer om.mu.Unlock()
-	om.screenEventHandler = handler
+	om.screenClearHook = hook
 }
 
-// SetNotifier sets the notifier
-func (om *OutputMonitor) SetNotifier(notifier notification.Notifier) {
+// SetBellHook sets a function called after a bell is detected
+func (om *OutputMonitor) SetBellHook(hook func()) {
 	om.mu.Lock()
 	defer om.mu.Unlock()
-	om.notifier = notifier
+	om.bellHook = hook
 }
 
-// containsVisibleContent checks if the data contains any visible characters
-// Visible characters include printable ASCII, newlines, tabs, and Unicode text
-// Returns false for data containing only ANSI escape sequences or control characters
-func containsVisibleContent(data []byte) bool {
-	i := 0
-	for i < len(data) {
-		b := data[i]
-
-		// Skip ANSI escape sequences
-		if b == 0x1B { // ESC
-			i++
-			if i < len(data) {
-				next := data[i]
-				if next == '[' { // CSI sequence
-					i++
-					// Skip until we find the terminator (0x40-0x7E)
-					for i < len(data) {
-						c := data[i]
-						i++
-						if c >= 0x40 && c <= 0x7E {
-							break
-						}
-					}
-					continue
-				} else if next == ']' { // OSC sequence
-					i++
-					// Skip until we find BEL or ST terminator
-					for i < len(data) {
-						c := data[i]
-						i++
-						if c == 0x07 { // BEL
-							break
-						}
-						// Check for ST (ESC \)
-						if c == 0x1B && i < len(data) && data[i] == '\\' {
-							i++
-							break
-						}
-					}
-					continue
-				} else if next == '(' || next == ')' { // Character set sequences
-					i++
-					if i < len(data) {
-						i++ // Skip the character set designation
-					}
-					continue
-				}
-			}
-		} else if b == 0x9B { // CSI (8-bit)
-			i++
-			// Skip until we find the terminator (0x40-0x7E)
-			for i < len(data) {
-				c := data[i]
-				i++
-				if c >= 0x40 && c <= 0x7E {
-					break
-				}
-			}
-			continue
-		}
+// SetClipboardHook sets a function called with the text the wrapped CLI
+// copies to the terminal's clipboard with OSC 52
+func (om *OutputMonitor) SetClipboardHook(hook func(text string)) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.clipboardHook = hook
+}
+
+// SetNotifier sets the notifier
+func (om *OutputMonitor) SetNotifier(notifier notification.Notifier) {
+	om.notifier.Store(&notifier)
+}
+
+// currentNotifier returns the notifier that output events are reported to
+func (om *OutputMonitor) currentNotifier() notification.Notifier {
+	return *om.notifier.Load()
+}
 
-		// Check for visible characters
-		if b == '\n' || b == '\r' || b == '\t' { // Newline, carriage return, tab
-			return true
+// outputAnalysis is the ansi.Handler that HandleData feeds each chunk
+// through. It collects everything the monitor needs to know about the chunk
+// and passes sequences on to the terminal sequence detector.
+type outputAnalysis struct {
+	detector *TerminalSequenceDetector
+
+	// Results for the current chunk
+	visible bool
+	bell    bool
+	// 1 or -1 if the child enabled or disabled focus reporting
+	focusReporting int
+	// Text copied to the clipboard
+	clipboard []string
+
+	// A bell on a line that is not complete yet
+	pendingBell bool
+
+	// The chunk's text without escape sequences, for the tail buffer
+	text textCollector
+}
+
+// reset clears the per-chunk results
+func (a *outputAnalysis) reset() {
+	a.visible = false
+	a.bell = false
+	a.focusReporting = 0
+	a.clipboard = nil
+	a.text.reset()
+}
+
+// Print implements ansi.Handler
+func (a *outputAnalysis) Print(text []byte) {
+	a.visible = true
+	a.text.Print(text)
+	if a.detector != nil {
+		a.detector.Print(text)
+	}
+}
+
+// Execute implements ansi.Handler
+func (a *outputAnalysis) Execute(c byte) {
+	switch c {
+	case '\n':
+		a.visible = true
+		// Bells count once their line is complete
+		if a.pendingBell {
+			a.bell = true
+			a.pendingBell = false
 		}
-		if b >= 32 && b <= 126 { // Printable ASCII
-			return true
+	case '\r', '\t':
+		a.visible = true
+	case 0x07:
+		a.pendingBell = true
+	}
+	a.text.Execute(c)
+	if a.detector != nil {
+		a.detector.Execute(c)
+	}
+}
+
+// Dispatch implements ansi.Handler
+func (a *outputAnalysis) Dispatch(seq *ansi.Sequence) {
+	// Track whether the child wants focus events forwarded to it
+	if seq.Kind == ansi.KindCSI && (seq.Final == 'h' || seq.Final == 'l') && string(seq.Params) == "?1004" {
+		if seq.Final == 'h' {
+			a.focusReporting = 1
+		} else {
+			a.focusReporting = -1
 		}
-		if b >= 128 { // Extended ASCII/Unicode (simplified check)
-			return true
+	}
+	if seq.Kind == ansi.KindOSC {
+		if text, ok := clipboardWrite(seq.Data); ok {
+			a.clipboard = append(a.clipboard, text)
 		}
-
-		i++
 	}
+	if a.detector != nil {
+		a.detector.Dispatch(seq)
+	}
+}
 
-	return false
+// containsVisibleContent checks if the data contains any visible characters
+// Visible characters include printable ASCII, newlines, tabs, and Unicode text
+// Returns false for data containing only ANSI escape sequences or control characters
+func containsVisibleContent(data []byte) bool {
+	var a outputAnalysis
+	ansi.NewParser().Feed(data, &a)
+	return a.visible
 }
 
 // HandleData processes raw output data
 func (om *OutputMonitor) HandleData(data []byte) {
-	// Detect terminal sequences before locking (non-blocking operation)
-	if om.sequenceDetector != nil && om.screenEventHandler != nil {
-		om.sequenceDetector.DetectSequences(data, om.screenEventHandler)
+	// Analyze the chunk in one pass before locking: screen events, focus
+	// reporting, visible content, bells and the text for screen snapshots
+	om.parseMu.Lock()
+	om.analysis.reset()
+	om.sequenceDetector.begin(om.screenEventHandler)
+	om.parser.Feed(data, &om.analysis)
+	om.sequenceDetector.end()
+	visible, bell, focusReporting := om.analysis.visible, om.analysis.bell, om.analysis.focusReporting
+	clipboard := om.analysis.clipboard
+
+	// Record output for screen snapshots (has its own lock)
+	om.tailBuffer.writeClean(om.analysis.text.buf)
+	om.parseMu.Unlock()
+
+	if focusReporting != 0 {
+		om.terminalState.SetChildFocusReporting(focusReporting > 0)
 	}
 
-	om.mu.Lock()
-	defer om.mu.Unlock()
-
 	// Always update last output time when we receive data
-	om.lastOutputTime = time.Now()
+	om.lastOutput.Store(int64(time.Since(om.start)))
 
-	// Mark activity for backstop timer only if visible content is detected
-	if containsVisibleContent(data) {
-		if marker, ok := om.notifier.(notification.ActivityMarker); ok {
+	// Mark activity for backstop timer only if visible content is detected.
+	// No monitor lock is held, so a slow notifier can't stall other readers.
+	if visible {
+		if marker, ok := om.currentNotifier().(notification.ActivityMarker); ok {
 			marker.MarkActivity()
 		}
 	}
 
-	// Add data to line buffer for processing
-	om.lineBuffer.Write(data)
-
-	// Process complete lines for bell detection
-	buffer := om.lineBuffer.Bytes()
-	om.lineBuffer.Reset()
-
-	// Find complete lines
-	start := 0
-	for i := 0; i < len(buffer); i++ {
-		if buffer[i] == '\n' {
-			line := buffer[start:i]
-			om.processLine(line)
-			start = i + 1
-		}
+	if bell {
+		om.handleBell()
 	}
 
-	// Keep any incomplete line in the buffer
-	if start < len(buffer) {
-		om.lineBuffer.Write(buffer[start:])
+	if len(clipboard) > 0 {
+		om.mu.Lock()
+		hook := om.clipboardHook
+		om.mu.Unlock()
+		if hook != nil {
+			for _, text := range clipboard {
+				hook(text)
+			}
+		}
 	}
 }
 
-// processLine checks for bell character
-func (om *OutputMonitor) processLine(line []byte) {
-	// Check for bell character
-	if bytes.Contains(line, []byte{0x07}) {
-		// Bell detected, disable backstop timer
-		if backstopSetter, ok := om.notifier.(interface{ SetBackstopSent(bool) }); ok {
-			backstopSetter.SetBackstopSent(true)
-			if os.Getenv("CLAUDE_NOTIFY_DEBUG") == "true" {
-				fmt.Fprintf(os.Stderr, "claude-code-ntfy: bell detected, disabling backstop timer\n")
-			}
+// handleBell disables the backstop timer, since the bell already alerted the user
+func (om *OutputMonitor) handleBell() {
+	if backstopSetter, ok := om.currentNotifier().(interface{ SetBackstopSent(bool) }); ok {
+		backstopSetter.SetBackstopSent(true)
+		if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: bell detected, disabling backstop timer\n")
 		}
 	}
+
+	om.mu.Lock()
+	hook := om.bellHook
+	om.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
 }
 
-// Flush processes any remaining data in the buffer
+// Flush processes a bell on any remaining partial line
 func (om *OutputMonitor) Flush() {
-	om.mu.Lock()
-	defer om.mu.Unlock()
+	om.parseMu.Lock()
+	bell := om.analysis.pendingBell
+	om.analysis.pendingBell = false
+	om.parseMu.Unlock()
 
-	// Process any remaining line
-	if om.lineBuffer.Len() > 0 {
-		line := om.lineBuffer.Bytes()
-		om.processLine(line)
-		om.lineBuffer.Reset()
+	if bell {
+		om.handleBell()
 	}
 }
 
@@ -211,44 +294,63 @@ func (om *OutputMonitor) HandleLine(line string) {
 
 // GetLastOutputTime returns the last time output was received
 func (om *OutputMonitor) GetLastOutputTime() time.Time {
-	om.mu.Lock()
-	defer om.mu.Unlock()
-	return om.lastOutputTime
+	return om.start.Add(time.Duration(om.lastOutput.Load()))
 }
 
 // HandleScreenClear implements ScreenEventHandler
 func (om *OutputMonitor) HandleScreenClear() {
 	// Reset backstop notifier session on screen clear (indicates new prompt)
-	if resetter, ok := om.notifier.(interface{ ResetSession() }); ok {
+	if resetter, ok := om.currentNotifier().(interface{ ResetSession() }); ok {
 		resetter.ResetSession()
 	}
 
-	if os.Getenv("CLAUDE_NOTIFY_DEBUG") == "true" {
-		fmt.Fprintf(os.Stderr, "claude-code-ntfy: screen cleared - resetting session\n")
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: screen cleared - resetting session\n")
+	}
+
+	om.mu.Lock()
+	hook := om.screenClearHook
+	om.mu.Unlock()
+	if hook != nil {
+		hook()
+	}
+}
+
+// ResetConversation starts over when the user begins another conversation,
+// e.g. with /clear: the recent output no longer describes the session, and
+// the backstop session is reset as for a screen clear
+func (om *OutputMonitor) ResetConversation() {
+	om.tailBuffer.Reset()
+	if resetter, ok := om.currentNotifier().(interface{ ResetSession() }); ok {
+		resetter.ResetSession()
+	}
+
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: new conversation - resetting session\n")
 	}
 }
 
 // HandleTitleChange implements ScreenEventHandler
 func (om *OutputMonitor) HandleTitleChange(title string) {
 	om.terminalState.SetTitle(title)
-	if os.Getenv("CLAUDE_NOTIFY_DEBUG") == "true" {
-		fmt.Fprintf(os.Stderr, "claude-code-ntfy: terminal title changed to: %q\n", title)
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: terminal title changed to: %q\n", title)
 	}
 }
 
 // HandleFocusIn implements ScreenEventHandler
 func (om *OutputMonitor) HandleFocusIn() {
 	om.terminalState.SetFocused(true)
-	if os.Getenv("CLAUDE_NOTIFY_DEBUG") == "true" {
-		fmt.Fprintf(os.Stderr, "claude-code-ntfy: terminal gained focus\n")
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: terminal gained focus\n")
 	}
 }
 
 // HandleFocusOut implements ScreenEventHandler
 func (om *OutputMonitor) HandleFocusOut() {
 	om.terminalState.SetFocused(false)
-	if os.Getenv("CLAUDE_NOTIFY_DEBUG") == "true" {
-		fmt.Fprintf(os.Stderr, "claude-code-ntfy: terminal lost focus\n")
+	if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: terminal lost focus\n")
 	}
 }
 
@@ -259,9 +361,18 @@ func (om *OutputMonitor) SetFocusReportingEnabled(enabled bool) {
 
 // LastOutputTime returns the time of the last output
 func (om *OutputMonitor) LastOutputTime() time.Time {
-	om.mu.Lock()
-	defer om.mu.Unlock()
-	return om.lastOutputTime
+	return om.GetLastOutputTime()
+}
+
+// MarkInput records that input from the user reached the wrapped CLI
+func (om *OutputMonitor) MarkInput() {
+	om.lastInput.Store(int64(time.Since(om.start)))
+}
+
+// LastInputTime returns the time of the last user input, or the start of
+// the session if there was none
+func (om *OutputMonitor) LastInputTime() time.Time {
+	return om.start.Add(time.Duration(om.lastInput.Load()))
 }
 
 // GetTerminalTitle returns the current terminal title
@@ -271,3 +382,144 @@ func (om *OutputMonitor) GetTerminalTitle() string {
 	}
 	return ""
 }
+
+// GetScreenTail returns up to n of the most recent output lines with escape
+// sequences removed
+func (om *OutputMonitor) GetScreenTail(n int) []string {
+	return om.tailBuffer.Lines(n)
+}
+
+// promptSearchLines is how many recent lines are searched for a prompt, as
+// a question is often followed by its answer choices
+const promptSearchLines = 5
+
+// SetPromptPatterns sets the patterns that recognize a question from the
+// wrapped CLI in its recent output
+func (om *OutputMonitor) SetPromptPatterns(patterns []*regexp.Regexp) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.promptPatterns = patterns
+}
+
+// WaitingPrompt returns a recent output line that matches a prompt pattern,
+// or "" if the wrapped CLI doesn't appear to be asking anything. Earlier
+// patterns win, so a question is preferred over the answer choices below it.
+func (om *OutputMonitor) WaitingPrompt() string {
+	om.mu.Lock()
+	patterns := om.promptPatterns
+	om.mu.Unlock()
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	lines := om.tailBuffer.Lines(promptSearchLines)
+	for _, pattern := range patterns {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if pattern.MatchString(lines[i]) {
+				return strings.TrimSpace(lines[i])
+			}
+		}
+	}
+	return ""
+}
+
+// busySearchLines is how many recent lines are searched for a working
+// indicator, which sits above the CLI's input box
+const busySearchLines = 8
+
+// SetBusyPatterns sets the patterns that recognize the wrapped CLI working
+// on a task in its recent output
+func (om *OutputMonitor) SetBusyPatterns(patterns []*regexp.Regexp) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.busyPatterns = patterns
+}
+
+// Busy reports whether the recent output shows the wrapped CLI working on a
+// task, e.g. its spinner with a hint to cancel. Output that stopped while
+// busy suggests the CLI stalled rather than finished.
+func (om *OutputMonitor) Busy() bool {
+	om.mu.Lock()
+	patterns := om.busyPatterns
+	om.mu.Unlock()
+	if len(patterns) == 0 {
+		return false
+	}
+
+	for _, line := range om.tailBuffer.Lines(busySearchLines) {
+		for _, pattern := range patterns {
+			if pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetModelPatterns sets the patterns that recognize the name of the model
+// in the wrapped CLI's output
+func (om *OutputMonitor) SetModelPatterns(patterns []*regexp.Regexp) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.modelPatterns = patterns
+}
+
+// Model returns the name of the model the wrapped CLI last showed, in its
+// title or recent output, or "" if it hasn't shown one. A model named once
+// is remembered after it has scrolled out of the recent output.
+func (om *OutputMonitor) Model() string {
+	om.mu.Lock()
+	patterns := om.modelPatterns
+	om.mu.Unlock()
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	lines := append(om.tailBuffer.Lines(defaultTailLines), om.GetTerminalTitle())
+	model := ""
+	for i := len(lines) - 1; i >= 0 && model == ""; i-- {
+		for _, pattern := range patterns {
+			if match := pattern.FindStringSubmatch(lines[i]); match != nil {
+				model = match[len(match)-1]
+				break
+			}
+		}
+	}
+
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	if model != "" {
+		om.model = model
+	}
+	return om.model
+}
+
+// IsFocused returns whether the terminal is focused, as far as is known
+func (om *OutputMonitor) IsFocused() bool {
+	return om.terminalState.IsFocused()
+}
+
+// FilterInput is an input filter that tracks focus events sent by the
+// terminal on stdin. The events are removed from the input unless the wrapped
+// process enabled focus reporting itself and expects them.
+func (om *OutputMonitor) FilterInput(data []byte) []byte {
+	hasIn := bytes.Contains(data, focusInSequence)
+	hasOut := bytes.Contains(data, focusOutSequence)
+	if !hasIn && !hasOut {
+		return data
+	}
+
+	// Apply the most recent event when both are present
+	if hasIn && (!hasOut || bytes.LastIndex(data, focusInSequence) > bytes.LastIndex(data, focusOutSequence)) {
+		om.HandleFocusIn()
+	} else {
+		om.HandleFocusOut()
+	}
+
+	if om.terminalState.IsChildFocusReporting() {
+		return data
+	}
+
+	data = bytes.ReplaceAll(data, focusInSequence, nil)
+	return bytes.ReplaceAll(data, focusOutSequence, nil)
+}
@@ -1,7 +1,9 @@
 package monitor
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -222,7 +224,7 @@ func TestOutputMonitor_HandleData(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Clear debug env
-			_ = os.Unsetenv("CLAUDE_NOTIFY_DEBUG")
+			_ = os.Unsetenv("GEMINI_NOTIFY_DEBUG")
 
 			cfg := &config.Config{}
 			mockNotifier := &MockBackstopNotifier{}
@@ -330,6 +332,15 @@ func TestOutputMonitor_LastOutputTime(t *testing.T) {
 	if !newTime.After(initialTime) {
 		t.Error("expected last output time to be updated")
 	}
+
+	// Output does not count as input
+	if got := om.LastInputTime(); !got.Equal(initialTime) {
+		t.Errorf("expected no input since the start, got last input at %v", got)
+	}
+	om.MarkInput()
+	if got := om.LastInputTime(); got.Before(newTime) {
+		t.Errorf("expected last input time to be updated, got %v", got)
+	}
 }
 
 func TestOutputMonitor_FlushPartialLine(t *testing.T) {
@@ -359,3 +370,303 @@ func TestOutputMonitor_FlushPartialLine(t *testing.T) {
 		t.Error("bell should be detected after flush")
 	}
 }
+
+func TestOutputMonitorFilterInput(t *testing.T) {
+	t.Run("tracks and strips focus events", func(t *testing.T) {
+		om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+
+		got := om.FilterInput([]byte("a\033[Ob"))
+		if string(got) != "ab" {
+			t.Errorf("expected focus event stripped, got %q", got)
+		}
+		if om.IsFocused() {
+			t.Error("expected unfocused after focus out event")
+		}
+
+		got = om.FilterInput([]byte("\033[I"))
+		if len(got) != 0 {
+			t.Errorf("expected empty input, got %q", got)
+		}
+		if !om.IsFocused() {
+			t.Error("expected focused after focus in event")
+		}
+	})
+
+	t.Run("last event wins", func(t *testing.T) {
+		om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+		om.FilterInput([]byte("\033[I\033[O"))
+		if om.IsFocused() {
+			t.Error("expected unfocused when focus out came last")
+		}
+	})
+
+	t.Run("forwards events when child enabled reporting", func(t *testing.T) {
+		om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+		om.HandleData([]byte("\033[?1004h"))
+
+		got := om.FilterInput([]byte("\033[O"))
+		if string(got) != "\033[O" {
+			t.Errorf("expected focus event forwarded, got %q", got)
+		}
+		if om.IsFocused() {
+			t.Error("expected unfocused after focus out event")
+		}
+	})
+
+	t.Run("passes other input through", func(t *testing.T) {
+		om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+		input := []byte("hello\r")
+		if got := om.FilterInput(input); string(got) != "hello\r" {
+			t.Errorf("expected input unchanged, got %q", got)
+		}
+	})
+}
+
+// benchmarkOutput returns a chunk of colored diff output, typical of what
+// Gemini prints when showing file changes
+func benchmarkOutput() []byte {
+	var b strings.Builder
+	for b.Len() < 4096 {
+		b.WriteString("\033[32m+\tfmt.Fprintf(os.Stderr, \"added line %d\\n\", i)\033[0m\n")
+		b.WriteString("\033[31m-\tfmt.Printf(\"removed line\")\033[0m\n")
+		b.WriteString("  unchanged context line\r\n")
+	}
+	return []byte(b.String())
+}
+
+// nopActivityNotifier accepts activity marks without recording them, so
+// benchmarks measure only the monitor
+type nopActivityNotifier struct{}
+
+func (nopActivityNotifier) Send(notification.Notification) error { return nil }
+
+func (nopActivityNotifier) MarkActivity() {}
+
+func BenchmarkOutputMonitorHandleData(b *testing.B) {
+	om := NewOutputMonitor(&config.Config{}, nopActivityNotifier{})
+	data := benchmarkOutput()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		om.HandleData(data)
+	}
+}
+
+func TestOutputMonitorSinglePass(t *testing.T) {
+	t.Run("title terminator is not a bell", func(t *testing.T) {
+		mockNotifier := &MockBackstopNotifier{}
+		om := NewOutputMonitor(&config.Config{}, mockNotifier)
+
+		om.HandleData([]byte("\033]0;Gemini\007done\n"))
+
+		mockNotifier.mu.Lock()
+		backstopSent := mockNotifier.backstopSent
+		mockNotifier.mu.Unlock()
+		if backstopSent {
+			t.Error("BEL ending a title sequence should not count as a bell")
+		}
+		if got := om.GetTerminalTitle(); got != "Gemini" {
+			t.Errorf("expected title %q, got %q", "Gemini", got)
+		}
+	})
+
+	t.Run("bell completed in a later chunk", func(t *testing.T) {
+		mockNotifier := &MockBackstopNotifier{}
+		om := NewOutputMonitor(&config.Config{}, mockNotifier)
+
+		om.HandleData([]byte("waiting\x07"))
+		om.HandleData([]byte("\n"))
+
+		mockNotifier.mu.Lock()
+		backstopSent := mockNotifier.backstopSent
+		mockNotifier.mu.Unlock()
+		if !backstopSent {
+			t.Error("expected bell to be detected once its line completed")
+		}
+	})
+
+	t.Run("focus reporting split across chunks", func(t *testing.T) {
+		om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+		om.HandleData([]byte("\033[?10"))
+		om.HandleData([]byte("04h"))
+
+		if got := om.FilterInput([]byte("\033[I")); string(got) != "\033[I" {
+			t.Errorf("expected focus event forwarded, got %q", got)
+		}
+	})
+}
+
+func TestOutputMonitorWaitingPrompt(t *testing.T) {
+	profile, err := config.LookupProfile("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := profile.CompilePromptPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+	if got := om.WaitingPrompt(); got != "" {
+		t.Errorf("expected no prompt without patterns, got %q", got)
+	}
+	om.SetPromptPatterns(patterns)
+
+	om.HandleData([]byte("Editing main.go\n"))
+	if got := om.WaitingPrompt(); got != "" {
+		t.Errorf("expected no prompt, got %q", got)
+	}
+
+	om.HandleData([]byte("\033[1m Do you want to make this edit to main.go?\033[0m\r\n ❯ 1. Yes\r\n   2. No\r\n"))
+	if got := om.WaitingPrompt(); got != "Do you want to make this edit to main.go?" {
+		t.Errorf("expected the question, got %q", got)
+	}
+}
+
+func TestOutputMonitorBusy(t *testing.T) {
+	profile, err := config.LookupProfile("gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := profile.CompileBusyPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+	om.SetBusyPatterns(patterns)
+
+	om.HandleData([]byte("⠏ Reading files (esc to cancel, 12s)\r\n╭──────╮\r\n│ >    │\r\n╰──────╯\r\n~/src/app  gemini-2.5-pro\r\n"))
+	if !om.Busy() {
+		t.Error("expected the spinner to show Gemini is busy")
+	}
+
+	// The answer and a fresh input box push the spinner out of view
+	om.HandleData([]byte("Done: the tests pass now.\r\n\r\n╭──────╮\r\n│ >    │\r\n╰──────╯\r\n~/src/app  gemini-2.5-pro\r\n"))
+	if om.Busy() {
+		t.Error("expected Gemini not to be busy after answering")
+	}
+}
+
+func TestOutputMonitorModel(t *testing.T) {
+	profile, err := config.LookupProfile("gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := profile.CompileModelPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	om := NewOutputMonitor(&config.Config{}, &MockNotifier{})
+	om.SetModelPatterns(patterns)
+	if got := om.Model(); got != "" {
+		t.Errorf("expected no model before any output, got %q", got)
+	}
+
+	om.HandleData([]byte("╰──────╯\r\n~/src/app  no sandbox  gemini-2.5-pro (98% context left)\r\n"))
+	if got := om.Model(); got != "gemini-2.5-pro" {
+		t.Errorf("expected gemini-2.5-pro from the footer, got %q", got)
+	}
+
+	// Switching with /model shows up in the next footer
+	om.HandleData([]byte("> /model\r\n~/src/app  no sandbox  gemini-2.5-flash-lite (97% context left)\r\n"))
+	if got := om.Model(); got != "gemini-2.5-flash-lite" {
+		t.Errorf("expected gemini-2.5-flash-lite after switching, got %q", got)
+	}
+
+	// The model is remembered once it has scrolled out of view
+	om.HandleData([]byte(strings.Repeat("output\r\n", defaultTailLines)))
+	if got := om.Model(); got != "gemini-2.5-flash-lite" {
+		t.Errorf("expected the model to be remembered, got %q", got)
+	}
+}
+
+func TestOutputMonitorConcurrentAccess(t *testing.T) {
+	om := NewOutputMonitor(&config.Config{}, &MockBackstopNotifier{})
+	done := make(chan struct{})
+
+	// Readers and notifier changes race with the PTY read path
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = om.LastOutputTime()
+				_ = om.GetTerminalTitle()
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			om.SetNotifier(&MockBackstopNotifier{})
+		}
+	}()
+
+	before := time.Now()
+	for i := 0; i < 1000; i++ {
+		om.HandleData([]byte("output\x07\n\033]0;title\007"))
+	}
+	close(done)
+	wg.Wait()
+
+	if om.LastOutputTime().Before(before) {
+		t.Error("expected last output time to be updated")
+	}
+}
+
+// referenceBell is the original bell detection: a bell counts once its line
+// is complete, or when a partial line is flushed
+func referenceBell(data []byte, flush bool) bool {
+	lines := bytes.Split(data, []byte("\n"))
+	complete, partial := lines[:len(lines)-1], lines[len(lines)-1]
+	for _, line := range complete {
+		if bytes.IndexByte(line, 0x07) >= 0 {
+			return true
+		}
+	}
+	return flush && bytes.IndexByte(partial, 0x07) >= 0
+}
+
+func FuzzOutputMonitorBell(f *testing.F) {
+	f.Add([]byte("test\x07text\n"), uint16(5), uint16(6))
+	f.Add([]byte("partial with bell\x07"), uint16(3), uint16(17))
+	f.Add([]byte("line1\nbell\x07\nline3"), uint16(11), uint16(12))
+	f.Add([]byte("\x07"), uint16(0), uint16(1))
+
+	f.Fuzz(func(t *testing.T, data []byte, a, b uint16) {
+		// Escape sequences may legitimately contain BEL as a terminator,
+		// which the original scan miscounted as a bell
+		if bytes.ContainsAny(data, "\x1b\x9b\x18\x1a") {
+			return
+		}
+
+		mockNotifier := &MockBackstopNotifier{}
+		om := NewOutputMonitor(&config.Config{}, mockNotifier)
+		for _, chunk := range splitChunks(data, a, b) {
+			om.HandleData(chunk)
+		}
+
+		sent := func() bool {
+			mockNotifier.mu.Lock()
+			defer mockNotifier.mu.Unlock()
+			return mockNotifier.backstopSent
+		}
+
+		if got, want := sent(), referenceBell(data, false); got != want {
+			t.Errorf("bell for %q split at %d,%d: got %v, want %v", data, a, b, got, want)
+		}
+
+		om.Flush()
+		if got, want := sent(), referenceBell(data, true); got != want {
+			t.Errorf("bell after flush for %q: got %v, want %v", data, got, want)
+		}
+	})
+}
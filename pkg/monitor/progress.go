@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressGap is the longest pause in output that still counts as working
+// continuously
+const progressGap = 10 * time.Second
+
+// progressCheckInterval is how often the recent output is read, often
+// enough that lines rarely scroll past unseen
+const progressCheckInterval = 2 * time.Second
+
+// progressSearchLines is how many recent output lines are read per check
+const progressSearchLines = 60
+
+// maxProgressLines bounds the lines remembered for one stretch of output
+const maxProgressLines = 10000
+
+// Progress summarizes a stretch of continuous output
+type Progress struct {
+	// How long the output has been going on
+	Elapsed time.Duration
+	// Distinct lines of output since it started
+	Lines int
+	// Files the CLI edited since it started, in the order first seen
+	Files []string
+}
+
+// ProgressWatcher reports on the CLI's output while it works without a
+// pause for long, e.g. on a big refactoring, and again as often while it
+// continues. Redrawn lines are counted once.
+type ProgressWatcher struct {
+	lastOutput func() time.Time
+	lines      func(n int) []string
+	fileEdit   *regexp.Regexp
+	every      time.Duration
+	onProgress func(Progress)
+
+	mu sync.Mutex
+	// When the current stretch of output started; zero if there is none
+	started time.Time
+	// When progress is reported next
+	next time.Time
+	// Lines seen since the stretch started, and how many of them were
+	// already on screen when it did
+	seen     map[string]struct{}
+	baseline int
+	files    []string
+}
+
+// NewProgressWatcher creates a watcher that calls onProgress every interval
+// of continuous output. fileEdit matches the line of a file edit, with the
+// file in its first group; nil leaves files out.
+func NewProgressWatcher(lastOutput func() time.Time, lines func(n int) []string, fileEdit *regexp.Regexp, every time.Duration, onProgress func(Progress)) *ProgressWatcher {
+	return &ProgressWatcher{
+		lastOutput: lastOutput,
+		lines:      lines,
+		fileEdit:   fileEdit,
+		every:      every,
+		onProgress: onProgress,
+	}
+}
+
+// Run checks the output until stop is closed
+func (pw *ProgressWatcher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(progressCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			pw.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check follows the current stretch of output and reports it when due
+func (pw *ProgressWatcher) check(now time.Time) {
+	last := pw.lastOutput()
+	lines := pw.lines(progressSearchLines)
+
+	pw.mu.Lock()
+	if now.Sub(last) > progressGap {
+		pw.started = time.Time{}
+		pw.seen = nil
+		pw.files = nil
+		pw.mu.Unlock()
+		return
+	}
+
+	starting := pw.started.IsZero()
+	if starting {
+		pw.started = now
+		pw.next = now.Add(pw.every)
+		pw.seen = make(map[string]struct{})
+	}
+	for _, line := range lines {
+		pw.record(strings.TrimSpace(line), !starting)
+	}
+	if starting {
+		pw.baseline = len(pw.seen)
+	}
+
+	if now.Before(pw.next) {
+		pw.mu.Unlock()
+		return
+	}
+	pw.next = now.Add(pw.every)
+	progress := Progress{
+		Elapsed: now.Sub(pw.started),
+		Lines:   len(pw.seen) - pw.baseline,
+		Files:   append([]string(nil), pw.files...),
+	}
+	pw.mu.Unlock()
+
+	pw.onProgress(progress)
+}
+
+// record remembers a line of output, and the file it edited if it is a
+// new edit. Caller must hold pw.mu.
+func (pw *ProgressWatcher) record(line string, edits bool) {
+	if line == "" || len(pw.seen) >= maxProgressLines {
+		return
+	}
+	if _, ok := pw.seen[line]; ok {
+		return
+	}
+	pw.seen[line] = struct{}{}
+
+	if !edits || pw.fileEdit == nil {
+		return
+	}
+	match := pw.fileEdit.FindStringSubmatch(line)
+	if len(match) < 2 || match[1] == "" {
+		return
+	}
+	for _, file := range pw.files {
+		if file == match[1] {
+			return
+		}
+	}
+	pw.files = append(pw.files, match[1])
+}
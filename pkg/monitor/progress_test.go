@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestProgressWatcher(t *testing.T) {
+	start := time.Now()
+	last := start
+	screen := []string{"> refactor the parser", "✓  Edit old.go: a => b"}
+	var reports []Progress
+	pw := NewProgressWatcher(func() time.Time { return last }, func(int) []string { return screen },
+		regexp.MustCompile(`^[✓✔]\s+(?:WriteFile|Edit)\s+(?:Writing to\s+)?(\S+?):?(?:\s|$)`), 10*time.Minute,
+		func(p Progress) { reports = append(reports, p) })
+
+	// Lines on screen when the output starts don't count
+	pw.check(start)
+	screen = append(screen, "thinking", "✓  Edit parser.go: x => y", "✓  WriteFile Writing to lexer.go", "thinking")
+	last = start.Add(5 * time.Minute)
+	pw.check(last)
+	if len(reports) != 0 {
+		t.Fatalf("expected no report before the interval, got %v", reports)
+	}
+
+	screen = append(screen, "✓  Edit parser.go: y => z")
+	last = start.Add(10 * time.Minute)
+	pw.check(last)
+	want := Progress{Elapsed: 10 * time.Minute, Lines: 4, Files: []string{"parser.go", "lexer.go"}}
+	if len(reports) != 1 || !reflect.DeepEqual(reports[0], want) {
+		t.Fatalf("expected %+v, got %+v", want, reports)
+	}
+
+	// A pause ends the stretch; the next one starts from scratch
+	pw.check(last.Add(time.Minute))
+	last = last.Add(2 * time.Minute)
+	pw.check(last)
+	pw.check(last.Add(5 * time.Minute))
+	if len(reports) != 1 {
+		t.Errorf("expected no report for a new stretch, got %+v", reports[1:])
+	}
+}
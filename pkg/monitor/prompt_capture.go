@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxPromptLength is how many characters of a captured prompt are kept
+const maxPromptLength = 60
+
+// PromptCapture remembers the last line the user submitted to the wrapped
+// CLI, so notifications can say what a session is working on. It follows
+// typing, deleting and pasting well enough for that; cursor movement within
+// the line is ignored.
+type PromptCapture struct {
+	mu sync.Mutex
+	// The line being typed
+	line []rune
+	// Partial UTF-8 character at the end of the last chunk
+	partial []byte
+	// Escape sequence parsing state
+	escape  escapeState
+	params  []byte
+	inPaste bool
+	// The last submitted prompt, sanitized
+	last string
+	// Called with each line submitted, guarded by mu
+	submitHook func(line string)
+	// Lines submitted in the current chunk of input, for the hook
+	submitted []string
+}
+
+// escapeState tracks where the capture is within an escape sequence
+type escapeState int
+
+const (
+	escapeNone escapeState = iota
+	// After ESC
+	escapeStart
+	// Within a CSI sequence, after ESC [
+	escapeCSI
+	// Before the final byte of an SS3 sequence, after ESC O
+	escapeSS3
+)
+
+// NewPromptCapture creates a prompt capture
+func NewPromptCapture() *PromptCapture {
+	return &PromptCapture{}
+}
+
+// SetSubmitHook sets a function called with each non-empty line submitted,
+// sanitized but not shortened, e.g. to follow slash commands. It is called
+// before the input reaches the wrapped CLI.
+func (pc *PromptCapture) SetSubmitHook(hook func(line string)) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.submitHook = hook
+}
+
+// FilterInput is an input filter that follows what is typed. The input is
+// passed on unchanged.
+func (pc *PromptCapture) FilterInput(data []byte) []byte {
+	pc.mu.Lock()
+	pc.follow(data)
+	hook, submitted := pc.submitHook, pc.submitted
+	pc.submitted = nil
+	pc.mu.Unlock()
+
+	// Called without the lock, so the hook may ask for the last prompt
+	if hook != nil {
+		for _, line := range submitted {
+			hook(line)
+		}
+	}
+	return data
+}
+
+// follow updates the typed line with a chunk of input. Caller must hold
+// pc.mu.
+func (pc *PromptCapture) follow(data []byte) {
+
+	buf := data
+	if len(pc.partial) > 0 {
+		buf = append(pc.partial, data...)
+		pc.partial = nil
+	}
+	for len(buf) > 0 {
+		b := buf[0]
+		if b < utf8.RuneSelf || pc.escape != escapeNone {
+			pc.handleByte(b)
+			buf = buf[1:]
+			continue
+		}
+		if !utf8.FullRune(buf) {
+			pc.partial = append([]byte(nil), buf...)
+			break
+		}
+		r, size := utf8.DecodeRune(buf)
+		pc.line = append(pc.line, r)
+		buf = buf[size:]
+	}
+}
+
+// handleByte handles one ASCII byte, or a byte within an escape sequence.
+// Caller must hold pc.mu.
+func (pc *PromptCapture) handleByte(b byte) {
+	switch pc.escape {
+	case escapeStart:
+		switch b {
+		case '[':
+			pc.escape = escapeCSI
+			pc.params = pc.params[:0]
+		case 'O':
+			pc.escape = escapeSS3
+		default:
+			// Alt with a key, e.g. Alt+Enter for a newline
+			pc.escape = escapeNone
+			if b == '\r' {
+				pc.line = append(pc.line, ' ')
+			}
+		}
+		return
+	case escapeCSI:
+		if b < 0x40 || b > 0x7e {
+			pc.params = append(pc.params, b)
+			return
+		}
+		pc.escape = escapeNone
+		if b == '~' {
+			switch string(pc.params) {
+			case "200":
+				pc.inPaste = true
+			case "201":
+				pc.inPaste = false
+			}
+		}
+		return
+	case escapeSS3:
+		pc.escape = escapeNone
+		return
+	}
+
+	switch {
+	case b == 0x1b:
+		pc.escape = escapeStart
+	case b == '\r' || b == '\n':
+		if pc.inPaste {
+			pc.line = append(pc.line, ' ')
+			return
+		}
+		pc.submit()
+	case b == 0x7f || b == 0x08:
+		if len(pc.line) > 0 {
+			pc.line = pc.line[:len(pc.line)-1]
+		}
+	case b == 0x03 || b == 0x15:
+		// Ctrl-C and Ctrl-U discard the line
+		pc.line = pc.line[:0]
+	case b == '\t':
+		pc.line = append(pc.line, ' ')
+	case b >= 0x20:
+		pc.line = append(pc.line, rune(b))
+	}
+}
+
+// submit records the typed line as the last prompt. Empty lines and single
+// characters, e.g. answers to a yes/no question, are not prompts. Caller
+// must hold pc.mu.
+func (pc *PromptCapture) submit() {
+	if pc.submitHook != nil {
+		if line := collapseLine(pc.line); line != "" {
+			pc.submitted = append(pc.submitted, line)
+		}
+	}
+	prompt := sanitizePrompt(pc.line)
+	pc.line = pc.line[:0]
+	if utf8.RuneCountInString(prompt) > 1 {
+		pc.last = prompt
+	}
+}
+
+// LastPrompt returns the last prompt submitted, or "" if there was none
+func (pc *PromptCapture) LastPrompt() string {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.last
+}
+
+// collapseLine collapses whitespace and drops unprintable characters in a
+// typed line
+func collapseLine(line []rune) string {
+	printable := strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, string(line))
+	return strings.Join(strings.Fields(printable), " ")
+}
+
+// sanitizePrompt collapses whitespace, drops unprintable characters and
+// shortens a typed line to maxPromptLength characters
+func sanitizePrompt(line []rune) string {
+	prompt := collapseLine(line)
+	if runes := []rune(prompt); len(runes) > maxPromptLength {
+		prompt = strings.TrimRight(string(runes[:maxPromptLength]), " ") + "…"
+	}
+	return prompt
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptCapture(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected string
+	}{
+		{"typed line", []string{"refactor", " the auth module", "\r"}, "refactor the auth module"},
+		{"backspace", []string{"fix teh", "\x7f\x7f\x7fthe tests\r"}, "fix the tests"},
+		{"ctrl-u discards the line", []string{"oops\x15explain main.go\r"}, "explain main.go"},
+		{"arrow keys are ignored", []string{"add \x1b[Dlogging\x1bOA\r"}, "add logging"},
+		{"bracketed paste keeps its newlines", []string{"\x1b[200~line one\rline two\x1b[201~", "\r"}, "line one line two"},
+		{"multibyte characters split across reads", []string{"résumé \xe2\x9c", "\x93\r"}, "résumé ✓"},
+		{"answers are not prompts", []string{"write a test\r", "y\r", "\r"}, "write a test"},
+		{"long prompts are shortened", []string{strings.Repeat("word ", 20) + "\r"}, strings.TrimSpace(strings.Repeat("word ", 12)) + "…"},
+		{"control characters are dropped", []string{"list\x01 files\r"}, "list files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := NewPromptCapture()
+			for _, chunk := range tt.input {
+				if got := pc.FilterInput([]byte(chunk)); string(got) != chunk {
+					t.Fatalf("expected input passed on unchanged, got %q", got)
+				}
+			}
+			if got := pc.LastPrompt(); got != tt.expected {
+				t.Errorf("expected prompt %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPromptCaptureSubmitHook(t *testing.T) {
+	pc := NewPromptCapture()
+	var lines []string
+	pc.SetSubmitHook(func(line string) { lines = append(lines, line) })
+
+	pc.FilterInput([]byte("/chat  resume\tsome-long-tag-" + strings.Repeat("x", 60) + "\r\ry\r"))
+	want := []string{"/chat resume some-long-tag-" + strings.Repeat("x", 60), "y"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected lines %q, got %q", want, lines)
+	}
+}
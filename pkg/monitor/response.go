@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// responseQuietPeriod is how long output must have stopped before a
+// response counts as complete, unless Gemini reports its turns itself
+const responseQuietPeriod = 5 * time.Second
+
+// ResponseTracker times Gemini's responses to prompts. Submitting a prompt
+// with Enter starts a response; it is complete once Gemini reports its turn
+// finished or, without such reports, once its output has stopped. A
+// response still running after the timeout is reported once.
+type ResponseTracker struct {
+	lastOutput func() time.Time
+	timeout    time.Duration
+	onTimeout  func(elapsed time.Duration)
+	// onComplete is called with the time a completed response took
+	onComplete func(elapsed time.Duration)
+
+	mu sync.Mutex
+	// When the pending prompt was submitted; zero if none is pending
+	submitted time.Time
+	// Whether the pending response has been reported as late
+	reported bool
+	// Whether Gemini reports its turns, which replaces the quiet heuristic
+	turnEvents bool
+}
+
+// NewResponseTracker creates a tracker that calls onTimeout once a response
+// has taken longer than timeout; a zero timeout disables it
+func NewResponseTracker(lastOutput func() time.Time, timeout time.Duration, onTimeout func(elapsed time.Duration)) *ResponseTracker {
+	return &ResponseTracker{
+		lastOutput: lastOutput,
+		timeout:    timeout,
+		onTimeout:  onTimeout,
+	}
+}
+
+// SetCompleteHook sets a function called with the time each completed
+// response took
+func (rt *ResponseTracker) SetCompleteHook(hook func(elapsed time.Duration)) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.onComplete = hook
+}
+
+// Submit starts timing the response to a prompt just submitted
+func (rt *ResponseTracker) Submit() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.submitted = time.Now()
+	rt.reported = false
+}
+
+// Complete ends the pending response when Gemini reports its turn finished
+func (rt *ResponseTracker) Complete() {
+	rt.mu.Lock()
+	if rt.submitted.IsZero() {
+		rt.mu.Unlock()
+		return
+	}
+	elapsed := time.Since(rt.submitted)
+	rt.submitted = time.Time{}
+	hook := rt.onComplete
+	rt.mu.Unlock()
+
+	if hook != nil {
+		hook(elapsed)
+	}
+}
+
+// Cancel ends the pending response without completing it, e.g. when Gemini
+// stops to ask for approval
+func (rt *ResponseTracker) Cancel() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.submitted = time.Time{}
+}
+
+// UseTurnEvents switches off the quiet output heuristic once Gemini reports
+// its turns, so only Complete ends a response
+func (rt *ResponseTracker) UseTurnEvents() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.turnEvents = true
+}
+
+// Run checks the pending response until stop is closed
+func (rt *ResponseTracker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			rt.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check completes the pending response if its output has stopped, and
+// reports it if it has run past the timeout
+func (rt *ResponseTracker) check(now time.Time) {
+	rt.mu.Lock()
+	if rt.submitted.IsZero() {
+		rt.mu.Unlock()
+		return
+	}
+
+	// Gemini answers with output, so output that has stopped since the
+	// prompt was submitted ends the response
+	if !rt.turnEvents {
+		if last := rt.lastOutput(); last.After(rt.submitted) && now.Sub(last) >= responseQuietPeriod {
+			elapsed := last.Sub(rt.submitted)
+			rt.submitted = time.Time{}
+			hook := rt.onComplete
+			rt.mu.Unlock()
+
+			if hook != nil {
+				hook(elapsed)
+			}
+			return
+		}
+	}
+
+	elapsed := now.Sub(rt.submitted)
+	if rt.onTimeout == nil || rt.timeout <= 0 || rt.reported || elapsed < rt.timeout {
+		rt.mu.Unlock()
+		return
+	}
+	rt.reported = true
+	rt.mu.Unlock()
+
+	rt.onTimeout(elapsed)
+}
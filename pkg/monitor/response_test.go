@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseTracker(t *testing.T) {
+	var last time.Time
+	var reports []time.Duration
+	newTracker := func() *ResponseTracker {
+		last = time.Time{}
+		reports = nil
+		return NewResponseTracker(func() time.Time { return last }, 10*time.Minute, func(elapsed time.Duration) {
+			reports = append(reports, elapsed)
+		})
+	}
+
+	t.Run("late response is reported once", func(t *testing.T) {
+		rt := newTracker()
+		rt.Submit()
+		submitted := rt.submitted
+
+		// Gemini keeps printing, e.g. its spinner
+		last = submitted.Add(5 * time.Minute)
+		rt.check(last)
+		last = submitted.Add(11 * time.Minute)
+		rt.check(last)
+		last = submitted.Add(12 * time.Minute)
+		rt.check(last)
+		if len(reports) != 1 || reports[0] != 11*time.Minute {
+			t.Errorf("expected one report after 11m, got %v", reports)
+		}
+	})
+
+	t.Run("output stopping completes the response", func(t *testing.T) {
+		rt := newTracker()
+		var completed []time.Duration
+		rt.SetCompleteHook(func(elapsed time.Duration) { completed = append(completed, elapsed) })
+		rt.Submit()
+		submitted := rt.submitted
+
+		last = submitted.Add(time.Minute)
+		rt.check(last.Add(responseQuietPeriod))
+		rt.check(submitted.Add(time.Hour))
+		if len(reports) != 0 {
+			t.Errorf("expected no report for a completed response, got %v", reports)
+		}
+		// The response ended with its last output, not when that was noticed
+		if len(completed) != 1 || completed[0] != time.Minute {
+			t.Errorf("expected one response taking 1m, got %v", completed)
+		}
+	})
+
+	t.Run("no output is not a response", func(t *testing.T) {
+		rt := newTracker()
+		rt.Submit()
+		rt.check(rt.submitted.Add(10 * time.Minute))
+		if len(reports) != 1 {
+			t.Errorf("expected a report without any output, got %v", reports)
+		}
+	})
+
+	t.Run("turn events replace the heuristic", func(t *testing.T) {
+		rt := newTracker()
+		rt.UseTurnEvents()
+		rt.Submit()
+		submitted := rt.submitted
+
+		// Quiet output no longer ends the response, only the turn event does
+		last = submitted.Add(time.Minute)
+		rt.check(submitted.Add(10 * time.Minute))
+		if len(reports) != 1 {
+			t.Fatalf("expected a report while waiting for the turn event, got %v", reports)
+		}
+
+		var completed int
+		rt.SetCompleteHook(func(time.Duration) { completed++ })
+		rt.Submit()
+		rt.Complete()
+		rt.check(time.Now().Add(time.Hour))
+		if len(reports) != 1 {
+			t.Errorf("expected no report after the turn finished, got %v", reports)
+		}
+		if completed != 1 {
+			t.Errorf("expected the turn to complete one response, got %d", completed)
+		}
+
+		// Asking for approval ends the response without completing it
+		rt.Submit()
+		rt.Cancel()
+		rt.Complete()
+		rt.check(time.Now().Add(time.Hour))
+		if completed != 1 || len(reports) != 1 {
+			t.Errorf("expected a cancelled response to be dropped, got %d completed, %v reported", completed, reports)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import "strings"
+
+// SlashCommand is what a slash command typed into the wrapped CLI does to
+// the session
+type SlashCommand int
+
+const (
+	// SlashNone is any other line, including commands that don't change
+	// the session
+	SlashNone SlashCommand = iota
+	// SlashNewConversation starts over with another conversation, e.g.
+	// /clear or /chat resume
+	SlashNewConversation
+	// SlashQuit ends the session, e.g. /quit
+	SlashQuit
+)
+
+// SlashCommands recognizes the commands a CLI changes its session with in
+// the lines the user submits
+type SlashCommands struct {
+	// Commands as words, e.g. {"/chat", "resume"}
+	newConversation [][]string
+	quit            [][]string
+}
+
+// NewSlashCommands creates a matcher for commands that start a new
+// conversation and commands that quit. A command of several words, e.g.
+// "/chat resume", matches any line starting with those words.
+func NewSlashCommands(newConversation, quit []string) *SlashCommands {
+	return &SlashCommands{
+		newConversation: commandWords(newConversation),
+		quit:            commandWords(quit),
+	}
+}
+
+// Match returns what a submitted line does to the session
+func (sc *SlashCommands) Match(line string) SlashCommand {
+	words := strings.Fields(line)
+	if len(words) == 0 || !strings.HasPrefix(words[0], "/") {
+		return SlashNone
+	}
+	switch {
+	case matchCommand(sc.newConversation, words):
+		return SlashNewConversation
+	case matchCommand(sc.quit, words):
+		return SlashQuit
+	}
+	return SlashNone
+}
+
+// commandWords splits commands into their words
+func commandWords(commands []string) [][]string {
+	words := make([][]string, 0, len(commands))
+	for _, command := range commands {
+		if fields := strings.Fields(command); len(fields) > 0 {
+			words = append(words, fields)
+		}
+	}
+	return words
+}
+
+// matchCommand reports whether a line's words start with any of the commands
+func matchCommand(commands [][]string, words []string) bool {
+	for _, command := range commands {
+		if len(words) < len(command) {
+			continue
+		}
+		matched := true
+		for i, word := range command {
+			if !strings.EqualFold(words[i], word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
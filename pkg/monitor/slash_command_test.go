@@ -0,0 +1,29 @@
+package monitor
+
+import "testing"
+
+func TestSlashCommands(t *testing.T) {
+	sc := NewSlashCommands([]string{"/clear", "/chat resume"}, []string{"/quit", "/exit"})
+
+	tests := []struct {
+		line string
+		want SlashCommand
+	}{
+		{"/clear", SlashNewConversation},
+		{"/chat resume refactor", SlashNewConversation},
+		{"/chat  RESUME refactor", SlashNewConversation},
+		{"/chat save refactor", SlashNone},
+		{"/chat", SlashNone},
+		{"/quit", SlashQuit},
+		{"/exit", SlashQuit},
+		{"/clearly", SlashNone},
+		{"please /clear the cache", SlashNone},
+		{"", SlashNone},
+	}
+
+	for _, tt := range tests {
+		if got := sc.Match(tt.line); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
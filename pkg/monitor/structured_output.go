@@ -0,0 +1,351 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output formats of Gemini CLI's non-interactive mode that report
+// structured events
+const (
+	FormatJSON       = "json"
+	FormatStreamJSON = "stream-json"
+)
+
+// maxStructuredBuffer bounds how much output is kept while waiting for the
+// end of a JSON line or document
+const maxStructuredBuffer = 4 << 20
+
+// maxResponseExcerpt is how many characters of a response are reported
+const maxResponseExcerpt = 200
+
+// DetectOutputFormat returns the structured output format Gemini CLI was
+// asked for with -o or --output-format, or "" for its terminal UI
+func DetectOutputFormat(args []string) string {
+	format := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		value := ""
+		switch {
+		case arg == "-o" || arg == "--output-format":
+			if i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+		case strings.HasPrefix(arg, "--output-format="):
+			value = strings.TrimPrefix(arg, "--output-format=")
+		case strings.HasPrefix(arg, "-o="):
+			value = strings.TrimPrefix(arg, "-o=")
+		default:
+			continue
+		}
+		// The last occurrence wins, as in Gemini's own parsing
+		switch value {
+		case FormatJSON, FormatStreamJSON:
+			format = value
+		default:
+			format = ""
+		}
+	}
+	return format
+}
+
+// Kinds of structured events
+const (
+	// The CLI finished answering the prompt
+	StructuredTurn = "turn"
+	// A tool call failed
+	StructuredToolError = "tool_error"
+	// The CLI reported an error, e.g. from the API
+	StructuredError = "error"
+)
+
+// StructuredEvent is something the wrapped CLI reported in its structured
+// output
+type StructuredEvent struct {
+	Kind string
+	// Excerpt of the response for a turn, or the error message
+	Text string
+	// Name of the tool that failed, for tool errors
+	Tool string
+	// Tool calls made during the turn
+	ToolCalls int
+	// How long the turn took
+	Duration time.Duration
+	// Tokens the turn used, if reported
+	Tokens int
+}
+
+// StructuredOutput parses the JSON that Gemini CLI prints in non-interactive
+// mode with --output-format json or stream-json, so notifications rely on
+// what Gemini reports rather than on what the terminal shows. Anything that
+// isn't JSON, e.g. warnings on stderr, is ignored.
+type StructuredOutput struct {
+	format  string
+	onEvent func(StructuredEvent)
+
+	mu sync.Mutex
+	// Output not yet parsed: the current line, or the JSON document so far
+	buf []byte
+	// Dropping output until the next line, after a line that was too long
+	skipping bool
+	// When the current turn started
+	started time.Time
+	// Response text and tool calls of the current turn (stream-json)
+	response  strings.Builder
+	toolCalls int
+	// Names of the tools called, by call ID
+	tools map[string]string
+}
+
+// NewStructuredOutput creates a parser for the given format that calls
+// onEvent for every event worth a notification
+func NewStructuredOutput(format string, onEvent func(StructuredEvent)) *StructuredOutput {
+	return &StructuredOutput{
+		format:  format,
+		onEvent: onEvent,
+		started: time.Now(),
+		tools:   make(map[string]string),
+	}
+}
+
+// Write implements io.Writer for the wrapped CLI's output
+func (so *StructuredOutput) Write(data []byte) (int, error) {
+	so.mu.Lock()
+	var events []StructuredEvent
+	if so.format == FormatStreamJSON {
+		events = so.parseLines(data)
+	} else {
+		events = so.parseDocument(data)
+	}
+	so.mu.Unlock()
+
+	// Events are reported without the lock, so a slow notifier can't hold
+	// up the output
+	for _, event := range events {
+		so.onEvent(event)
+	}
+	return len(data), nil
+}
+
+// parseLines parses newline-delimited JSON events. Caller must hold so.mu.
+func (so *StructuredOutput) parseLines(data []byte) []StructuredEvent {
+	var events []StructuredEvent
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			if !so.skipping {
+				so.buf = append(so.buf, data...)
+				if len(so.buf) > maxStructuredBuffer {
+					so.buf = so.buf[:0]
+					so.skipping = true
+				}
+			}
+			break
+		}
+		line := data[:i]
+		data = data[i+1:]
+		if so.skipping {
+			so.skipping = false
+			continue
+		}
+		if len(so.buf) > 0 {
+			line = append(so.buf, line...)
+		}
+		if event, ok := so.handleStreamEvent(bytes.TrimSpace(line)); ok {
+			events = append(events, event)
+		}
+		so.buf = so.buf[:0]
+	}
+	return events
+}
+
+// streamEvent holds the fields of a stream-json event that notifications
+// use
+type streamEvent struct {
+	Type     string     `json:"type"`
+	Role     string     `json:"role"`
+	Content  string     `json:"content"`
+	Delta    bool       `json:"delta"`
+	ToolName string     `json:"tool_name"`
+	ToolID   string     `json:"tool_id"`
+	Status   string     `json:"status"`
+	Severity string     `json:"severity"`
+	Message  string     `json:"message"`
+	Error    *jsonError `json:"error"`
+	Stats    *struct {
+		DurationMS  int64 `json:"duration_ms"`
+		ToolCalls   *int  `json:"tool_calls"`
+		TotalTokens int   `json:"total_tokens"`
+	} `json:"stats"`
+}
+
+// handleStreamEvent handles one line of stream-json output and returns the
+// event to report, if any. Caller must hold so.mu.
+func (so *StructuredOutput) handleStreamEvent(line []byte) (StructuredEvent, bool) {
+	if len(line) == 0 || line[0] != '{' {
+		return StructuredEvent{}, false
+	}
+	var e streamEvent
+	if err := json.Unmarshal(line, &e); err != nil {
+		return StructuredEvent{}, false
+	}
+
+	switch e.Type {
+	case "init":
+		so.resetTurn()
+	case "message":
+		if e.Role == "assistant" && so.response.Len() < maxStructuredBuffer {
+			if !e.Delta && so.response.Len() > 0 {
+				so.response.WriteByte('\n')
+			}
+			so.response.WriteString(e.Content)
+		}
+	case "tool_use":
+		so.toolCalls++
+		if e.ToolID != "" {
+			so.tools[e.ToolID] = e.ToolName
+		}
+	case "tool_result":
+		if e.Status != "error" {
+			return StructuredEvent{}, false
+		}
+		tool := so.tools[e.ToolID]
+		if tool == "" {
+			tool = e.ToolName
+		}
+		return StructuredEvent{Kind: StructuredToolError, Tool: tool, Text: errorMessage(e.Error, e.Message)}, true
+	case "error":
+		// Warnings don't stop Gemini
+		if e.Severity == "warning" {
+			return StructuredEvent{}, false
+		}
+		return StructuredEvent{Kind: StructuredError, Text: errorMessage(e.Error, e.Message)}, true
+	case "result":
+		defer so.resetTurn()
+		if e.Status == "error" {
+			return StructuredEvent{Kind: StructuredError, Text: errorMessage(e.Error, e.Message)}, true
+		}
+		event := StructuredEvent{
+			Kind:      StructuredTurn,
+			Text:      excerpt(so.response.String()),
+			ToolCalls: so.toolCalls,
+			Duration:  time.Since(so.started),
+		}
+		if e.Stats != nil {
+			if e.Stats.DurationMS > 0 {
+				event.Duration = time.Duration(e.Stats.DurationMS) * time.Millisecond
+			}
+			if e.Stats.ToolCalls != nil {
+				event.ToolCalls = *e.Stats.ToolCalls
+			}
+			event.Tokens = e.Stats.TotalTokens
+		}
+		return event, true
+	}
+	return StructuredEvent{}, false
+}
+
+// resetTurn starts a new turn. Caller must hold so.mu.
+func (so *StructuredOutput) resetTurn() {
+	so.started = time.Now()
+	so.response.Reset()
+	so.toolCalls = 0
+	clear(so.tools)
+}
+
+// jsonResult holds the fields of the json output format that notifications
+// use
+type jsonResult struct {
+	Response *string `json:"response"`
+	Stats    *struct {
+		Tools struct {
+			TotalCalls int `json:"totalCalls"`
+		} `json:"tools"`
+		// Usage by model name
+		Models map[string]struct {
+			Tokens struct {
+				Total int `json:"total"`
+			} `json:"tokens"`
+		} `json:"models"`
+	} `json:"stats"`
+	Error *jsonError `json:"error"`
+}
+
+// parseDocument collects the single JSON document of the json format,
+// which is printed indented over many lines, and reports it once complete.
+// Caller must hold so.mu.
+func (so *StructuredOutput) parseDocument(data []byte) []StructuredEvent {
+	if so.skipping {
+		return nil
+	}
+	so.buf = append(so.buf, data...)
+	if len(so.buf) > maxStructuredBuffer {
+		so.buf = nil
+		so.skipping = true
+		return nil
+	}
+
+	// The document ends with a closing brace; anything before its opening
+	// brace, e.g. a warning, is skipped
+	trimmed := bytes.TrimSpace(so.buf)
+	start := bytes.IndexByte(trimmed, '{')
+	if start < 0 || trimmed[len(trimmed)-1] != '}' {
+		return nil
+	}
+	var result jsonResult
+	if err := json.Unmarshal(trimmed[start:], &result); err != nil {
+		return nil
+	}
+	so.buf = so.buf[:0]
+	// Some other JSON, not Gemini's result
+	if result.Response == nil && result.Error == nil {
+		return nil
+	}
+
+	if result.Error != nil {
+		return []StructuredEvent{{Kind: StructuredError, Text: result.Error.Message}}
+	}
+	event := StructuredEvent{
+		Kind:     StructuredTurn,
+		Text:     excerpt(*result.Response),
+		Duration: time.Since(so.started),
+	}
+	if result.Stats != nil {
+		event.ToolCalls = result.Stats.Tools.TotalCalls
+		for _, model := range result.Stats.Models {
+			event.Tokens += model.Tokens.Total
+		}
+	}
+	so.started = time.Now()
+	return []StructuredEvent{event}
+}
+
+// jsonError is an error object in structured output
+type jsonError struct {
+	Message string `json:"message"`
+}
+
+// errorMessage returns the message of an error object, or fallback
+func errorMessage(err *jsonError, fallback string) string {
+	if err != nil && err.Message != "" {
+		return err.Message
+	}
+	return fallback
+}
+
+// excerpt collapses a response to one line of at most maxResponseExcerpt
+// characters
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxResponseExcerpt {
+		text = strings.TrimRight(string(runes[:maxResponseExcerpt]), " ") + "…"
+	}
+	return text
+}
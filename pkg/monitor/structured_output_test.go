@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectOutputFormat(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"-p", "hello"}, ""},
+		{[]string{"-p", "hello", "-o", "json"}, FormatJSON},
+		{[]string{"--output-format", "stream-json", "-p", "hello"}, FormatStreamJSON},
+		{[]string{"--output-format=json"}, FormatJSON},
+		{[]string{"-o", "json", "-o", "text"}, ""},
+		{[]string{"--", "-o", "json"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectOutputFormat(tt.args); got != tt.want {
+			t.Errorf("DetectOutputFormat(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// parse feeds output to a parser in chunks of the given size and returns
+// the events it reported
+func parse(format, output string, chunk int) []StructuredEvent {
+	var events []StructuredEvent
+	so := NewStructuredOutput(format, func(e StructuredEvent) { events = append(events, e) })
+	for len(output) > 0 {
+		n := min(chunk, len(output))
+		_, _ = so.Write([]byte(output[:n]))
+		output = output[n:]
+	}
+	return events
+}
+
+func TestStructuredOutputStreamJSON(t *testing.T) {
+	// As read from the PTY, with CRLF line endings and a warning on stderr
+	output := strings.Join([]string{
+		`{"type":"init","session_id":"abc","model":"gemini-2.5-pro"}`,
+		`{"type":"message","role":"user","content":"fix the tests"}`,
+		`Warning: something on stderr`,
+		`{"type":"tool_use","tool_name":"run_shell_command","tool_id":"t1","parameters":{}}`,
+		`{"type":"tool_result","tool_id":"t1","status":"error","error":{"type":"exit","message":"exit code 1"}}`,
+		`{"type":"message","role":"assistant","content":"All tests","delta":true}`,
+		`{"type":"message","role":"assistant","content":" pass now.","delta":true}`,
+		`{"type":"result","status":"success","stats":{"total_tokens":5120,"duration_ms":42000,"tool_calls":1}}`,
+		`{"type":"error","severity":"warning","message":"loop detected"}`,
+		`{"type":"result","status":"error","error":{"type":"api","message":"quota exceeded"}}`,
+	}, "\r\n") + "\r\n"
+
+	for _, chunk := range []int{len(output), 7} {
+		events := parse(FormatStreamJSON, output, chunk)
+		want := []StructuredEvent{
+			{Kind: StructuredToolError, Tool: "run_shell_command", Text: "exit code 1"},
+			{Kind: StructuredTurn, Text: "All tests pass now.", ToolCalls: 1, Duration: 42 * time.Second, Tokens: 5120},
+			{Kind: StructuredError, Text: "quota exceeded"},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("chunk %d: expected %d events, got %+v", chunk, len(want), events)
+		}
+		for i := range want {
+			if events[i] != want[i] {
+				t.Errorf("chunk %d: event %d = %+v, want %+v", chunk, i, events[i], want[i])
+			}
+		}
+	}
+}
+
+func TestStructuredOutputJSON(t *testing.T) {
+	t.Run("response", func(t *testing.T) {
+		output := "Loaded cached credentials.\r\n{\r\n  \"response\": \"Done.\\n\\nThe  build passes.\",\r\n" +
+			"  \"stats\": {\r\n    \"models\": {\r\n      \"gemini-2.5-pro\": {\"tokens\": {\"total\": 900}},\r\n" +
+			"      \"gemini-2.5-flash\": {\"tokens\": {\"total\": 100}}\r\n    },\r\n" +
+			"    \"tools\": {\r\n      \"totalCalls\": 3\r\n    }\r\n  }\r\n}\r\n"
+		for _, chunk := range []int{len(output), 5} {
+			events := parse(FormatJSON, output, chunk)
+			if len(events) != 1 {
+				t.Fatalf("chunk %d: expected 1 event, got %+v", chunk, events)
+			}
+			e := events[0]
+			if e.Kind != StructuredTurn || e.Text != "Done. The build passes." || e.ToolCalls != 3 || e.Tokens != 1000 {
+				t.Errorf("chunk %d: unexpected event %+v", chunk, e)
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		output := "{\n  \"error\": {\n    \"type\": \"FatalAuthenticationError\",\n    \"message\": \"not logged in\",\n    \"code\": 41\n  }\n}\n"
+		events := parse(FormatJSON, output, len(output))
+		if len(events) != 1 || events[0].Kind != StructuredError || events[0].Text != "not logged in" {
+			t.Errorf("unexpected events %+v", events)
+		}
+	})
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	got := excerpt(long)
+	if len([]rune(got)) > maxResponseExcerpt+1 || !strings.HasSuffix(got, "word…") {
+		t.Errorf("expected at most %d characters ending in an ellipsis, got %q", maxResponseExcerpt+1, got)
+	}
+}
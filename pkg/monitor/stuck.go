@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+)
+
+// StuckWatcher reports a session that has produced no output for much
+// longer than the backstop timeout, e.g. an autonomous agent waiting for an
+// approval nobody gives. Each quiet period is reported once.
+type StuckWatcher struct {
+	lastOutput func() time.Time
+	after      time.Duration
+	onStuck    func(idle time.Duration)
+
+	mu sync.Mutex
+	// Output time of the quiet period already reported
+	reported time.Time
+}
+
+// NewStuckWatcher creates a watcher that calls onStuck once output has
+// stopped for longer than after
+func NewStuckWatcher(lastOutput func() time.Time, after time.Duration, onStuck func(idle time.Duration)) *StuckWatcher {
+	return &StuckWatcher{
+		lastOutput: lastOutput,
+		after:      after,
+		onStuck:    onStuck,
+	}
+}
+
+// Run checks the session until stop is closed
+func (sw *StuckWatcher) Run(stop <-chan struct{}) {
+	// Check often enough that the report is not much later than due
+	interval := min(sw.after/10, time.Minute)
+	ticker := time.NewTicker(max(interval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			sw.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check reports the session if it has been quiet for too long and this
+// quiet period has not been reported yet
+func (sw *StuckWatcher) check(now time.Time) {
+	last := sw.lastOutput()
+	idle := now.Sub(last)
+	if idle < sw.after {
+		return
+	}
+
+	sw.mu.Lock()
+	if last.Equal(sw.reported) {
+		sw.mu.Unlock()
+		return
+	}
+	sw.reported = last
+	sw.mu.Unlock()
+
+	sw.onStuck(idle)
+}
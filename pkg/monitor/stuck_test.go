@@ -0,0 +1,34 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStuckWatcher(t *testing.T) {
+	start := time.Now()
+	last := start
+	var reports []time.Duration
+	sw := NewStuckWatcher(func() time.Time { return last }, time.Hour, func(idle time.Duration) {
+		reports = append(reports, idle)
+	})
+
+	sw.check(start.Add(30 * time.Minute))
+	if len(reports) != 0 {
+		t.Fatalf("expected no report before the threshold, got %v", reports)
+	}
+
+	sw.check(start.Add(2 * time.Hour))
+	sw.check(start.Add(3 * time.Hour))
+	if len(reports) != 1 || reports[0] != 2*time.Hour {
+		t.Fatalf("expected one report after 2h, got %v", reports)
+	}
+
+	// New output starts a new quiet period
+	last = start.Add(4 * time.Hour)
+	sw.check(start.Add(4*time.Hour + time.Minute))
+	sw.check(start.Add(5*time.Hour + time.Minute))
+	if len(reports) != 2 || reports[1] != time.Hour+time.Minute {
+		t.Errorf("expected a second report for the new quiet period, got %v", reports)
+	}
+}
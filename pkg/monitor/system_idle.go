@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/interfaces"
+)
+
+// SystemIdleDetector reports how long the user has been away from the
+// machine's keyboard and mouse, using the platform's idle counter
+type SystemIdleDetector struct {
+	idleTime func() (time.Duration, error)
+}
+
+// Ensure SystemIdleDetector implements IdleDetector
+var _ interfaces.IdleDetector = (*SystemIdleDetector)(nil)
+
+// NewSystemIdleDetector creates a new system idle detector
+func NewSystemIdleDetector() *SystemIdleDetector {
+	return &SystemIdleDetector{
+		idleTime: systemIdleTime,
+	}
+}
+
+// IsUserIdle returns whether the user has been idle for at least threshold
+func (d *SystemIdleDetector) IsUserIdle(threshold time.Duration) (bool, error) {
+	idle, err := d.idleTime()
+	if err != nil {
+		return false, err
+	}
+	return idle >= threshold, nil
+}
+
+// LastActivity returns the time of the user's last input, or the zero time
+// if it cannot be determined
+func (d *SystemIdleDetector) LastActivity() time.Time {
+	idle, err := d.idleTime()
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Now().Add(-idle)
+}
@@ -0,0 +1,35 @@
+//go:build darwin
+// +build darwin
+
+package monitor
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// hidIdleTimePattern extracts HIDIdleTime (nanoseconds) from ioreg output
+var hidIdleTimePattern = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(\d+)`)
+
+// systemIdleTime returns the HID idle time reported by IOKit
+func systemIdleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
+	}
+
+	match := hidIdleTimePattern.FindSubmatch(out)
+	if match == nil {
+		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
+	}
+
+	ns, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HIDIdleTime %q", match[1])
+	}
+
+	return time.Duration(ns), nil
+}
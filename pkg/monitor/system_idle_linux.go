@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemIdleTime returns the X11 idle time via xprintidle
+func systemIdleTime() (time.Duration, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return 0, fmt.Errorf("no X display available")
+	}
+
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle failed: %w", err)
+	}
+
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected xprintidle output %q", out)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
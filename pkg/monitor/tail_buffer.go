@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
+)
+
+// maxRetainedBuffer is the largest scratch buffer kept between chunks, so a
+// rare huge chunk doesn't stay in memory
+const maxRetainedBuffer = 64 * 1024
+
+// textCollector is an ansi.Handler that gathers the text of parsed output,
+// keeping only the controls that shape lines
+type textCollector struct {
+	buf []byte
+}
+
+// reset empties the collector, dropping an oversized buffer
+func (c *textCollector) reset() {
+	if cap(c.buf) > maxRetainedBuffer {
+		c.buf = nil
+	}
+	c.buf = c.buf[:0]
+}
+
+// Print implements ansi.Handler
+func (c *textCollector) Print(text []byte) {
+	c.buf = append(c.buf, text...)
+}
+
+// Execute implements ansi.Handler
+func (c *textCollector) Execute(b byte) {
+	if b == '\n' || b == '\r' || b == '\t' {
+		c.buf = append(c.buf, b)
+	}
+}
+
+// Dispatch implements ansi.Handler
+func (c *textCollector) Dispatch(*ansi.Sequence) {}
+
+// TailBuffer keeps the most recent lines of output with escape sequences
+// removed, approximating what is currently visible on screen
+type TailBuffer struct {
+	// Raw output is parsed statefully, so sequences and characters split
+	// across writes are handled
+	writeMu sync.Mutex
+	parser  *ansi.Parser
+	text    textCollector
+
+	mu sync.Mutex
+	// Ring of completed lines; the storage of evicted lines is reused
+	lines    [][]byte
+	start    int
+	count    int
+	current  []byte
+	maxLines int
+	// The last write ended in a carriage return
+	pendingCR bool
+}
+
+// NewTailBuffer creates a tail buffer that keeps up to maxLines lines
+func NewTailBuffer(maxLines int) *TailBuffer {
+	return &TailBuffer{
+		parser:   ansi.NewParser(),
+		lines:    make([][]byte, maxLines),
+		maxLines: maxLines,
+	}
+}
+
+// Write appends raw terminal output to the buffer
+func (tb *TailBuffer) Write(data []byte) {
+	tb.writeMu.Lock()
+	defer tb.writeMu.Unlock()
+
+	tb.text.reset()
+	tb.parser.Feed(data, &tb.text)
+	tb.writeClean(tb.text.buf)
+}
+
+// writeClean appends output that has already had escape sequences removed
+func (tb *TailBuffer) writeClean(clean []byte) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	for len(clean) > 0 {
+		// A carriage return that ended the previous write only redraws the
+		// line if it isn't the first half of a CRLF
+		if tb.pendingCR {
+			tb.pendingCR = false
+			if clean[0] != '\n' {
+				tb.current = tb.current[:0]
+			}
+		}
+
+		i := bytes.IndexAny(clean, "\r\n")
+		if i < 0 {
+			tb.current = append(tb.current, clean...)
+			return
+		}
+		tb.current = append(tb.current, clean[:i]...)
+
+		if clean[i] == '\n' {
+			tb.appendLine(tb.current)
+			tb.current = tb.current[:0]
+		} else if i+1 == len(clean) {
+			tb.pendingCR = true
+		} else if clean[i+1] != '\n' {
+			// A bare carriage return means the line is about to be redrawn
+			tb.current = tb.current[:0]
+		}
+		clean = clean[i+1:]
+	}
+}
+
+// Reset forgets all output, e.g. when the screen starts over
+func (tb *TailBuffer) Reset() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.start = 0
+	tb.count = 0
+	tb.current = tb.current[:0]
+	tb.pendingCR = false
+}
+
+// appendLine adds a completed line, collapsing runs of blank lines
+func (tb *TailBuffer) appendLine(line []byte) {
+	if tb.maxLines <= 0 {
+		return
+	}
+
+	line = bytes.TrimRight(line, " \t")
+	if len(line) == 0 && tb.count > 0 && len(tb.lines[(tb.start+tb.count-1)%tb.maxLines]) == 0 {
+		return
+	}
+
+	var slot int
+	if tb.count < tb.maxLines {
+		slot = (tb.start + tb.count) % tb.maxLines
+		tb.count++
+	} else {
+		// Overwrite the oldest line
+		slot = tb.start
+		tb.start = (tb.start + 1) % tb.maxLines
+	}
+	tb.lines[slot] = append(tb.lines[slot][:0], line...)
+}
+
+// Lines returns up to n of the most recent lines, including any partial line
+func (tb *TailBuffer) Lines(n int) []string {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	all := make([]string, 0, tb.count+1)
+	for i := 0; i < tb.count; i++ {
+		all = append(all, string(tb.lines[(tb.start+i)%tb.maxLines]))
+	}
+	if partial := strings.TrimRight(string(tb.current), " \t"); partial != "" {
+		all = append(all, partial)
+	}
+
+	// Trailing blank lines carry no information
+	for len(all) > 0 && all[len(all)-1] == "" {
+		all = all[:len(all)-1]
+	}
+
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+
+	return all
+}
@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
+)
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		n        int
+		expected []string
+	}{
+		{
+			name:     "strips escape sequences",
+			writes:   []string{"\033[1;32mHello\033[0m\n\033]0;title\007World\n"},
+			n:        10,
+			expected: []string{"Hello", "World"},
+		},
+		{
+			name:     "includes partial line",
+			writes:   []string{"first\nsec", "ond"},
+			n:        10,
+			expected: []string{"first", "second"},
+		},
+		{
+			name:     "carriage return redraws line",
+			writes:   []string{"Loading 10%\rLoading 100%\n"},
+			n:        10,
+			expected: []string{"Loading 100%"},
+		},
+		{
+			name:     "collapses blank lines",
+			writes:   []string{"a\n\n\n\nb\n\n"},
+			n:        10,
+			expected: []string{"a", "", "b"},
+		},
+		{
+			name:     "limits to last n lines",
+			writes:   []string{"1\n2\n3\n4\n"},
+			n:        2,
+			expected: []string{"3", "4"},
+		},
+		{
+			name:     "drops overflowing lines",
+			writes:   []string{"1\n2\n3\n4\n5\n6\n"},
+			n:        0,
+			expected: []string{"3", "4", "5", "6"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := NewTailBuffer(4)
+			for _, w := range tt.writes {
+				tb.Write([]byte(w))
+			}
+			got := tb.Lines(tt.n)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTailBufferReset(t *testing.T) {
+	tb := NewTailBuffer(4)
+	tb.Write([]byte("1\n2\n3\n4\n5\npartial"))
+	tb.Reset()
+	if got := tb.Lines(0); len(got) != 0 {
+		t.Fatalf("expected no lines after a reset, got %q", got)
+	}
+
+	tb.Write([]byte("a\nb"))
+	if got, want := tb.Lines(0), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func BenchmarkTailBufferWrite(b *testing.B) {
+	tb := NewTailBuffer(defaultTailLines)
+	data := benchmarkOutput()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		tb.Write(data)
+	}
+}
+
+// referenceTailLines is the original byte-at-a-time line assembly, applied
+// to all output at once
+func referenceTailLines(data []byte, maxLines, n int) []string {
+	var lines []string
+	var current []byte
+	appendLine := func(line string) {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			return
+		}
+		if len(lines) >= maxLines {
+			lines = lines[1:]
+		}
+		lines = append(lines, line)
+	}
+
+	clean := ansi.Strip(data)
+	for i := 0; i < len(clean); i++ {
+		switch clean[i] {
+		case '\n':
+			appendLine(string(current))
+			current = current[:0]
+		case '\r':
+			if i+1 < len(clean) && clean[i+1] != '\n' {
+				current = current[:0]
+			}
+		default:
+			current = append(current, clean[i])
+		}
+	}
+
+	all := lines
+	if partial := strings.TrimRight(string(current), " \t"); partial != "" {
+		all = append(all[:len(all):len(all)], partial)
+	}
+	for len(all) > 0 && all[len(all)-1] == "" {
+		all = all[:len(all)-1]
+	}
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	if all == nil {
+		all = []string{}
+	}
+	return all
+}
+
+// splitChunks splits data at up to two points, as reads from the PTY might
+func splitChunks(data []byte, a, b uint16) [][]byte {
+	i, j := int(a)%(len(data)+1), int(b)%(len(data)+1)
+	if i > j {
+		i, j = j, i
+	}
+	return [][]byte{data[:i], data[i:j], data[j:]}
+}
+
+func FuzzTailBuffer(f *testing.F) {
+	f.Add([]byte("Loading 10%\rLoading 100%\r\nnext\n"), uint16(11), uint16(12))
+	f.Add([]byte("a\r\nb\rc\n\n\nd  \t\n"), uint16(2), uint16(5))
+	f.Add([]byte("\033[1mbold\033[0m\r\033]0;t\007x\n"), uint16(3), uint16(9))
+
+	f.Fuzz(func(t *testing.T, data []byte, a, b uint16) {
+		// Splitting an escape sequence changes what Strip removes from each
+		// piece, so only plain text is compared across chunkings
+		if strings.ContainsAny(string(data), "\x1b\x9b") {
+			return
+		}
+
+		tb := NewTailBuffer(4)
+		for _, chunk := range splitChunks(data, a, b) {
+			tb.Write(chunk)
+		}
+
+		got := tb.Lines(0)
+		want := referenceTailLines(data, 4, 0)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("lines for %q split at %d,%d: got %q, want %q", data, a, b, got, want)
+		}
+	})
+}
@@ -1,63 +1,52 @@
 package monitor
 
 import (
-	"bytes"
-	"regexp"
-
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/interfaces"
 )
 
-// Common ANSI escape sequences for screen clearing
-var screenClearSequences = [][]byte{
-	[]byte("\033[2J"), // Clear entire screen
-	[]byte("\033[3J"), // Clear entire screen and scrollback
-	[]byte("\033[H"),  // Move cursor to home position (often follows clear)
-	[]byte("\033[0J"), // Clear from cursor to end of screen
-	[]byte("\033[1J"), // Clear from cursor to beginning of screen
-	[]byte("\033c"),   // Reset terminal
-}
-
-// Sequences that might interfere with status line display
-var statusInterferingSequences = [][]byte{
-	[]byte("\033[r"),      // Reset scrolling region (might affect bottom line)
-	[]byte("\033[?47h"),   // Switch to alternate screen buffer
-	[]byte("\033[?1047h"), // Save cursor and switch to alternate screen
-	[]byte("\033[?1049h"), // Save cursor and switch to alternate screen (xterm)
-	[]byte("\033[?47l"),   // Switch back from alternate screen
-	[]byte("\033[?1047l"), // Restore cursor and switch from alternate screen
-	[]byte("\033[?1049l"), // Restore cursor and switch from alternate screen (xterm)
-	[]byte("\033D"),       // Index (scroll down)
-	[]byte("\033M"),       // Reverse index (scroll up)
-	[]byte("\033[S"),      // Scroll up (might affect bottom line)
-	[]byte("\033[T"),      // Scroll down (might affect bottom line)
-}
-
 // Focus event sequences
 var (
-	focusInSequence  = []byte("\033[I")
-	focusOutSequence = []byte("\033[O")
-	// Enable focus reporting: \033[?1004h
-	// Disable focus reporting: \033[?1004l
+	focusInSequence               = []byte("\033[I")
+	focusOutSequence              = []byte("\033[O")
+	enableFocusReportingSequence  = []byte("\033[?1004h")
+	disableFocusReportingSequence = []byte("\033[?1004l")
 )
 
-// OSC terminal title sequence pattern
-// Matches: ESC]0;title BEL or ESC]0;title ESC\
-// Also matches ESC]1; and ESC]2; variants
-var titlePattern = regexp.MustCompile(`\033\](?:0|1|2);([^\007\033]*?)(?:\007|\033\\)`)
+// bottomLineClearWindow is how much text may follow a cursor positioning
+// before an erase-line is no longer considered to clear the bottom line
+const bottomLineClearWindow = 20
 
 // TerminalSequenceDetector detects terminal escape sequences in output
 type TerminalSequenceDetector struct {
-	// Buffer to handle sequences that might be split across data chunks
-	buffer []byte
+	// Streaming parser, which carries sequences split across data chunks
+	parser *ansi.Parser
 	// Track if we've enabled focus reporting
 	focusReportingEnabled bool
+
+	// Per-chunk results, reported once the chunk has been parsed
+	handler    interfaces.ScreenEventHandler
+	foundClear bool
+	title      string
+	titleSeen  bool
+
+	// Text written since the last cursor positioning, or -1 if the cursor
+	// has not been positioned
+	sinceCursorMove int
 }
 
 // NewTerminalSequenceDetector creates a new terminal sequence detector
 func NewTerminalSequenceDetector() interfaces.TerminalSequenceDetector {
+	return newTerminalSequenceDetector()
+}
+
+// newTerminalSequenceDetector creates a detector that can also be driven by
+// another parser's events, see begin and end
+func newTerminalSequenceDetector() *TerminalSequenceDetector {
 	return &TerminalSequenceDetector{
-		buffer:                make([]byte, 0, 1024), // Larger buffer for OSC sequences
+		parser:                ansi.NewParser(),
 		focusReportingEnabled: false,
+		sinceCursorMove:       -1,
 	}
 }
 
@@ -67,103 +56,125 @@ func (t *TerminalSequenceDetector) DetectSequences(data []byte, handler interfac
 		return
 	}
 
-	// Append new data to buffer
-	t.buffer = append(t.buffer, data...)
-
-	// Look for screen clear sequences
-	foundClear := false
-	for _, seq := range screenClearSequences {
-		if bytes.Contains(t.buffer, seq) {
-			foundClear = true
-			break
-		}
-	}
+	t.begin(handler)
+	t.parser.Feed(data, t)
+	t.end()
+}
 
-	// Also check for sequences that interfere with status display
-	if !foundClear {
-		for _, seq := range statusInterferingSequences {
-			if bytes.Contains(t.buffer, seq) {
-				foundClear = true
-				break
-			}
-		}
-	}
+// begin starts a chunk whose events are reported to handler. Callers that
+// parse output themselves pass the parser's events on between begin and end.
+func (t *TerminalSequenceDetector) begin(handler interfaces.ScreenEventHandler) {
+	t.handler = handler
+	t.foundClear = false
+	t.titleSeen = false
+}
 
-	// Check for cursor positioning that might affect bottom line
-	if !foundClear && t.detectBottomLineClear(t.buffer) {
-		foundClear = true
+// end finishes a chunk, reporting the events collected for it
+func (t *TerminalSequenceDetector) end() {
+	handler := t.handler
+	t.handler = nil
+	if handler == nil {
+		return
 	}
 
-	if foundClear {
+	// Screen clears often come in bursts, so report them once per chunk
+	if t.foundClear {
 		handler.HandleScreenClear()
 	}
 
-	// Look for focus events
-	if bytes.Contains(t.buffer, focusInSequence) {
-		handler.HandleFocusIn()
+	// Only the most recent title matters
+	if t.titleSeen {
+		handler.HandleTitleChange(t.title)
 	}
-	if bytes.Contains(t.buffer, focusOutSequence) {
-		handler.HandleFocusOut()
+}
+
+// Print implements ansi.Handler
+func (t *TerminalSequenceDetector) Print(text []byte) {
+	if t.sinceCursorMove >= 0 {
+		t.sinceCursorMove += len(text)
+		if t.sinceCursorMove > bottomLineClearWindow {
+			t.sinceCursorMove = -1
+		}
 	}
+}
 
-	// Look for terminal title changes
-	if matches := titlePattern.FindAllSubmatch(t.buffer, -1); matches != nil {
-		// Get the last title change (most recent)
-		lastMatch := matches[len(matches)-1]
-		if len(lastMatch) > 1 {
-			title := string(lastMatch[1])
-			handler.HandleTitleChange(title)
+// Execute implements ansi.Handler
+func (t *TerminalSequenceDetector) Execute(c byte) {}
+
+// Dispatch implements ansi.Handler
+func (t *TerminalSequenceDetector) Dispatch(seq *ansi.Sequence) {
+	switch seq.Kind {
+	case ansi.KindEscape:
+		if len(seq.Intermediates) > 0 {
+			return
+		}
+		switch seq.Final {
+		case 'c', // Reset terminal
+			'D', // Index (scroll down)
+			'M': // Reverse index (scroll up)
+			t.foundClear = true
+		}
+
+	case ansi.KindCSI:
+		t.dispatchCSI(seq)
+
+	case ansi.KindOSC:
+		// Terminal title: ESC]0;title, ESC]1;title or ESC]2;title
+		data := seq.Data
+		if len(data) >= 2 && data[0] >= '0' && data[0] <= '2' && data[1] == ';' {
+			t.title = string(data[2:])
+			t.titleSeen = true
 		}
 	}
+}
 
-	// Keep buffer reasonable size - OSC sequences can be longer than regular escape sequences
-	// Title sequences can be up to ~200 chars, so keep a larger buffer
-	maxBufferSize := 512
-	if len(t.buffer) > maxBufferSize {
-		// Keep the last portion that might contain incomplete sequences
-		t.buffer = t.buffer[len(t.buffer)-maxBufferSize:]
+// dispatchCSI handles a control sequence
+func (t *TerminalSequenceDetector) dispatchCSI(seq *ansi.Sequence) {
+	if len(seq.Intermediates) > 0 {
+		return
+	}
+	params := string(seq.Params)
+
+	switch seq.Final {
+	case 'J': // Erase display, in whole or in part
+		t.foundClear = true
+	case 'H', 'f': // Cursor positioning, which may move to the bottom line
+		if seq.Final == 'H' && params == "" { // Cursor home, often follows a clear
+			t.foundClear = true
+		}
+		t.sinceCursorMove = 0
+	case 'K': // Erase line, which clears the bottom line after positioning there
+		if t.sinceCursorMove >= 0 && (params == "" || params == "2") {
+			t.foundClear = true
+		}
+	case 'r': // Reset scrolling region (might affect bottom line)
+		if params == "" {
+			t.foundClear = true
+		}
+	case 'S', 'T': // Scroll up or down (might affect bottom line)
+		t.foundClear = true
+	case 'h', 'l': // Alternate screen buffer switches
+		switch params {
+		case "?47", "?1047", "?1049":
+			t.foundClear = true
+		}
+	case 'I': // Focus in
+		if params == "" && t.handler != nil {
+			t.handler.HandleFocusIn()
+		}
+	case 'O': // Focus out
+		if params == "" && t.handler != nil {
+			t.handler.HandleFocusOut()
+		}
 	}
 }
 
 // EnableFocusReporting returns the escape sequence to enable focus reporting
 func EnableFocusReporting() []byte {
-	return []byte("\033[?1004h")
+	return enableFocusReportingSequence
 }
 
 // DisableFocusReporting returns the escape sequence to disable focus reporting
 func DisableFocusReporting() []byte {
-	return []byte("\033[?1004l")
-}
-
-// detectBottomLineClear checks for sequences that might clear the bottom line
-func (t *TerminalSequenceDetector) detectBottomLineClear(data []byte) bool {
-	// Check for cursor positioning to bottom line followed by clear
-	// Pattern: ESC[<row>;<col>H followed by ESC[K or ESC[2K
-	for i := 0; i < len(data)-5; i++ {
-		if data[i] == '\033' && data[i+1] == '[' {
-			// Look for cursor positioning
-			j := i + 2
-			for j < len(data) && data[j] != 'H' && data[j] != 'f' {
-				j++
-			}
-			if j < len(data) && (data[j] == 'H' || data[j] == 'f') {
-				// Found cursor positioning, check if it's followed by line clear
-				for k := j + 1; k < len(data)-2 && k < j+20; k++ {
-					if data[k] == '\033' && data[k+1] == '[' &&
-						(data[k+2] == 'K' || (k+3 < len(data) && data[k+2] == '2' && data[k+3] == 'K')) {
-						return true
-					}
-				}
-			}
-		}
-	}
-
-	// Check for ED (Erase Display) sequences that affect bottom
-	// ESC[0J clears from cursor to end of screen
-	if bytes.Contains(data, []byte("\033[0J")) ||
-		bytes.Contains(data, []byte("\033[J")) { // Same as ESC[0J
-		return true
-	}
-
-	return false
+	return disableFocusReportingSequence
 }
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -248,3 +249,45 @@ func TestTerminalSequenceDetectorBufferManagement(t *testing.T) {
 		t.Errorf("expected 1 screen clear after buffer management, got %d", handler.screenClearCount)
 	}
 }
+
+func TestTerminalSequenceDetectorReportsEachSequenceOnce(t *testing.T) {
+	detector := NewTerminalSequenceDetector()
+	handler := &mockScreenEventHandler{}
+
+	detector.DetectSequences([]byte("\033[2J\033]0;First\007"), handler)
+	detector.DetectSequences([]byte("plain output"), handler)
+	detector.DetectSequences([]byte("more output"), handler)
+
+	if handler.screenClearCount != 1 {
+		t.Errorf("expected 1 screen clear, got %d", handler.screenClearCount)
+	}
+	if len(handler.titleChanges) != 1 {
+		t.Errorf("expected 1 title change, got %d", len(handler.titleChanges))
+	}
+}
+
+func TestTerminalSequenceDetectorLongTitleSplit(t *testing.T) {
+	detector := NewTerminalSequenceDetector()
+	handler := &mockScreenEventHandler{}
+
+	// A title longer than the old 512 byte buffer, split across chunks
+	title := strings.Repeat("t", 600)
+	detector.DetectSequences([]byte("\033]2;"+title[:300]), handler)
+	detector.DetectSequences([]byte(title[300:]+"\007"), handler)
+
+	if len(handler.titleChanges) != 1 || handler.titleChanges[0] != title {
+		t.Errorf("expected the full title, got %d title changes", len(handler.titleChanges))
+	}
+}
+
+func BenchmarkTerminalSequenceDetector(b *testing.B) {
+	detector := NewTerminalSequenceDetector()
+	handler := &mockScreenEventHandler{}
+	data := benchmarkOutput()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		detector.DetectSequences(data, handler)
+	}
+}
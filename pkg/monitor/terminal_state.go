@@ -17,6 +17,8 @@ type TerminalState struct {
 	lastFocusChange time.Time
 	// Whether focus reporting is enabled
 	focusReportingEnabled bool
+	// Whether the wrapped process itself asked for focus reporting
+	childFocusReporting bool
 }
 
 // NewTerminalState creates a new terminal state tracker
@@ -78,3 +80,18 @@ func (ts *TerminalState) IsFocusReportingEnabled() bool {
 	defer ts.mu.RUnlock()
 	return ts.focusReportingEnabled
 }
+
+// SetChildFocusReporting records whether the wrapped process enabled focus
+// reporting itself and therefore expects focus events on its input
+func (ts *TerminalState) SetChildFocusReporting(enabled bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.childFocusReporting = enabled
+}
+
+// IsChildFocusReporting returns whether the wrapped process enabled focus reporting
+func (ts *TerminalState) IsChildFocusReporting() bool {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.childFocusReporting
+}
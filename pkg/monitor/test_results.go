@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// TestResult sums up test runs of one test runner
+type TestResult struct {
+	// Runner is the test runner, e.g. "pytest"
+	Runner string
+	// Packages is set when the counts are of packages, as go test reports
+	// them, rather than of tests
+	Packages bool
+	Passed   int
+	Failed   int
+	Skipped  int
+}
+
+// testRunners recognize the summary lines of common test runners
+var testRunners = []struct {
+	name     string
+	packages bool
+	pattern  *regexp.Regexp
+}{
+	// "ok  	example.com/pkg	0.012s" or "FAIL	example.com/pkg	0.3s"; tabs
+	// may have been drawn as spaces
+	{"go test", true, regexp.MustCompile(`(?:^|\s)(ok|FAIL)\s+\S+\s+(?:\d+(?:\.\d+)?s|\(cached\))`)},
+	// "===== 3 failed, 42 passed, 1 skipped in 1.23s ====="
+	{"pytest", false, regexp.MustCompile(`=+ ((?:\d+ \w+(?:, )?)+) in \d+(?:\.\d+)?s`)},
+	// "Tests:       3 failed, 40 passed, 43 total"
+	{"jest", false, regexp.MustCompile(`Tests:\s+((?:\d+ \w+(?:, )?)+)`)},
+}
+
+// testCountPattern matches a count in a summary, e.g. "3 failed"
+var testCountPattern = regexp.MustCompile(`(\d+) (\w+)`)
+
+// TestResultPatterns match the summary lines TestResults recognizes
+var TestResultPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(testRunners))
+	for i, runner := range testRunners {
+		patterns[i] = runner.pattern
+	}
+	return patterns
+}()
+
+// TestResults sums up the summary lines of test runners in lines, by runner
+// in the order they first appear. A go test run prints a line per package,
+// which are summed up like the runs of several test suites.
+func TestResults(lines []string) []TestResult {
+	var results []TestResult
+	index := make(map[string]int)
+	for _, line := range lines {
+		for _, runner := range testRunners {
+			match := runner.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			i, ok := index[runner.name]
+			if !ok {
+				i = len(results)
+				index[runner.name] = i
+				results = append(results, TestResult{Runner: runner.name, Packages: runner.packages})
+			}
+			if runner.packages {
+				if match[1] == "ok" {
+					results[i].Passed++
+				} else {
+					results[i].Failed++
+				}
+			} else {
+				results[i].add(match[1])
+			}
+			break
+		}
+	}
+	return results
+}
+
+// add adds the counts of a summary such as "3 failed, 42 passed"
+func (tr *TestResult) add(summary string) {
+	for _, count := range testCountPattern.FindAllStringSubmatch(summary, -1) {
+		n, err := strconv.Atoi(count[1])
+		if err != nil {
+			continue
+		}
+		switch count[2] {
+		case "passed":
+			tr.Passed += n
+		case "failed", "error", "errors":
+			tr.Failed += n
+		case "skipped", "todo", "xfailed":
+			tr.Skipped += n
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import "testing"
+
+func TestTestResults(t *testing.T) {
+	lines := []string{
+		"> run the tests",
+		"ok  	github.com/example/app/pkg/config	0.012s",
+		"│ ok      github.com/example/app/pkg/monitor    (cached)         │",
+		"FAIL	github.com/example/app/pkg/server	0.301s",
+		"?   	github.com/example/app/cmd/app	[no test files]",
+		"========= 3 failed, 42 passed, 1 skipped, 2 warnings in 1.23s =========",
+		"Tests:       1 failed, 2 todo, 40 passed, 43 total",
+		"Test Suites: 1 failed, 5 passed, 6 total",
+		"The tests are ok now",
+	}
+	results := TestResults(lines)
+
+	expected := []TestResult{
+		{Runner: "go test", Packages: true, Passed: 2, Failed: 1},
+		{Runner: "pytest", Passed: 42, Failed: 3, Skipped: 1},
+		{Runner: "jest", Passed: 40, Failed: 1, Skipped: 2},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, results)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], results[i])
+		}
+	}
+
+	for _, line := range lines {
+		matched := false
+		for _, pattern := range TestResultPatterns {
+			matched = matched || pattern.MatchString(line)
+		}
+		if want := len(TestResults([]string{line})) > 0; matched != want {
+			t.Errorf("expected the patterns to match %q: %v", line, want)
+		}
+	}
+}
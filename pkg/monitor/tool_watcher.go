@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// toolSearchLines is how many recent output lines are searched for the
+// status of a tool call
+const toolSearchLines = 40
+
+// ToolWatcher reports a tool call, e.g. a shell command Gemini started, that
+// has been running for longer than a threshold, which is when the user has
+// likely walked away. The CLI marks a running tool call on screen and
+// replaces the mark once it ends; each run is reported once.
+type ToolWatcher struct {
+	lines   func(n int) []string
+	running *regexp.Regexp
+	done    *regexp.Regexp
+	after   time.Duration
+	onLong  func(tool string, elapsed time.Duration)
+
+	mu sync.Mutex
+	// The tool call running, as the CLI shows it, and since when
+	tool     string
+	since    time.Time
+	reported bool
+}
+
+// NewToolWatcher creates a watcher that searches the recent output lines
+// returned by lines. A line matching running shows a tool call in progress,
+// named by the pattern's first group; one matching done shows a tool call
+// that ended. onLong is called once a call has been running for after.
+func NewToolWatcher(lines func(n int) []string, running, done *regexp.Regexp, after time.Duration, onLong func(tool string, elapsed time.Duration)) *ToolWatcher {
+	return &ToolWatcher{
+		lines:   lines,
+		running: running,
+		done:    done,
+		after:   after,
+		onLong:  onLong,
+	}
+}
+
+// Run checks the output until stop is closed
+func (tw *ToolWatcher) Run(stop <-chan struct{}) {
+	// Check often enough that the report is not much later than due
+	interval := min(tw.after/10, 5*time.Second)
+	ticker := time.NewTicker(max(interval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			tw.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// check follows the running tool call and reports it once it has run too
+// long
+func (tw *ToolWatcher) check(now time.Time) {
+	tool := tw.current()
+
+	tw.mu.Lock()
+	if tool != tw.tool {
+		tw.tool = tool
+		tw.since = now
+		tw.reported = false
+	}
+	elapsed := now.Sub(tw.since)
+	report := tool != "" && !tw.reported && elapsed >= tw.after
+	if report {
+		tw.reported = true
+	}
+	tw.mu.Unlock()
+
+	if report {
+		tw.onLong(tool, elapsed)
+	}
+}
+
+// current returns the tool call the latest status on screen shows running,
+// or "" if the latest shows one ended or there is none
+func (tw *ToolWatcher) current() string {
+	lines := tw.lines(toolSearchLines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if match := tw.running.FindStringSubmatch(lines[i]); len(match) > 1 {
+			return strings.TrimSpace(match[1])
+		}
+		if tw.done != nil && tw.done.MatchString(lines[i]) {
+			return ""
+		}
+	}
+	return ""
+}
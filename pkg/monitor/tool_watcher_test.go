@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+)
+
+func TestToolWatcher(t *testing.T) {
+	profile, err := config.LookupProfile("gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	running, done, err := profile.CompileToolPatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type report struct {
+		tool    string
+		elapsed time.Duration
+	}
+	var screen []string
+	var reports []report
+	tw := NewToolWatcher(func(n int) []string { return screen }, running, done, 10*time.Minute, func(tool string, elapsed time.Duration) {
+		reports = append(reports, report{tool, elapsed})
+	})
+
+	start := time.Now()
+	screen = []string{
+		"> run the tests",
+		"╭──────────────────────────────────────────────╮",
+		"│ ⊷  Shell go test ./... (Run the tests)        │",
+		"╰──────────────────────────────────────────────╯",
+		"⠏ Running the tests (esc to cancel, 2s)",
+	}
+	tw.check(start)
+	tw.check(start.Add(9 * time.Minute))
+	if len(reports) != 0 {
+		t.Fatalf("expected no reports before the threshold, got %v", reports)
+	}
+
+	tw.check(start.Add(11 * time.Minute))
+	tw.check(start.Add(12 * time.Minute))
+	if len(reports) != 1 {
+		t.Fatalf("expected one report, got %v", reports)
+	}
+	if reports[0].tool != "Shell go test ./... (Run the tests)" || reports[0].elapsed != 11*time.Minute {
+		t.Errorf("unexpected report %v", reports[0])
+	}
+
+	// The call ends, and another one starts later
+	screen = append(screen, "│ ✓  Shell go test ./... (Run the tests)        │")
+	tw.check(start.Add(13 * time.Minute))
+	screen = append(screen, "│ ⊷  Shell make build                           │")
+	tw.check(start.Add(14 * time.Minute))
+	tw.check(start.Add(20 * time.Minute))
+	if len(reports) != 1 {
+		t.Fatalf("expected the new call not to be reported yet, got %v", reports)
+	}
+	tw.check(start.Add(24 * time.Minute))
+	if len(reports) != 2 || reports[1].tool != "Shell make build" {
+		t.Errorf("expected the new call to be reported, got %v", reports)
+	}
+}
@@ -0,0 +1,31 @@
+package notification
+
+// ActionNotifier wraps another notifier and attaches action buttons to
+// outgoing notifications
+type ActionNotifier struct {
+	underlying Notifier
+	actions    func(notification Notification) []Action
+}
+
+// NewActionNotifier creates a new action notifier. The actions function is
+// called for every notification and returns the buttons to attach, if any.
+func NewActionNotifier(underlying Notifier, actions func(notification Notification) []Action) *ActionNotifier {
+	return &ActionNotifier{
+		underlying: underlying,
+		actions:    actions,
+	}
+}
+
+// Send implements the Notifier interface
+func (an *ActionNotifier) Send(notification Notification) error {
+	if an.actions != nil {
+		if extra := an.actions(notification); len(extra) > 0 {
+			// Copy so the caller's action slice is never modified
+			actions := make([]Action, 0, len(notification.Actions)+len(extra))
+			actions = append(actions, notification.Actions...)
+			notification.Actions = append(actions, extra...)
+		}
+	}
+
+	return an.underlying.Send(notification)
+}
@@ -2,6 +2,7 @@ package notification
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,57 +11,131 @@ type ActivityMarker interface {
 	MarkActivity()
 }
 
-// BackstopNotifier wraps another notifier and sends a notification after inactivity
+// BackstopNotifier wraps another notifier and sends a notification after inactivity.
+// A single goroutine waits for the inactivity deadline, so marking activity
+// during bursts of output only records a timestamp.
 type BackstopNotifier struct {
 	underlying Notifier
 	timeout    time.Duration
+	messages   Messages
+	// prompt returns the question the wrapped CLI is waiting on, if any
+	prompt func() string
+	// stalled reports whether the wrapped CLI stopped in the middle of a task
+	stalled func() bool
+	// interactions are the kinds of input that count as user interaction;
+	// nil means all of them
+	interactions map[InputKind]bool
+	// resume tells whether output after a backstop notification, without
+	// the user's input, sends a resumed notification
+	resume bool
+
+	// Time of the last activity as an offset from start, which keeps the
+	// monotonic clock reading
+	start        time.Time
+	lastActivity atomic.Int64
+	// clean is true while MarkActivity has nothing to do but record the
+	// time: the deadline is armed and nothing was sent or disabled
+	clean atomic.Bool
+	// wake tells the deadline goroutine that the deadline was armed or changed
+	wake chan struct{}
 
 	mu                                       sync.Mutex
 	lastNotificationTime                     time.Time
-	lastActivityTime                         time.Time
 	lastUserInteraction                      time.Time
-	timer                                    *time.Timer
+	armed                                    bool // Waiting for the inactivity deadline
+	closed                                   bool
 	backstopSent                             bool // Track if backstop notification was sent for current session
 	backstopDisabled                         bool // Track if backstop timer has been disabled by user input
 	idleNotificationSentSinceLastInteraction bool // Track if we've sent an idle notification since last user interaction
+	// When the quiet period a backstop notification was sent for began,
+	// while its end is still to be reported as a resume
+	resumeFrom time.Time
 }
 
 // NewBackstopNotifier creates a new backstop notifier
 func NewBackstopNotifier(underlying Notifier, timeout time.Duration) *BackstopNotifier {
+	now := time.Now()
 	bn := &BackstopNotifier{
 		underlying:          underlying,
 		timeout:             timeout,
-		lastActivityTime:    time.Now(),
-		lastUserInteraction: time.Now(),
+		messages:            DefaultMessages(),
+		start:               now,
+		wake:                make(chan struct{}, 1),
+		lastUserInteraction: now,
 	}
 
-	if timeout > 0 {
-		bn.startTimer()
-	}
+	bn.mu.Lock()
+	bn.armLocked()
+	bn.mu.Unlock()
+
+	go bn.run()
 
 	return bn
 }
 
+// SetMessages sets the catalog the backstop notification text is taken from
+func (bn *BackstopNotifier) SetMessages(messages Messages) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.messages = messages
+}
+
+// SetPromptFunc sets a function that returns the question the wrapped CLI is
+// waiting on; when it returns one, the backstop notification shows it
+func (bn *BackstopNotifier) SetPromptFunc(prompt func() string) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.prompt = prompt
+}
+
+// SetStalledFunc sets a function that reports whether the wrapped CLI
+// stopped in the middle of a task; the backstop notification then says it
+// appears stalled instead of waiting for input
+func (bn *BackstopNotifier) SetStalledFunc(stalled func() bool) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.stalled = stalled
+}
+
+// SetInteractionInputs sets which kinds of input count as user interaction
+// and disable the backstop timer; nil, the default, means all of them do
+func (bn *BackstopNotifier) SetInteractionInputs(kinds []InputKind) {
+	var interactions map[InputKind]bool
+	if kinds != nil {
+		interactions = make(map[InputKind]bool, len(kinds))
+		for _, kind := range kinds {
+			interactions[kind] = true
+		}
+	}
+
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.interactions = interactions
+}
+
+// SetResumeNotify sets whether output resuming after a backstop
+// notification, before any user input, sends a resumed notification, so the
+// user knows the session got going again by itself
+func (bn *BackstopNotifier) SetResumeNotify(resume bool) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	bn.resume = resume
+}
+
 // Send implements the Notifier interface
 func (bn *BackstopNotifier) Send(notification Notification) error {
 	bn.mu.Lock()
-	defer bn.mu.Unlock()
 
 	// Reset activity time
-	bn.lastActivityTime = time.Now()
+	bn.touch()
 	bn.lastNotificationTime = time.Now()
 
 	// Reset backstop sent flag since we have new activity
 	bn.backstopSent = false
 
-	// Reset the timer
-	if bn.timer != nil {
-		bn.timer.Stop()
-	}
-	// Always restart timer after a notification
-	if bn.timeout > 0 {
-		bn.timer = time.AfterFunc(bn.timeout, bn.sendBackstopNotification)
-	}
+	// Always restart the deadline after a notification
+	bn.armLocked()
+	bn.mu.Unlock()
 
 	// Forward to underlying notifier
 	return bn.underlying.Send(notification)
@@ -68,65 +143,172 @@ func (bn *BackstopNotifier) Send(notification Notification) error {
 
 // MarkActivity marks that there was activity (output) without sending a notification
 func (bn *BackstopNotifier) MarkActivity() {
-	bn.mu.Lock()
-	defer bn.mu.Unlock()
+	bn.touch()
+
+	// The common case during output: the deadline just moves later, which
+	// the deadline goroutine notices when it wakes up
+	if bn.clean.Load() {
+		return
+	}
 
-	bn.lastActivityTime = time.Now()
+	bn.mu.Lock()
 
 	// Reset backstop sent flag and disabled flag since we have new activity
 	bn.backstopSent = false
 	bn.backstopDisabled = false
 
-	// Reset the timer
-	if bn.timer != nil {
-		bn.timer.Stop()
+	// Always restart the deadline after activity
+	bn.armLocked()
+
+	resumeFrom, messages := bn.resumeFrom, bn.messages
+	bn.resumeFrom = time.Time{}
+	bn.mu.Unlock()
+
+	// Output is handled on this goroutine, so the network must not hold it up
+	if !resumeFrom.IsZero() {
+		now := time.Now()
+		go func() {
+			_ = bn.underlying.Send(Notification{
+				Title:   messages.Get("resumed.title"),
+				Message: messages.Get("resumed.message", roughDuration(now.Sub(resumeFrom))),
+				Time:    now,
+				Pattern: "resumed",
+			})
+		}()
 	}
-	// Always restart timer after activity
-	if bn.timeout > 0 {
-		bn.timer = time.AfterFunc(bn.timeout, bn.sendBackstopNotification)
+}
+
+// touch records activity now
+func (bn *BackstopNotifier) touch() {
+	bn.lastActivity.Store(int64(time.Since(bn.start)))
+}
+
+// deadline returns when the backstop notification is due
+func (bn *BackstopNotifier) deadline(timeout time.Duration) time.Time {
+	return bn.start.Add(time.Duration(bn.lastActivity.Load()) + timeout)
+}
+
+// run waits for inactivity deadlines until the notifier is closed
+func (bn *BackstopNotifier) run() {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		bn.mu.Lock()
+		if bn.closed {
+			bn.mu.Unlock()
+			return
+		}
+
+		wait := time.Duration(-1)
+		if bn.armed {
+			wait = time.Until(bn.deadline(bn.timeout))
+			if wait <= 0 {
+				// Make MarkActivity take the lock from here on, then check
+				// that no activity slipped in before it would notice
+				bn.clean.Store(false)
+				if time.Until(bn.deadline(bn.timeout)) > 0 {
+					bn.updateCleanLocked()
+					bn.mu.Unlock()
+					continue
+				}
+
+				notification, ok := bn.expireLocked()
+				prompt, stalled := bn.prompt, bn.stalled
+				messages, timeout := bn.messages, bn.timeout
+				bn.mu.Unlock()
+				if ok {
+					// A question on screen means waiting for the user, even
+					// if a working indicator is visible too
+					question := ""
+					if prompt != nil {
+						question = prompt()
+					}
+					if question != "" {
+						notification.Message = question
+					} else if stalled != nil && stalled() {
+						notification.Title = messages.Get("stalled.title")
+						notification.Message = messages.Get("stalled.message", roughDuration(timeout))
+						notification.Pattern = "stalled"
+					}
+					_ = bn.underlying.Send(notification)
+				}
+				continue
+			}
+		}
+		bn.mu.Unlock()
+
+		if wait < 0 {
+			<-bn.wake
+			continue
+		}
+
+		// Activity in the meantime only moves the deadline later, so the
+		// deadline is simply checked again on waking
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-bn.wake:
+			timer.Stop()
+		}
 	}
 }
 
-// sendBackstopNotification sends a notification after inactivity
-func (bn *BackstopNotifier) sendBackstopNotification() {
-	bn.mu.Lock()
-	defer bn.mu.Unlock()
+// expireLocked handles a passed deadline, returning the backstop
+// notification to send if one is due. Caller must hold bn.mu.
+func (bn *BackstopNotifier) expireLocked() (Notification, bool) {
+	// Do NOT rearm - we only send one backstop per session
+	bn.disarmLocked()
 
 	// Only send if we haven't already sent a backstop for this session and it's not disabled
 	if bn.backstopSent || bn.backstopDisabled {
-		return
+		return Notification{}, false
 	}
 
 	// Check if we've already sent an idle notification since the last user interaction
 	if bn.idleNotificationSentSinceLastInteraction {
-		return
-	}
-
-	// Send backstop notification
-	notification := Notification{
-		Title:   "Gemini needs attention",
-		Message: "No activity detected",
-		Time:    time.Now(),
-		Pattern: "backstop",
+		return Notification{}, false
 	}
 
 	bn.lastNotificationTime = time.Now()
 	bn.backstopSent = true
 	bn.idleNotificationSentSinceLastInteraction = true
+	bn.updateCleanLocked()
+	if bn.resume {
+		bn.resumeFrom = bn.deadline(0)
+	}
+
+	return Notification{
+		Title:   bn.messages.Get("backstop.title"),
+		Message: bn.messages.Get("backstop.message"),
+		Time:    time.Now(),
+		Pattern: "backstop",
+	}, true
+}
 
-	// Send via underlying notifier
-	_ = bn.underlying.Send(notification)
+// armLocked (re)starts waiting for the inactivity deadline. Caller must hold bn.mu.
+func (bn *BackstopNotifier) armLocked() {
+	bn.armed = bn.timeout > 0
+	bn.updateCleanLocked()
+	bn.notifyLocked()
+}
 
-	// Do NOT restart timer - we only send one backstop per session
+// disarmLocked stops waiting for the deadline. Caller must hold bn.mu.
+func (bn *BackstopNotifier) disarmLocked() {
+	bn.armed = false
+	bn.updateCleanLocked()
 }
 
-// startTimer starts the initial timer
-func (bn *BackstopNotifier) startTimer() {
-	bn.mu.Lock()
-	defer bn.mu.Unlock()
+// updateCleanLocked refreshes the MarkActivity fast path flag. Caller must hold bn.mu.
+func (bn *BackstopNotifier) updateCleanLocked() {
+	bn.clean.Store((bn.armed || bn.timeout == 0) && !bn.backstopSent && !bn.backstopDisabled)
+}
 
-	if bn.timeout > 0 {
-		bn.timer = time.AfterFunc(bn.timeout, bn.sendBackstopNotification)
+// notifyLocked wakes the deadline goroutine. Caller must hold bn.mu.
+func (bn *BackstopNotifier) notifyLocked() {
+	select {
+	case bn.wake <- struct{}{}:
+	default:
 	}
 }
 
@@ -137,10 +319,11 @@ func (bn *BackstopNotifier) SetBackstopSent(sent bool) {
 
 	bn.backstopSent = sent
 
-	// If we're marking it as sent, stop the timer
-	if sent && bn.timer != nil {
-		bn.timer.Stop()
+	// If we're marking it as sent, stop waiting for the deadline
+	if sent {
+		bn.disarmLocked()
 	}
+	bn.updateCleanLocked()
 }
 
 // ResetSession resets the backstop state for a new prompt/session
@@ -150,18 +333,13 @@ func (bn *BackstopNotifier) ResetSession() {
 
 	bn.backstopSent = false
 	bn.backstopDisabled = false
-	bn.lastActivityTime = time.Now()
+	bn.touch()
 	// Reset idle notification flag since this is a new session that warrants attention
 	bn.idleNotificationSentSinceLastInteraction = false
+	bn.resumeFrom = time.Time{}
 
-	// Reset the timer
-	if bn.timer != nil {
-		bn.timer.Stop()
-	}
-	// Start a new timer for the new session
-	if bn.timeout > 0 {
-		bn.timer = time.AfterFunc(bn.timeout, bn.sendBackstopNotification)
-	}
+	// Start a new deadline for the new session
+	bn.armLocked()
 }
 
 // DisableBackstopTimer disables the backstop timer (e.g., when user input is detected)
@@ -172,21 +350,89 @@ func (bn *BackstopNotifier) DisableBackstopTimer() {
 	bn.backstopDisabled = true
 	bn.lastUserInteraction = time.Now()
 	bn.idleNotificationSentSinceLastInteraction = false
+	// The user is back, so output that follows is no news
+	bn.resumeFrom = time.Time{}
+
+	// Stop waiting for the deadline
+	bn.disarmLocked()
+}
 
-	// Stop the timer
-	if bn.timer != nil {
-		bn.timer.Stop()
+// HandleInput disables the backstop timer if the kind of input counts as
+// user interaction, and reports whether it does
+func (bn *BackstopNotifier) HandleInput(kind InputKind) bool {
+	if kind == InputEnter {
+		kind = InputKeystroke
 	}
+
+	bn.mu.Lock()
+	counts := bn.interactions == nil || bn.interactions[kind]
+	bn.mu.Unlock()
+
+	if counts {
+		bn.DisableBackstopTimer()
+	}
+	return counts
 }
 
-// Close stops the timer
+// Close stops the deadline goroutine
 func (bn *BackstopNotifier) Close() error {
 	bn.mu.Lock()
 	defer bn.mu.Unlock()
 
-	if bn.timer != nil {
-		bn.timer.Stop()
-	}
+	bn.closed = true
+	bn.disarmLocked()
+	bn.notifyLocked()
 
 	return nil
-}
\ No newline at end of file
+}
+
+// TimeUntilBackstop returns how long until the backstop notification fires,
+// and false if no backstop notification is pending
+func (bn *BackstopNotifier) TimeUntilBackstop() (time.Duration, bool) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+
+	if !bn.armed || bn.backstopSent || bn.backstopDisabled {
+		return 0, false
+	}
+
+	remaining := time.Until(bn.deadline(bn.timeout))
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// BackstopSent returns whether the backstop notification has been sent for
+// the current idle period
+func (bn *BackstopNotifier) BackstopSent() bool {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	return bn.backstopSent
+}
+
+// Timeout returns the current backstop timeout
+func (bn *BackstopNotifier) Timeout() time.Duration {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	return bn.timeout
+}
+
+// SetTimeout changes the backstop timeout and restarts the timer; a
+// non-positive timeout disables backstop notifications
+func (bn *BackstopNotifier) SetTimeout(timeout time.Duration) {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+
+	if timeout < 0 {
+		timeout = 0
+	}
+	bn.timeout = timeout
+	if timeout == 0 {
+		bn.disarmLocked()
+		return
+	}
+	if !bn.backstopSent && !bn.backstopDisabled {
+		bn.armLocked()
+	}
+}
@@ -0,0 +1,236 @@
+package notification
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingNotifier counts sent notifications and is safe for concurrent use
+type countingNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (c *countingNotifier) Send(n Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, n)
+	return nil
+}
+
+func (c *countingNotifier) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+// countPattern returns the number of notifications of a type sent so far
+func (c *countingNotifier) countPattern(pattern string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, sent := range c.sent {
+		if sent.Pattern == pattern {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBackstopNotifier(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	t.Run("fires once after inactivity", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+
+		time.Sleep(4 * timeout)
+		if got := rec.count(); got != 1 {
+			t.Fatalf("expected 1 backstop notification, got %d", got)
+		}
+		if rec.sent[0].Pattern != "backstop" {
+			t.Errorf("expected backstop pattern, got %q", rec.sent[0].Pattern)
+		}
+	})
+
+	t.Run("shows the waiting prompt", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+		bn.SetPromptFunc(func() string { return "Allow execution? (y/n)" })
+
+		time.Sleep(4 * timeout)
+		if got := rec.count(); got != 1 {
+			t.Fatalf("expected 1 backstop notification, got %d", got)
+		}
+		if rec.sent[0].Message != "Allow execution? (y/n)" {
+			t.Errorf("expected the prompt as message, got %q", rec.sent[0].Message)
+		}
+	})
+
+	t.Run("tells a stall from waiting", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			question string
+			stalled  bool
+			pattern  string
+		}{
+			{"waiting", "", false, "backstop"},
+			{"stalled mid-task", "", true, "stalled"},
+			{"question while busy", "Allow execution? (y/n)", true, "backstop"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := &countingNotifier{}
+				bn := NewBackstopNotifier(rec, timeout)
+				defer func() { _ = bn.Close() }()
+				bn.SetPromptFunc(func() string { return tt.question })
+				bn.SetStalledFunc(func() bool { return tt.stalled })
+
+				time.Sleep(4 * timeout)
+				if got := rec.count(); got != 1 {
+					t.Fatalf("expected 1 notification, got %d", got)
+				}
+				if rec.sent[0].Pattern != tt.pattern {
+					t.Errorf("expected pattern %q, got %q", tt.pattern, rec.sent[0].Pattern)
+				}
+			})
+		}
+	})
+
+	t.Run("activity postpones the deadline", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+
+		// A burst of output lasting longer than the timeout
+		end := time.Now().Add(3 * timeout)
+		for time.Now().Before(end) {
+			bn.MarkActivity()
+			time.Sleep(time.Millisecond)
+		}
+		if got := rec.count(); got != 0 {
+			t.Fatalf("expected no notification during activity, got %d", got)
+		}
+
+		time.Sleep(3 * timeout)
+		if got := rec.count(); got != 1 {
+			t.Errorf("expected 1 notification after activity stopped, got %d", got)
+		}
+	})
+
+	t.Run("new activity re-arms after firing", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+
+		time.Sleep(3 * timeout)
+		bn.ResetSession()
+		time.Sleep(3 * timeout)
+		if got := rec.count(); got != 2 {
+			t.Errorf("expected 2 notifications, got %d", got)
+		}
+	})
+
+	t.Run("reports output resuming by itself", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+		bn.SetResumeNotify(true)
+
+		time.Sleep(3 * timeout)
+		bn.MarkActivity()
+		bn.MarkActivity()
+		// Well before the activity re-arms the backstop
+		time.Sleep(timeout / 2)
+		if got := rec.countPattern("resumed"); got != 1 {
+			t.Errorf("expected one resumed notification, got %d", got)
+		}
+	})
+
+	t.Run("resume after user input is no news", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+		bn.SetResumeNotify(true)
+
+		time.Sleep(3 * timeout)
+		bn.DisableBackstopTimer()
+		bn.MarkActivity()
+		time.Sleep(timeout / 2)
+		if got := rec.countPattern("resumed"); got != 0 {
+			t.Errorf("expected no resumed notification, got %d", got)
+		}
+	})
+
+	t.Run("user input disables it", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, timeout)
+		defer func() { _ = bn.Close() }()
+
+		bn.DisableBackstopTimer()
+		if _, pending := bn.TimeUntilBackstop(); pending {
+			t.Error("expected no pending backstop after user input")
+		}
+		time.Sleep(3 * timeout)
+		if got := rec.count(); got != 0 {
+			t.Errorf("expected no notification, got %d", got)
+		}
+	})
+
+	t.Run("only selected input kinds disable it", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, time.Hour)
+		defer func() { _ = bn.Close() }()
+
+		if !bn.HandleInput(InputPaste) {
+			t.Error("expected every kind to count by default")
+		}
+		bn.ResetSession()
+
+		bn.SetInteractionInputs([]InputKind{InputKeystroke})
+		if bn.HandleInput(InputBracketedPaste) {
+			t.Error("expected a paste not to count")
+		}
+		if _, pending := bn.TimeUntilBackstop(); !pending {
+			t.Error("expected the backstop to stay pending after a paste")
+		}
+		if !bn.HandleInput(InputKeystroke) {
+			t.Error("expected a keystroke to count")
+		}
+		if _, pending := bn.TimeUntilBackstop(); pending {
+			t.Error("expected no pending backstop after a keystroke")
+		}
+	})
+
+	t.Run("timeout can be enabled later", func(t *testing.T) {
+		rec := &countingNotifier{}
+		bn := NewBackstopNotifier(rec, 0)
+		defer func() { _ = bn.Close() }()
+
+		bn.MarkActivity()
+		bn.SetTimeout(timeout)
+		if remaining, pending := bn.TimeUntilBackstop(); !pending || remaining > timeout {
+			t.Errorf("expected pending backstop within %v, got %v %v", timeout, remaining, pending)
+		}
+		time.Sleep(3 * timeout)
+		if got := rec.count(); got != 1 {
+			t.Errorf("expected 1 notification, got %d", got)
+		}
+	})
+}
+
+func BenchmarkBackstopNotifierMarkActivity(b *testing.B) {
+	bn := NewBackstopNotifier(&countingNotifier{}, time.Minute)
+	defer func() { _ = bn.Close() }()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			bn.MarkActivity()
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBarkServer is the public Bark server
+const DefaultBarkServer = "https://api.day.app"
+
+// barkPush is the body of a Bark push request
+type barkPush struct {
+	DeviceKey string `json:"device_key"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Group     string `json:"group,omitempty"`
+	Sound     string `json:"sound,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// barkResponse is the status Bark reports in its response body
+type barkResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// BarkNotifier sends notifications to the Bark iOS app through a Bark server
+type BarkNotifier struct {
+	url       string
+	deviceKey string
+	// Sound played on arrival, e.g. "minuet" (empty for the app's default)
+	sound string
+	// Group the notifications are collected under in Notification Center,
+	// and whether each session gets its own group instead
+	group          string
+	groupBySession bool
+	httpClient     *http.Client
+}
+
+// NewBarkNotifier creates a notifier for a device key on a Bark server, e.g.
+// DefaultBarkServer
+func NewBarkNotifier(server, deviceKey string) *BarkNotifier {
+	return &BarkNotifier{
+		url:       strings.TrimSuffix(server, "/") + "/push",
+		deviceKey: deviceKey,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// SetSound sets the sound played when a notification arrives
+func (bn *BarkNotifier) SetSound(sound string) {
+	bn.sound = sound
+}
+
+// SetGroup sets the group notifications are collected under
+func (bn *BarkNotifier) SetGroup(group string) {
+	bn.group = group
+}
+
+// SetGroupBySession collects each session's notifications in a group of
+// its own, named after the group set with SetGroup and the session
+func (bn *BarkNotifier) SetGroupBySession(bySession bool) {
+	bn.groupBySession = bySession
+}
+
+// Send implements the Notifier interface
+func (bn *BarkNotifier) Send(notification Notification) error {
+	group := bn.group
+	if bn.groupBySession && notification.Session != "" {
+		group = strings.TrimPrefix(group+"-"+notification.Session, "-")
+	}
+
+	body, err := json.Marshal(barkPush{
+		DeviceKey: bn.deviceKey,
+		Title:     notification.Title,
+		Body:      notification.Message,
+		Group:     group,
+		Sound:     bn.sound,
+		URL:       notification.Click,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Bark push: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", bn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := bn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to Bark: %w", err)
+	}
+	defer drainBody(resp)
+
+	// Bark repeats the status in the body, with the reason for failures
+	var result barkResponse
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK || (result.Code != 0 && result.Code != http.StatusOK) {
+		if result.Message != "" {
+			return fmt.Errorf("request to Bark failed with status %d: %s", resp.StatusCode, result.Message)
+		}
+		return fmt.Errorf("request to Bark failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
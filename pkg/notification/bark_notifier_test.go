@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBarkNotifier(t *testing.T) {
+	var path string
+	var push barkPush
+	reply := `{"code":200,"message":"success"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		push = barkPush{}
+		_ = json.NewDecoder(r.Body).Decode(&push)
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	bn := NewBarkNotifier(server.URL+"/", "device123")
+	bn.SetSound("minuet")
+	bn.SetGroup("gemini")
+
+	if err := bn.Send(Notification{Title: "Gemini CLI: app", Message: "No activity detected", Click: "https://example.com"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if path != "/push" {
+		t.Errorf("unexpected path %q", path)
+	}
+	expected := barkPush{
+		DeviceKey: "device123",
+		Title:     "Gemini CLI: app",
+		Body:      "No activity detected",
+		Group:     "gemini",
+		Sound:     "minuet",
+		URL:       "https://example.com",
+	}
+	if push != expected {
+		t.Errorf("expected %+v, got %+v", expected, push)
+	}
+
+	t.Run("group by session", func(t *testing.T) {
+		bn.SetGroupBySession(true)
+		defer bn.SetGroupBySession(false)
+		if err := bn.Send(Notification{Title: "t", Session: "my-api"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if push.Group != "gemini-my-api" {
+			t.Errorf("expected the session's group, got %q", push.Group)
+		}
+	})
+
+	t.Run("error in body", func(t *testing.T) {
+		reply = `{"code":400,"message":"failed to get device token"}`
+		err := bn.Send(Notification{Title: "t"})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if got := err.Error(); got != "request to Bark failed with status 200: failed to get device token" {
+			t.Errorf("unexpected error %q", got)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package notification
+
+// Escape sequences used to alert the local terminal
+var (
+	bellSequence = []byte("\a")
+	// iTerm2 bounces the dock icon; other terminals ignore the sequence
+	urgencySequence = []byte("\033]1337;RequestAttention=yes\a")
+)
+
+// BellNotifier wraps another notifier and alerts the local terminal whenever
+// a notification is sent, so terminal emulators and tmux bell monitors also
+// notice
+type BellNotifier struct {
+	underlying Notifier
+	write      func(seq []byte)
+	bell       bool
+	urgency    bool
+}
+
+// NewBellNotifier creates a new bell notifier. write sends an escape
+// sequence to the real terminal.
+func NewBellNotifier(underlying Notifier, write func(seq []byte), bell, urgency bool) *BellNotifier {
+	return &BellNotifier{
+		underlying: underlying,
+		write:      write,
+		bell:       bell,
+		urgency:    urgency,
+	}
+}
+
+// Send implements the Notifier interface
+func (bn *BellNotifier) Send(notification Notification) error {
+	if bn.bell {
+		bn.write(bellSequence)
+	}
+	if bn.urgency {
+		bn.write(urgencySequence)
+	}
+
+	return bn.underlying.Send(notification)
+}
@@ -1,16 +1,52 @@
 package notification
 
 import (
+	"net/url"
 	"os"
+	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
 )
 
+// gitRefreshInterval is how long the detected git branch is reused before
+// it is looked up again, since it can change during a session
+const gitRefreshInterval = 30 * time.Second
+
 // ContextNotifier wraps another notifier and adds context to notifications
 type ContextNotifier struct {
 	underlying   Notifier
+	cwd          string
 	cwdBasename  string
 	terminalInfo func() string
+	// The tmux pane the wrapper runs in, looked up once at start
+	tmuxTarget   string
+	tmuxClickURL string
+
+	// Name of the wrapped CLI in titles, and terminal titles that only name it
+	appName       string
+	ignoredTitles []string
+	// Text around the context in titles, with "{app}" for appName
+	titlePrefix string
+	titleSuffix string
+
+	// Identifier of the session, set on every notification
+	session string
+
+	// Set when running over SSH so notifications identify the machine
+	hostname string
+	username string
+	showUser bool
+
+	// Looks up "repo@branch" for a directory, refreshed lazily
+	repoLabel     func(dir string) string
+	mu            sync.Mutex
+	cachedRepo    string
+	repoCheckedAt time.Time
 }
 
 // NewContextNotifier creates a new context notifier
@@ -22,24 +58,116 @@ func NewContextNotifier(underlying Notifier, terminalInfo func() string) *Contex
 		cwdBasename = filepath.Base(cwd)
 	}
 
-	return &ContextNotifier{
-		underlying:   underlying,
-		cwdBasename:  cwdBasename,
-		terminalInfo: terminalInfo,
+	cn := &ContextNotifier{
+		underlying:    underlying,
+		cwd:           cwd,
+		cwdBasename:   cwdBasename,
+		terminalInfo:  terminalInfo,
+		tmuxTarget:    tmuxTarget(),
+		repoLabel:     gitRepoLabel,
+		appName:       "Gemini CLI",
+		ignoredTitles: []string{"gemini"},
+		titlePrefix:   "{app}: ",
 	}
+
+	if os.Getenv("SSH_CONNECTION") != "" {
+		cn.hostname = shortHostname()
+		if u, err := user.Current(); err == nil {
+			cn.username = u.Username
+		}
+	}
+
+	return cn
+}
+
+// SetTitleRules sets the name of the wrapped CLI that prefixes notification
+// titles, and the terminal titles that only name the CLI and are left out
+func (cn *ContextNotifier) SetTitleRules(appName string, ignoredTitles []string) {
+	cn.appName = appName
+	cn.ignoredTitles = ignoredTitles
+}
+
+// SetTitleAffixes sets the text put before and after the context in
+// notification titles; "{app}" in either is replaced with the name of the
+// wrapped CLI
+func (cn *ContextNotifier) SetTitleAffixes(prefix, suffix string) {
+	cn.titlePrefix = prefix
+	cn.titleSuffix = suffix
+}
+
+// SetSession sets the identifier of the session that notifications are
+// marked with
+func (cn *ContextNotifier) SetSession(id string) {
+	cn.session = id
+}
+
+// SetShowUser includes the user name (user@host) in the title of
+// notifications sent over SSH
+func (cn *ContextNotifier) SetShowUser(show bool) {
+	cn.showUser = show
+}
+
+// shortHostname returns the hostname without its domain
+func shortHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	if i := strings.IndexByte(hostname, '.'); i > 0 {
+		hostname = hostname[:i]
+	}
+	return hostname
+}
+
+// hostLabel returns "host" or "user@host" when running over SSH
+func (cn *ContextNotifier) hostLabel() string {
+	if cn.hostname == "" {
+		return ""
+	}
+	if cn.showUser && cn.username != "" {
+		return cn.username + "@" + cn.hostname
+	}
+	return cn.hostname
+}
+
+// repo returns the cached "repo@branch" label, looking it up again once it
+// is older than gitRefreshInterval
+func (cn *ContextNotifier) repo() string {
+	if cn.repoLabel == nil || cn.cwd == "" {
+		return ""
+	}
+
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+
+	if cn.repoCheckedAt.IsZero() || time.Since(cn.repoCheckedAt) >= gitRefreshInterval {
+		cn.cachedRepo = cn.repoLabel(cn.cwd)
+		cn.repoCheckedAt = time.Now()
+	}
+	return cn.cachedRepo
+}
+
+// SetTmuxClickURL sets a URL template opened when a notification is tapped
+// inside tmux. "{target}" is replaced with the session:window.pane and
+// "{command}" with the matching `tmux switch-client` command, both URL-escaped.
+func (cn *ContextNotifier) SetTmuxClickURL(template string) {
+	cn.tmuxClickURL = template
 }
 
 // Send implements the Notifier interface
 func (cn *ContextNotifier) Send(notification Notification) error {
-	// Add context to title
+	// Add context to title, preferring repo@branch over the directory name
 	context := cn.cwdBasename
+	if repo := cn.repo(); repo != "" {
+		context = repo
+	}
 
 	// Get terminal title if available
 	if cn.terminalInfo != nil {
 		if title := cn.terminalInfo(); title != "" {
-			// Parse out the Gemini icon and clean up the title
+			// Parse out status icons and clean up the title
 			cleanTitle := cn.cleanTerminalTitle(title)
-			if cleanTitle != "" && cleanTitle != "gemini" {
+			if cleanTitle != "" && !cn.isIgnoredTitle(cleanTitle) {
 				if context != "" {
 					context = context + " - " + cleanTitle
 				} else {
@@ -49,60 +177,65 @@ func (cn *ContextNotifier) Send(notification Notification) error {
 		}
 	}
 
-	// Replace notification title with context if available
-	if context != "" {
-		notification.Title = "Gemini CLI: " + context
+	// Prefix the remote machine, like a shell prompt (user@host:dir)
+	if host := cn.hostLabel(); host != "" {
+		if context != "" {
+			context = host + ":" + context
+		} else {
+			context = host
+		}
+	}
+
+	// Identify the tmux pane so it can be found quickly
+	if target := cn.tmuxTarget; target != "" {
+		if context != "" {
+			context = context + " [" + target + "]"
+		} else {
+			context = "[" + target + "]"
+		}
+		if cn.tmuxClickURL != "" && notification.Click == "" {
+			notification.Click = strings.NewReplacer(
+				"{target}", url.QueryEscape(target),
+				"{command}", url.QueryEscape(tmuxSwitchCommand(target)),
+			).Replace(cn.tmuxClickURL)
+		}
+	}
+
+	// Replace notification title with context if available, unless the
+	// title was chosen on purpose
+	if context != "" && !notification.KeepTitle {
+		notification.Title = cn.expandApp(cn.titlePrefix) + context + cn.expandApp(cn.titleSuffix)
+	}
+	if notification.Session == "" {
+		notification.Session = cn.session
 	}
 
 	// Forward to underlying notifier
 	return cn.underlying.Send(notification)
 }
 
-// cleanTerminalTitle removes the Gemini icon and cleans up the title
-func (cn *ContextNotifier) cleanTerminalTitle(title string) string {
-	// Common Gemini icon patterns (various Unicode representations)
-	geminiIcons := []string{
-		"✅",  // Checkmark
-		"🤖",  // Robot emoji sometimes used
-		"⚡",  // Lightning bolt
-		"✨",  // Sparkles
-		"🔮",  // Crystal ball
-		"💫",  // Dizzy symbol
-		"☁️", // Cloud
-		"🌟",  // Star
-		"💎",  // Diamond for Gemini
-		"🔆",  // Bright button
-	}
-
-	// Remove any of the Gemini icons from the beginning
-	cleaned := title
-	for _, icon := range geminiIcons {
-		cleaned = strings.TrimPrefix(cleaned, icon)
-		cleaned = strings.TrimPrefix(cleaned, icon+" ")
-	}
-
-	// Remove garbage/control characters at the beginning
-	// This handles cases like "ÓÇ∂‚ú≥ Test Coverage"
-	runes := []rune(cleaned)
-	startIdx := 0
-
-	// Skip any non-printable or garbage characters at the start
-	for startIdx < len(runes) {
-		r := runes[startIdx]
-		// Keep ASCII letters, numbers, and common punctuation
-		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') ||
-			(r >= '0' && r <= '9') || r == ' ' || r == '-' ||
-			r == '_' || r == '.' || r == '/' || r == '[' || r == ']' {
-			break
+// expandApp replaces "{app}" in a title prefix or suffix with the name of
+// the wrapped CLI
+func (cn *ContextNotifier) expandApp(s string) string {
+	return strings.ReplaceAll(s, "{app}", cn.appName)
+}
+
+// isIgnoredTitle reports whether a cleaned title only names the wrapped CLI
+func (cn *ContextNotifier) isIgnoredTitle(title string) bool {
+	for _, ignored := range cn.ignoredTitles {
+		if strings.EqualFold(title, ignored) {
+			return true
 		}
-		startIdx++
 	}
+	return false
+}
 
-	if startIdx < len(runes) {
-		cleaned = string(runes[startIdx:])
-	} else {
-		cleaned = ""
-	}
+// cleanTerminalTitle removes status icons and cleans up the title
+func (cn *ContextNotifier) cleanTerminalTitle(title string) string {
+	// Drop status icons, spinners and powerline glyphs in front of the text
+	cleaned := strings.TrimLeftFunc(ansi.SanitizeLine(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("[/._-", r)
+	})
 
 	return strings.TrimSpace(cleaned)
 }
\ No newline at end of file
@@ -0,0 +1,328 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recordingNotifier records sent notifications
+type recordingNotifier struct {
+	sent []Notification
+}
+
+func (r *recordingNotifier) Send(n Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func TestContextNotifierTmux(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+
+	t.Run("adds pane to title", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		cn := NewContextNotifier(rec, nil)
+		cn.repoLabel = nil
+		cn.cwdBasename = "project"
+		cn.tmuxTarget = "work:2.1"
+
+		_ = cn.Send(Notification{Title: "Gemini needs attention"})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: project [work:2.1]" {
+			t.Errorf("unexpected title %q", got)
+		}
+		if rec.sent[0].Click != "" {
+			t.Errorf("expected no click URL, got %q", rec.sent[0].Click)
+		}
+	})
+
+	t.Run("expands click URL template", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		cn := NewContextNotifier(rec, nil)
+		cn.repoLabel = nil
+		cn.tmuxTarget = "work:2.1"
+		cn.SetTmuxClickURL("handler://run?target={target}&cmd={command}")
+
+		_ = cn.Send(Notification{})
+
+		expected := "handler://run?target=work%3A2.1&cmd=tmux+switch-client+-t+work%3A2.1"
+		if got := rec.sent[0].Click; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+
+	t.Run("outside tmux", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		cn := NewContextNotifier(rec, nil)
+		cn.repoLabel = nil
+		cn.cwdBasename = "project"
+		cn.tmuxTarget = ""
+		cn.SetTmuxClickURL("handler://{target}")
+
+		_ = cn.Send(Notification{})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: project" {
+			t.Errorf("unexpected title %q", got)
+		}
+		if rec.sent[0].Click != "" {
+			t.Errorf("expected no click URL, got %q", rec.sent[0].Click)
+		}
+	})
+}
+
+func TestContextNotifierTmuxLookup(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + calls + "\necho work:2.1\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	t.Setenv("TMUX_PANE", "%3")
+
+	rec := &recordingNotifier{}
+	cn := NewContextNotifier(rec, nil)
+	cn.repoLabel = nil
+	cn.cwdBasename = "project"
+	for i := 0; i < 3; i++ {
+		_ = cn.Send(Notification{})
+	}
+
+	if got := rec.sent[2].Title; got != "Gemini CLI: project [work:2.1]" {
+		t.Errorf("unexpected title %q", got)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "display-message -p -t %3 #S:#I.#P\n" {
+		t.Errorf("expected tmux to be asked about our pane once, got %q", got)
+	}
+
+	t.Setenv("TMUX", "")
+	if cn := NewContextNotifier(rec, nil); cn.tmuxTarget != "" {
+		t.Errorf("expected no pane outside tmux, got %q", cn.tmuxTarget)
+	}
+}
+
+func TestContextNotifierSSH(t *testing.T) {
+	newNotifier := func(rec *recordingNotifier) *ContextNotifier {
+		cn := NewContextNotifier(rec, nil)
+		cn.repoLabel = nil
+		cn.cwdBasename = "project"
+		cn.tmuxTarget = ""
+		cn.hostname = "devbox"
+		cn.username = "alice"
+		return cn
+	}
+
+	t.Run("prefixes hostname", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		_ = newNotifier(rec).Send(Notification{})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: devbox:project" {
+			t.Errorf("unexpected title %q", got)
+		}
+	})
+
+	t.Run("prefixes user@host when enabled", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		cn := newNotifier(rec)
+		cn.SetShowUser(true)
+		_ = cn.Send(Notification{})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: alice@devbox:project" {
+			t.Errorf("unexpected title %q", got)
+		}
+	})
+
+	t.Run("detects ssh session", func(t *testing.T) {
+		t.Setenv("SSH_CONNECTION", "10.0.0.1 50000 10.0.0.2 22")
+		if cn := NewContextNotifier(&recordingNotifier{}, nil); cn.hostname == "" {
+			t.Error("expected hostname to be set over SSH")
+		}
+
+		t.Setenv("SSH_CONNECTION", "")
+		if cn := NewContextNotifier(&recordingNotifier{}, nil); cn.hostname != "" {
+			t.Errorf("expected no hostname outside SSH, got %q", cn.hostname)
+		}
+	})
+}
+
+func TestContextNotifierGit(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+
+	newNotifier := func(rec *recordingNotifier, label *string, lookups *int) *ContextNotifier {
+		cn := NewContextNotifier(rec, nil)
+		cn.cwd = "/home/me/project/sub"
+		cn.cwdBasename = "sub"
+		cn.tmuxTarget = ""
+		cn.repoLabel = func(dir string) string {
+			*lookups++
+			return *label
+		}
+		return cn
+	}
+
+	t.Run("prefers repo@branch over directory", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		label, lookups := "project@main", 0
+		_ = newNotifier(rec, &label, &lookups).Send(Notification{})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: project@main" {
+			t.Errorf("unexpected title %q", got)
+		}
+	})
+
+	t.Run("falls back to directory outside a repository", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		label, lookups := "", 0
+		_ = newNotifier(rec, &label, &lookups).Send(Notification{})
+
+		if got := rec.sent[0].Title; got != "Gemini CLI: sub" {
+			t.Errorf("unexpected title %q", got)
+		}
+	})
+
+	t.Run("refreshes lazily", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		label, lookups := "project@main", 0
+		cn := newNotifier(rec, &label, &lookups)
+
+		_ = cn.Send(Notification{})
+		label = "project@feature"
+		_ = cn.Send(Notification{})
+		if lookups != 1 {
+			t.Errorf("expected a single lookup within the refresh interval, got %d", lookups)
+		}
+		if got := rec.sent[1].Title; got != "Gemini CLI: project@main" {
+			t.Errorf("expected cached branch, got %q", got)
+		}
+
+		cn.repoCheckedAt = time.Now().Add(-gitRefreshInterval)
+		_ = cn.Send(Notification{})
+		if got := rec.sent[2].Title; got != "Gemini CLI: project@feature" {
+			t.Errorf("expected refreshed branch, got %q", got)
+		}
+	})
+}
+
+func TestContextNotifierTitleRules(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"✳ Claude Code", "Claude Code: project"},
+		{"✳ Fix login bug", "Claude Code: project - Fix login bug"},
+		{"gemini", "Claude Code: project - gemini"},
+	}
+
+	for _, tt := range tests {
+		rec := &recordingNotifier{}
+		cn := NewContextNotifier(rec, func() string { return tt.title })
+		cn.repoLabel = nil
+		cn.tmuxTarget = ""
+		cn.cwdBasename = "project"
+		cn.SetTitleRules("Claude Code", []string{"claude", "claude code"})
+
+		_ = cn.Send(Notification{})
+
+		if got := rec.sent[0].Title; got != tt.expected {
+			t.Errorf("title %q: expected %q, got %q", tt.title, tt.expected, got)
+		}
+	}
+}
+
+func TestContextNotifierTitleAffixes(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+
+	tests := []struct {
+		prefix, suffix string
+		expected       string
+	}{
+		{"{app}: ", "", "Gemini CLI: project"},
+		{"[build-box] {app}: ", "", "[build-box] Gemini CLI: project"},
+		{"", " ({app})", "project (Gemini CLI)"},
+		{"", "", "project"},
+	}
+
+	for _, tt := range tests {
+		rec := &recordingNotifier{}
+		cn := NewContextNotifier(rec, nil)
+		cn.repoLabel = nil
+		cn.tmuxTarget = ""
+		cn.cwdBasename = "project"
+		cn.SetTitleAffixes(tt.prefix, tt.suffix)
+
+		_ = cn.Send(Notification{Title: "Gemini needs attention"})
+
+		if got := rec.sent[0].Title; got != tt.expected {
+			t.Errorf("prefix %q, suffix %q: expected %q, got %q", tt.prefix, tt.suffix, tt.expected, got)
+		}
+	}
+}
+
+func TestContextNotifierSession(t *testing.T) {
+	rec := &recordingNotifier{}
+	cn := NewContextNotifier(rec, nil)
+	cn.repoLabel = nil
+	cn.tmuxTarget = ""
+	cn.SetSession("my-api")
+
+	_ = cn.Send(Notification{Title: "t"})
+	_ = cn.Send(Notification{Title: "t", Session: "other"})
+
+	if rec.sent[0].Session != "my-api" {
+		t.Errorf("expected the session to be set, got %q", rec.sent[0].Session)
+	}
+	if rec.sent[1].Session != "other" {
+		t.Errorf("expected an existing session to be kept, got %q", rec.sent[1].Session)
+	}
+}
+
+func TestContextNotifierKeepTitle(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+	rec := &recordingNotifier{}
+	cn := NewContextNotifier(rec, nil)
+	cn.repoLabel = nil
+	cn.tmuxTarget = ""
+	cn.cwdBasename = "project"
+
+	_ = cn.Send(Notification{Title: "Gemini needs attention"})
+	_ = cn.Send(Notification{Title: "devbox: waiting (main)", KeepTitle: true})
+
+	if got := rec.sent[0].Title; got != "Gemini CLI: project" {
+		t.Errorf("expected the context as title, got %q", got)
+	}
+	if got := rec.sent[1].Title; got != "devbox: waiting (main)" {
+		t.Errorf("expected the title to be kept, got %q", got)
+	}
+}
+
+func TestCleanTerminalTitle(t *testing.T) {
+	cn := &ContextNotifier{}
+
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"✨ Test Coverage", "Test Coverage"},
+		{"☁️ Deploy", "Deploy"},
+		{"✳ Refactor", "Refactor"},
+		{"\033[1m💎\033[0m Build\x07", "Build"},
+		{"日本語のタイトル", "日本語のタイトル"},
+		{"gemini - project\nsecond line", "gemini - project second line"},
+		{"✅", ""},
+	}
+
+	for _, tt := range tests {
+		if got := cn.cleanTerminalTitle(tt.in); got != tt.expected {
+			t.Errorf("cleanTerminalTitle(%q) = %q, want %q", tt.in, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestControlVerifier(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	newVerifier := func() *ControlVerifier {
+		v := NewControlVerifier("s3cret", 15*time.Minute)
+		v.now = func() time.Time { return now }
+		return v
+	}
+
+	t.Run("accepts signed message", func(t *testing.T) {
+		msg, err := SignControlMessage("s3cret", "reply Hello World", now)
+		if err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+		command, err := newVerifier().Verify(msg)
+		if err != nil {
+			t.Fatalf("verify failed: %v", err)
+		}
+		if command != "reply Hello World" {
+			t.Errorf("expected 'reply Hello World', got %q", command)
+		}
+	})
+
+	t.Run("rejects unsigned message", func(t *testing.T) {
+		if _, err := newVerifier().Verify("quiet on"); err == nil {
+			t.Error("expected error for unsigned message")
+		}
+	})
+
+	t.Run("rejects wrong secret", func(t *testing.T) {
+		msg, _ := SignControlMessage("guess", "kill", now)
+		if _, err := newVerifier().Verify(msg); err == nil {
+			t.Error("expected error for wrong secret")
+		}
+	})
+
+	t.Run("rejects tampered command", func(t *testing.T) {
+		msg, _ := SignControlMessage("s3cret", "status", now)
+		tampered := strings.TrimSuffix(msg, "status") + "kill"
+		if _, err := newVerifier().Verify(tampered); err == nil {
+			t.Error("expected error for tampered command")
+		}
+	})
+
+	t.Run("rejects expired message", func(t *testing.T) {
+		msg, _ := SignControlMessage("s3cret", "status", now.Add(-time.Hour))
+		if _, err := newVerifier().Verify(msg); err == nil {
+			t.Error("expected error for expired message")
+		}
+	})
+
+	t.Run("rejects replayed nonce", func(t *testing.T) {
+		v := newVerifier()
+		msg, _ := SignControlMessage("s3cret", "interrupt", now)
+		if _, err := v.Verify(msg); err != nil {
+			t.Fatalf("first verify failed: %v", err)
+		}
+		if _, err := v.Verify(msg); err == nil {
+			t.Error("expected error for replayed message")
+		}
+	})
+
+	t.Run("scoped message", func(t *testing.T) {
+		v := newVerifier()
+		v.SetScope("my-api/1a2b3c4d")
+		msg, err := SignScopedControlMessage("s3cret", "my-api/1a2b3c4d", "kill", now)
+		if err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+		if command, err := v.Verify(msg); err != nil || command != "kill" {
+			t.Errorf("expected kill, got %q, %v", command, err)
+		}
+
+		// Another session, or the same one after a restart, has another scope
+		other := newVerifier()
+		other.SetScope("my-api/5e6f7a8b")
+		for _, verifier := range []*ControlVerifier{other, newVerifier()} {
+			msg, _ := SignScopedControlMessage("s3cret", "my-api/1a2b3c4d", "kill", now)
+			if _, err := verifier.Verify(msg); err == nil {
+				t.Error("expected a message signed for another scope to be rejected")
+			}
+		}
+
+		// Unscoped messages, e.g. from scripts, are still accepted
+		msg, _ = SignControlMessage("s3cret", "status", now)
+		if _, err := v.Verify(msg); err != nil {
+			t.Errorf("expected an unscoped message to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("scoped signature is not an unscoped one", func(t *testing.T) {
+		msg, _ := SignScopedControlMessage("s3cret", "1700000000", "kill", now)
+		parts := strings.SplitN(msg, " ", 4)
+		// Moving the scope into the command must not make a valid message
+		forged := strings.Join([]string{parts[0], parts[1], parts[2], "1700000000 kill"}, " ")
+		if _, err := newVerifier().Verify(forged); err == nil {
+			t.Error("expected a forged unscoped message to be rejected")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+//go:build darwin
+// +build darwin
+
+package notification
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// terminalBundleIDs maps $TERM_PROGRAM to the bundle ID of the terminal app
+var terminalBundleIDs = map[string]string{
+	"Apple_Terminal": "com.apple.Terminal",
+	"iTerm.app":      "com.googlecode.iterm2",
+	"WezTerm":        "com.github.wez.wezterm",
+	"ghostty":        "com.mitchellh.ghostty",
+	"vscode":         "com.microsoft.VSCode",
+	"Hyper":          "co.zeit.hyper",
+	"Tabby":          "org.tabby",
+}
+
+// detectDesktopCommand prefers terminal-notifier, which shows the terminal's
+// icon and brings the terminal to the front when clicked, over osascript
+func detectDesktopCommand() desktopCommandFunc {
+	path, err := exec.LookPath("terminal-notifier")
+	if err != nil {
+		return osascriptCommand
+	}
+
+	sender := terminalBundleID()
+	return func(title, message string) (*exec.Cmd, error) {
+		// terminal-notifier reads the message from stdin if it is empty
+		if message == "" {
+			message = " "
+		}
+		args := []string{"-title", title, "-message", message}
+		// Posing as the terminal shows its icon and activates it on click
+		if sender != "" {
+			args = append(args, "-sender", sender)
+		}
+		// #nosec G204 -- Title and message are passed as separate arguments, not through a shell
+		return exec.Command(path, args...), nil
+	}
+}
+
+// terminalBundleID returns the bundle ID of the terminal app we run in, if known
+func terminalBundleID() string {
+	// Set by macOS for processes started from an app
+	if id := os.Getenv("__CFBundleIdentifier"); id != "" {
+		return id
+	}
+	return terminalBundleIDs[os.Getenv("TERM_PROGRAM")]
+}
+
+// osascriptCommand returns the command that shows a desktop notification
+// with AppleScript
+func osascriptCommand(title, message string) (*exec.Cmd, error) {
+	script := fmt.Sprintf("display notification %s with title %s",
+		appleScriptString(message), appleScriptString(title))
+
+	// #nosec G204 -- Text is escaped as AppleScript string literals
+	return exec.Command("osascript", "-e", script), nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
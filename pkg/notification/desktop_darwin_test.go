@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCommand puts an executable called name in a directory of its own and
+// makes it the only one on $PATH
+func fakeCommand(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return path
+}
+
+func TestTerminalBundleID(t *testing.T) {
+	tests := []struct {
+		name         string
+		bundleID     string
+		termProgram  string
+		wantBundleID string
+	}{
+		{"from the app", "com.example.Term", "iTerm.app", "com.example.Term"},
+		{"from TERM_PROGRAM", "", "iTerm.app", "com.googlecode.iterm2"},
+		{"unknown terminal", "", "SomeTerm", ""},
+		{"no terminal", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("__CFBundleIdentifier", tt.bundleID)
+			t.Setenv("TERM_PROGRAM", tt.termProgram)
+			if got := terminalBundleID(); got != tt.wantBundleID {
+				t.Errorf("expected %q, got %q", tt.wantBundleID, got)
+			}
+		})
+	}
+}
+
+func TestDetectDesktopCommandTerminalNotifier(t *testing.T) {
+	t.Setenv("__CFBundleIdentifier", "")
+	t.Setenv("TERM_PROGRAM", "Apple_Terminal")
+	path := fakeCommand(t, "terminal-notifier")
+
+	cmd, err := detectDesktopCommand()("title", "")
+	if err != nil {
+		t.Fatalf("expected terminal-notifier, got %v", err)
+	}
+	if cmd.Path != path {
+		t.Errorf("expected %s, got %s", path, cmd.Path)
+	}
+	// An empty message would make terminal-notifier wait for stdin
+	if got := strings.Join(cmd.Args[1:], " "); got != "-title title -message   -sender com.apple.Terminal" {
+		t.Errorf("unexpected arguments %q", got)
+	}
+}
+
+func TestDetectDesktopCommandOsascript(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	cmd, err := detectDesktopCommand()("say \"hi\"", `C:\path`)
+	if err != nil {
+		t.Fatalf("expected osascript, got %v", err)
+	}
+	want := `display notification "C:\\path" with title "say \"hi\""`
+	if len(cmd.Args) != 3 || cmd.Args[0] != "osascript" || cmd.Args[2] != want {
+		t.Errorf("expected osascript -e %q, got %q", want, cmd.Args)
+	}
+}
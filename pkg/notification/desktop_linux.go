@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+package notification
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// detectDesktopCommand returns the notify-send command; it is looked up on
+// every notification, so it can be installed while the wrapper runs
+func detectDesktopCommand() desktopCommandFunc {
+	return notifySendCommand
+}
+
+// notifySendCommand returns the command that shows a desktop notification
+func notifySendCommand(title, message string) (*exec.Cmd, error) {
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return nil, fmt.Errorf("notify-send not found: %w", err)
+	}
+
+	// #nosec G204 -- Title and message are passed as separate arguments, not through a shell
+	return exec.Command(path, "--app-name=gemini-cli-ntfy", "--", title, message), nil
+}
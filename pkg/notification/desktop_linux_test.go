@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCommand puts an executable called name in a directory of its own and
+// makes it the only one on $PATH
+func fakeCommand(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return path
+}
+
+func TestDetectDesktopCommandNotifySend(t *testing.T) {
+	command := detectDesktopCommand()
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := command("title", "message"); err == nil {
+		t.Error("expected an error without notify-send")
+	}
+
+	// It is looked up again for every notification
+	path := fakeCommand(t, "notify-send")
+	cmd, err := command("title", "-message")
+	if err != nil {
+		t.Fatalf("expected notify-send to be found, got %v", err)
+	}
+	if cmd.Path != path {
+		t.Errorf("expected %s, got %s", path, cmd.Path)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "--app-name=gemini-cli-ntfy -- title -message" {
+		t.Errorf("unexpected arguments %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// desktopCommandFunc returns the command that shows a desktop notification
+type desktopCommandFunc func(title, message string) (*exec.Cmd, error)
+
+// DesktopNotifier shows notifications on the local desktop
+type DesktopNotifier struct {
+	timeout time.Duration
+	command desktopCommandFunc
+}
+
+// NewDesktopNotifier creates a new desktop notifier, using the richest
+// notification tool found on this machine
+func NewDesktopNotifier() *DesktopNotifier {
+	return &DesktopNotifier{
+		timeout: 5 * time.Second,
+		command: detectDesktopCommand(),
+	}
+}
+
+// Send implements the Notifier interface
+func (d *DesktopNotifier) Send(notification Notification) error {
+	cmd, err := d.command(notification.Title, notification.Message)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to show desktop notification: %w", err)
+	}
+
+	// Don't let a hung notification daemon block the caller forever
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("desktop notification failed: %w", err)
+		}
+		return nil
+	case <-time.After(d.timeout):
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("desktop notification timed out")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package notification
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// detectDesktopCommand reports that this platform has no desktop
+// notifications
+func detectDesktopCommand() desktopCommandFunc {
+	return func(string, string) (*exec.Cmd, error) {
+		return nil, fmt.Errorf("desktop notifications unsupported on %s", runtime.GOOS)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build windows
+// +build windows
+
+package notification
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// toastScript shows a toast with the BurntToast module if it is installed,
+// and through the WinRT API otherwise. The text is passed in environment
+// variables so it never needs quoting.
+const toastScript = `$title = $env:GEMINI_NOTIFY_TOAST_TITLE
+$message = $env:GEMINI_NOTIFY_TOAST_MESSAGE
+if (Get-Module -ListAvailable -Name BurntToast) {
+  Import-Module BurntToast
+  New-BurntToastNotification -Text $title, $message -UniqueIdentifier 'gemini-cli-ntfy'
+} else {
+  [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+  [Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+  $xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+  $xml.LoadXml('<toast><visual><binding template="ToastGeneric"><text>' + [Security.SecurityElement]::Escape($title) + '</text><text>' + [Security.SecurityElement]::Escape($message) + '</text></binding></visual></toast>')
+  $toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+  # Toasts need a registered app ID; borrow PowerShell's
+  [Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)
+}
+`
+
+// detectDesktopCommand finds PowerShell to show toasts with. Windows
+// PowerShell comes first, since only it can load the WinRT types; PowerShell
+// 7 can still use BurntToast.
+func detectDesktopCommand() desktopCommandFunc {
+	for _, name := range []string{"powershell.exe", "pwsh.exe"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return func(title, message string) (*exec.Cmd, error) {
+				// #nosec G204 -- The script is constant; the text is passed in the environment
+				cmd := exec.Command(path, "-NoProfile", "-NonInteractive", "-Command", toastScript)
+				cmd.Env = append(os.Environ(),
+					"GEMINI_NOTIFY_TOAST_TITLE="+title,
+					"GEMINI_NOTIFY_TOAST_MESSAGE="+message,
+				)
+				return cmd, nil
+			}
+		}
+	}
+
+	return func(string, string) (*exec.Cmd, error) {
+		return nil, fmt.Errorf("PowerShell not found")
+	}
+}
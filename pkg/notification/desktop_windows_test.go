@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectDesktopCommandPowerShell(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := detectDesktopCommand()("title", "message"); err == nil {
+		t.Error("expected an error without PowerShell")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pwsh.exe")
+	if err := os.WriteFile(path, nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	cmd, err := detectDesktopCommand()("title", "it's \"done\"")
+	if err != nil {
+		t.Fatalf("expected PowerShell to be found, got %v", err)
+	}
+	if cmd.Path != path {
+		t.Errorf("expected %s, got %s", path, cmd.Path)
+	}
+	// The text is passed in the environment rather than in the script
+	env := map[string]bool{}
+	for _, e := range cmd.Env {
+		env[e] = true
+	}
+	if !env["GEMINI_NOTIFY_TOAST_TITLE=title"] || !env["GEMINI_NOTIFY_TOAST_MESSAGE=it's \"done\""] {
+		t.Errorf("expected the title and message in the environment")
+	}
+}
@@ -0,0 +1,175 @@
+package notification
+
+import (
+	"sync"
+	"time"
+)
+
+// EscalatingNotifier wraps another notifier and sends notifications of
+// critical types again until they are acknowledged: each reminder one
+// priority higher, up to urgent, and twice as long after the previous one,
+// up to a maximum number of reminders. Only the latest critical
+// notification is pending; a newer one takes its place.
+type EscalatingNotifier struct {
+	underlying Notifier
+	types      map[string]bool
+	after      time.Duration
+	max        int
+	messages   Messages
+	// suppressed reports whether reminders should be dropped, e.g. in
+	// quiet mode
+	suppressed func() bool
+
+	mu      sync.Mutex
+	pending *escalation
+	// done is closed when nothing is pending any more
+	done chan struct{}
+}
+
+// escalation is a notification waiting for acknowledgement
+type escalation struct {
+	notification Notification
+	// Reminders sent so far
+	sent  int
+	timer *time.Timer
+}
+
+// NewEscalatingNotifier creates a notifier that escalates notifications of
+// the given types, sending the first reminder after the given delay and at
+// most max reminders
+func NewEscalatingNotifier(underlying Notifier, types []string, after time.Duration, max int) *EscalatingNotifier {
+	enabled := make(map[string]bool, len(types))
+	for _, t := range types {
+		enabled[t] = true
+	}
+	done := make(chan struct{})
+	close(done)
+	return &EscalatingNotifier{
+		underlying: underlying,
+		types:      enabled,
+		after:      after,
+		max:        max,
+		messages:   DefaultMessages(),
+		done:       done,
+	}
+}
+
+// SetMessages sets the catalog the reminder text is taken from
+func (en *EscalatingNotifier) SetMessages(messages Messages) {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	en.messages = messages
+}
+
+// SetSuppressedFunc sets a function that reports whether reminders should
+// be dropped; a pending notification is then given up
+func (en *EscalatingNotifier) SetSuppressedFunc(suppressed func() bool) {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	en.suppressed = suppressed
+}
+
+// Send implements the Notifier interface
+func (en *EscalatingNotifier) Send(notification Notification) error {
+	if en.types[notification.Pattern] && en.max > 0 {
+		en.mu.Lock()
+		en.stopLocked()
+		e := &escalation{notification: notification}
+		e.timer = time.AfterFunc(en.after, func() { en.remind(e) })
+		en.pending = e
+		en.done = make(chan struct{})
+		en.mu.Unlock()
+	}
+
+	return en.underlying.Send(notification)
+}
+
+// remind sends the next reminder of an escalation, unless it was
+// acknowledged or replaced in the meantime
+func (en *EscalatingNotifier) remind(e *escalation) {
+	en.mu.Lock()
+	if en.pending != e {
+		en.mu.Unlock()
+		return
+	}
+	if en.suppressed != nil && en.suppressed() {
+		en.stopLocked()
+		en.mu.Unlock()
+		return
+	}
+
+	e.sent++
+	reminder := e.notification
+	reminder.Priority = priorityOf(e.notification) + e.sent
+	if reminder.Priority > PriorityUrgent {
+		reminder.Priority = PriorityUrgent
+	}
+	reminder.Time = time.Now()
+	note := en.messages.Get("escalation.reminder", e.sent, en.max)
+	if reminder.Message != "" {
+		note = reminder.Message + "\n" + note
+	}
+	reminder.Message = note
+
+	last := e.sent >= en.max
+	if !last {
+		e.timer = time.AfterFunc(en.after<<e.sent, func() { en.remind(e) })
+	}
+	en.mu.Unlock()
+
+	_ = en.underlying.Send(reminder)
+
+	// Done only once the last reminder went out
+	if last {
+		en.mu.Lock()
+		if en.pending == e {
+			en.stopLocked()
+		}
+		en.mu.Unlock()
+	}
+}
+
+// Acknowledge stops reminding of the pending notification, and reports
+// whether one was pending
+func (en *EscalatingNotifier) Acknowledge() bool {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	pending := en.pending != nil
+	en.stopLocked()
+	return pending
+}
+
+// Pending returns the type of the notification waiting for acknowledgement,
+// or "" if there is none
+func (en *EscalatingNotifier) Pending() string {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	if en.pending == nil {
+		return ""
+	}
+	return en.pending.notification.Pattern
+}
+
+// Done returns a channel that is closed once no notification is pending,
+// because it was acknowledged or all reminders were sent
+func (en *EscalatingNotifier) Done() <-chan struct{} {
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	return en.done
+}
+
+// Close stops reminding
+func (en *EscalatingNotifier) Close() error {
+	en.Acknowledge()
+	return nil
+}
+
+// stopLocked gives up the pending notification
+func (en *EscalatingNotifier) stopLocked() {
+	if en.pending == nil {
+		return
+	}
+	en.pending.timer.Stop()
+	en.pending = nil
+	close(en.done)
+}
@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscalatingNotifier(t *testing.T) {
+	const after = 20 * time.Millisecond
+
+	t.Run("reminds louder until the maximum", func(t *testing.T) {
+		rec := &countingNotifier{}
+		en := NewEscalatingNotifier(rec, []string{"crash"}, after, 2)
+		defer func() { _ = en.Close() }()
+
+		_ = en.Send(Notification{Pattern: "crash", Message: "Gemini exited", Priority: PriorityHigh})
+		select {
+		case <-en.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the escalation to end after the maximum")
+		}
+
+		if got := rec.count(); got != 3 {
+			t.Fatalf("expected the notification and 2 reminders, got %d", got)
+		}
+		if rec.sent[1].Priority != PriorityUrgent || rec.sent[2].Priority != PriorityUrgent {
+			t.Errorf("expected reminders to be urgent, got %d and %d", rec.sent[1].Priority, rec.sent[2].Priority)
+		}
+		if !strings.HasPrefix(rec.sent[1].Message, "Gemini exited\n") || !strings.Contains(rec.sent[2].Message, "2 of 2") {
+			t.Errorf("expected the message with a reminder note, got %q and %q", rec.sent[1].Message, rec.sent[2].Message)
+		}
+		if en.Pending() != "" {
+			t.Errorf("expected nothing pending, got %q", en.Pending())
+		}
+	})
+
+	t.Run("raises the default priority one step at a time", func(t *testing.T) {
+		rec := &countingNotifier{}
+		en := NewEscalatingNotifier(rec, []string{"backstop"}, after, 1)
+		defer func() { _ = en.Close() }()
+
+		_ = en.Send(Notification{Pattern: "backstop"})
+		<-en.Done()
+		if got := rec.count(); got != 2 {
+			t.Fatalf("expected the notification and a reminder, got %d", got)
+		}
+		if rec.sent[1].Priority != PriorityHigh {
+			t.Errorf("expected the reminder at high priority, got %d", rec.sent[1].Priority)
+		}
+	})
+
+	t.Run("stops when acknowledged", func(t *testing.T) {
+		rec := &countingNotifier{}
+		en := NewEscalatingNotifier(rec, []string{"approval"}, after, 3)
+		defer func() { _ = en.Close() }()
+
+		_ = en.Send(Notification{Pattern: "approval"})
+		if en.Pending() != "approval" {
+			t.Fatalf("expected approval to be pending, got %q", en.Pending())
+		}
+		if !en.Acknowledge() {
+			t.Fatal("expected a pending notification to be acknowledged")
+		}
+		if en.Acknowledge() {
+			t.Error("expected nothing left to acknowledge")
+		}
+
+		time.Sleep(3 * after)
+		if got := rec.count(); got != 1 {
+			t.Errorf("expected no reminders, got %d notifications", got)
+		}
+	})
+
+	t.Run("leaves other types alone", func(t *testing.T) {
+		rec := &countingNotifier{}
+		en := NewEscalatingNotifier(rec, []string{"crash"}, after, 3)
+		defer func() { _ = en.Close() }()
+
+		_ = en.Send(Notification{Pattern: "context"})
+		time.Sleep(3 * after)
+		if got := rec.count(); got != 1 || en.Pending() != "" {
+			t.Errorf("expected only the notification, got %d with %q pending", got, en.Pending())
+		}
+	})
+
+	t.Run("gives up while suppressed", func(t *testing.T) {
+		rec := &countingNotifier{}
+		en := NewEscalatingNotifier(rec, []string{"crash"}, after, 3)
+		defer func() { _ = en.Close() }()
+		en.SetSuppressedFunc(func() bool { return true })
+
+		_ = en.Send(Notification{Pattern: "crash"})
+		select {
+		case <-en.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the escalation to be given up")
+		}
+		if got := rec.count(); got != 1 {
+			t.Errorf("expected no reminders, got %d notifications", got)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Lifecycle events that hook commands can be run on
+const (
+	EventSessionStart = "session_start"
+	EventIdle         = "idle"
+	EventPatternMatch = "pattern_match"
+	EventSessionEnd   = "session_end"
+)
+
+// hookCommandTimeout bounds how long a single hook command may run
+const hookCommandTimeout = 30 * time.Second
+
+// LifecycleEvent is passed as JSON on the stdin of hook commands
+type LifecycleEvent struct {
+	Event   string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Cwd     string    `json:"cwd,omitempty"`
+	PID     int       `json:"pid"`
+	Pattern string    `json:"pattern,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// ExitCode is set for session_end
+	ExitCode *int `json:"exit_code,omitempty"`
+	// OutputLog is set for session_end if the output was logged
+	OutputLog string `json:"output_log,omitempty"`
+	// UpdateAvailable is set for session_end to the CLI's message if it
+	// said a newer version is available
+	UpdateAvailable string `json:"update_available,omitempty"`
+	// PeakRSS and CPUSeconds are set for session_end to the most memory, in
+	// bytes, and the CPU time Gemini and its child processes used, if the
+	// resource watchdog ran
+	PeakRSS    uint64  `json:"peak_rss,omitempty"`
+	CPUSeconds float64 `json:"cpu_seconds,omitempty"`
+	// Error is set for a notification that could not be sent
+	Error string `json:"error,omitempty"`
+}
+
+// fill sets the fields that describe the wrapper itself, if unset
+func (e *LifecycleEvent) fill() {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Cwd == "" {
+		e.Cwd, _ = os.Getwd()
+	}
+	if e.PID == 0 {
+		e.PID = os.Getpid()
+	}
+}
+
+// EventHooks runs user commands on lifecycle events. Commands run through
+// the shell in the background, with the event as JSON on stdin.
+type EventHooks struct {
+	commands map[string][]string
+	timeout  time.Duration
+	wg       sync.WaitGroup
+}
+
+// NewEventHooks creates a hook runner. commands maps an event name to the
+// shell commands run on it.
+func NewEventHooks(commands map[string][]string) *EventHooks {
+	return &EventHooks{
+		commands: commands,
+		timeout:  hookCommandTimeout,
+	}
+}
+
+// Run starts the commands configured for the event
+func (eh *EventHooks) Run(event LifecycleEvent) {
+	commands := eh.commands[event.Event]
+	if len(commands) == 0 {
+		return
+	}
+
+	event.fill()
+	input, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	for _, command := range commands {
+		eh.wg.Add(1)
+		go func(command string) {
+			defer eh.wg.Done()
+			if err := eh.runCommand(command, event.Event, input); err != nil && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: %s hook %q: %v\n", event.Event, command, err)
+			}
+		}(command)
+	}
+}
+
+// runCommand runs a single hook command to completion
+func (eh *EventHooks) runCommand(command, event string, input []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), eh.timeout)
+	defer cancel()
+
+	// #nosec G204 -- Hook commands come from the user's own config file
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), "GEMINI_NOTIFY_EVENT="+event)
+	// Output is discarded, since it would corrupt the wrapped CLI's screen
+	return cmd.Run()
+}
+
+// Wait waits up to timeout for running hook commands to finish
+func (eh *EventHooks) Wait(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		eh.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// LifecycleNotifier wraps another notifier and reports an idle event for
+// backstop notifications and a pattern_match event for notifications raised
+// by a recognized event, such as a Gemini CLI hook. Events are reported even
+// when the notification itself is dropped further down, e.g. in quiet mode.
+type LifecycleNotifier struct {
+	underlying Notifier
+	report     func(LifecycleEvent)
+}
+
+// NewLifecycleNotifier creates a notifier that reports lifecycle events for
+// notifications, e.g. to EventHooks.Run
+func NewLifecycleNotifier(underlying Notifier, report func(LifecycleEvent)) *LifecycleNotifier {
+	return &LifecycleNotifier{
+		underlying: underlying,
+		report:     report,
+	}
+}
+
+// Send implements the Notifier interface
+func (ln *LifecycleNotifier) Send(notification Notification) error {
+	event := LifecycleEvent{
+		Time:    notification.Time,
+		Pattern: notification.Pattern,
+		Title:   notification.Title,
+		Message: notification.Message,
+	}
+	switch notification.Pattern {
+	case "startup":
+		// session_start is reported when the process starts, whether or not
+		// a startup notification is sent
+	case "backstop", "stalled":
+		event.Event = EventIdle
+		ln.report(event)
+	default:
+		event.Event = EventPatternMatch
+		ln.report(event)
+	}
+
+	return ln.underlying.Send(notification)
+}
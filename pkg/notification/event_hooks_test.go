@@ -0,0 +1,140 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readHookEvents returns the events recorded by recordingHooks
+func readHookEvents(t *testing.T, path string) []LifecycleEvent {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []LifecycleEvent
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var e LifecycleEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("invalid event JSON: %v", err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// recordingHooks appends each event's JSON and $GEMINI_NOTIFY_EVENT to files
+// in a temporary directory
+func recordingHooks(t *testing.T, events ...string) (*EventHooks, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	out := filepath.Join(dir, "events.json")
+	names := filepath.Join(dir, "names")
+
+	commands := make(map[string][]string)
+	for _, event := range events {
+		commands[event] = []string{
+			"cat >> '" + out + "'; echo >> '" + out + "'; echo \"$GEMINI_NOTIFY_EVENT\" >> '" + names + "'",
+		}
+	}
+	return NewEventHooks(commands), out, names
+}
+
+func TestEventHooksRun(t *testing.T) {
+	hooks, out, names := recordingHooks(t, EventSessionEnd)
+
+	code := 3
+	hooks.Run(LifecycleEvent{Event: EventSessionEnd, ExitCode: &code})
+	// No command is configured for this event
+	hooks.Run(LifecycleEvent{Event: EventSessionStart})
+	hooks.Wait(5 * time.Second)
+
+	events := readHookEvents(t, out)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Event != EventSessionEnd {
+		t.Errorf("unexpected event %q", e.Event)
+	}
+	if e.ExitCode == nil || *e.ExitCode != 3 {
+		t.Errorf("unexpected exit code %v", e.ExitCode)
+	}
+	if e.PID != os.Getpid() {
+		t.Errorf("expected pid %d, got %d", os.Getpid(), e.PID)
+	}
+	if e.Time.IsZero() || e.Cwd == "" {
+		t.Errorf("expected time and cwd to be filled in, got %+v", e)
+	}
+
+	data, err := os.ReadFile(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "session_end\n" {
+		t.Errorf("unexpected GEMINI_NOTIFY_EVENT %q", data)
+	}
+}
+
+func TestEventHooksTimeout(t *testing.T) {
+	hooks := NewEventHooks(map[string][]string{EventIdle: {"sleep 10"}})
+	hooks.timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	hooks.Run(LifecycleEvent{Event: EventIdle})
+	hooks.Wait(5 * time.Second)
+
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("hook was not stopped after its timeout, took %v", elapsed)
+	}
+}
+
+func TestLifecycleNotifier(t *testing.T) {
+	tests := []struct {
+		pattern string
+		event   string
+	}{
+		{"backstop", EventIdle},
+		{"approval", EventPatternMatch},
+		{"startup", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			hooks, out, _ := recordingHooks(t, EventIdle, EventPatternMatch)
+			rec := &recordingNotifier{}
+			ln := NewLifecycleNotifier(rec, hooks.Run)
+
+			_ = ln.Send(Notification{Title: "Title", Message: "Message", Pattern: tt.pattern})
+			hooks.Wait(5 * time.Second)
+
+			if len(rec.sent) != 1 {
+				t.Fatalf("expected the notification to be forwarded, got %d", len(rec.sent))
+			}
+
+			events := readHookEvents(t, out)
+			if tt.event == "" {
+				if len(events) != 0 {
+					t.Errorf("expected no hook to run, got %+v", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(events))
+			}
+			e := events[0]
+			if e.Event != tt.event || e.Pattern != tt.pattern || e.Title != "Title" || e.Message != "Message" {
+				t.Errorf("unexpected event %+v", e)
+			}
+		})
+	}
+}
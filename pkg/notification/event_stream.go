@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Events only written to the event stream
+const (
+	EventActivity     = "activity"
+	EventNotification = "notification"
+)
+
+// eventStreamBuffer is how many events can wait for a slow reader before
+// new ones are dropped
+const eventStreamBuffer = 256
+
+// activityInterval limits how often output activity is reported
+const activityInterval = time.Second
+
+// EventStream writes lifecycle events as newline-delimited JSON, e.g. to a
+// file descriptor another program reads. Events are written in the
+// background and dropped if the reader falls behind, so a stalled reader
+// never holds up the wrapped process.
+type EventStream struct {
+	events chan LifecycleEvent
+	done   chan struct{}
+
+	mu           sync.Mutex
+	closed       bool
+	lastActivity time.Time
+}
+
+// NewEventStream starts writing events to w
+func NewEventStream(w io.Writer) *EventStream {
+	es := &EventStream{
+		events: make(chan LifecycleEvent, eventStreamBuffer),
+		done:   make(chan struct{}),
+	}
+	go es.write(w)
+	return es
+}
+
+// write encodes events until the stream is closed
+func (es *EventStream) write(w io.Writer) {
+	defer close(es.done)
+	enc := json.NewEncoder(w)
+	for event := range es.events {
+		if err := enc.Encode(event); err != nil {
+			// Nobody is reading any more; drain the remaining events
+			for range es.events {
+			}
+			return
+		}
+	}
+}
+
+// Emit queues an event for writing
+func (es *EventStream) Emit(event LifecycleEvent) {
+	event.fill()
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		return
+	}
+	select {
+	case es.events <- event:
+	default:
+		// The reader is too slow; drop the event
+	}
+}
+
+// Activity reports output from the wrapped process, at most once a second
+func (es *EventStream) Activity() {
+	now := time.Now()
+	es.mu.Lock()
+	if now.Sub(es.lastActivity) < activityInterval {
+		es.mu.Unlock()
+		return
+	}
+	es.lastActivity = now
+	es.mu.Unlock()
+
+	es.Emit(LifecycleEvent{Event: EventActivity, Time: now})
+}
+
+// Close writes the queued events and stops the stream, waiting up to timeout
+func (es *EventStream) Close(timeout time.Duration) {
+	es.mu.Lock()
+	if !es.closed {
+		es.closed = true
+		close(es.events)
+	}
+	es.mu.Unlock()
+
+	select {
+	case <-es.done:
+	case <-time.After(timeout):
+	}
+}
+
+// SentNotifier wraps the notifier that delivers notifications and reports
+// each one on an event stream, with the error if it could not be sent
+type SentNotifier struct {
+	underlying Notifier
+	stream     *EventStream
+}
+
+// NewSentNotifier creates a notifier that reports delivered notifications
+func NewSentNotifier(underlying Notifier, stream *EventStream) *SentNotifier {
+	return &SentNotifier{
+		underlying: underlying,
+		stream:     stream,
+	}
+}
+
+// Send implements the Notifier interface
+func (sn *SentNotifier) Send(notification Notification) error {
+	err := sn.underlying.Send(notification)
+
+	event := LifecycleEvent{
+		Event:   EventNotification,
+		Pattern: notification.Pattern,
+		Title:   notification.Title,
+		Message: notification.Message,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	sn.stream.Emit(event)
+
+	return err
+}
@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the stream's writer goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// events decodes the JSON lines written so far
+func (b *syncBuffer) events(t *testing.T) []LifecycleEvent {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []LifecycleEvent
+	for _, line := range bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var e LifecycleEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	out := &syncBuffer{}
+	es := NewEventStream(out)
+
+	es.Emit(LifecycleEvent{Event: EventSessionStart})
+	es.Activity()
+	es.Activity() // Within a second of the last one
+	code := 0
+	es.Emit(LifecycleEvent{Event: EventSessionEnd, ExitCode: &code})
+	es.Close(time.Second)
+	es.Emit(LifecycleEvent{Event: EventIdle}) // After Close
+
+	events := out.events(t)
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Event)
+	}
+	if got := len(names); got != 3 || names[0] != EventSessionStart || names[1] != EventActivity || names[2] != EventSessionEnd {
+		t.Fatalf("unexpected events %v", names)
+	}
+	if events[0].PID == 0 || events[0].Time.IsZero() {
+		t.Errorf("expected pid and time to be filled in, got %+v", events[0])
+	}
+	if events[2].ExitCode == nil || *events[2].ExitCode != 0 {
+		t.Errorf("expected exit code 0, got %v", events[2].ExitCode)
+	}
+}
+
+func TestEventStreamSlowReader(t *testing.T) {
+	// Nobody reads from the pipe, so every write blocks
+	r, w := io.Pipe()
+	defer func() { _ = r.Close() }()
+	es := NewEventStream(w)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventStreamBuffer*4; i++ {
+			es.Emit(LifecycleEvent{Event: EventActivity})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Emit blocked on a stalled reader")
+	}
+	es.Close(10 * time.Millisecond)
+}
+
+// failingNotifier fails every send
+type failingNotifier struct{}
+
+func (failingNotifier) Send(Notification) error {
+	return errors.New("server unavailable")
+}
+
+func TestSentNotifier(t *testing.T) {
+	out := &syncBuffer{}
+	es := NewEventStream(out)
+
+	sent := NewSentNotifier(&recordingNotifier{}, es)
+	failed := NewSentNotifier(failingNotifier{}, es)
+	_ = sent.Send(Notification{Title: "Title", Message: "Message", Pattern: "backstop"})
+	if err := failed.Send(Notification{Pattern: "approval"}); err == nil {
+		t.Error("expected the error to be passed on")
+	}
+	es.Close(time.Second)
+
+	events := out.events(t)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if e := events[0]; e.Event != EventNotification || e.Pattern != "backstop" || e.Title != "Title" || e.Error != "" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e := events[1]; e.Pattern != "approval" || e.Error != "server unavailable" {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,172 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitBranch returns the current branch of the repository in dir, the short
+// commit hash when detached, or an empty string outside a repository
+func GitBranch(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(out))
+
+	if branch == "HEAD" {
+		out, err = exec.Command("git", "-C", dir, "rev-parse", "--short", "HEAD").Output()
+		if err != nil {
+			return ""
+		}
+		branch = strings.TrimSpace(string(out))
+	}
+
+	return branch
+}
+
+// gitRepoLabel returns "repo@branch" for the repository containing dir, or
+// an empty string outside a repository
+func gitRepoLabel(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	repo := filepath.Base(strings.TrimSpace(string(out)))
+
+	if branch := GitBranch(dir); branch != "" {
+		return repo + "@" + branch
+	}
+	return repo
+}
+
+// maxGitCommits bounds the commits GitCommits lists, e.g. after a pull
+const maxGitCommits = 20
+
+// GitCommit is a commit in a repository's history
+type GitCommit struct {
+	// Abbreviated commit hash
+	Hash    string
+	Subject string
+	// Time the commit was made, as opposed to authored, e.g. when rebased
+	Time time.Time
+}
+
+// GitHead returns the commit checked out in the repository containing dir,
+// or an empty string outside a repository or before its first commit
+func GitHead(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--quiet", "--verify", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GitCommits returns the commits reachable from the checked out commit but
+// not from since, oldest first, or all of them when since is empty
+func GitCommits(dir, since string) []GitCommit {
+	commits := "HEAD"
+	if since != "" {
+		commits = since + "..HEAD"
+	}
+	out, err := exec.Command("git", "-C", dir, "log", fmt.Sprintf("-n%d", maxGitCommits),
+		"--format=%h%x09%ct%x09%s", commits).Output()
+	if err != nil {
+		return nil
+	}
+	return parseGitLog(string(out))
+}
+
+// parseGitLog parses the output of git log --format=%h%x09%ct%x09%s into
+// commits, oldest first
+func parseGitLog(out string) []GitCommit {
+	var commits []GitCommit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, GitCommit{Hash: fields[0], Subject: fields[2], Time: time.Unix(seconds, 0)})
+	}
+	slices.Reverse(commits)
+	return commits
+}
+
+// GitWorkTree is the state of a repository's working tree, as reported by
+// git status
+type GitWorkTree struct {
+	// Top level directory of the repository
+	Root string
+	// Status codes of the files with uncommitted changes, e.g. " M" or "??",
+	// by their path relative to Root
+	Changes map[string]string
+}
+
+// GitStatus returns the state of the working tree of the repository
+// containing dir, or nil outside a repository
+func GitStatus(dir string) *GitWorkTree {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	root := strings.TrimSpace(string(out))
+
+	// Name each untracked file, not just its directory, so files are counted
+	out, err = exec.Command("git", "-C", root, "status", "--porcelain", "--untracked-files=all").Output()
+	if err != nil {
+		return nil
+	}
+	return &GitWorkTree{Root: root, Changes: parseGitStatus(string(out))}
+}
+
+// parseGitStatus parses the output of git status --porcelain
+func parseGitStatus(out string) map[string]string {
+	changes := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		// A renamed file is listed as "from -> to"
+		if i := strings.Index(path, " -> "); i >= 0 {
+			path = path[i+len(" -> "):]
+		}
+		changes[strings.Trim(path, `"`)] = line[:2]
+	}
+	return changes
+}
+
+// ChangedSince returns how many files changed since an earlier state of the
+// working tree taken at a time: files whose status differs, and files
+// already changed then that were written to since
+func (wt *GitWorkTree) ChangedSince(earlier *GitWorkTree, since time.Time) int {
+	changed := 0
+	for path, status := range wt.Changes {
+		if earlier == nil || earlier.Changes[path] != status {
+			changed++
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(wt.Root, path)); err == nil && info.ModTime().After(since) {
+			changed++
+		}
+	}
+	// Changes that were committed or undone
+	if earlier != nil {
+		for path := range earlier.Changes {
+			if _, ok := wt.Changes[path]; !ok {
+				changed++
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	out := " M main.go\n?? docs/new.md\nR  old.go -> renamed.go\nA  \"with space.go\"\n"
+	changes := parseGitStatus(out)
+
+	expected := map[string]string{
+		"main.go":       " M",
+		"docs/new.md":   "??",
+		"renamed.go":    "R ",
+		"with space.go": "A ",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+	for path, status := range expected {
+		if changes[path] != status {
+			t.Errorf("expected %q for %s, got %q", status, path, changes[path])
+		}
+	}
+}
+
+func TestGitWorkTreeChangedSince(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"edited.go", "untouched.go", "new.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Changed before the session started and not written to since
+	started := time.Now()
+	old := started.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "untouched.go"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	earlier := &GitWorkTree{Root: root, Changes: map[string]string{
+		"edited.go":    " M",
+		"untouched.go": " M",
+		"committed.go": " M",
+	}}
+	now := &GitWorkTree{Root: root, Changes: map[string]string{
+		"edited.go":    " M",
+		"untouched.go": " M",
+		"new.go":       "??",
+	}}
+
+	// edited.go was written after the start, new.go is new and committed.go
+	// was committed
+	if got := now.ChangedSince(earlier, started.Add(-time.Minute)); got != 3 {
+		t.Errorf("expected 3 changed files, got %d", got)
+	}
+	if got := now.ChangedSince(nil, started); got != 3 {
+		t.Errorf("expected every change to count without an earlier state, got %d", got)
+	}
+}
+
+func TestParseGitLog(t *testing.T) {
+	out := "b2c3d4e\t1700000100\tAdd tests\na1b2c3d\t1700000000\tFix:\tthe parser\n"
+	commits := parseGitLog(out)
+
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %v", commits)
+	}
+	if commits[0].Hash != "a1b2c3d" || commits[0].Subject != "Fix:\tthe parser" || commits[0].Time.Unix() != 1700000000 {
+		t.Errorf("expected the oldest commit first, got %+v", commits[0])
+	}
+	if commits[1].Hash != "b2c3d4e" || commits[1].Subject != "Add tests" {
+		t.Errorf("expected the newest commit last, got %+v", commits[1])
+	}
+}
@@ -0,0 +1,68 @@
+package notification
+
+import "sync"
+
+// HistoryNotifier wraps another notifier and remembers the most recent
+// notifications sent through it
+type HistoryNotifier struct {
+	underlying Notifier
+	max        int
+
+	mu      sync.Mutex
+	history []Notification
+	// Notifications sent by type, over the whole session
+	counts map[string]int
+}
+
+// NewHistoryNotifier creates a history notifier that keeps up to max
+// notifications
+func NewHistoryNotifier(underlying Notifier, max int) *HistoryNotifier {
+	return &HistoryNotifier{
+		underlying: underlying,
+		max:        max,
+	}
+}
+
+// Send implements the Notifier interface
+func (hn *HistoryNotifier) Send(notification Notification) error {
+	hn.mu.Lock()
+	// Attachments can be large and are not needed for the history
+	entry := notification
+	entry.Attachment = nil
+	if len(hn.history) >= hn.max {
+		copy(hn.history, hn.history[1:])
+		hn.history = hn.history[:len(hn.history)-1]
+	}
+	hn.history = append(hn.history, entry)
+	if hn.counts == nil {
+		hn.counts = make(map[string]int)
+	}
+	hn.counts[notification.Pattern]++
+	hn.mu.Unlock()
+
+	return hn.underlying.Send(notification)
+}
+
+// Recent returns the remembered notifications, newest first
+func (hn *HistoryNotifier) Recent() []Notification {
+	hn.mu.Lock()
+	defer hn.mu.Unlock()
+
+	result := make([]Notification, len(hn.history))
+	for i, n := range hn.history {
+		result[len(hn.history)-1-i] = n
+	}
+	return result
+}
+
+// Counts returns how many notifications of each type were sent
+func (hn *HistoryNotifier) Counts() map[string]int {
+	hn.mu.Lock()
+	defer hn.mu.Unlock()
+
+	counts := make(map[string]int, len(hn.counts))
+	for pattern, n := range hn.counts {
+		counts[pattern] = n
+	}
+	return counts
+}
@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// homeAssistantEvent is the body sent to Home Assistant webhooks and to
+// services outside the notify domain, such as scripts, which receive the
+// fields as variables
+type homeAssistantEvent struct {
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Pattern string    `json:"pattern"`
+	Time    time.Time `json:"time"`
+	Click   string    `json:"click,omitempty"`
+	Session string    `json:"session,omitempty"`
+}
+
+// homeAssistantNotify is the body of a notify service call
+type homeAssistantNotify struct {
+	Title   string                 `json:"title"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// HomeAssistantNotifier sends notifications to Home Assistant, either by
+// calling a service with a long-lived access token or by triggering a
+// webhook automation, so that events can drive automations such as flashing
+// a light when Gemini waits for approval
+type HomeAssistantNotifier struct {
+	url   string
+	token string
+	// Whether the target is a notify service, which takes its own schema
+	notify bool
+	// Whether each session gets its own notification group on the phone
+	groupBySession bool
+	httpClient     *http.Client
+}
+
+// NewHomeAssistantNotifier creates a notifier that calls a Home Assistant
+// service, given as "domain.service", e.g. "notify.mobile_app_pixel" or
+// "script.flash_office_light". A name without a domain is a notify service.
+func NewHomeAssistantNotifier(baseURL, token, service string) *HomeAssistantNotifier {
+	domain, name, found := strings.Cut(service, ".")
+	if !found {
+		domain, name = "notify", service
+	}
+	return &HomeAssistantNotifier{
+		url:        fmt.Sprintf("%s/api/services/%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(domain), url.PathEscape(name)),
+		token:      token,
+		notify:     domain == "notify",
+		httpClient: newHomeAssistantClient(),
+	}
+}
+
+// NewHomeAssistantWebhookNotifier creates a notifier that triggers a Home
+// Assistant webhook automation. Webhooks need no token; the ID is the secret.
+func NewHomeAssistantWebhookNotifier(baseURL, webhookID string) *HomeAssistantNotifier {
+	return &HomeAssistantNotifier{
+		url:        fmt.Sprintf("%s/api/webhook/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(webhookID)),
+		httpClient: newHomeAssistantClient(),
+	}
+}
+
+// newHomeAssistantClient returns the HTTP client used to reach Home Assistant
+func newHomeAssistantClient() *http.Client {
+	return &http.Client{
+		Timeout:   DefaultRequestTimeout,
+		Transport: sharedTransport,
+	}
+}
+
+// SetGroupBySession groups notifications on the phone by session rather
+// than collecting all of them in one group
+func (hn *HomeAssistantNotifier) SetGroupBySession(bySession bool) {
+	hn.groupBySession = bySession
+}
+
+// Send implements the Notifier interface
+func (hn *HomeAssistantNotifier) Send(notification Notification) error {
+	var body interface{}
+	if hn.notify {
+		// The tag lets a newer notification of the same type replace an
+		// older one on the phone
+		data := map[string]interface{}{
+			"group": "gemini-cli",
+			"tag":   notification.Pattern,
+		}
+		if hn.groupBySession && notification.Session != "" {
+			// Sessions must not replace each other's notifications either
+			data["group"] = "gemini-cli-" + notification.Session
+			data["tag"] = notification.Session + "-" + notification.Pattern
+		}
+		if notification.Click != "" {
+			// Android and iOS companion apps use different keys
+			data["clickAction"] = notification.Click
+			data["url"] = notification.Click
+		}
+		body = homeAssistantNotify{
+			Title:   notification.Title,
+			Message: notification.Message,
+			Data:    data,
+		}
+	} else {
+		body = homeAssistantEvent{
+			Title:   notification.Title,
+			Message: notification.Message,
+			Pattern: notification.Pattern,
+			Time:    notification.Time,
+			Click:   notification.Click,
+			Session: notification.Session,
+		}
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Home Assistant payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", hn.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hn.token != "" {
+		req.Header.Set("Authorization", "Bearer "+hn.token)
+	}
+
+	resp, err := hn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to Home Assistant: %w", err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to Home Assistant failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHomeAssistantNotifier(t *testing.T) {
+	var path, auth string
+	var body map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n := Notification{Title: "Gemini CLI: app", Message: "Approve?", Pattern: "approval", Click: "https://example.com"}
+
+	t.Run("notify service", func(t *testing.T) {
+		hn := NewHomeAssistantNotifier(server.URL+"/", "tok", "mobile_app_pixel")
+		if err := hn.Send(n); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if path != "/api/services/notify/mobile_app_pixel" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if auth != "Bearer tok" {
+			t.Errorf("unexpected Authorization %q", auth)
+		}
+		if body["title"] != n.Title || body["message"] != n.Message {
+			t.Errorf("unexpected body %v", body)
+		}
+		data, _ := body["data"].(map[string]interface{})
+		if data["tag"] != "approval" || data["url"] != n.Click || data["clickAction"] != n.Click {
+			t.Errorf("unexpected data %v", data)
+		}
+	})
+
+	t.Run("group by session", func(t *testing.T) {
+		hn := NewHomeAssistantNotifier(server.URL, "tok", "notify.mobile_app_pixel")
+		hn.SetGroupBySession(true)
+		withSession := n
+		withSession.Session = "my-api"
+		if err := hn.Send(withSession); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		data, _ := body["data"].(map[string]interface{})
+		if data["group"] != "gemini-cli-my-api" || data["tag"] != "my-api-approval" {
+			t.Errorf("unexpected data %v", data)
+		}
+	})
+
+	t.Run("script service", func(t *testing.T) {
+		hn := NewHomeAssistantNotifier(server.URL, "tok", "script.flash_light")
+		if err := hn.Send(n); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if path != "/api/services/script/flash_light" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if body["pattern"] != "approval" || body["message"] != n.Message {
+			t.Errorf("expected fields as variables, got %v", body)
+		}
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		hn := NewHomeAssistantWebhookNotifier(server.URL, "gemini-abc123")
+		if err := hn.Send(n); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if path != "/api/webhook/gemini-abc123" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if auth != "" {
+			t.Errorf("expected no Authorization header, got %q", auth)
+		}
+		if body["pattern"] != "approval" || body["title"] != n.Title {
+			t.Errorf("unexpected body %v", body)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		status = http.StatusUnauthorized
+		defer func() { status = http.StatusOK }()
+		if err := NewHomeAssistantNotifier(server.URL, "bad", "notify.phone").Send(n); err == nil {
+			t.Error("expected an error for status 401")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+)
+
+// IdlePatterns are the notifications about Gemini waiting for the user,
+// whose urgency depends on how long both sides have been idle
+var IdlePatterns = []string{"backstop", "stalled", "approval"}
+
+// IdleNotifier wraps another notifier and adds how long the user and the
+// wrapped CLI have been idle to the message of selected notifications, e.g.
+// "Idle for 12m (you) / 45s (Gemini)"
+type IdleNotifier struct {
+	underlying Notifier
+	patterns   map[string]bool
+	messages   Messages
+	// idle returns how long the user and the CLI have been idle
+	idle func() (user, cli time.Duration)
+}
+
+// NewIdleNotifier creates an idle notifier for notifications of the given
+// patterns
+func NewIdleNotifier(underlying Notifier, patterns []string, idle func() (user, cli time.Duration)) *IdleNotifier {
+	in := &IdleNotifier{
+		underlying: underlying,
+		patterns:   make(map[string]bool, len(patterns)),
+		messages:   DefaultMessages(),
+		idle:       idle,
+	}
+	for _, pattern := range patterns {
+		in.patterns[pattern] = true
+	}
+	return in
+}
+
+// SetMessages sets the catalog the idle line is taken from
+func (in *IdleNotifier) SetMessages(messages Messages) {
+	in.messages = messages
+}
+
+// Send implements the Notifier interface
+func (in *IdleNotifier) Send(notification Notification) error {
+	if in.patterns[notification.Pattern] {
+		user, cli := in.idle()
+		line := in.messages.Get("idle.message", roughDuration(user), roughDuration(cli))
+		if notification.Message == "" {
+			notification.Message = line
+		} else {
+			notification.Message += "\n" + line
+		}
+	}
+	return in.underlying.Send(notification)
+}
+
+// roughDuration formats a duration in its largest unit, e.g. "45s", "12m"
+// or "2h5m", since the precise time adds nothing at a glance
+func roughDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d%time.Hour < time.Minute:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdleNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	in := NewIdleNotifier(rec, IdlePatterns, func() (time.Duration, time.Duration) {
+		return 12*time.Minute + 30*time.Second, 45 * time.Second
+	})
+
+	tests := []struct {
+		name     string
+		input    Notification
+		expected string
+	}{
+		{"backstop", Notification{Message: "Waiting for your input", Pattern: "backstop"}, "Waiting for your input\nIdle for 12m (you) / 45s (Gemini)"},
+		{"empty message", Notification{Pattern: "approval"}, "Idle for 12m (you) / 45s (Gemini)"},
+		{"other pattern", Notification{Message: "Exited with code 1", Pattern: "crash"}, "Exited with code 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := in.Send(tt.input); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if got := rec.sent[len(rec.sent)-1].Message; got != tt.expected {
+				t.Errorf("expected message %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRoughDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 59*time.Second, "12m"},
+		{2 * time.Hour, "2h"},
+		{2*time.Hour + 5*time.Minute, "2h5m"},
+	}
+
+	for _, tt := range tests {
+		if got := roughDuration(tt.duration); got != tt.expected {
+			t.Errorf("roughDuration(%v) = %q, expected %q", tt.duration, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Incident services
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// DefaultOpsgenieURL is Opsgenie's US API; EU accounts use
+	// https://api.eu.opsgenie.com
+	DefaultOpsgenieURL = "https://api.opsgenie.com"
+)
+
+// Limits of the incident services' summary fields
+const (
+	maxPagerDutySummary = 1024
+	maxOpsgenieMessage  = 130
+)
+
+// incidentSource names the machine an incident comes from
+func incidentSource() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// incidentKey groups repeated incidents of one type from the same session
+// into one alert
+func incidentKey(pattern string) string {
+	return fmt.Sprintf("gemini-cli-ntfy/%s/%d/%s", incidentSource(), os.Getpid(), pattern)
+}
+
+// incidentSummary is the one-line summary of a notification
+func incidentSummary(notification Notification, limit int) string {
+	summary := notification.Title
+	if notification.Message != "" {
+		if summary != "" {
+			summary += ": "
+		}
+		summary += strings.Join(strings.Fields(notification.Message), " ")
+	}
+	return TruncateMiddle(summary, limit)
+}
+
+// PagerDutyNotifier triggers PagerDuty incidents through the Events API v2
+type PagerDutyNotifier struct {
+	url        string
+	routingKey string
+	httpClient *http.Client
+}
+
+// NewPagerDutyNotifier creates a notifier for the integration with the given
+// routing key
+func NewPagerDutyNotifier(routingKey string) *PagerDutyNotifier {
+	return &PagerDutyNotifier{
+		url:        pagerDutyEventsURL,
+		routingKey: routingKey,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// pagerDutyEvent is an Events API v2 trigger event
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+	Links       []pagerDutyLink  `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Component     string            `json:"component"`
+	Class         string            `json:"class"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Send implements the Notifier interface
+func (pn *PagerDutyNotifier) Send(notification Notification) error {
+	event := pagerDutyEvent{
+		RoutingKey:  pn.routingKey,
+		EventAction: "trigger",
+		DedupKey:    incidentKey(notification.Pattern),
+		Payload: pagerDutyPayload{
+			Summary:   incidentSummary(notification, maxPagerDutySummary),
+			Source:    incidentSource(),
+			Severity:  "critical",
+			Component: "gemini-cli",
+			Class:     notification.Pattern,
+			CustomDetails: map[string]string{
+				"title":   notification.Title,
+				"message": notification.Message,
+			},
+		},
+	}
+	if !notification.Time.IsZero() {
+		event.Payload.Timestamp = notification.Time.Format(time.RFC3339)
+	}
+	if notification.Click != "" {
+		event.Links = []pagerDutyLink{{Href: notification.Click, Text: "Open session"}}
+	}
+
+	// PagerDuty answers 202 Accepted
+	return postIncident(pn.httpClient, pn.url, "", event, "PagerDuty")
+}
+
+// OpsgenieNotifier creates Opsgenie alerts
+type OpsgenieNotifier struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpsgenieNotifier creates a notifier using an API integration's key.
+// baseURL is the API for the account's region, e.g. DefaultOpsgenieURL.
+func NewOpsgenieNotifier(baseURL, apiKey string) *OpsgenieNotifier {
+	return &OpsgenieNotifier{
+		url:    strings.TrimSuffix(baseURL, "/") + "/v2/alerts",
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// opsgenieAlert is the body of an Opsgenie create alert request
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+}
+
+// Send implements the Notifier interface
+func (on *OpsgenieNotifier) Send(notification Notification) error {
+	alert := opsgenieAlert{
+		Message:     incidentSummary(notification, maxOpsgenieMessage),
+		Alias:       incidentKey(notification.Pattern),
+		Description: notification.Message,
+		Source:      incidentSource(),
+		Priority:    "P1",
+		Tags:        []string{"gemini-cli", notification.Pattern},
+		Details:     map[string]string{"title": notification.Title},
+	}
+	if notification.Click != "" {
+		alert.Details["url"] = notification.Click
+	}
+
+	// Opsgenie answers 202 Accepted and creates the alert asynchronously
+	return postIncident(on.httpClient, on.url, "GenieKey "+on.apiKey, alert, "Opsgenie")
+}
+
+// postIncident posts an incident as JSON to a service
+func postIncident(client *http.Client, url, authorization string, body interface{}, service string) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", service, err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", service, err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to %s failed with status %d", service, resp.StatusCode)
+	}
+
+	return nil
+}
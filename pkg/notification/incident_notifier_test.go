@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIncidentNotifiers(t *testing.T) {
+	var path, auth string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := Notification{
+		Title:   "Gemini CLI: app@main",
+		Message: "Exited with code 2",
+		Pattern: "crash",
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Click:   "https://example.com/session",
+	}
+
+	t.Run("PagerDuty", func(t *testing.T) {
+		pn := NewPagerDutyNotifier("routing-key")
+		pn.url = server.URL + "/v2/enqueue"
+		if err := pn.Send(n); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if body["routing_key"] != "routing-key" || body["event_action"] != "trigger" {
+			t.Errorf("unexpected event %v", body)
+		}
+		if key, _ := body["dedup_key"].(string); !strings.HasSuffix(key, "/crash") {
+			t.Errorf("expected dedup key per type, got %q", key)
+		}
+		payload, _ := body["payload"].(map[string]interface{})
+		if payload["summary"] != "Gemini CLI: app@main: Exited with code 2" {
+			t.Errorf("unexpected summary %q", payload["summary"])
+		}
+		if payload["severity"] != "critical" || payload["class"] != "crash" || payload["timestamp"] != "2026-01-02T03:04:05Z" {
+			t.Errorf("unexpected payload %v", payload)
+		}
+	})
+
+	t.Run("Opsgenie", func(t *testing.T) {
+		on := NewOpsgenieNotifier(server.URL+"/", "api-key")
+		long := n
+		long.Message = strings.Repeat("line\n", 100)
+		if err := on.Send(long); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if path != "/v2/alerts" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if auth != "GenieKey api-key" {
+			t.Errorf("unexpected Authorization %q", auth)
+		}
+		message, _ := body["message"].(string)
+		if len([]rune(message)) > maxOpsgenieMessage || strings.Contains(message, "\n") {
+			t.Errorf("expected a one-line message of at most %d characters, got %q", maxOpsgenieMessage, message)
+		}
+		if body["priority"] != "P1" || body["description"] != long.Message {
+			t.Errorf("unexpected alert %v", body)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer failing.Close()
+
+		if err := NewOpsgenieNotifier(failing.URL, "k").Send(n); err == nil {
+			t.Error("expected an error for status 400")
+		}
+	})
+}
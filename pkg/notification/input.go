@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InputKind classifies a chunk of input that reached the wrapped CLI
+type InputKind string
+
+const (
+	// InputKeystroke is typed input: a key, or the escape sequence of one
+	InputKeystroke InputKind = "keystroke"
+	// InputEnter is typed input that includes the Enter key, submitting a
+	// prompt. It counts as a keystroke wherever kinds are selected.
+	InputEnter InputKind = "enter"
+	// InputPaste is a large chunk arriving at once, e.g. text pasted into a
+	// terminal without bracketed paste
+	InputPaste InputKind = "paste"
+	// InputBracketedPaste is text the terminal marked as pasted
+	InputBracketedPaste InputKind = "bracketed_paste"
+	// InputRemote is input injected from outside the terminal, e.g. a reply
+	// sent from the phone
+	InputRemote InputKind = "remote"
+)
+
+// InputKinds lists the kinds of input that can be selected, e.g. in
+// interaction_inputs
+var InputKinds = []InputKind{InputKeystroke, InputPaste, InputBracketedPaste, InputRemote}
+
+// ParseInputKind parses the name of an input kind
+func ParseInputKind(name string) (InputKind, error) {
+	for _, kind := range InputKinds {
+		if strings.EqualFold(name, string(kind)) {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown input kind %q (use keystroke, paste, bracketed_paste or remote)", name)
+}
@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// mattermostPost is the body of a Mattermost incoming webhook request
+type mattermostPost struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// MattermostNotifier posts notifications to a Mattermost incoming webhook
+type MattermostNotifier struct {
+	url        string
+	channel    string
+	username   string
+	httpClient *http.Client
+}
+
+// NewMattermostNotifier creates a notifier for the given incoming webhook URL
+func NewMattermostNotifier(url string) *MattermostNotifier {
+	return &MattermostNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// SetChannel posts to another channel than the webhook's own, if the
+// webhook allows it
+func (mn *MattermostNotifier) SetChannel(channel string) {
+	mn.channel = channel
+}
+
+// SetUsername overrides the name posts appear under, if the server allows it
+func (mn *MattermostNotifier) SetUsername(username string) {
+	mn.username = username
+}
+
+// Send implements the Notifier interface
+func (mn *MattermostNotifier) Send(notification Notification) error {
+	body, err := json.Marshal(mattermostPost{
+		Text:     chatMarkdown(notification),
+		Channel:  mn.channel,
+		Username: mn.username,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Mattermost post: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", mn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := mn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to Mattermost: %w", err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to Mattermost failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
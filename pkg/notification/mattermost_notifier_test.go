@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMattermostNotifier(t *testing.T) {
+	var post mattermostPost
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&post)
+	}))
+	defer server.Close()
+
+	mn := NewMattermostNotifier(server.URL)
+	mn.SetChannel("town-square")
+	mn.SetUsername("gemini")
+	if err := mn.Send(Notification{Title: "Gemini needs approval", Message: "Waiting for tool approval"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	expected := mattermostPost{
+		Text:     "**Gemini needs approval**\nWaiting for tool approval",
+		Channel:  "town-square",
+		Username: "gemini",
+	}
+	if post != expected {
+		t.Errorf("expected %+v, got %+v", expected, post)
+	}
+}
@@ -0,0 +1,595 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Messages is a catalog of built-in notification strings keyed by message
+// ID, e.g. "backstop.title". Values may contain fmt verbs.
+type Messages map[string]string
+
+// builtinMessages holds the built-in catalogs by language
+var builtinMessages = map[string]Messages{
+	"en": {
+		"backstop.title":         "Gemini needs attention",
+		"backstop.message":       "Waiting for your input",
+		"stalled.title":          "Gemini appears stalled",
+		"stalled.message":        "Stopped mid-task with no output for %s",
+		"resumed.title":          "Gemini resumed",
+		"resumed.message":        "Output resumed after %s without activity",
+		"startup.title":          "Gemini CLI Session Started",
+		"startup.message":        "Working directory: %s",
+		"approval.title":         "Gemini needs approval",
+		"approval.message":       "Waiting for tool approval",
+		"turn.title":             "Gemini finished",
+		"turn.message":           "Ready for the next prompt",
+		"turn.stats":             "Tool calls: %d, took %s",
+		"tool_error.title":       "A Gemini tool call failed",
+		"tool_error.message":     "%s failed: %s",
+		"error.title":            "Gemini reported an error",
+		"error.message":          "Stopped with an error",
+		"command_start.title":    "%s started",
+		"command_start.message":  "Command: %s",
+		"command_finish.title":   "%s finished",
+		"command_finish.message": "%s exited with code %d after %s",
+		"command_error.title":    "%s failed",
+		"command_error.message":  "%s failed with exit code %d after %s",
+		"crash.title":            "Gemini exited unexpectedly",
+		"crash.message":          "Exited with code %d",
+		"crash.killed":           "Killed by a signal",
+		"crash.signal":           "Killed by %s",
+		"oom.title":              "Gemini was OOM-killed",
+		"oom.message":            "The kernel killed it for running out of memory",
+		"exit.title":             "Gemini exited",
+		"exit.message":           "Exited with code %d",
+		"stuck.title":            "Gemini seems stuck",
+		"stuck.message":          "No output for %s",
+		"no_response.title":      "Gemini hasn't responded in %s",
+		"no_response.message":    "No response for %s since the prompt at %s",
+		"response_ready.title":   "Gemini's response is ready",
+		"response_ready.message": "Response ready (took %s)",
+		"context.title":          "Gemini compressed its context",
+		"context.low_title":      "Gemini's context is almost full",
+		"context.low_message":    "%d%% of the context window left",
+		"mcp_error.title":        "An MCP server failed",
+		"mcp_error.hint":         "Its tools are unavailable; check /mcp",
+		"tool_running.title":     "A tool is still running",
+		"tool_running.message":   "%s has been running for %s",
+		"escalation.reminder":    "Reminder %d of %d: not acknowledged yet",
+		"budget.title":           "Budget used up",
+		"budget.tokens":          "%d tokens used of a budget of %d",
+		"budget.cost":            "About %.2f spent of a budget of %.2f",
+		"budget.paused":          "Input is paused until you send \"budget ack\"",
+		"progress.title":         "Still working",
+		"progress.message":       "Working for %s: %d lines of output, %d files edited",
+		"progress.files":         "Edited: %s",
+		"progress.more":          "and %d more",
+		"heartbeat.title":        "Still running",
+		"heartbeat.message":      "Running for %s, %d turns completed, last activity %s ago",
+		"digest.title":           "Daily summary",
+		"digest.none":            "No sessions finished in the last 24h",
+		"digest.sessions":        "%d sessions, active for %s",
+		"digest.running":         "%d sessions still running",
+		"digest.notifications":   "Notifications: %s",
+		"digest.errors":          "Errors: %d",
+		"update.title":           "An update is available",
+		"clipboard.title":        "Gemini copied text",
+		"file_burst.title":       "Gemini changed many files",
+		"file_burst.message":     "%d files changed within %s:\n%s",
+		"file_burst.more":        "and %d more",
+		"sensitive_file.title":   "Gemini touched a sensitive file",
+		"sensitive_file.message": "%s changed",
+		"commit.title":           "New commit on %s",
+		"commits.title":          "%d new commits on %s",
+		"tests_passed.title":     "Tests passed",
+		"tests_failed.title":     "Tests failed",
+		"tests.message":          "%s: %d failed, %d passed",
+		"tests.packages":         "%s: %d packages failed, %d passed",
+		"tests.skipped":          "%d skipped",
+		"build_error.title":      "The build failed",
+		"build_error.more":       "and %d more errors",
+		"high_memory.title":      "Gemini is using a lot of memory",
+		"high_memory.message":    "%s in use, over the limit of %s",
+		"high_cpu.title":         "Gemini is keeping the CPU busy",
+		"high_cpu.message":       "%.0f%% CPU for %s",
+		"pty_fallback.title":     "Gemini is running without a terminal",
+		"pty_fallback.message":   "%v. Output is piped instead, so Gemini may not be interactive, and the status line, hotkeys and remote input are unavailable.",
+		"api_auth.title":         "Gemini's credentials were rejected",
+		"api_auth.hint":          "Fix: sign in again (/auth) or check the API key",
+		"api_quota.title":        "Gemini hit a quota or rate limit",
+		"api_quota.hint":         "Fix: wait for the limit to reset, or switch models (/model)",
+		"api_server.title":       "Gemini's API is having problems",
+		"api_server.hint":        "Fix: retry in a few minutes",
+		"api_error.title":        "Gemini's API returned an error",
+		"api_error.hint":         "Fix: check the terminal, then retry",
+		"max_duration.title":     "Gemini has been running for %s",
+		"max_duration.message":   "Running for %s since %s",
+		"max_duration.stopping":  "Running for %s since %s, stopping it now",
+		"output_log.message":     "Output log: %s",
+		"idle.message":           "Idle for %s (you) / %s (Gemini)",
+		"prompt.message":         "re: '%s'",
+		"model.message":          "Model: %s",
+		"git.clean":              "Working tree clean",
+		"git.dirty":              "Working tree: %d uncommitted files",
+		"git.changed":            "%d files changed during the session",
+	},
+	"de": {
+		"backstop.title":         "Gemini braucht Aufmerksamkeit",
+		"backstop.message":       "Wartet auf deine Eingabe",
+		"stalled.title":          "Gemini scheint festzuhängen",
+		"stalled.message":        "Mitten in der Aufgabe stehen geblieben, keine Ausgabe seit %s",
+		"resumed.title":          "Gemini macht weiter",
+		"resumed.message":        "Wieder Ausgabe nach %s ohne Aktivität",
+		"startup.title":          "Gemini CLI-Sitzung gestartet",
+		"startup.message":        "Arbeitsverzeichnis: %s",
+		"approval.title":         "Gemini braucht eine Freigabe",
+		"approval.message":       "Wartet auf Freigabe eines Tools",
+		"turn.title":             "Gemini ist fertig",
+		"turn.message":           "Bereit für die nächste Eingabe",
+		"turn.stats":             "Tool-Aufrufe: %d, Dauer %s",
+		"tool_error.title":       "Ein Tool-Aufruf von Gemini ist fehlgeschlagen",
+		"tool_error.message":     "%s fehlgeschlagen: %s",
+		"error.title":            "Gemini meldet einen Fehler",
+		"error.message":          "Mit einem Fehler abgebrochen",
+		"command_start.title":    "%s gestartet",
+		"command_start.message":  "Befehl: %s",
+		"command_finish.title":   "%s beendet",
+		"command_finish.message": "%s beendet mit Exitcode %d nach %s",
+		"command_error.title":    "%s fehlgeschlagen",
+		"command_error.message":  "%s fehlgeschlagen mit Exitcode %d nach %s",
+		"crash.title":            "Gemini unerwartet beendet",
+		"crash.message":          "Beendet mit Exitcode %d",
+		"crash.killed":           "Durch ein Signal beendet",
+		"crash.signal":           "Durch %s beendet",
+		"oom.title":              "Gemini wurde wegen Speichermangels beendet",
+		"oom.message":            "Der Kernel hat es wegen Speichermangels beendet",
+		"exit.title":             "Gemini beendet",
+		"exit.message":           "Beendet mit Exitcode %d",
+		"stuck.title":            "Gemini scheint festzustecken",
+		"stuck.message":          "Keine Ausgabe seit %s",
+		"no_response.title":      "Gemini hat seit %s nicht geantwortet",
+		"no_response.message":    "Keine Antwort seit %s (Eingabe um %s)",
+		"response_ready.title":   "Geminis Antwort ist fertig",
+		"response_ready.message": "Antwort fertig (dauerte %s)",
+		"context.title":          "Gemini hat seinen Kontext komprimiert",
+		"context.low_title":      "Geminis Kontext ist fast voll",
+		"context.low_message":    "Noch %d%% des Kontextfensters frei",
+		"mcp_error.title":        "Ein MCP-Server ist ausgefallen",
+		"mcp_error.hint":         "Seine Tools fehlen; siehe /mcp",
+		"tool_running.title":     "Ein Tool läuft noch",
+		"tool_running.message":   "%s läuft seit %s",
+		"escalation.reminder":    "Erinnerung %d von %d: noch nicht bestätigt",
+		"budget.title":           "Budget aufgebraucht",
+		"budget.tokens":          "%d Tokens von einem Budget von %d verbraucht",
+		"budget.cost":            "Etwa %.2f von einem Budget von %.2f ausgegeben",
+		"budget.paused":          "Eingaben sind angehalten, bis du \"budget ack\" sendest",
+		"progress.title":         "Arbeitet noch",
+		"progress.message":       "Arbeitet seit %s: %d Zeilen Ausgabe, %d Dateien bearbeitet",
+		"progress.files":         "Bearbeitet: %s",
+		"progress.more":          "und %d weitere",
+		"heartbeat.title":        "Läuft noch",
+		"heartbeat.message":      "Läuft seit %s, %d Durchgänge abgeschlossen, letzte Aktivität vor %s",
+		"digest.title":           "Tageszusammenfassung",
+		"digest.none":            "In den letzten 24h wurde keine Sitzung beendet",
+		"digest.sessions":        "%d Sitzungen, aktiv für %s",
+		"digest.running":         "%d Sitzungen laufen noch",
+		"digest.notifications":   "Benachrichtigungen: %s",
+		"digest.errors":          "Fehler: %d",
+		"update.title":           "Ein Update ist verfügbar",
+		"clipboard.title":        "Gemini hat Text kopiert",
+		"file_burst.title":       "Gemini hat viele Dateien geändert",
+		"file_burst.message":     "%d Dateien innerhalb von %s geändert:\n%s",
+		"file_burst.more":        "und %d weitere",
+		"sensitive_file.title":   "Gemini hat eine sensible Datei berührt",
+		"sensitive_file.message": "%s wurde geändert",
+		"commit.title":           "Neuer Commit auf %s",
+		"commits.title":          "%d neue Commits auf %s",
+		"tests_passed.title":     "Tests bestanden",
+		"tests_failed.title":     "Tests fehlgeschlagen",
+		"tests.message":          "%s: %d fehlgeschlagen, %d bestanden",
+		"tests.packages":         "%s: %d Pakete fehlgeschlagen, %d bestanden",
+		"tests.skipped":          "%d übersprungen",
+		"build_error.title":      "Der Build ist fehlgeschlagen",
+		"build_error.more":       "und %d weitere Fehler",
+		"high_memory.title":      "Gemini belegt viel Speicher",
+		"high_memory.message":    "%s belegt, über der Grenze von %s",
+		"high_cpu.title":         "Gemini lastet die CPU aus",
+		"high_cpu.message":       "%.0f%% CPU seit %s",
+		"pty_fallback.title":     "Gemini läuft ohne Terminal",
+		"pty_fallback.message":   "%v. Die Ausgabe wird stattdessen über eine Pipe geleitet; Gemini ist womöglich nicht interaktiv, und Statuszeile, Tastenkürzel und Ferneingabe sind nicht verfügbar.",
+		"api_auth.title":         "Geminis Anmeldedaten wurden abgelehnt",
+		"api_auth.hint":          "Lösung: neu anmelden (/auth) oder den API-Schlüssel prüfen",
+		"api_quota.title":        "Gemini hat ein Kontingent- oder Ratenlimit erreicht",
+		"api_quota.hint":         "Lösung: warten, bis das Limit zurückgesetzt ist, oder das Modell wechseln (/model)",
+		"api_server.title":       "Geminis API hat Probleme",
+		"api_server.hint":        "Lösung: in ein paar Minuten erneut versuchen",
+		"api_error.title":        "Geminis API hat einen Fehler gemeldet",
+		"api_error.hint":         "Lösung: Terminal prüfen, dann erneut versuchen",
+		"max_duration.title":     "Gemini läuft seit %s",
+		"max_duration.message":   "Läuft seit %s (gestartet um %s)",
+		"max_duration.stopping":  "Läuft seit %s (gestartet um %s), wird jetzt beendet",
+		"output_log.message":     "Ausgabeprotokoll: %s",
+		"idle.message":           "Untätig seit %s (du) / %s (Gemini)",
+		"prompt.message":         "zu: '%s'",
+		"model.message":          "Modell: %s",
+		"git.clean":              "Arbeitsverzeichnis sauber",
+		"git.dirty":              "Arbeitsverzeichnis: %d Dateien nicht committet",
+		"git.changed":            "%d Dateien während der Sitzung geändert",
+	},
+	"es": {
+		"backstop.title":         "Gemini necesita atención",
+		"backstop.message":       "Esperando tu respuesta",
+		"stalled.title":          "Gemini parece bloqueado",
+		"stalled.message":        "Detenido a mitad de la tarea, sin salida desde hace %s",
+		"resumed.title":          "Gemini se reanudó",
+		"resumed.message":        "Salida reanudada tras %s sin actividad",
+		"startup.title":          "Sesión de Gemini CLI iniciada",
+		"startup.message":        "Directorio de trabajo: %s",
+		"approval.title":         "Gemini necesita aprobación",
+		"approval.message":       "Esperando la aprobación de una herramienta",
+		"turn.title":             "Gemini ha terminado",
+		"turn.message":           "Listo para la siguiente instrucción",
+		"turn.stats":             "Llamadas a herramientas: %d, duración %s",
+		"tool_error.title":       "Falló una herramienta de Gemini",
+		"tool_error.message":     "%s falló: %s",
+		"error.title":            "Gemini informó de un error",
+		"error.message":          "Se detuvo con un error",
+		"command_start.title":    "%s iniciado",
+		"command_start.message":  "Comando: %s",
+		"command_finish.title":   "%s ha terminado",
+		"command_finish.message": "%s terminó con el código %d tras %s",
+		"command_error.title":    "%s ha fallado",
+		"command_error.message":  "%s falló con el código %d tras %s",
+		"crash.title":            "Gemini terminó inesperadamente",
+		"crash.message":          "Terminó con el código %d",
+		"crash.killed":           "Terminado por una señal",
+		"crash.signal":           "Terminado por %s",
+		"oom.title":              "Gemini fue terminado por falta de memoria",
+		"oom.message":            "El kernel lo terminó por quedarse sin memoria",
+		"exit.title":             "Gemini terminó",
+		"exit.message":           "Terminó con el código %d",
+		"stuck.title":            "Gemini parece bloqueado",
+		"stuck.message":          "Sin salida desde hace %s",
+		"no_response.title":      "Gemini no ha respondido en %s",
+		"no_response.message":    "Sin respuesta desde hace %s (indicación enviada a las %s)",
+		"response_ready.title":   "La respuesta de Gemini está lista",
+		"response_ready.message": "Respuesta lista (tardó %s)",
+		"context.title":          "Gemini comprimió su contexto",
+		"context.low_title":      "El contexto de Gemini está casi lleno",
+		"context.low_message":    "Queda el %d%% de la ventana de contexto",
+		"mcp_error.title":        "Falló un servidor MCP",
+		"mcp_error.hint":         "Sus herramientas no están disponibles; revisa /mcp",
+		"tool_running.title":     "Una herramienta sigue en ejecución",
+		"tool_running.message":   "%s lleva %s en ejecución",
+		"escalation.reminder":    "Recordatorio %d de %d: aún sin confirmar",
+		"budget.title":           "Presupuesto agotado",
+		"budget.tokens":          "%d tokens usados de un presupuesto de %d",
+		"budget.cost":            "Unos %.2f gastados de un presupuesto de %.2f",
+		"budget.paused":          "La entrada está en pausa hasta que envíes \"budget ack\"",
+		"progress.title":         "Sigue trabajando",
+		"progress.message":       "Trabajando desde hace %s: %d líneas de salida, %d archivos editados",
+		"progress.files":         "Editados: %s",
+		"progress.more":          "y %d más",
+		"heartbeat.title":        "Sigue en ejecución",
+		"heartbeat.message":      "En ejecución desde hace %s, %d turnos completados, última actividad hace %s",
+		"digest.title":           "Resumen diario",
+		"digest.none":            "Ninguna sesión terminó en las últimas 24h",
+		"digest.sessions":        "%d sesiones, activas durante %s",
+		"digest.running":         "%d sesiones siguen en ejecución",
+		"digest.notifications":   "Notificaciones: %s",
+		"digest.errors":          "Errores: %d",
+		"update.title":           "Hay una actualización disponible",
+		"clipboard.title":        "Gemini copió texto",
+		"file_burst.title":       "Gemini cambió muchos archivos",
+		"file_burst.message":     "%d archivos cambiados en %s:\n%s",
+		"file_burst.more":        "y %d más",
+		"sensitive_file.title":   "Gemini tocó un archivo sensible",
+		"sensitive_file.message": "%s cambió",
+		"commit.title":           "Nuevo commit en %s",
+		"commits.title":          "%d commits nuevos en %s",
+		"tests_passed.title":     "Las pruebas pasaron",
+		"tests_failed.title":     "Las pruebas fallaron",
+		"tests.message":          "%s: %d fallidas, %d correctas",
+		"tests.packages":         "%s: %d paquetes fallidos, %d correctos",
+		"tests.skipped":          "%d omitidas",
+		"build_error.title":      "La compilación falló",
+		"build_error.more":       "y %d errores más",
+		"high_memory.title":      "Gemini está usando mucha memoria",
+		"high_memory.message":    "%s en uso, por encima del límite de %s",
+		"high_cpu.title":         "Gemini mantiene ocupada la CPU",
+		"high_cpu.message":       "%.0f%% de CPU durante %s",
+		"pty_fallback.title":     "Gemini se ejecuta sin terminal",
+		"pty_fallback.message":   "%v. La salida pasa por una tubería, así que Gemini puede no ser interactivo, y la línea de estado, los atajos y la entrada remota no están disponibles.",
+		"api_auth.title":         "Se rechazaron las credenciales de Gemini",
+		"api_auth.hint":          "Solución: vuelve a iniciar sesión (/auth) o revisa la clave de API",
+		"api_quota.title":        "Gemini alcanzó una cuota o límite de solicitudes",
+		"api_quota.hint":         "Solución: espera a que se restablezca el límite o cambia de modelo (/model)",
+		"api_server.title":       "La API de Gemini tiene problemas",
+		"api_server.hint":        "Solución: vuelve a intentarlo en unos minutos",
+		"api_error.title":        "La API de Gemini devolvió un error",
+		"api_error.hint":         "Solución: revisa el terminal y vuelve a intentarlo",
+		"max_duration.title":     "Gemini lleva %s en ejecución",
+		"max_duration.message":   "En ejecución durante %s desde las %s",
+		"max_duration.stopping":  "En ejecución durante %s desde las %s, deteniéndolo ahora",
+		"output_log.message":     "Registro de salida: %s",
+		"idle.message":           "Inactivo desde hace %s (tú) / %s (Gemini)",
+		"prompt.message":         "sobre: '%s'",
+		"model.message":          "Modelo: %s",
+		"git.clean":              "Árbol de trabajo limpio",
+		"git.dirty":              "Árbol de trabajo: %d archivos sin confirmar",
+		"git.changed":            "%d archivos cambiados durante la sesión",
+	},
+	"fr": {
+		"backstop.title":         "Gemini a besoin de votre attention",
+		"backstop.message":       "En attente de votre saisie",
+		"stalled.title":          "Gemini semble bloqué",
+		"stalled.message":        "Arrêté en pleine tâche, aucune sortie depuis %s",
+		"resumed.title":          "Gemini a repris",
+		"resumed.message":        "Sortie reprise après %s sans activité",
+		"startup.title":          "Session Gemini CLI démarrée",
+		"startup.message":        "Répertoire de travail : %s",
+		"approval.title":         "Gemini attend une autorisation",
+		"approval.message":       "En attente d'autorisation d'un outil",
+		"turn.title":             "Gemini a terminé",
+		"turn.message":           "Prêt pour la prochaine demande",
+		"turn.stats":             "Appels d'outils : %d, durée %s",
+		"tool_error.title":       "Un appel d'outil de Gemini a échoué",
+		"tool_error.message":     "%s a échoué : %s",
+		"error.title":            "Gemini a signalé une erreur",
+		"error.message":          "Arrêté sur une erreur",
+		"command_start.title":    "%s démarré",
+		"command_start.message":  "Commande : %s",
+		"command_finish.title":   "%s a terminé",
+		"command_finish.message": "%s terminé avec le code %d après %s",
+		"command_error.title":    "%s a échoué",
+		"command_error.message":  "%s a échoué avec le code %d après %s",
+		"crash.title":            "Gemini s'est arrêté de façon inattendue",
+		"crash.message":          "Terminé avec le code %d",
+		"crash.killed":           "Tué par un signal",
+		"crash.signal":           "Tué par %s",
+		"oom.title":              "Gemini a été tué par manque de mémoire",
+		"oom.message":            "Le noyau l'a tué faute de mémoire",
+		"exit.title":             "Gemini s'est arrêté",
+		"exit.message":           "Terminé avec le code %d",
+		"stuck.title":            "Gemini semble bloqué",
+		"stuck.message":          "Aucune sortie depuis %s",
+		"no_response.title":      "Gemini n'a pas répondu depuis %s",
+		"no_response.message":    "Aucune réponse depuis %s (invite envoyée à %s)",
+		"response_ready.title":   "La réponse de Gemini est prête",
+		"response_ready.message": "Réponse prête (en %s)",
+		"context.title":          "Gemini a compressé son contexte",
+		"context.low_title":      "Le contexte de Gemini est presque plein",
+		"context.low_message":    "Il reste %d %% de la fenêtre de contexte",
+		"mcp_error.title":        "Un serveur MCP a échoué",
+		"mcp_error.hint":         "Ses outils sont indisponibles ; voir /mcp",
+		"tool_running.title":     "Un outil est toujours en cours",
+		"tool_running.message":   "%s tourne depuis %s",
+		"escalation.reminder":    "Rappel %d sur %d : pas encore confirmé",
+		"budget.title":           "Budget épuisé",
+		"budget.tokens":          "%d jetons utilisés sur un budget de %d",
+		"budget.cost":            "Environ %.2f dépensés sur un budget de %.2f",
+		"budget.paused":          "La saisie est suspendue jusqu'à ce que vous envoyiez \"budget ack\"",
+		"progress.title":         "Travaille toujours",
+		"progress.message":       "Travaille depuis %s : %d lignes de sortie, %d fichiers modifiés",
+		"progress.files":         "Modifiés : %s",
+		"progress.more":          "et %d de plus",
+		"heartbeat.title":        "Toujours en cours",
+		"heartbeat.message":      "En cours depuis %s, %d tours terminés, dernière activité il y a %s",
+		"digest.title":           "Résumé quotidien",
+		"digest.none":            "Aucune session terminée ces dernières 24h",
+		"digest.sessions":        "%d sessions, actives pendant %s",
+		"digest.running":         "%d sessions toujours en cours",
+		"digest.notifications":   "Notifications : %s",
+		"digest.errors":          "Erreurs : %d",
+		"update.title":           "Une mise à jour est disponible",
+		"clipboard.title":        "Gemini a copié du texte",
+		"file_burst.title":       "Gemini a modifié de nombreux fichiers",
+		"file_burst.message":     "%d fichiers modifiés en %s :\n%s",
+		"file_burst.more":        "et %d de plus",
+		"sensitive_file.title":   "Gemini a touché un fichier sensible",
+		"sensitive_file.message": "%s a été modifié",
+		"commit.title":           "Nouveau commit sur %s",
+		"commits.title":          "%d nouveaux commits sur %s",
+		"tests_passed.title":     "Tests réussis",
+		"tests_failed.title":     "Tests échoués",
+		"tests.message":          "%s : %d échoués, %d réussis",
+		"tests.packages":         "%s : %d paquets échoués, %d réussis",
+		"tests.skipped":          "%d ignorés",
+		"build_error.title":      "La compilation a échoué",
+		"build_error.more":       "et %d autres erreurs",
+		"high_memory.title":      "Gemini utilise beaucoup de mémoire",
+		"high_memory.message":    "%s utilisés, au-delà de la limite de %s",
+		"high_cpu.title":         "Gemini occupe le processeur",
+		"high_cpu.message":       "%.0f %% de CPU depuis %s",
+		"pty_fallback.title":     "Gemini tourne sans terminal",
+		"pty_fallback.message":   "%v. La sortie passe par un tube, donc Gemini peut ne pas être interactif, et la barre d'état, les raccourcis et la saisie à distance sont indisponibles.",
+		"api_auth.title":         "Les identifiants de Gemini ont été refusés",
+		"api_auth.hint":          "Solution : se reconnecter (/auth) ou vérifier la clé d'API",
+		"api_quota.title":        "Gemini a atteint un quota ou une limite de débit",
+		"api_quota.hint":         "Solution : attendre la réinitialisation de la limite ou changer de modèle (/model)",
+		"api_server.title":       "L'API de Gemini rencontre des problèmes",
+		"api_server.hint":        "Solution : réessayer dans quelques minutes",
+		"api_error.title":        "L'API de Gemini a renvoyé une erreur",
+		"api_error.hint":         "Solution : vérifier le terminal, puis réessayer",
+		"max_duration.title":     "Gemini tourne depuis %s",
+		"max_duration.message":   "En cours depuis %s (démarré à %s)",
+		"max_duration.stopping":  "En cours depuis %s (démarré à %s), arrêt en cours",
+		"output_log.message":     "Journal de sortie : %s",
+		"idle.message":           "Inactif depuis %s (vous) / %s (Gemini)",
+		"prompt.message":         "à propos de : « %s »",
+		"model.message":          "Modèle : %s",
+		"git.clean":              "Arbre de travail propre",
+		"git.dirty":              "Arbre de travail : %d fichiers non validés",
+		"git.changed":            "%d fichiers modifiés pendant la session",
+	},
+	"ja": {
+		"backstop.title":         "Gemini が応答を待っています",
+		"backstop.message":       "入力を待っています",
+		"stalled.title":          "Gemini が停止しているようです",
+		"stalled.message":        "タスクの途中で止まっています（%s 出力なし）",
+		"resumed.title":          "Gemini が再開しました",
+		"resumed.message":        "%s 動きがなかった後に出力が再開しました",
+		"startup.title":          "Gemini CLI セッションを開始しました",
+		"startup.message":        "作業ディレクトリ: %s",
+		"approval.title":         "Gemini が承認を待っています",
+		"approval.message":       "ツールの承認を待っています",
+		"turn.title":             "Gemini が完了しました",
+		"turn.message":           "次のプロンプトを入力できます",
+		"turn.stats":             "ツール呼び出し: %d 回、所要 %s",
+		"tool_error.title":       "Gemini のツール呼び出しが失敗しました",
+		"tool_error.message":     "%s が失敗しました: %s",
+		"error.title":            "Gemini がエラーを報告しました",
+		"error.message":          "エラーで停止しました",
+		"command_start.title":    "%s を開始しました",
+		"command_start.message":  "コマンド: %s",
+		"command_finish.title":   "%s が終了しました",
+		"command_finish.message": "%s が終了しました (終了コード %d、%s)",
+		"command_error.title":    "%s が失敗しました",
+		"command_error.message":  "%s が失敗しました (終了コード %d、%s)",
+		"crash.title":            "Gemini が予期せず終了しました",
+		"crash.message":          "終了コード %d で終了しました",
+		"crash.killed":           "シグナルで強制終了されました",
+		"crash.signal":           "%s で強制終了されました",
+		"oom.title":              "Gemini がメモリ不足で強制終了されました",
+		"oom.message":            "メモリ不足のためカーネルが強制終了しました",
+		"exit.title":             "Gemini が終了しました",
+		"exit.message":           "終了コード %d で終了しました",
+		"stuck.title":            "Gemini が停止しているようです",
+		"stuck.message":          "%s 間出力がありません",
+		"no_response.title":      "Gemini が %s 応答していません",
+		"no_response.message":    "%s 応答がありません（%s に送信）",
+		"response_ready.title":   "Gemini の応答が完了しました",
+		"response_ready.message": "応答が完了しました（%s かかりました）",
+		"context.title":          "Gemini がコンテキストを圧縮しました",
+		"context.low_title":      "Gemini のコンテキストがほぼ一杯です",
+		"context.low_message":    "コンテキストウィンドウの残り %d%%",
+		"mcp_error.title":        "MCP サーバーが失敗しました",
+		"mcp_error.hint":         "そのツールは使えません。/mcp を確認してください",
+		"tool_running.title":     "ツールが実行中です",
+		"tool_running.message":   "%s が %s 実行中です",
+		"escalation.reminder":    "リマインダー %d/%d: まだ確認されていません",
+		"budget.title":           "予算を使い切りました",
+		"budget.tokens":          "%d トークンを使用 (予算 %d トークン)",
+		"budget.cost":            "約 %.2f を使用 (予算 %.2f)",
+		"budget.paused":          "\"budget ack\" を送るまで入力を停止しています",
+		"progress.title":         "作業中です",
+		"progress.message":       "%s 作業中: 出力 %d 行、編集したファイル %d 個",
+		"progress.files":         "編集: %s",
+		"progress.more":          "ほか %d 個",
+		"heartbeat.title":        "実行中です",
+		"heartbeat.message":      "%s 実行中、%d ターン完了、最後の動きは %s 前",
+		"digest.title":           "1日のまとめ",
+		"digest.none":            "過去 24 時間に終了したセッションはありません",
+		"digest.sessions":        "%d セッション、合計 %s 稼働",
+		"digest.running":         "%d セッションが実行中",
+		"digest.notifications":   "通知: %s",
+		"digest.errors":          "エラー: %d",
+		"update.title":           "アップデートがあります",
+		"clipboard.title":        "Gemini がテキストをコピーしました",
+		"file_burst.title":       "Gemini が多数のファイルを変更しました",
+		"file_burst.message":     "%[2]s 以内に %[1]d 個のファイルが変更されました:\n%[3]s",
+		"file_burst.more":        "他 %d 個",
+		"sensitive_file.title":   "Gemini が機密ファイルに触れました",
+		"sensitive_file.message": "%s が変更されました",
+		"commit.title":           "%s に新しいコミット",
+		"commits.title":          "%[2]s に %[1]d 件の新しいコミット",
+		"tests_passed.title":     "テストが成功しました",
+		"tests_failed.title":     "テストが失敗しました",
+		"tests.message":          "%s: 失敗 %d、成功 %d",
+		"tests.packages":         "%s: 失敗したパッケージ %d、成功 %d",
+		"tests.skipped":          "スキップ %d",
+		"build_error.title":      "ビルドが失敗しました",
+		"build_error.more":       "他 %d 件のエラー",
+		"high_memory.title":      "Gemini が大量のメモリを使用しています",
+		"high_memory.message":    "%s 使用中（上限 %s を超過）",
+		"high_cpu.title":         "Gemini が CPU を占有しています",
+		"high_cpu.message":       "CPU %.0f%% が %s 続いています",
+		"pty_fallback.title":     "Gemini が端末なしで実行されています",
+		"pty_fallback.message":   "%v。出力はパイプ経由のため、Gemini は対話的に動作しない可能性があり、ステータスライン、ホットキー、リモート入力は使用できません。",
+		"api_auth.title":         "Gemini の認証情報が拒否されました",
+		"api_auth.hint":          "対処: 再ログイン (/auth) するか API キーを確認してください",
+		"api_quota.title":        "Gemini がクォータまたはレート制限に達しました",
+		"api_quota.hint":         "対処: 制限のリセットを待つか、モデルを切り替えてください (/model)",
+		"api_server.title":       "Gemini の API に問題が発生しています",
+		"api_server.hint":        "対処: 数分後に再試行してください",
+		"api_error.title":        "Gemini の API がエラーを返しました",
+		"api_error.hint":         "対処: ターミナルを確認してから再試行してください",
+		"max_duration.title":     "Gemini が %s 実行されています",
+		"max_duration.message":   "%s 実行中です（%s に開始）",
+		"max_duration.stopping":  "%s 実行中です（%s に開始）。終了します",
+		"output_log.message":     "出力ログ: %s",
+		"idle.message":           "無操作 %s（あなた）/ %s（Gemini）",
+		"prompt.message":         "指示: 「%s」",
+		"model.message":          "モデル: %s",
+		"git.clean":              "作業ツリーはクリーンです",
+		"git.dirty":              "作業ツリー: 未コミットのファイル %d 件",
+		"git.changed":            "セッション中に変更されたファイル %d 件",
+	},
+}
+
+// DefaultMessages returns the English catalog
+func DefaultMessages() Messages {
+	return LoadMessages("en", nil)
+}
+
+// LoadMessages returns the catalog for locale (e.g. "de" or "de_DE"). Strings
+// missing from a catalog fall back to English. extra holds additional
+// catalogs by locale, which override the built-in ones.
+func LoadMessages(locale string, extra map[string]map[string]string) Messages {
+	messages := make(Messages)
+	merge := func(catalog map[string]string) {
+		for id, text := range catalog {
+			messages[id] = text
+		}
+	}
+
+	merge(builtinMessages["en"])
+
+	// Apply the language first, then the more specific region, e.g. de then de_DE
+	lang := locale
+	if i := strings.IndexAny(lang, "_-"); i >= 0 {
+		lang = lang[:i]
+	}
+	merge(builtinMessages[lang])
+	merge(extra[lang])
+	if locale != lang {
+		merge(builtinMessages[locale])
+		merge(extra[locale])
+	}
+
+	return messages
+}
+
+// Get returns the message with the given ID, formatted with args if any
+func (m Messages) Get(id string, args ...interface{}) string {
+	text, ok := m[id]
+	if !ok {
+		text = builtinMessages["en"][id]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// DetectLocale returns the user's message locale from the environment, e.g.
+// "de_DE" for LANG=de_DE.UTF-8, defaulting to "en"
+func DetectLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		// Strip the encoding and modifier, e.g. ".UTF-8" or "@euro"
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		if value == "C" || value == "POSIX" || value == "" {
+			return "en"
+		}
+		return value
+	}
+	return "en"
+}
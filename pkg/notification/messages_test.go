@@ -0,0 +1,65 @@
+package notification
+
+import "testing"
+
+func TestLoadMessages(t *testing.T) {
+	t.Run("built-in language", func(t *testing.T) {
+		m := LoadMessages("de_DE", nil)
+		if got := m.Get("backstop.title"); got != "Gemini braucht Aufmerksamkeit" {
+			t.Errorf("unexpected title %q", got)
+		}
+		if got := m.Get("startup.message", "/tmp"); got != "Arbeitsverzeichnis: /tmp" {
+			t.Errorf("unexpected message %q", got)
+		}
+	})
+
+	t.Run("unknown language falls back to English", func(t *testing.T) {
+		if got := LoadMessages("xx", nil).Get("backstop.title"); got != "Gemini needs attention" {
+			t.Errorf("unexpected title %q", got)
+		}
+	})
+
+	t.Run("extra catalogs override and fill in", func(t *testing.T) {
+		extra := map[string]map[string]string{
+			"nl":    {"backstop.title": "Gemini heeft aandacht nodig"},
+			"de_AT": {"backstop.message": "Nix los"},
+		}
+
+		nl := LoadMessages("nl_NL", extra)
+		if got := nl.Get("backstop.title"); got != "Gemini heeft aandacht nodig" {
+			t.Errorf("unexpected title %q", got)
+		}
+		if got := nl.Get("backstop.message"); got != "Waiting for your input" {
+			t.Errorf("expected English fallback, got %q", got)
+		}
+
+		at := LoadMessages("de_AT", extra)
+		if got := at.Get("backstop.message"); got != "Nix los" {
+			t.Errorf("expected region override, got %q", got)
+		}
+		if got := at.Get("backstop.title"); got != "Gemini braucht Aufmerksamkeit" {
+			t.Errorf("expected language catalog, got %q", got)
+		}
+	})
+}
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		lcAll, lang string
+		expected    string
+	}{
+		{"", "de_DE.UTF-8", "de_DE"},
+		{"fr_FR@euro", "de_DE.UTF-8", "fr_FR"},
+		{"", "C", "en"},
+		{"", "", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang)
+		if got := DetectLocale(); got != tt.expected {
+			t.Errorf("LC_ALL=%q LANG=%q: expected %q, got %q", tt.lcAll, tt.lang, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package notification
+
+// MultiNotifier sends every notification to several backends
+type MultiNotifier struct {
+	notifiers []Notifier
+}
+
+// NewMultiNotifier creates a notifier that fans out to all given notifiers
+func NewMultiNotifier(notifiers ...Notifier) *MultiNotifier {
+	return &MultiNotifier{
+		notifiers: notifiers,
+	}
+}
+
+// Send implements the Notifier interface. Every backend is tried even if an
+// earlier one fails; the first error is returned.
+func (mn *MultiNotifier) Send(notification Notification) error {
+	var firstErr error
+	for _, n := range mn.notifiers {
+		if err := n.Send(notification); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
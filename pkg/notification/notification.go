@@ -8,6 +8,32 @@ type Notification struct {
 	Message string
 	Time    time.Time
 	Pattern string
+	Actions []Action
+	// URL opened when the notification is tapped
+	Click string
+	// Priority from PriorityMin to PriorityUrgent (0 for the default)
+	Priority int
+	// ntfy tags (or emoji short codes) instead of those of the pattern
+	Tags []string
+	// Identifier of the session the notification comes from, so clients
+	// can tell sessions apart and group their notifications
+	Session string
+	// Keep the title as it is instead of replacing it with the session
+	// context, for titles rendered from a template or given by the sender
+	KeepTitle bool
+
+	// Optional file attached to the notification
+	Attachment     []byte
+	AttachmentName string
+}
+
+// Action is a button attached to a notification that publishes a message
+// when tapped
+type Action struct {
+	Label  string
+	URL    string
+	Method string
+	Body   string
 }
 
 // Notifier interface for sending notifications
@@ -2,9 +2,13 @@ package notification
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +17,11 @@ type NtfyClient struct {
 	server     string
 	topic      string
 	httpClient *http.Client
+	// Used to build a dedicated transport when the defaults don't apply
+	tlsConfig      *tls.Config
+	connectTimeout time.Duration
+	// Tags (or emoji short codes) per notification pattern
+	tags map[string][]string
 }
 
 // NewNtfyClient creates a new ntfy.sh client
@@ -21,50 +30,192 @@ func NewNtfyClient(server, topic string) *NtfyClient {
 		server: server,
 		topic:  topic,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
 		},
+		connectTimeout: DefaultConnectTimeout,
 	}
 }
 
+// SetTLSConfig sets the TLS configuration used to connect to the server,
+// e.g. to present a client certificate
+func (c *NtfyClient) SetTLSConfig(config *tls.Config) {
+	c.tlsConfig = config
+	c.updateTransport()
+}
+
+// SetTimeouts sets how long a whole send may take, and how long connecting
+// to the server may take within it. Zero keeps the default.
+func (c *NtfyClient) SetTimeouts(request, connect time.Duration) {
+	if request > 0 {
+		c.httpClient.Timeout = request
+	}
+	if connect > 0 {
+		c.connectTimeout = connect
+	}
+	c.updateTransport()
+}
+
+// updateTransport uses the shared transport unless the client needs its own
+// TLS or connection settings
+func (c *NtfyClient) updateTransport() {
+	if c.tlsConfig == nil && c.connectTimeout == DefaultConnectTimeout {
+		c.httpClient.Transport = sharedTransport
+		return
+	}
+	c.httpClient.Transport = newTransport(c.connectTimeout, c.tlsConfig)
+}
+
+// SetTags sets the ntfy tags sent for each notification pattern, e.g.
+// "backstop" -> ["hourglass"]. Patterns without an entry get the default
+// tags "gemini-cli" and the pattern name.
+func (c *NtfyClient) SetTags(tags map[string][]string) {
+	c.tags = tags
+}
+
+// tagsFor returns the ntfy tags for a notification: its own or those of
+// its pattern, and its session's identifier to tell sessions apart
+func (c *NtfyClient) tagsFor(notification Notification) []string {
+	tags, ok := notification.Tags, len(notification.Tags) > 0
+	if !ok {
+		tags, ok = c.tags[notification.Pattern]
+	}
+	if !ok {
+		tags = []string{"gemini-cli", notification.Pattern}
+	}
+	if notification.Session != "" {
+		tags = append(tags[:len(tags):len(tags)], notification.Session)
+	}
+	return tags
+}
+
 // Send sends a notification to ntfy.sh
 func (c *NtfyClient) Send(notification Notification) error {
 	if c.topic == "" {
 		return fmt.Errorf("ntfy topic not configured")
 	}
 
+	var req *http.Request
+	var err error
+	if len(notification.Attachment) > 0 {
+		req, err = c.newAttachmentRequest(notification)
+	} else {
+		req, err = c.newJSONRequest(notification)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Send the request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer drainBody(resp)
+
+	// Check response
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// newJSONRequest creates a JSON publish request for a plain notification
+func (c *NtfyClient) newJSONRequest(notification Notification) (*http.Request, error) {
 	// Create the request payload
 	payload := map[string]interface{}{
 		"topic":   c.topic,
 		"title":   notification.Title,
 		"message": notification.Message,
-		"tags":    []string{"gemini-cli", notification.Pattern},
+		"tags":    c.tagsFor(notification),
+	}
+	if len(notification.Actions) > 0 {
+		payload["actions"] = ntfyActions(notification.Actions)
+	}
+	if notification.Click != "" {
+		payload["click"] = notification.Click
+	}
+	if notification.Priority != 0 {
+		payload["priority"] = notification.Priority
 	}
 
-	jsonData, err := json.Marshal(payload)
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal notification: %w", err)
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
 	// Create the request
 	url := fmt.Sprintf("%s/", c.server)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	// Send the request
-	resp, err := c.httpClient.Do(req)
+	return req, nil
+}
+
+// newAttachmentRequest creates a publish request that uploads the
+// notification's attachment as the body, with metadata in headers
+func (c *NtfyClient) newAttachmentRequest(notification Notification) (*http.Request, error) {
+	url := fmt.Sprintf("%s/%s", c.server, c.topic)
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(notification.Attachment))
 	if err != nil {
-		return fmt.Errorf("failed to send notification: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	// Check response
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	filename := notification.AttachmentName
+	if filename == "" {
+		filename = "attachment.txt"
 	}
 
-	return nil
-}
\ No newline at end of file
+	// Non-ASCII header values are RFC 2047 encoded, which ntfy decodes
+	req.Header.Set("Title", mime.BEncoding.Encode("utf-8", notification.Title))
+	req.Header.Set("Message", mime.BEncoding.Encode("utf-8", notification.Message))
+	req.Header.Set("Tags", strings.Join(c.tagsFor(notification), ","))
+	req.Header.Set("Filename", filename)
+	if notification.Click != "" {
+		req.Header.Set("Click", notification.Click)
+	}
+	if notification.Priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(notification.Priority))
+	}
+
+	if len(notification.Actions) > 0 {
+		actions, err := json.Marshal(ntfyActions(notification.Actions))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal actions: %w", err)
+		}
+		req.Header.Set("Actions", string(actions))
+	}
+
+	return req, nil
+}
+
+// maxNtfyActions is the number of action buttons ntfy accepts per message
+const maxNtfyActions = 3
+
+// ntfyActions converts notification actions to ntfy http action buttons
+func ntfyActions(actions []Action) []map[string]interface{} {
+	if len(actions) > maxNtfyActions {
+		actions = actions[:maxNtfyActions]
+	}
+	result := make([]map[string]interface{}, 0, len(actions))
+	for _, action := range actions {
+		method := action.Method
+		if method == "" {
+			method = "POST"
+		}
+		result = append(result, map[string]interface{}{
+			"action": "http",
+			"label":  action.Label,
+			"url":    action.URL,
+			"method": method,
+			"body":   action.Body,
+			"clear":  true,
+		})
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package notification
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNtfyClientTags(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Tags []string `json:"tags"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		got = payload.Tags
+	}))
+	defer server.Close()
+
+	client := NewNtfyClient(server.URL, "topic")
+	client.SetTags(map[string][]string{"backstop": {"hourglass"}})
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"backstop", []string{"hourglass"}},
+		{"startup", []string{"gemini-cli", "startup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := client.Send(Notification{Title: "t", Pattern: tt.pattern}); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected tags %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("expected tags %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+
+	t.Run("attachment header", func(t *testing.T) {
+		var header string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Get("Tags")
+		}))
+		defer server.Close()
+
+		client := NewNtfyClient(server.URL, "topic")
+		client.SetTags(map[string][]string{"screen": {"camera", "gemini"}})
+		if err := client.Send(Notification{Pattern: "screen", Attachment: []byte("x")}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if header != "camera,gemini" {
+			t.Errorf("unexpected Tags header %q", header)
+		}
+	})
+
+	t.Run("notification tags", func(t *testing.T) {
+		if err := client.Send(Notification{Title: "t", Pattern: "backstop", Tags: []string{"skull"}}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if len(got) != 1 || got[0] != "skull" {
+			t.Errorf("expected tags [skull], got %v", got)
+		}
+	})
+
+	t.Run("session tag", func(t *testing.T) {
+		// The configured tags must not grow with every notification
+		for range 2 {
+			if err := client.Send(Notification{Title: "t", Pattern: "backstop", Session: "my-api"}); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if len(got) != 2 || got[0] != "hourglass" || got[1] != "my-api" {
+				t.Errorf("expected tags [hourglass my-api], got %v", got)
+			}
+		}
+	})
+}
+
+func TestNtfyClientReusesConnections(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ntfy answers with the published message
+		_, _ = w.Write([]byte(`{"id":"abc","event":"message"}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewNtfyClient(server.URL, "topic")
+	client.SetTimeouts(time.Second, time.Second)
+	for i := 0; i < 3; i++ {
+		if err := client.Send(Notification{Title: "t", Message: "m"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	if n := conns.Load(); n != 1 {
+		t.Errorf("expected sends to share 1 connection, got %d", n)
+	}
+}
+
+func BenchmarkNtfyClientNewJSONRequest(b *testing.B) {
+	client := NewNtfyClient("https://ntfy.example.com", "topic")
+	n := Notification{
+		Title:   "Gemini needs attention",
+		Message: "No output for 30s",
+		Pattern: "backstop",
+		Actions: []Action{{Label: "Quiet", URL: "https://ntfy.example.com/control", Body: "quiet on"}},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.newJSONRequest(n); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
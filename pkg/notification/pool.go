@@ -0,0 +1,32 @@
+package notification
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is the largest buffer returned to the pool, so a single
+// huge notification does not stay in memory
+const maxPooledBuffer = 64 * 1024
+
+// bufferPool holds buffers for formatting notifications
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+// putBuffer returns a buffer to the pool
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(b)
+}
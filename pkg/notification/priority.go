@@ -0,0 +1,127 @@
+package notification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Notification priorities, on ntfy's scale. A notification without a
+// priority has the default one.
+const (
+	PriorityMin     = 1
+	PriorityLow     = 2
+	PriorityDefault = 3
+	PriorityHigh    = 4
+	PriorityUrgent  = 5
+)
+
+// priorityNames maps the names accepted in the config to priorities
+var priorityNames = map[string]int{
+	"min":     PriorityMin,
+	"low":     PriorityLow,
+	"default": PriorityDefault,
+	"high":    PriorityHigh,
+	"urgent":  PriorityUrgent,
+	"max":     PriorityUrgent,
+}
+
+// DefaultPriorities are the priorities of the built-in notification types
+// that differ from the default: a dead or long stalled session is urgent,
+// and one stalled mid-task, stopped by an error, failing to reach the API,
+// without an MCP server's tools, running far longer than intended, over
+// its budget, touching a sensitive file or using too much memory or CPU is
+// worth a look, while news about the context window or an update, progress,
+// heartbeats, a session resuming and the daily digest are only informational
+var DefaultPriorities = map[string]int{
+	"crash":          PriorityUrgent,
+	"oom":            PriorityUrgent,
+	"stuck":          PriorityUrgent,
+	"stalled":        PriorityHigh,
+	"error":          PriorityHigh,
+	"api_auth":       PriorityHigh,
+	"api_quota":      PriorityHigh,
+	"api_server":     PriorityHigh,
+	"api_error":      PriorityHigh,
+	"mcp_error":      PriorityHigh,
+	"max_duration":   PriorityHigh,
+	"budget":         PriorityHigh,
+	"sensitive_file": PriorityHigh,
+	"high_memory":    PriorityHigh,
+	"high_cpu":       PriorityHigh,
+	"context":        PriorityLow,
+	"update":         PriorityLow,
+	"progress":       PriorityLow,
+	"heartbeat":      PriorityLow,
+	"resumed":        PriorityLow,
+	"digest":         PriorityLow,
+}
+
+// ParsePriority parses a priority given by name (min, low, default, high,
+// urgent or max) or number (1-5)
+func ParsePriority(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if p, ok := priorityNames[s]; ok {
+		return p, nil
+	}
+	if p, err := strconv.Atoi(s); err == nil && p >= PriorityMin && p <= PriorityUrgent {
+		return p, nil
+	}
+	return 0, fmt.Errorf("invalid priority %q (use min, low, default, high, urgent or 1-5)", s)
+}
+
+// priorityOf returns a notification's priority, filling in the default
+func priorityOf(notification Notification) int {
+	if notification.Priority == 0 {
+		return PriorityDefault
+	}
+	return notification.Priority
+}
+
+// PriorityNotifier wraps another notifier and gives notifications without a
+// priority the one configured for their type
+type PriorityNotifier struct {
+	underlying Notifier
+	priorities map[string]int
+}
+
+// NewPriorityNotifier creates a notifier that assigns priorities by
+// notification pattern
+func NewPriorityNotifier(underlying Notifier, priorities map[string]int) *PriorityNotifier {
+	return &PriorityNotifier{
+		underlying: underlying,
+		priorities: priorities,
+	}
+}
+
+// Send implements the Notifier interface
+func (pn *PriorityNotifier) Send(notification Notification) error {
+	if notification.Priority == 0 {
+		notification.Priority = pn.priorities[notification.Pattern]
+	}
+	return pn.underlying.Send(notification)
+}
+
+// MinPriorityNotifier wraps another notifier and drops notifications below a
+// priority, e.g. to keep a paid or intrusive channel for emergencies
+type MinPriorityNotifier struct {
+	underlying Notifier
+	min        int
+}
+
+// NewMinPriorityNotifier creates a notifier that only sends notifications of
+// at least the given priority
+func NewMinPriorityNotifier(underlying Notifier, min int) *MinPriorityNotifier {
+	return &MinPriorityNotifier{
+		underlying: underlying,
+		min:        min,
+	}
+}
+
+// Send implements the Notifier interface
+func (mn *MinPriorityNotifier) Send(notification Notification) error {
+	if priorityOf(notification) < mn.min {
+		return nil
+	}
+	return mn.underlying.Send(notification)
+}
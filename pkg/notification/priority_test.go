@@ -0,0 +1,45 @@
+package notification
+
+import "testing"
+
+func TestMinPriorityNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	priorities := NewPriorityNotifier(NewMinPriorityNotifier(rec, PriorityUrgent), DefaultPriorities)
+
+	for _, n := range []Notification{
+		{Pattern: "backstop"},
+		{Pattern: "stuck"},
+		{Pattern: "approval", Priority: PriorityHigh},
+		{Pattern: "backstop", Priority: PriorityUrgent},
+	} {
+		_ = priorities.Send(n)
+	}
+
+	if len(rec.sent) != 2 || rec.sent[0].Pattern != "stuck" || rec.sent[1].Pattern != "backstop" {
+		t.Errorf("expected only urgent notifications, got %+v", rec.sent)
+	}
+	if rec.sent[0].Priority != PriorityUrgent {
+		t.Errorf("expected stuck to be urgent by default, got %d", rec.sent[0].Priority)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"urgent", PriorityUrgent, false},
+		{"Max", PriorityUrgent, false},
+		{"low", PriorityLow, false},
+		{"2", PriorityLow, false},
+		{"0", 0, true},
+		{"loud", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("ParsePriority(%q) = %d, %v", tt.input, got, err)
+		}
+	}
+}
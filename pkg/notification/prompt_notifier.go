@@ -0,0 +1,38 @@
+package notification
+
+// PromptNotifier wraps another notifier and adds the prompt the user last
+// submitted to each message, e.g. "re: 'refactor the auth module'", so it
+// is clear what the session is working on
+type PromptNotifier struct {
+	underlying Notifier
+	messages   Messages
+	// prompt returns the last prompt, or "" if there was none
+	prompt func() string
+}
+
+// NewPromptNotifier creates a prompt notifier
+func NewPromptNotifier(underlying Notifier, prompt func() string) *PromptNotifier {
+	return &PromptNotifier{
+		underlying: underlying,
+		messages:   DefaultMessages(),
+		prompt:     prompt,
+	}
+}
+
+// SetMessages sets the catalog the prompt line is taken from
+func (pn *PromptNotifier) SetMessages(messages Messages) {
+	pn.messages = messages
+}
+
+// Send implements the Notifier interface
+func (pn *PromptNotifier) Send(notification Notification) error {
+	if prompt := pn.prompt(); prompt != "" {
+		line := pn.messages.Get("prompt.message", prompt)
+		if notification.Message == "" {
+			notification.Message = line
+		} else {
+			notification.Message += "\n" + line
+		}
+	}
+	return pn.underlying.Send(notification)
+}
@@ -0,0 +1,20 @@
+package notification
+
+import "testing"
+
+func TestPromptNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	prompt := ""
+	pn := NewPromptNotifier(rec, func() string { return prompt })
+
+	_ = pn.Send(Notification{Message: "Waiting for your input"})
+	if got := rec.sent[0].Message; got != "Waiting for your input" {
+		t.Errorf("expected the message unchanged without a prompt, got %q", got)
+	}
+
+	prompt = "refactor the auth module"
+	_ = pn.Send(Notification{Message: "Waiting for your input"})
+	if got := rec.sent[1].Message; got != "Waiting for your input\nre: 'refactor the auth module'" {
+		t.Errorf("expected the prompt after the message, got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"sync"
+	"time"
+)
+
+// QuietNotifier wraps another notifier and drops notifications while quiet
+// mode is enabled or a snooze is active
+type QuietNotifier struct {
+	underlying Notifier
+
+	mu           sync.Mutex
+	quiet        bool
+	snoozedUntil time.Time
+}
+
+// NewQuietNotifier creates a new quiet notifier
+func NewQuietNotifier(underlying Notifier, quiet bool) *QuietNotifier {
+	return &QuietNotifier{
+		underlying: underlying,
+		quiet:      quiet,
+	}
+}
+
+// Send implements the Notifier interface
+func (qn *QuietNotifier) Send(notification Notification) error {
+	if qn.IsSuppressed() {
+		return nil
+	}
+
+	return qn.underlying.Send(notification)
+}
+
+// SetQuiet enables or disables quiet mode
+func (qn *QuietNotifier) SetQuiet(quiet bool) {
+	qn.mu.Lock()
+	defer qn.mu.Unlock()
+	qn.quiet = quiet
+}
+
+// IsQuiet returns whether quiet mode is enabled
+func (qn *QuietNotifier) IsQuiet() bool {
+	qn.mu.Lock()
+	defer qn.mu.Unlock()
+	return qn.quiet
+}
+
+// Snooze suppresses notifications for the given duration; a non-positive
+// duration cancels any active snooze
+func (qn *QuietNotifier) Snooze(d time.Duration) {
+	qn.mu.Lock()
+	defer qn.mu.Unlock()
+
+	if d <= 0 {
+		qn.snoozedUntil = time.Time{}
+		return
+	}
+	qn.snoozedUntil = time.Now().Add(d)
+}
+
+// SnoozedUntil returns the end of the active snooze, or the zero time if none
+func (qn *QuietNotifier) SnoozedUntil() time.Time {
+	qn.mu.Lock()
+	defer qn.mu.Unlock()
+
+	if time.Now().After(qn.snoozedUntil) {
+		return time.Time{}
+	}
+	return qn.snoozedUntil
+}
+
+// IsSuppressed returns whether notifications are currently being dropped
+func (qn *QuietNotifier) IsSuppressed() bool {
+	qn.mu.Lock()
+	defer qn.mu.Unlock()
+	return qn.quiet || time.Now().Before(qn.snoozedUntil)
+}
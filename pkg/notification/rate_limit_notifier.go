@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows at most Max notifications in any Per long interval
+type RateLimit struct {
+	Max int
+	Per time.Duration
+}
+
+// ParseRateLimit parses a rate limit given as count/interval, e.g. "20/1h"
+func ParseRateLimit(value string) (RateLimit, error) {
+	count, interval, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit must be count/interval, e.g. 20/1h (got %q)", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("rate limit count must be a positive number (got %q)", count)
+	}
+	per, err := time.ParseDuration(strings.TrimSpace(interval))
+	if err != nil || per <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit interval must be a positive duration such as 10m (got %q)", interval)
+	}
+	return RateLimit{Max: n, Per: per}, nil
+}
+
+// RateLimitNotifier wraps another notifier and drops notifications over the
+// overall limit or over the limit of their type (pattern). A notification
+// dropped by its type's limit doesn't count towards the overall one, so a
+// chatty type can't use up the budget of the others.
+type RateLimitNotifier struct {
+	underlying Notifier
+	overall    RateLimit
+	patterns   map[string]RateLimit
+	now        func() time.Time
+
+	mu sync.Mutex
+	// Times of the notifications sent, overall and by pattern, oldest first
+	sent        []time.Time
+	patternSent map[string][]time.Time
+}
+
+// NewRateLimitNotifier creates a rate limiter with an overall limit (a zero
+// RateLimit for none) and limits by notification pattern
+func NewRateLimitNotifier(underlying Notifier, overall RateLimit, patterns map[string]RateLimit) *RateLimitNotifier {
+	return &RateLimitNotifier{
+		underlying:  underlying,
+		overall:     overall,
+		patterns:    patterns,
+		now:         time.Now,
+		patternSent: make(map[string][]time.Time),
+	}
+}
+
+// Send implements the Notifier interface
+func (rn *RateLimitNotifier) Send(notification Notification) error {
+	if !rn.allow(notification.Pattern) {
+		return nil
+	}
+	return rn.underlying.Send(notification)
+}
+
+// allow reports whether a notification of the pattern is within the limits,
+// and counts it if it is
+func (rn *RateLimitNotifier) allow(pattern string) bool {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	now := rn.now()
+	limit, limited := rn.patterns[pattern]
+	if limited {
+		rn.patternSent[pattern] = expire(rn.patternSent[pattern], now.Add(-limit.Per))
+		if len(rn.patternSent[pattern]) >= limit.Max {
+			return false
+		}
+	}
+	if rn.overall.Max > 0 {
+		rn.sent = expire(rn.sent, now.Add(-rn.overall.Per))
+		if len(rn.sent) >= rn.overall.Max {
+			return false
+		}
+		rn.sent = append(rn.sent, now)
+	}
+	if limited {
+		rn.patternSent[pattern] = append(rn.patternSent[pattern], now)
+	}
+	return true
+}
+
+// expire drops the times up to cutoff
+func expire(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
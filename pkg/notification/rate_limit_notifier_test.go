@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit(" 20 / 1h ")
+	if err != nil || limit != (RateLimit{Max: 20, Per: time.Hour}) {
+		t.Errorf("expected 20 per hour, got %+v, %v", limit, err)
+	}
+	for _, value := range []string{"", "20", "0/1h", "x/1h", "20/", "20/hour", "20/-1m"} {
+		if _, err := ParseRateLimit(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestRateLimitNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	rn := NewRateLimitNotifier(rec, RateLimit{Max: 3, Per: time.Hour}, map[string]RateLimit{
+		"progress": {Max: 1, Per: 10 * time.Minute},
+	})
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	rn.now = func() time.Time { return now }
+
+	send := func(patterns ...string) {
+		for _, pattern := range patterns {
+			_ = rn.Send(Notification{Pattern: pattern})
+		}
+	}
+	sent := func() []string {
+		var patterns []string
+		for _, n := range rec.sent {
+			patterns = append(patterns, n.Pattern)
+		}
+		rec.sent = nil
+		return patterns
+	}
+
+	// Progress over its own limit leaves the overall budget to the rest
+	send("progress", "progress", "progress", "backstop", "crash", "backstop")
+	if got := sent(); len(got) != 3 || got[0] != "progress" || got[1] != "backstop" || got[2] != "crash" {
+		t.Errorf("expected progress, backstop and crash, got %q", got)
+	}
+
+	// The type's limit frees up before the overall one
+	now = now.Add(10 * time.Minute)
+	send("progress")
+	if got := sent(); len(got) != 0 {
+		t.Errorf("expected the overall limit to hold, got %q", got)
+	}
+
+	// A notification dropped by the overall limit doesn't count for its type
+	now = now.Add(50 * time.Minute)
+	send("progress", "backstop")
+	if got := sent(); len(got) != 2 {
+		t.Errorf("expected both notifications once the hour is over, got %q", got)
+	}
+}
+
+func TestRateLimitNotifierPatternsOnly(t *testing.T) {
+	rec := &recordingNotifier{}
+	rn := NewRateLimitNotifier(rec, RateLimit{}, map[string]RateLimit{"tests_passed": {Max: 2, Per: time.Minute}})
+
+	for i := 0; i < 5; i++ {
+		_ = rn.Send(Notification{Pattern: "tests_passed"})
+		_ = rn.Send(Notification{Pattern: "backstop"})
+	}
+	if len(rec.sent) != 7 {
+		t.Errorf("expected 2 tests_passed and 5 backstop notifications, got %d", len(rec.sent))
+	}
+}
@@ -0,0 +1,60 @@
+package notification
+
+import "fmt"
+
+// Routing policies for RoutingNotifier
+const (
+	// RoutePush sends every notification to the push backend
+	RoutePush = "push"
+	// RouteDesktop sends every notification to the desktop backend
+	RouteDesktop = "desktop"
+	// RouteBoth sends every notification to both backends
+	RouteBoth = "both"
+	// RouteFocus uses the desktop while the user is at the terminal and push otherwise
+	RouteFocus = "focus"
+)
+
+// RoutingNotifier chooses between a push and a desktop backend for each
+// notification according to a routing policy
+type RoutingNotifier struct {
+	push    Notifier
+	desktop Notifier
+	policy  string
+	present func() bool
+}
+
+// NewRoutingNotifier creates a new routing notifier. present reports whether
+// the user is at the terminal (focused and not idle); it is only consulted
+// by the focus policy.
+func NewRoutingNotifier(push, desktop Notifier, policy string, present func() bool) *RoutingNotifier {
+	return &RoutingNotifier{
+		push:    push,
+		desktop: desktop,
+		policy:  policy,
+		present: present,
+	}
+}
+
+// Send implements the Notifier interface
+func (rn *RoutingNotifier) Send(notification Notification) error {
+	switch rn.policy {
+	case RouteDesktop:
+		return rn.desktop.Send(notification)
+	case RouteBoth:
+		pushErr := rn.push.Send(notification)
+		desktopErr := rn.desktop.Send(notification)
+		if pushErr != nil {
+			return pushErr
+		}
+		return desktopErr
+	case RouteFocus:
+		if rn.present != nil && rn.present() {
+			return rn.desktop.Send(notification)
+		}
+		return rn.push.Send(notification)
+	case RoutePush:
+		return rn.push.Send(notification)
+	default:
+		return fmt.Errorf("unknown routing policy %q", rn.policy)
+	}
+}
@@ -0,0 +1,43 @@
+package notification
+
+import "testing"
+
+func TestRoutingNotifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		present     bool
+		wantPush    int
+		wantDesktop int
+	}{
+		{"push", RoutePush, true, 1, 0},
+		{"desktop", RouteDesktop, false, 0, 1},
+		{"both", RouteBoth, false, 1, 1},
+		{"focus while present", RouteFocus, true, 0, 1},
+		{"focus while away", RouteFocus, false, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push, desktop := &recordingNotifier{}, &recordingNotifier{}
+			rn := NewRoutingNotifier(push, desktop, tt.policy, func() bool { return tt.present })
+
+			if err := rn.Send(Notification{Title: "test"}); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if len(push.sent) != tt.wantPush {
+				t.Errorf("expected %d push notifications, got %d", tt.wantPush, len(push.sent))
+			}
+			if len(desktop.sent) != tt.wantDesktop {
+				t.Errorf("expected %d desktop notifications, got %d", tt.wantDesktop, len(desktop.sent))
+			}
+		})
+	}
+
+	t.Run("unknown policy", func(t *testing.T) {
+		rn := NewRoutingNotifier(&recordingNotifier{}, &recordingNotifier{}, "carrier-pigeon", nil)
+		if err := rn.Send(Notification{}); err == nil {
+			t.Error("expected error for unknown policy")
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package notification
+
+import "github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
+
+// SanitizingNotifier wraps another notifier and removes escape sequences
+// and control characters from the text of every notification, since titles
+// and snippets taken from the terminal can contain them
+type SanitizingNotifier struct {
+	underlying Notifier
+}
+
+// NewSanitizingNotifier creates a new sanitizing notifier
+func NewSanitizingNotifier(underlying Notifier) *SanitizingNotifier {
+	return &SanitizingNotifier{
+		underlying: underlying,
+	}
+}
+
+// Send implements the Notifier interface
+func (sn *SanitizingNotifier) Send(notification Notification) error {
+	notification.Title = ansi.SanitizeLine(notification.Title)
+	notification.Message = ansi.Sanitize(notification.Message)
+	return sn.underlying.Send(notification)
+}
@@ -0,0 +1,38 @@
+package notification
+
+import "strings"
+
+// ScreenNotifier wraps another notifier and attaches the most recent lines
+// of the screen to notifications of the chosen types, so they come with
+// what the CLI was showing
+type ScreenNotifier struct {
+	underlying Notifier
+	types      map[string]bool
+	screen     func() []string
+}
+
+// NewScreenNotifier creates a notifier that attaches the lines screen
+// returns to notifications of the given types
+func NewScreenNotifier(underlying Notifier, types []string, screen func() []string) *ScreenNotifier {
+	enabled := make(map[string]bool, len(types))
+	for _, t := range types {
+		enabled[t] = true
+	}
+	return &ScreenNotifier{
+		underlying: underlying,
+		types:      enabled,
+		screen:     screen,
+	}
+}
+
+// Send implements the Notifier interface
+func (sn *ScreenNotifier) Send(notification Notification) error {
+	// A notification's own attachment, e.g. a requested snapshot, is kept
+	if sn.types[notification.Pattern] && notification.Attachment == nil {
+		if lines := sn.screen(); len(lines) > 0 {
+			notification.Attachment = []byte(strings.Join(lines, "\n") + "\n")
+			notification.AttachmentName = "screen.txt"
+		}
+	}
+	return sn.underlying.Send(notification)
+}
@@ -0,0 +1,28 @@
+package notification
+
+import "testing"
+
+func TestScreenNotifier(t *testing.T) {
+	rec := &countingNotifier{}
+	lines := []string{"✕ [API Error: 500 Internal error]", "> "}
+	sn := NewScreenNotifier(rec, []string{"crash", "api_server"}, func() []string { return lines })
+
+	_ = sn.Send(Notification{Pattern: "api_server"})
+	_ = sn.Send(Notification{Pattern: "backstop"})
+	_ = sn.Send(Notification{Pattern: "crash", Attachment: []byte("own"), AttachmentName: "own.txt"})
+	lines = nil
+	_ = sn.Send(Notification{Pattern: "crash"})
+
+	if got := string(rec.sent[0].Attachment); got != "✕ [API Error: 500 Internal error]\n> \n" || rec.sent[0].AttachmentName != "screen.txt" {
+		t.Errorf("expected the screen attached, got %q as %q", got, rec.sent[0].AttachmentName)
+	}
+	if rec.sent[1].Attachment != nil {
+		t.Error("expected no attachment for other types")
+	}
+	if rec.sent[2].AttachmentName != "own.txt" {
+		t.Errorf("expected the notification's own attachment to be kept, got %q", rec.sent[2].AttachmentName)
+	}
+	if rec.sent[3].Attachment != nil {
+		t.Error("expected no attachment without output")
+	}
+}
@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// signalRequestID numbers JSON-RPC requests so replies can be matched on a
+// socket shared with other messages from the daemon
+var signalRequestID atomic.Int64
+
+// signalRequest is a JSON-RPC request to signal-cli
+type signalRequest struct {
+	JSONRPC string       `json:"jsonrpc"`
+	Method  string       `json:"method"`
+	Params  signalParams `json:"params"`
+	ID      int64        `json:"id"`
+}
+
+type signalParams struct {
+	Account   string   `json:"account,omitempty"`
+	Recipient []string `json:"recipient,omitempty"`
+	GroupID   string   `json:"groupId,omitempty"`
+	Message   string   `json:"message"`
+}
+
+// signalResponse is a JSON-RPC response from signal-cli
+type signalResponse struct {
+	ID    *int64 `json:"id"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SignalNotifier sends notifications as Signal messages through a local
+// signal-cli daemon's JSON-RPC interface, started with --http or --socket
+type SignalNotifier struct {
+	// HTTP endpoint, e.g. http://127.0.0.1:8080/api/v1/rpc, or unix socket path
+	endpoint string
+	// Number of the signal-cli account to send from; only needed when the
+	// daemon serves several accounts
+	account    string
+	recipients []string
+	groupID    string
+	httpClient *http.Client
+}
+
+// NewSignalNotifier creates a notifier that sends to phone numbers or a
+// group (by its base64 ID) through the daemon at endpoint, which is either an
+// http(s) URL or the path of a unix socket
+func NewSignalNotifier(endpoint, account string, recipients []string, groupID string) *SignalNotifier {
+	return &SignalNotifier{
+		endpoint:   endpoint,
+		account:    account,
+		recipients: recipients,
+		groupID:    groupID,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// Send implements the Notifier interface
+func (sn *SignalNotifier) Send(notification Notification) error {
+	request := signalRequest{
+		JSONRPC: "2.0",
+		Method:  "send",
+		Params: signalParams{
+			Account:   sn.account,
+			Recipient: sn.recipients,
+			GroupID:   sn.groupID,
+			Message:   signalText(notification),
+		},
+		ID: signalRequestID.Add(1),
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Signal request: %w", err)
+	}
+
+	var response signalResponse
+	if strings.HasPrefix(sn.endpoint, "http://") || strings.HasPrefix(sn.endpoint, "https://") {
+		response, err = sn.callHTTP(body)
+	} else {
+		response, err = sn.callSocket(body, request.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("signal-cli failed to send: %s", response.Error.Message)
+	}
+	return nil
+}
+
+// signalText formats a notification as a message; Signal has no titles
+func signalText(notification Notification) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{notification.Title, notification.Message, notification.Click} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// callHTTP sends a request to a daemon started with --http
+func (sn *SignalNotifier) callHTTP(body []byte) (signalResponse, error) {
+	var response signalResponse
+
+	resp, err := sn.httpClient.Post(sn.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return response, fmt.Errorf("failed to reach signal-cli: %w", err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return response, fmt.Errorf("request to signal-cli failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return response, fmt.Errorf("invalid response from signal-cli: %w", err)
+	}
+	return response, nil
+}
+
+// callSocket sends a request to a daemon started with --socket, which
+// speaks newline-delimited JSON-RPC and may interleave notifications about
+// received messages with the reply
+func (sn *SignalNotifier) callSocket(body []byte, id int64) (signalResponse, error) {
+	var response signalResponse
+
+	conn, err := net.DialTimeout("unix", sn.endpoint, DefaultConnectTimeout)
+	if err != nil {
+		return response, fmt.Errorf("failed to reach signal-cli: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(DefaultRequestTimeout))
+
+	if _, err := conn.Write(append(body, '\n')); err != nil {
+		return response, fmt.Errorf("failed to send to signal-cli: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		response = signalResponse{}
+		if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			continue
+		}
+		if response.ID != nil && *response.ID == id {
+			return response, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return response, fmt.Errorf("failed to read from signal-cli: %w", err)
+	}
+	return response, fmt.Errorf("signal-cli closed the connection without replying")
+}
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignalNotifier(t *testing.T) {
+	n := Notification{Title: "Gemini CLI: app", Message: "No activity detected"}
+
+	t.Run("HTTP", func(t *testing.T) {
+		var request signalRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","result":{"timestamp":1},"id":%d}`, request.ID)
+		}))
+		defer server.Close()
+
+		sn := NewSignalNotifier(server.URL+"/api/v1/rpc", "+15550001", []string{"+15550002"}, "")
+		if err := sn.Send(n); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if request.Method != "send" || request.Params.Account != "+15550001" {
+			t.Errorf("unexpected request %+v", request)
+		}
+		if len(request.Params.Recipient) != 1 || request.Params.Recipient[0] != "+15550002" {
+			t.Errorf("unexpected recipients %v", request.Params.Recipient)
+		}
+		if request.Params.Message != "Gemini CLI: app\nNo activity detected" {
+			t.Errorf("unexpected message %q", request.Params.Message)
+		}
+	})
+
+	t.Run("socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "signal.sock")
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		defer func() { _ = listener.Close() }()
+
+		var request signalRequest
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			_ = json.Unmarshal(line, &request)
+			// A received message arrives before the reply
+			fmt.Fprintln(conn, `{"jsonrpc":"2.0","method":"receive","params":{}}`)
+			fmt.Fprintf(conn, `{"jsonrpc":"2.0","error":{"code":-1,"message":"Invalid group id"},"id":%d}`+"\n", request.ID)
+		}()
+
+		sn := NewSignalNotifier(path, "", nil, "Z3JvdXA=")
+		err = sn.Send(n)
+		if err == nil || err.Error() != "signal-cli failed to send: Invalid group id" {
+			t.Fatalf("expected the daemon's error, got %v", err)
+		}
+		if request.Params.GroupID != "Z3JvdXA=" || request.Params.Recipient != nil {
+			t.Errorf("unexpected request %+v", request)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ControlMessage is a message received on a subscribed ntfy topic
+type ControlMessage struct {
+	ID      string
+	Title   string
+	Message string
+	Time    time.Time
+	// Priority on ntfy's scale, PriorityDefault if the message set none
+	Priority int
+	Tags     []string
+}
+
+// ntfyEvent is the JSON payload of an ntfy SSE data line
+type ntfyEvent struct {
+	ID       string   `json:"id"`
+	Time     int64    `json:"time"`
+	Event    string   `json:"event"`
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags"`
+}
+
+// Subscriber listens on an ntfy topic via server-sent events and forwards
+// every received message to a handler
+type Subscriber struct {
+	server     string
+	topic      string
+	handler    func(ControlMessage)
+	verifier   *ControlVerifier
+	onError    func(error)
+	httpClient *http.Client
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	lastID string
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSubscriber creates a new ntfy topic subscriber
+func NewSubscriber(server, topic string, handler func(ControlMessage)) *Subscriber {
+	return &Subscriber{
+		server:  strings.TrimSuffix(server, "/"),
+		topic:   topic,
+		handler: handler,
+		// No overall timeout - the SSE stream is long-lived
+		httpClient: &http.Client{},
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// SetVerifier requires every message to be signed and verified before it is
+// forwarded to the handler. Must be called before Start.
+func (s *Subscriber) SetVerifier(verifier *ControlVerifier) {
+	s.verifier = verifier
+}
+
+// SetSince makes the first connection start with earlier messages, given
+// as anything ntfy accepts for since: a duration such as "10m", a Unix
+// time, a message ID or "all". Must be called before Start.
+func (s *Subscriber) SetSince(since string) {
+	s.lastID = since
+}
+
+// SetErrorHandler reports every failed connection to fn, which otherwise
+// is only logged in debug mode. Must be called before Start.
+func (s *Subscriber) SetErrorHandler(fn func(error)) {
+	s.onError = fn
+}
+
+// SetTLSConfig sets the TLS configuration used to connect to the server,
+// e.g. to present a client certificate. Must be called before Start.
+func (s *Subscriber) SetTLSConfig(config *tls.Config) {
+	s.httpClient.Transport = tlsTransport(config)
+}
+
+// Start begins listening in the background until Close is called
+func (s *Subscriber) Start() error {
+	if s.topic == "" {
+		return fmt.Errorf("subscriber topic not configured")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("subscriber already started")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+
+	return nil
+}
+
+// run keeps a subscription open, reconnecting after failures
+func (s *Subscriber) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		err := s.subscribe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && s.onError != nil {
+			s.onError(err)
+		} else if err != nil && os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: control subscription error: %v\n", err)
+		}
+
+		select {
+		case <-time.After(s.retryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribe opens a single SSE stream and reads it until it ends
+func (s *Subscriber) subscribe(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/%s/sse", s.server, url.PathEscape(s.topic))
+
+	// Resume after the last seen message so nothing is lost or replayed on reconnect
+	s.mu.Lock()
+	if s.lastID != "" {
+		endpoint += "?since=" + url.QueryEscape(s.lastID)
+	}
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy returned status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// Skip event names, ids and keepalive comments
+			continue
+		}
+		s.handleData(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+	}
+
+	return scanner.Err()
+}
+
+// handleData parses a single SSE data payload and dispatches messages
+func (s *Subscriber) handleData(data string) {
+	var event ntfyEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return
+	}
+
+	// Only actual messages are interesting, not open/keepalive events
+	if event.Event != "message" {
+		return
+	}
+
+	s.mu.Lock()
+	s.lastID = event.ID
+	s.mu.Unlock()
+
+	// Drop unauthenticated messages silently so attackers get no feedback
+	message := event.Message
+	if s.verifier != nil {
+		command, err := s.verifier.Verify(message)
+		if err != nil {
+			if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: rejected control message: %v\n", err)
+			}
+			return
+		}
+		message = command
+	}
+
+	if s.handler != nil {
+		s.handler(ControlMessage{
+			ID:       event.ID,
+			Title:    event.Title,
+			Message:  message,
+			Time:     time.Unix(event.Time, 0),
+			Priority: priorityOf(Notification{Priority: event.Priority}),
+			Tags:     event.Tags,
+		})
+	}
+}
+
+// Close stops the subscription and waits for it to finish
+func (s *Subscriber) Close() error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		s.wg.Wait()
+	}
+
+	return nil
+}
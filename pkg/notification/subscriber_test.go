@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscriber(t *testing.T) {
+	t.Run("forwards messages only", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/control/sse" {
+				t.Errorf("unexpected path %q", r.URL.Path)
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: open\n")
+			fmt.Fprint(w, `data: {"id":"a1","time":1700000000,"event":"open","topic":"control"}`+"\n\n")
+			fmt.Fprint(w, `data: {"id":"a2","time":1700000001,"event":"keepalive","topic":"control"}`+"\n\n")
+			fmt.Fprint(w, `data: {"id":"a3","time":1700000002,"event":"message","topic":"control","message":"quiet on"}`+"\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		received := make(chan ControlMessage, 10)
+		sub := NewSubscriber(server.URL, "control", func(msg ControlMessage) {
+			received <- msg
+		})
+		if err := sub.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer func() { _ = sub.Close() }()
+
+		select {
+		case msg := <-received:
+			if msg.Message != "quiet on" {
+				t.Errorf("expected 'quiet on', got %q", msg.Message)
+			}
+			if msg.ID != "a3" {
+				t.Errorf("expected id 'a3', got %q", msg.ID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for message")
+		}
+
+		select {
+		case msg := <-received:
+			t.Errorf("unexpected extra message: %+v", msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("resumes from last message on reconnect", func(t *testing.T) {
+		sinces := make(chan string, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sinces <- r.URL.Query().Get("since")
+			fmt.Fprint(w, `data: {"id":"m1","event":"message","message":"status"}`+"\n\n")
+		}))
+		defer server.Close()
+
+		sub := NewSubscriber(server.URL, "control", nil)
+		sub.retryDelay = 10 * time.Millisecond
+		if err := sub.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer func() { _ = sub.Close() }()
+
+		if since := <-sinces; since != "" {
+			t.Errorf("expected no since on first connect, got %q", since)
+		}
+		if since := <-sinces; since != "m1" {
+			t.Errorf("expected since=m1 on reconnect, got %q", since)
+		}
+	})
+
+	t.Run("starts from since", func(t *testing.T) {
+		sinces := make(chan string, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sinces <- r.URL.Query().Get("since")
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		sub := NewSubscriber(server.URL, "alerts", nil)
+		sub.SetSince("10m")
+		if err := sub.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer func() { _ = sub.Close() }()
+
+		if since := <-sinces; since != "10m" {
+			t.Errorf("expected since=10m, got %q", since)
+		}
+	})
+
+	t.Run("passes priority and tags", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `data: {"id":"b1","event":"message","message":"one","priority":5,"tags":["gemini-cli","crash"]}`+"\n\n")
+			fmt.Fprint(w, `data: {"id":"b2","event":"message","message":"two"}`+"\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		received := make(chan ControlMessage, 10)
+		sub := NewSubscriber(server.URL, "alerts", func(msg ControlMessage) {
+			received <- msg
+		})
+		if err := sub.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer func() { _ = sub.Close() }()
+
+		first, second := <-received, <-received
+		if first.Priority != PriorityUrgent || len(first.Tags) != 2 || first.Tags[1] != "crash" {
+			t.Errorf("expected urgent priority and tags, got %+v", first)
+		}
+		if second.Priority != PriorityDefault || second.Tags != nil {
+			t.Errorf("expected default priority and no tags, got %+v", second)
+		}
+	})
+
+	t.Run("reports connection errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		errs := make(chan error, 10)
+		sub := NewSubscriber(server.URL, "alerts", nil)
+		sub.retryDelay = time.Hour
+		sub.SetErrorHandler(func(err error) { errs <- err })
+		if err := sub.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		defer func() { _ = sub.Close() }()
+
+		select {
+		case err := <-errs:
+			if !strings.Contains(err.Error(), "403") {
+				t.Errorf("expected the status in the error, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the error")
+		}
+	})
+
+	t.Run("requires topic", func(t *testing.T) {
+		sub := NewSubscriber("https://ntfy.sh", "", nil)
+		if err := sub.Start(); err == nil {
+			t.Error("expected error for empty topic")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// adaptiveCardContentType identifies an Adaptive Card attachment
+const adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+
+// TeamsNotifier posts notifications to a Microsoft Teams channel through an
+// incoming webhook or a Workflows "post to a channel when a webhook request
+// is received" flow, as Adaptive Cards
+type TeamsNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewTeamsNotifier creates a notifier for the given webhook URL
+func NewTeamsNotifier(url string) *TeamsNotifier {
+	return &TeamsNotifier{
+		url: url,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// teamsMessage is a Teams message carrying one card
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     adaptiveCard `json:"content"`
+}
+
+// adaptiveCard is the subset of the Adaptive Card schema used for
+// notifications
+type adaptiveCard struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []map[string]interface{} `json:"actions,omitempty"`
+}
+
+// teamsCard lays out a notification as an Adaptive Card: the title as a
+// heading, the message, and the type, machine and time as facts
+func teamsCard(notification Notification) adaptiveCard {
+	body := []map[string]interface{}{
+		{
+			"type":   "TextBlock",
+			"text":   notification.Title,
+			"size":   "Medium",
+			"weight": "Bolder",
+			"wrap":   true,
+			"style":  "heading",
+		},
+	}
+	if notification.Message != "" {
+		body = append(body, map[string]interface{}{
+			"type": "TextBlock",
+			"text": notification.Message,
+			"wrap": true,
+		})
+	}
+
+	facts := []map[string]string{{"title": "Type", "value": notification.Pattern}}
+	if host, err := os.Hostname(); err == nil {
+		facts = append(facts, map[string]string{"title": "Host", "value": host})
+	}
+	if !notification.Time.IsZero() {
+		// Teams shows the time in the reader's own time zone
+		facts = append(facts, map[string]string{
+			"title": "Time",
+			"value": fmt.Sprintf("{{DATE(%[1]s, SHORT)}} {{TIME(%[1]s)}}", notification.Time.UTC().Format(time.RFC3339)),
+		})
+	}
+	body = append(body, map[string]interface{}{
+		"type":      "FactSet",
+		"facts":     facts,
+		"separator": true,
+	})
+
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    body,
+	}
+	if notification.Click != "" {
+		card.Actions = []map[string]interface{}{
+			{"type": "Action.OpenUrl", "title": "Open", "url": notification.Click},
+		}
+	}
+	return card
+}
+
+// Send implements the Notifier interface
+func (tn *TeamsNotifier) Send(notification Notification) error {
+	message := teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{
+			{ContentType: adaptiveCardContentType, Content: teamsCard(notification)},
+		},
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Teams message: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", tn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to Teams: %w", err)
+	}
+	defer drainBody(resp)
+
+	// Incoming webhooks answer 200 and workflows 202
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to Teams failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTeamsNotifier(t *testing.T) {
+	var message struct {
+		Type        string `json:"type"`
+		Attachments []struct {
+			ContentType string       `json:"contentType"`
+			Content     adaptiveCard `json:"content"`
+		} `json:"attachments"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tn := NewTeamsNotifier(server.URL)
+	err := tn.Send(Notification{
+		Title:   "Gemini CLI: app@main",
+		Message: "Waiting for tool approval",
+		Pattern: "approval",
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Click:   "https://example.com",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if message.Type != "message" || len(message.Attachments) != 1 {
+		t.Fatalf("unexpected message %+v", message)
+	}
+	attachment := message.Attachments[0]
+	if attachment.ContentType != adaptiveCardContentType {
+		t.Errorf("unexpected content type %q", attachment.ContentType)
+	}
+
+	card := attachment.Content
+	if card.Type != "AdaptiveCard" || len(card.Body) != 3 {
+		t.Fatalf("expected a card with title, message and facts, got %+v", card)
+	}
+	if card.Body[0]["text"] != "Gemini CLI: app@main" || card.Body[1]["text"] != "Waiting for tool approval" {
+		t.Errorf("unexpected text blocks %v", card.Body[:2])
+	}
+	facts, _ := card.Body[2]["facts"].([]interface{})
+	if len(facts) == 0 || facts[0].(map[string]interface{})["value"] != "approval" {
+		t.Errorf("expected the type as the first fact, got %v", facts)
+	}
+	if len(card.Actions) != 1 || card.Actions[0]["url"] != "https://example.com" {
+		t.Errorf("expected an open action, got %v", card.Actions)
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer failing.Close()
+
+		if err := NewTeamsNotifier(failing.URL).Send(Notification{Title: "t"}); err == nil {
+			t.Error("expected an error for status 400")
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MessageTemplate holds Go templates for a notification's title and
+// message; an empty template keeps the built-in text
+type MessageTemplate struct {
+	Title   string
+	Message string
+}
+
+// TemplateData is the data available to notification templates
+type TemplateData struct {
+	// The built-in title, message and pattern of the notification
+	Title   string
+	Message string
+	Pattern string
+	Time    time.Time
+
+	Cwd          string
+	GitBranch    string
+	Hostname     string
+	IdleDuration time.Duration
+	TailLines    []string
+	// How long the user has not typed, while IdleDuration is how long the
+	// CLI has printed nothing
+	InputIdleDuration time.Duration
+	// The last prompt the user submitted, if capture_prompt is set
+	Prompt string
+	// The model the session uses, if known
+	Model string
+}
+
+// templateFuncs are the helper functions available to notification templates
+var templateFuncs = template.FuncMap{
+	// join "\n" .TailLines
+	"join": func(sep string, lines []string) string {
+		return strings.Join(lines, sep)
+	},
+	// last 3 .TailLines
+	"last": func(n int, lines []string) []string {
+		if n >= 0 && len(lines) > n {
+			return lines[len(lines)-n:]
+		}
+		return lines
+	},
+}
+
+// parsedTemplate is a MessageTemplate ready for rendering
+type parsedTemplate struct {
+	title   *template.Template
+	message *template.Template
+}
+
+// TemplateNotifier wraps another notifier and renders the title and message
+// of notifications from user-defined templates, selected by pattern
+type TemplateNotifier struct {
+	underlying Notifier
+	templates  map[string]parsedTemplate
+	data       func() TemplateData
+}
+
+// NewTemplateNotifier creates a template notifier. templates maps a
+// notification pattern (e.g. "backstop") to its templates, and data returns
+// the context fields at the time a notification is sent.
+func NewTemplateNotifier(underlying Notifier, templates map[string]MessageTemplate, data func() TemplateData) (*TemplateNotifier, error) {
+	tn := &TemplateNotifier{
+		underlying: underlying,
+		templates:  make(map[string]parsedTemplate),
+		data:       data,
+	}
+
+	for pattern, t := range templates {
+		var parsed parsedTemplate
+		var err error
+		if t.Title != "" {
+			if parsed.title, err = template.New(pattern + " title").Funcs(templateFuncs).Parse(t.Title); err != nil {
+				return nil, fmt.Errorf("invalid %s title template: %w", pattern, err)
+			}
+		}
+		if t.Message != "" {
+			if parsed.message, err = template.New(pattern + " message").Funcs(templateFuncs).Parse(t.Message); err != nil {
+				return nil, fmt.Errorf("invalid %s message template: %w", pattern, err)
+			}
+		}
+		tn.templates[pattern] = parsed
+	}
+
+	return tn, nil
+}
+
+// Send implements the Notifier interface
+func (tn *TemplateNotifier) Send(notification Notification) error {
+	t, ok := tn.templates[notification.Pattern]
+	if !ok {
+		return tn.underlying.Send(notification)
+	}
+
+	var data TemplateData
+	if tn.data != nil {
+		data = tn.data()
+	}
+	data.Title = notification.Title
+	data.Message = notification.Message
+	data.Pattern = notification.Pattern
+	data.Time = notification.Time
+
+	// A template that fails to render falls back to the built-in text; a
+	// rendered title is not replaced with the session context
+	if title, ok := render(t.title, data); ok {
+		notification.Title = title
+		notification.KeepTitle = true
+	}
+	if message, ok := render(t.message, data); ok {
+		notification.Message = message
+	}
+
+	return tn.underlying.Send(notification)
+}
+
+// render executes tmpl with data, reporting false if tmpl is nil or fails
+func render(tmpl *template.Template, data TemplateData) (string, bool) {
+	if tmpl == nil {
+		return "", false
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	if err := tmpl.Execute(b, data); err != nil {
+		if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to render template: %v\n", err)
+		}
+		return "", false
+	}
+	return b.String(), true
+}
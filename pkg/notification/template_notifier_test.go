@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateNotifier(t *testing.T) {
+	data := func() TemplateData {
+		return TemplateData{
+			Cwd:          "/home/me/project",
+			GitBranch:    "main",
+			Hostname:     "devbox",
+			IdleDuration: 45 * time.Second,
+			TailLines:    []string{"one", "two", "three"},
+		}
+	}
+
+	t.Run("renders configured pattern", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		tn, err := NewTemplateNotifier(rec, map[string]MessageTemplate{
+			"backstop": {
+				Title:   "{{.Hostname}} ({{.GitBranch}}): {{.Title}}",
+				Message: "idle {{.IdleDuration}}\n{{join \"\\n\" (last 2 .TailLines)}}",
+			},
+		}, data)
+		if err != nil {
+			t.Fatalf("NewTemplateNotifier failed: %v", err)
+		}
+
+		_ = tn.Send(Notification{Title: "Gemini needs attention", Message: "No activity detected", Pattern: "backstop"})
+
+		if got := rec.sent[0].Title; got != "devbox (main): Gemini needs attention" {
+			t.Errorf("unexpected title %q", got)
+		}
+		if !rec.sent[0].KeepTitle {
+			t.Error("expected the rendered title to be kept")
+		}
+		if got := rec.sent[0].Message; got != "idle 45s\ntwo\nthree" {
+			t.Errorf("unexpected message %q", got)
+		}
+	})
+
+	t.Run("keeps built-in text for other patterns", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		tn, err := NewTemplateNotifier(rec, map[string]MessageTemplate{
+			"backstop": {Title: "custom"},
+		}, data)
+		if err != nil {
+			t.Fatalf("NewTemplateNotifier failed: %v", err)
+		}
+
+		_ = tn.Send(Notification{Title: "Gemini CLI Session Started", Message: "Working directory: /tmp", Pattern: "startup"})
+
+		if rec.sent[0].Title != "Gemini CLI Session Started" || rec.sent[0].Message != "Working directory: /tmp" {
+			t.Errorf("expected unchanged notification, got %+v", rec.sent[0])
+		}
+	})
+
+	t.Run("empty template keeps built-in text", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		tn, _ := NewTemplateNotifier(rec, map[string]MessageTemplate{
+			"startup": {Title: "Started in {{.Cwd}}"},
+		}, data)
+
+		_ = tn.Send(Notification{Title: "Gemini CLI Session Started", Message: "Working directory: /tmp", Pattern: "startup"})
+
+		if rec.sent[0].Title != "Started in /home/me/project" {
+			t.Errorf("unexpected title %q", rec.sent[0].Title)
+		}
+		if rec.sent[0].Message != "Working directory: /tmp" {
+			t.Errorf("expected built-in message, got %q", rec.sent[0].Message)
+		}
+	})
+
+	t.Run("render failure falls back", func(t *testing.T) {
+		rec := &recordingNotifier{}
+		tn, _ := NewTemplateNotifier(rec, map[string]MessageTemplate{
+			"backstop": {Title: "{{.Missing}}"},
+		}, data)
+
+		_ = tn.Send(Notification{Title: "Gemini needs attention", Pattern: "backstop"})
+
+		if rec.sent[0].Title != "Gemini needs attention" || rec.sent[0].KeepTitle {
+			t.Errorf("expected fallback title, got %q", rec.sent[0].Title)
+		}
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		if _, err := NewTemplateNotifier(&recordingNotifier{}, map[string]MessageTemplate{
+			"backstop": {Message: "{{.Title"},
+		}, data); err == nil {
+			t.Error("expected parse error")
+		}
+	})
+}
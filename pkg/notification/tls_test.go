@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeClientCert creates a self-signed client certificate and key in dir
+// and returns their paths and the parsed certificate
+func writeClientCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gemini-cli-ntfy"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile, cert
+}
+
+func TestNtfyClientMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeClientCert(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	// Trust the test server's certificate through the CA file option
+	caFile := filepath.Join(dir, "ca.crt")
+	_ = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+
+	t.Run("with client certificate", func(t *testing.T) {
+		tlsConfig, err := NewTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			t.Fatalf("NewTLSConfig failed: %v", err)
+		}
+		client := NewNtfyClient(server.URL, "topic")
+		client.SetTLSConfig(tlsConfig)
+
+		if err := client.Send(Notification{Title: "t"}); err != nil {
+			t.Errorf("Send failed: %v", err)
+		}
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		tlsConfig, err := NewTLSConfig("", "", caFile)
+		if err != nil {
+			t.Fatalf("NewTLSConfig failed: %v", err)
+		}
+		client := NewNtfyClient(server.URL, "topic")
+		client.SetTLSConfig(tlsConfig)
+
+		if err := client.Send(Notification{Title: "t"}); err == nil {
+			t.Error("expected the server to reject the connection")
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		if _, err := NewTLSConfig(filepath.Join(dir, "missing.crt"), keyFile, ""); err == nil {
+			t.Error("expected error for missing certificate")
+		}
+		if _, err := NewTLSConfig("", "", keyFile); err == nil {
+			t.Error("expected error for CA file without certificates")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package notification
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// tmuxTarget returns the session:window.pane of the pane the wrapper runs
+// in, or an empty string when not running inside tmux
+func tmuxTarget() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+
+	args := []string{"display-message", "-p"}
+	// Target our own pane rather than whichever pane is currently active
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	args = append(args, "#S:#I.#P")
+
+	// #nosec G204 -- Arguments are fixed apart from the pane id set by tmux
+	out, err := exec.Command("tmux", args...).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// tmuxSwitchCommand returns the shell command that jumps to a tmux target
+func tmuxSwitchCommand(target string) string {
+	return "tmux switch-client -t " + target
+}
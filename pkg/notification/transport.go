@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Default timeouts for talking to notification services
+const (
+	// DefaultRequestTimeout bounds a whole send, including reading the reply
+	DefaultRequestTimeout = 10 * time.Second
+	// DefaultConnectTimeout bounds establishing a new connection
+	DefaultConnectTimeout = 5 * time.Second
+)
+
+// maxDrainSize is how much of an unread response body is read so the
+// connection can be reused; longer bodies are abandoned with their connection
+const maxDrainSize = 64 * 1024
+
+// sharedTransport is used by all clients with the default connection
+// settings, so notifications to the same server share warm connections
+var sharedTransport = newTransport(DefaultConnectTimeout, nil)
+
+// newTransport returns a transport that keeps connections alive between
+// sends and negotiates HTTP/2 where the server offers it, so only the first
+// notification pays for the TCP and TLS handshakes
+func newTransport(connectTimeout time.Duration, config *tls.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       config,
+		TLSHandshakeTimeout:   connectTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          16,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// drainBody reads what is left of a response body and closes it, which lets
+// the transport reuse the connection
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainSize))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,81 @@
+package notification
+
+import "strings"
+
+// codeFence delimits a markdown code block
+const codeFence = "```"
+
+// TruncatingNotifier wraps another notifier and shortens titles and
+// messages that exceed the configured lengths
+type TruncatingNotifier struct {
+	underlying       Notifier
+	maxTitleLength   int
+	maxMessageLength int
+}
+
+// NewTruncatingNotifier creates a truncating notifier. Lengths are in
+// characters; zero means no limit.
+func NewTruncatingNotifier(underlying Notifier, maxTitleLength, maxMessageLength int) *TruncatingNotifier {
+	return &TruncatingNotifier{
+		underlying:       underlying,
+		maxTitleLength:   maxTitleLength,
+		maxMessageLength: maxMessageLength,
+	}
+}
+
+// Send implements the Notifier interface
+func (tn *TruncatingNotifier) Send(notification Notification) error {
+	notification.Title = TruncateMiddle(notification.Title, tn.maxTitleLength)
+	notification.Message = TruncateMiddle(notification.Message, tn.maxMessageLength)
+	return tn.underlying.Send(notification)
+}
+
+// TruncateMiddle shortens s to at most max characters by cutting out its
+// middle, which keeps both how a message starts and how it ends. Multi-line
+// text is cut at line boundaries where possible, and code blocks split by
+// the cut are closed and reopened so they still render.
+func TruncateMiddle(s string, max int) string {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+
+	multiline := strings.Contains(s, "\n")
+	ellipsis := "…"
+	if multiline {
+		ellipsis = "\n…\n"
+	}
+
+	budget := max - len([]rune(ellipsis))
+	if strings.Contains(s, codeFence) {
+		// Room to close and reopen a code block around the cut
+		budget -= 2 * len(codeFence+"\n")
+	}
+	if budget < 2 {
+		return string(runes[:max-1]) + "…"
+	}
+
+	headLen := (budget + 1) / 2
+	tailLen := budget - headLen
+	head := string(runes[:headLen])
+	tail := string(runes[len(runes)-tailLen:])
+
+	if multiline {
+		// Prefer whole lines unless that would throw away most of a side
+		if i := strings.LastIndexByte(head, '\n'); i >= len(head)/2 {
+			head = head[:i]
+		}
+		if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)/2 {
+			tail = tail[i+1:]
+		}
+	}
+
+	if strings.Count(head, codeFence)%2 == 1 {
+		head += "\n" + codeFence
+	}
+	if strings.Count(s[:len(s)-len(tail)], codeFence)%2 == 1 {
+		tail = codeFence + "\n" + tail
+	}
+
+	return head + ellipsis + tail
+}
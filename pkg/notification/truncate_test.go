@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	t.Run("short text unchanged", func(t *testing.T) {
+		if got := TruncateMiddle("hello", 10); got != "hello" {
+			t.Errorf("got %q", got)
+		}
+		if got := TruncateMiddle("hello", 0); got != "hello" {
+			t.Errorf("zero limit should not truncate, got %q", got)
+		}
+	})
+
+	t.Run("single line keeps both ends", func(t *testing.T) {
+		got := TruncateMiddle("Gemini CLI: devbox:project - a very long title", 20)
+		if utf8.RuneCountInString(got) > 20 {
+			t.Errorf("result too long: %q", got)
+		}
+		if !strings.HasPrefix(got, "Gemini CLI") || !strings.HasSuffix(got, "title") || !strings.Contains(got, "…") {
+			t.Errorf("expected middle ellipsis, got %q", got)
+		}
+	})
+
+	t.Run("multi-line cuts at line boundaries", func(t *testing.T) {
+		var lines []string
+		for i := 0; i < 20; i++ {
+			lines = append(lines, strings.Repeat("x", 9))
+		}
+		got := TruncateMiddle(strings.Join(lines, "\n"), 60)
+		if utf8.RuneCountInString(got) > 60 {
+			t.Errorf("result too long (%d): %q", utf8.RuneCountInString(got), got)
+		}
+		for _, line := range strings.Split(got, "\n") {
+			if line != "…" && line != strings.Repeat("x", 9) {
+				t.Errorf("expected whole lines, got line %q in %q", line, got)
+			}
+		}
+	})
+
+	t.Run("code block split by cut stays balanced", func(t *testing.T) {
+		body := "Result:\n```\n" + strings.Repeat("line of code\n", 30) + "```\ndone"
+		got := TruncateMiddle(body, 100)
+		if utf8.RuneCountInString(got) > 100 {
+			t.Errorf("result too long (%d): %q", utf8.RuneCountInString(got), got)
+		}
+
+		parts := strings.SplitN(got, "\n…\n", 2)
+		if len(parts) != 2 {
+			t.Fatalf("expected ellipsis line, got %q", got)
+		}
+		if strings.Count(parts[0], codeFence)%2 != 0 {
+			t.Errorf("head leaves code block open: %q", parts[0])
+		}
+		if strings.Count(parts[1], codeFence)%2 != 0 {
+			t.Errorf("tail has unbalanced code block: %q", parts[1])
+		}
+	})
+}
+
+// nopNotifier discards notifications
+type nopNotifier struct{}
+
+func (nopNotifier) Send(Notification) error { return nil }
+
+// BenchmarkNotificationFormatting measures the formatting wrappers every
+// notification passes through on its way to a backend
+func BenchmarkNotificationFormatting(b *testing.B) {
+	chain := NewSanitizingNotifier(NewTruncatingNotifier(nopNotifier{}, 250, 4000))
+	n := Notification{
+		Title:   "\033[1mGemini\033[0m needs attention",
+		Message: strings.Repeat("\033[32m+ changed line in a long diff\033[0m\n", 200),
+		Pattern: "backstop",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := chain.Send(n); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
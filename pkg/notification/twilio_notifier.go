@@ -0,0 +1,87 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// twilioAPI is the base URL of Twilio's REST API
+const twilioAPI = "https://api.twilio.com/2010-04-01"
+
+// maxSMSLength keeps texts to two SMS segments; the rest is cut out
+const maxSMSLength = 306
+
+// TwilioNotifier sends notifications as SMS through Twilio, to reach a
+// phone without a data connection
+type TwilioNotifier struct {
+	url        string
+	accountSID string
+	authToken  string
+	from       string
+	to         []string
+	httpClient *http.Client
+}
+
+// NewTwilioNotifier creates a notifier that texts each of the to numbers
+// from a Twilio number
+func NewTwilioNotifier(accountSID, authToken, from string, to []string) *TwilioNotifier {
+	return &TwilioNotifier{
+		url:        fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPI, url.PathEscape(accountSID)),
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		to:         to,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// Send implements the Notifier interface. Every number is tried even if an
+// earlier one fails; the first error is returned.
+func (tn *TwilioNotifier) Send(notification Notification) error {
+	text := notification.Title
+	if notification.Message != "" {
+		text += "\n" + notification.Message
+	}
+	text = TruncateMiddle(strings.TrimSpace(text), maxSMSLength)
+
+	var firstErr error
+	for _, to := range tn.to {
+		if err := tn.sendSMS(to, text); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sendSMS sends one text message
+func (tn *TwilioNotifier) sendSMS(to, text string) error {
+	form := url.Values{
+		"From": {tn.from},
+		"To":   {to},
+		"Body": {text},
+	}
+	req, err := http.NewRequest("POST", tn.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(tn.accountSID, tn.authToken)
+
+	resp, err := tn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer drainBody(resp)
+
+	// Twilio answers 201 Created once the message is queued
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request to Twilio failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
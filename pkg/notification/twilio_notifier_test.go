@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwilioNotifier(t *testing.T) {
+	var path, user, pass string
+	var to, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		_ = r.ParseForm()
+		to = append(to, r.PostForm.Get("To"))
+		bodies = append(bodies, r.PostForm.Get("Body"))
+		if r.PostForm.Get("From") != "+15550000" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	tn := NewTwilioNotifier("AC123", "token", "+15550000", []string{"+15550001", "+15550002"})
+	tn.url = server.URL + "/Accounts/AC123/Messages.json"
+
+	err := tn.Send(Notification{Title: "Gemini seems stuck", Message: strings.Repeat("output line\n", 100)})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if path != "/Accounts/AC123/Messages.json" || user != "AC123" || pass != "token" {
+		t.Errorf("unexpected request to %q as %q:%q", path, user, pass)
+	}
+	if len(to) != 2 || to[0] != "+15550001" || to[1] != "+15550002" {
+		t.Errorf("expected a text to each number, got %v", to)
+	}
+	if len([]rune(bodies[0])) > maxSMSLength || !strings.HasPrefix(bodies[0], "Gemini seems stuck\n") {
+		t.Errorf("expected a shortened text starting with the title, got %q", bodies[0])
+	}
+}
@@ -0,0 +1,46 @@
+package notification
+
+// TypeFilterNotifier wraps another notifier and drops notifications of the
+// types (patterns) that have been turned off
+type TypeFilterNotifier struct {
+	underlying Notifier
+	enabled    map[string]bool
+	// Whether patterns without an entry are sent
+	others bool
+}
+
+// NewTypeFilterNotifier creates a type filter. enabled maps a notification
+// pattern to whether it is sent; patterns without an entry are sent.
+func NewTypeFilterNotifier(underlying Notifier, enabled map[string]bool) *TypeFilterNotifier {
+	return &TypeFilterNotifier{
+		underlying: underlying,
+		enabled:    enabled,
+		others:     true,
+	}
+}
+
+// NewTypeAllowNotifier creates a type filter that only sends notifications
+// of the given types
+func NewTypeAllowNotifier(underlying Notifier, types []string) *TypeFilterNotifier {
+	enabled := make(map[string]bool, len(types))
+	for _, t := range types {
+		enabled[t] = true
+	}
+	return &TypeFilterNotifier{
+		underlying: underlying,
+		enabled:    enabled,
+	}
+}
+
+// Send implements the Notifier interface
+func (fn *TypeFilterNotifier) Send(notification Notification) error {
+	enabled, ok := fn.enabled[notification.Pattern]
+	if !ok {
+		enabled = fn.others
+	}
+	if !enabled {
+		return nil
+	}
+
+	return fn.underlying.Send(notification)
+}
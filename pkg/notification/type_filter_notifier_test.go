@@ -0,0 +1,35 @@
+package notification
+
+import "testing"
+
+func TestTypeFilterNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	fn := NewTypeFilterNotifier(rec, map[string]bool{"startup": false, "backstop": true})
+
+	for _, pattern := range []string{"startup", "backstop", "exit"} {
+		_ = fn.Send(Notification{Pattern: pattern})
+	}
+
+	if len(rec.sent) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(rec.sent))
+	}
+	if rec.sent[0].Pattern != "backstop" || rec.sent[1].Pattern != "exit" {
+		t.Errorf("unexpected notifications %+v", rec.sent)
+	}
+}
+
+func TestTypeAllowNotifier(t *testing.T) {
+	rec := &recordingNotifier{}
+	fn := NewTypeAllowNotifier(rec, []string{"crash", "stuck"})
+
+	for _, pattern := range []string{"startup", "crash", "backstop", "stuck"} {
+		_ = fn.Send(Notification{Pattern: pattern})
+	}
+
+	if len(rec.sent) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(rec.sent))
+	}
+	if rec.sent[0].Pattern != "crash" || rec.sent[1].Pattern != "stuck" {
+		t.Errorf("unexpected notifications %+v", rec.sent)
+	}
+}
@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of the request body in the
+// same "sha256=<hex>" form GitHub uses, so existing receivers can verify it
+const WebhookSignatureHeader = "X-Hub-Signature-256"
+
+// webhookPayload is the JSON body posted to a webhook
+type webhookPayload struct {
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Pattern string    `json:"pattern"`
+	Time    time.Time `json:"time"`
+	// Set when the notification's priority isn't the default
+	Priority int    `json:"priority,omitempty"`
+	Session  string `json:"session,omitempty"`
+}
+
+// WebhookNotifier posts notifications as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier. If secret is set, every
+// request body is signed with it.
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		secret: []byte(secret),
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// Send implements the Notifier interface
+func (wn *WebhookNotifier) Send(notification Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		Title:    notification.Title,
+		Message:  notification.Message,
+		Pattern:  notification.Pattern,
+		Time:     notification.Time,
+		Priority: notification.Priority,
+		Session:  notification.Session,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", wn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(wn.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+webhookSignature(wn.secret, body))
+	}
+
+	resp, err := wn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// webhookSignature computes the hex HMAC-SHA256 of a webhook body
+func webhookSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var body []byte
+	var signature string
+	var contentLength int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		contentLength = r.ContentLength
+		signature = r.Header.Get(WebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	t.Run("signs body", func(t *testing.T) {
+		wn := NewWebhookNotifier(server.URL, "s3cret")
+		if err := wn.Send(Notification{Title: "Gemini needs attention", Pattern: "backstop"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("invalid payload: %v", err)
+		}
+		if payload.Title != "Gemini needs attention" || payload.Pattern != "backstop" {
+			t.Errorf("unexpected payload %+v", payload)
+		}
+
+		// Verify the way a receiver would
+		expected := "sha256=" + webhookSignature([]byte("s3cret"), body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			t.Errorf("signature %q does not match %q", signature, expected)
+		}
+		want, _ := json.Marshal(payload)
+		if string(body) != string(want) {
+			t.Errorf("expected the body to be exactly the payload, got %q", body)
+		}
+	})
+
+	t.Run("resends body on redirect", func(t *testing.T) {
+		body = nil
+		wn := NewWebhookNotifier(server.URL+"/moved", "s3cret")
+		if err := wn.Send(Notification{Title: "Gemini needs attention"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if contentLength != int64(len(body)) || len(body) == 0 {
+			t.Fatalf("expected the body with its length after the redirect, got %d bytes of %d", len(body), contentLength)
+		}
+		if signature != "sha256="+webhookSignature([]byte("s3cret"), body) {
+			t.Errorf("signature %q does not match the redirected body", signature)
+		}
+	})
+
+	t.Run("unsigned without secret", func(t *testing.T) {
+		wn := NewWebhookNotifier(server.URL, "")
+		if err := wn.Send(Notification{Title: "t"}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if signature != "" {
+			t.Errorf("expected no signature, got %q", signature)
+		}
+	})
+}
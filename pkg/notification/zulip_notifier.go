@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// zulipResponse is the result Zulip reports in its response body
+type zulipResponse struct {
+	Result string `json:"result"`
+	Msg    string `json:"msg"`
+}
+
+// ZulipNotifier posts notifications to a Zulip stream topic as a bot
+type ZulipNotifier struct {
+	url        string
+	email      string
+	apiKey     string
+	stream     string
+	topic      string
+	httpClient *http.Client
+}
+
+// NewZulipNotifier creates a notifier that posts to a stream and topic on
+// the Zulip site at siteURL, e.g. https://example.zulipchat.com, with a
+// bot's email address and API key
+func NewZulipNotifier(siteURL, email, apiKey, stream, topic string) *ZulipNotifier {
+	return &ZulipNotifier{
+		url:    strings.TrimSuffix(siteURL, "/") + "/api/v1/messages",
+		email:  email,
+		apiKey: apiKey,
+		stream: stream,
+		topic:  topic,
+		httpClient: &http.Client{
+			Timeout:   DefaultRequestTimeout,
+			Transport: sharedTransport,
+		},
+	}
+}
+
+// chatMarkdown formats a notification as Markdown for team chats: the title
+// in bold, the message, and a link to open the session
+func chatMarkdown(notification Notification) string {
+	var b strings.Builder
+	if notification.Title != "" {
+		fmt.Fprintf(&b, "**%s**\n", notification.Title)
+	}
+	b.WriteString(notification.Message)
+	if notification.Click != "" {
+		fmt.Fprintf(&b, "\n[Open](%s)", notification.Click)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Send implements the Notifier interface
+func (zn *ZulipNotifier) Send(notification Notification) error {
+	form := url.Values{
+		"type":    {"stream"},
+		"to":      {zn.stream},
+		"topic":   {zn.topic},
+		"content": {chatMarkdown(notification)},
+	}
+	req, err := http.NewRequest("POST", zn.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(zn.email, zn.apiKey)
+
+	resp, err := zn.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to Zulip: %w", err)
+	}
+	defer drainBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		// Zulip explains failures, e.g. an unknown stream, in the body
+		var result zulipResponse
+		if json.NewDecoder(resp.Body).Decode(&result) == nil && result.Msg != "" {
+			return fmt.Errorf("request to Zulip failed with status %d: %s", resp.StatusCode, result.Msg)
+		}
+		return fmt.Errorf("request to Zulip failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
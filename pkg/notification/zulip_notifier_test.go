@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestZulipNotifier(t *testing.T) {
+	var path, user, pass string
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		_ = r.ParseForm()
+		form = map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		if form["to"] == "missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"result":"error","msg":"Stream 'missing' does not exist"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":"success","id":42}`))
+	}))
+	defer server.Close()
+
+	zn := NewZulipNotifier(server.URL+"/", "gemini-bot@example.zulipchat.com", "key", "dev", "gemini-cli")
+	err := zn.Send(Notification{Title: "Gemini CLI: app", Message: "No activity detected", Click: "https://example.com"})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if path != "/api/v1/messages" || user != "gemini-bot@example.zulipchat.com" || pass != "key" {
+		t.Errorf("unexpected request to %q as %q:%q", path, user, pass)
+	}
+	if form["type"] != "stream" || form["to"] != "dev" || form["topic"] != "gemini-cli" {
+		t.Errorf("unexpected destination %v", form)
+	}
+	if expected := "**Gemini CLI: app**\nNo activity detected\n[Open](https://example.com)"; form["content"] != expected {
+		t.Errorf("expected content %q, got %q", expected, form["content"])
+	}
+
+	t.Run("error message", func(t *testing.T) {
+		err := NewZulipNotifier(server.URL, "bot", "key", "missing", "t").Send(Notification{Title: "t"})
+		if err == nil || err.Error() != "request to Zulip failed with status 400: Stream 'missing' does not exist" {
+			t.Errorf("expected Zulip's explanation, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// DirectProcess runs a process on the wrapper's own stdin, stdout and stderr
+// instead of a PTY, for environments without a terminal such as CI jobs.
+// Output is not seen by the wrapper, unless it is piped through it.
+type DirectProcess struct {
+	cmd *exec.Cmd
+	mu  sync.Mutex
+	// Read end of the pipe stdout goes to, if piped
+	output *os.File
+	piped  bool
+}
+
+// Ensure DirectProcess implements PTY
+var _ PTY = (*DirectProcess)(nil)
+
+// NewDirectProcess creates a runner that doesn't use a PTY
+func NewDirectProcess() *DirectProcess {
+	return &DirectProcess{}
+}
+
+// NewPipeProcess creates a runner that doesn't use a PTY but pipes the
+// process's stdout through the wrapper, so CopyIO sees the output, e.g. when
+// no PTY can be opened
+func NewPipeProcess() *DirectProcess {
+	return &DirectProcess{piped: true}
+}
+
+// Start starts the process with the standard streams inherited, or stdout
+// piped
+func (d *DirectProcess) Start(command string, args []string, env []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cmd != nil {
+		return fmt.Errorf("process already started")
+	}
+
+	d.cmd = exec.Command(command, args...)
+	d.cmd.Env = env
+	d.cmd.Stdin = os.Stdin
+	d.cmd.Stdout = os.Stdout
+	d.cmd.Stderr = os.Stderr
+	if !d.piped {
+		return d.cmd.Start()
+	}
+
+	// A pipe of our own rather than StdoutPipe, which Wait would close
+	// while CopyIO still reads from it
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create output pipe: %w", err)
+	}
+	d.cmd.Stdout = w
+	err = d.cmd.Start()
+	_ = w.Close()
+	if err != nil {
+		_ = r.Close()
+		return err
+	}
+	d.output = r
+	return nil
+}
+
+// Wait waits for the process to complete
+func (d *DirectProcess) Wait() error {
+	if d.cmd == nil {
+		return fmt.Errorf("process not started")
+	}
+	return d.cmd.Wait()
+}
+
+// Stop does nothing, since the terminal is never changed
+func (d *DirectProcess) Stop() error {
+	return nil
+}
+
+// ProcessState returns the process state
+func (d *DirectProcess) ProcessState() *os.ProcessState {
+	if d.cmd == nil {
+		return nil
+	}
+	return d.cmd.ProcessState
+}
+
+// Process returns the underlying process
+func (d *DirectProcess) Process() *os.Process {
+	if d.cmd == nil {
+		return nil
+	}
+	return d.cmd.Process
+}
+
+// GetPTY returns nil; there is no PTY to write to
+func (d *DirectProcess) GetPTY() *os.File {
+	return nil
+}
+
+// SetReservedRows does nothing without a terminal
+func (d *DirectProcess) SetReservedRows(int) {}
+
+// SetInputFilter does nothing, since input goes straight to the process
+func (d *DirectProcess) SetInputFilter(func([]byte) []byte) {}
+
+// SetZeroCopy does nothing, since output goes straight to stdout
+func (d *DirectProcess) SetZeroCopy(bool) {}
+
+// SetHeadless does nothing; a direct process never assumes a terminal
+func (d *DirectProcess) SetHeadless(bool) {}
+
+// CopyIO relays piped output to stdout and the output handler until the
+// process closes it, or returns at once if the process uses the standard
+// streams itself
+func (d *DirectProcess) CopyIO(_ io.Reader, stdout, _ io.Writer, outputHandler func([]byte), _ func(notification.InputKind)) error {
+	d.mu.Lock()
+	output := d.output
+	d.mu.Unlock()
+	if output == nil {
+		return nil
+	}
+	defer func() { _ = output.Close() }()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := output.Read(buf)
+		if n > 0 {
+			if _, werr := stdout.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if outputHandler != nil {
+				outputHandler(buf[:n])
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
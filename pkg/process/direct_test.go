@@ -0,0 +1,67 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+)
+
+func TestDirectManager(t *testing.T) {
+	t.Setenv("GEMINI_CLI_NTFY_WRAPPED", "")
+
+	cfg := config.DefaultConfig()
+	cfg.RemoteInput = true
+	m := NewDirectManager(cfg)
+	if err := m.Start("/bin/sh", []string{"-c", "[ \"$GEMINI_CLI_NTFY_WRAPPED\" = 1 ] && exit 3"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	_ = m.Wait()
+
+	if code := m.ExitCode(); code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+	if err := m.InjectInput("hello"); err == nil {
+		t.Error("expected input injection to fail without a PTY")
+	}
+}
+
+// noPTY is a PTY that can't be opened, as in a container without /dev/ptmx
+type noPTY struct {
+	*DirectProcess
+}
+
+func (noPTY) Start(string, []string, []string) error {
+	return fmt.Errorf("%w: open /dev/ptmx: no such file or directory", ErrNoPTY)
+}
+
+func TestManagerPipeFallback(t *testing.T) {
+	t.Setenv("GEMINI_CLI_NTFY_WRAPPED", "")
+
+	output := make(chan string, 10)
+	m := NewManager(config.DefaultConfig(), replayHandler(func(data []byte) { output <- string(data) }), nil)
+	m.ptyManager = noPTY{NewDirectProcess()}
+	m.SetOutput(io.Discard)
+	if err := m.Start("/bin/sh", []string{"-c", "echo hello; exit 2"}); err != nil {
+		t.Fatalf("expected a fallback to a pipe, got %v", err)
+	}
+	if err := m.PTYError(); !errors.Is(err, ErrNoPTY) {
+		t.Errorf("expected the PTY error to be kept, got %v", err)
+	}
+
+	select {
+	case data := <-output:
+		if data != "hello\n" {
+			t.Errorf("expected the output to be handled, got %q", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the piped output to be handled")
+	}
+	_ = m.Wait()
+	if code := m.ExitCode(); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+}
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"bytes"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+// maxKeystrokeLength is the longest chunk still taken for typed input. A
+// terminal sends one key at a time, so more arriving at once was pasted.
+const maxKeystrokeLength = 16
+
+var (
+	// Terminals wrap pasted text in these when the application asks for it
+	pasteStart = []byte("\x1b[200~")
+	pasteEnd   = []byte("\x1b[201~")
+)
+
+// inputClassifier tells typed input from pasted input. A bracketed paste
+// can span many reads, so it remembers whether one is in progress.
+type inputClassifier struct {
+	inPaste bool
+}
+
+// classify returns the kind of a chunk of input
+func (c *inputClassifier) classify(data []byte) notification.InputKind {
+	wasInPaste := c.inPaste
+	start := bytes.LastIndex(data, pasteStart)
+	end := bytes.LastIndex(data, pasteEnd)
+	if start >= 0 {
+		c.inPaste = end < start
+	} else if end >= 0 {
+		c.inPaste = false
+	}
+	if start >= 0 || wasInPaste {
+		return notification.InputBracketedPaste
+	}
+
+	// Escape sequences of special keys, e.g. with modifiers, can be long
+	if len(data) > maxKeystrokeLength && data[0] != 0x1b {
+		return notification.InputPaste
+	}
+	// Alt+Enter, ESC CR, inserts a newline rather than submitting
+	if data[0] != 0x1b && bytes.IndexByte(data, '\r') >= 0 {
+		return notification.InputEnter
+	}
+	return notification.InputKeystroke
+}
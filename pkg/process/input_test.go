@@ -0,0 +1,80 @@
+package process
+
+import (
+	"io"
+	"testing"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+func TestInputClassifier(t *testing.T) {
+	// Each step is one read from the terminal, in order
+	steps := []struct {
+		name     string
+		input    string
+		expected notification.InputKind
+	}{
+		{"key", "y", notification.InputKeystroke},
+		{"enter", "\r", notification.InputEnter},
+		{"typed ahead with enter", "ok\r", notification.InputEnter},
+		{"arrow key", "\x1b[A", notification.InputKeystroke},
+		{"alt+enter", "\x1b\r", notification.InputKeystroke},
+		{"long escape sequence", "\x1b[13;2;0;13;0;1_\x1b[13;2;0;13;0;1_", notification.InputKeystroke},
+		{"paste", "fix the failing test in pkg/config\r", notification.InputPaste},
+		{"bracketed paste", "\x1b[200~hello\x1b[201~", notification.InputBracketedPaste},
+		{"key after paste", "x", notification.InputKeystroke},
+		{"start of long bracketed paste", "\x1b[200~first part", notification.InputBracketedPaste},
+		{"middle of long bracketed paste", "y", notification.InputBracketedPaste},
+		{"end of long bracketed paste", "last part\x1b[201~", notification.InputBracketedPaste},
+		{"enter after long paste", "\r", notification.InputEnter},
+	}
+
+	var c inputClassifier
+	for _, step := range steps {
+		if got := c.classify([]byte(step.input)); got != step.expected {
+			t.Errorf("%s: expected %s, got %s", step.name, step.expected, got)
+		}
+	}
+}
+
+// chunkReader returns one chunk per read
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestInputReaderReportsKind(t *testing.T) {
+	var kinds []notification.InputKind
+	reader := &inputReader{
+		reader:  &chunkReader{chunks: []string{"\x1b[200~pasted", " text\x1b[201~", "a"}},
+		handler: func(kind notification.InputKind) { kinds = append(kinds, kind) },
+		// The filter removing the paste markers must not hide the paste
+		filter: func(data []byte) []byte {
+			if data[0] == 0x1b {
+				return data[len(pasteStart):]
+			}
+			return data
+		},
+	}
+
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	expected := []notification.InputKind{notification.InputBracketedPaste, notification.InputBracketedPaste, notification.InputKeystroke}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected kinds %v, got %v", expected, kinds)
+	}
+	for i := range kinds {
+		if kinds[i] != expected[i] {
+			t.Errorf("expected kinds %v, got %v", expected, kinds)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package process
 import (
 	"io"
 	"os"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
 )
 
 // PTY defines the interface for PTY operations
@@ -13,5 +15,9 @@ type PTY interface {
 	ProcessState() *os.ProcessState
 	Process() *os.Process
 	GetPTY() *os.File
-	CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHandler func([]byte), inputHandler func()) error
+	SetReservedRows(rows int)
+	SetInputFilter(filter func([]byte) []byte)
+	SetZeroCopy(enabled bool)
+	SetHeadless(enabled bool)
+	CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHandler func([]byte), inputHandler func(notification.InputKind)) error
 }
\ No newline at end of file
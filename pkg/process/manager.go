@@ -1,15 +1,19 @@
 package process
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
 	"github.com/nakkulla/gemini-cli-ntfy/pkg/interfaces"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
 )
 
 // Manager manages the wrapped Gemini CLI process
@@ -17,24 +21,95 @@ type Manager struct {
 	config        *config.Config
 	ptyManager    PTY
 	outputHandler interfaces.DataHandler
-	inputHandler  func()
+	inputHandler  func(notification.InputKind)
+	output        io.Writer
+	inputFilters  []func([]byte) []byte
 	exitCode      int
 	mu            sync.Mutex
 	sigChan       chan os.Signal
 	done          chan struct{}
+	// Why the process runs without a PTY, if it fell back to a pipe
+	ptyError error
+	// Signal that killed the process, if one did
+	exitSignal syscall.Signal
+	// Set if the OOM killer killed the process
+	oomKilled bool
+	// Set if Terminate killed the process after its grace period
+	killed bool
+	// How long Terminate waits after SIGTERM before killing the process
+	terminateGrace time.Duration
+	// OOM kills in the cgroup before the process started, if known
+	oomKillsBefore int
+	oomKillsKnown  bool
+	// Tells replayed input apart like the terminal's
+	replayed inputClassifier
 }
 
 // NewManager creates a new process manager
-func NewManager(cfg *config.Config, outputHandler interfaces.DataHandler, inputHandler func()) *Manager {
+func NewManager(cfg *config.Config, outputHandler interfaces.DataHandler, inputHandler func(notification.InputKind)) *Manager {
 	return &Manager{
-		config:        cfg,
-		ptyManager:    NewPTYManager(),
-		outputHandler: outputHandler,
-		inputHandler:  inputHandler,
-		done:          make(chan struct{}),
+		config:         cfg,
+		ptyManager:     NewPTYManager(),
+		outputHandler:  outputHandler,
+		inputHandler:   inputHandler,
+		output:         os.Stdout,
+		done:           make(chan struct{}),
+		terminateGrace: terminateGrace,
 	}
 }
 
+// NewDirectManager creates a process manager that runs the process on the
+// wrapper's own standard streams, without a PTY. Its output is not monitored
+// and input cannot be injected.
+func NewDirectManager(cfg *config.Config) *Manager {
+	m := NewManager(cfg, nil, nil)
+	m.ptyManager = NewDirectProcess()
+	return m
+}
+
+// SetOutput sets where the wrapped process's output is written (default
+// os.Stdout). Must be called before Start.
+func (m *Manager) SetOutput(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.output = w
+}
+
+// AddInputFilter adds a filter that sees stdin before the wrapped process
+// does and may remove, hold back or release bytes, e.g. to intercept key
+// chords. Filters run in the order they were added. Must be called before Start.
+func (m *Manager) AddInputFilter(filter func([]byte) []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputFilters = append(m.inputFilters, filter)
+}
+
+// ReserveRows keeps the given number of terminal rows away from the wrapped
+// process. Must be called before Start.
+func (m *Manager) ReserveRows(rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ptyManager.SetReservedRows(rows)
+}
+
+// SetZeroCopyOutput relays output to stdout with splice(2) where supported,
+// handing the output handler a copy asynchronously. Only useful when the
+// output is os.Stdout itself. Must be called before Start.
+func (m *Manager) SetZeroCopyOutput(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ptyManager.SetZeroCopy(enabled)
+}
+
+// SetHeadless runs the process without assuming a terminal on stdin: it gets
+// a fixed terminal size and stdin is not switched to raw mode. Must be
+// called before Start.
+func (m *Manager) SetHeadless(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ptyManager.SetHeadless(enabled)
+}
+
 // Start starts the Gemini CLI process
 func (m *Manager) Start(command string, args []string) error {
 	m.mu.Lock()
@@ -56,9 +131,30 @@ func (m *Manager) Start(command string, args []string) error {
 	}
 	env = append(env, "GEMINI_CLI_NTFY_WRAPPED=1")
 
+	// Chain input filters
+	if len(m.inputFilters) > 0 {
+		filters := m.inputFilters
+		m.ptyManager.SetInputFilter(func(data []byte) []byte {
+			return filterInput(filters, data)
+		})
+	}
+
+	// To tell later whether the OOM killer killed the process
+	m.oomKillsBefore, m.oomKillsKnown = cgroupOOMKills()
+
 	// Start the process with PTY
 	if err := m.ptyManager.Start(command, args, env); err != nil {
-		return fmt.Errorf("failed to start process: %w", err)
+		if !errors.Is(err, ErrNoPTY) {
+			return fmt.Errorf("failed to start process: %w", err)
+		}
+		// Without a PTY, the process can still run with its output piped
+		// through the wrapper, which keeps notifications working
+		pipe := NewPipeProcess()
+		if pipeErr := pipe.Start(command, args, env); pipeErr != nil {
+			return fmt.Errorf("failed to start process: %w", pipeErr)
+		}
+		m.ptyManager = pipe
+		m.ptyError = err
 	}
 
 	// Start I/O copying with output handling
@@ -69,7 +165,7 @@ func (m *Manager) Start(command string, args []string) error {
 				m.outputHandler.HandleData(data)
 			}
 		}
-		if err := m.ptyManager.CopyIO(os.Stdin, os.Stdout, os.Stderr, handler, m.inputHandler); err != nil {
+		if err := m.ptyManager.CopyIO(os.Stdin, m.output, os.Stderr, handler, m.inputHandler); err != nil {
 			fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: I/O error: %v\n", err)
 		}
 	}()
@@ -80,6 +176,38 @@ func (m *Manager) Start(command string, args []string) error {
 	return nil
 }
 
+// filterInput runs input through the filters in order
+func filterInput(filters []func([]byte) []byte, data []byte) []byte {
+	for _, filter := range filters {
+		if len(data) == 0 {
+			break
+		}
+		data = filter(data)
+	}
+	return data
+}
+
+// ReplayOutput hands recorded output to the output handler as if the
+// process had printed it, e.g. to replay a session without running it
+func (m *Manager) ReplayOutput(data []byte) {
+	if m.outputHandler != nil {
+		m.outputHandler.HandleData(data)
+	}
+}
+
+// ReplayInput passes recorded input through the input filters and reports
+// it to the input handler as if it had been typed. It is not sent anywhere.
+func (m *Manager) ReplayInput(data []byte) {
+	m.mu.Lock()
+	filters := m.inputFilters
+	kind := m.replayed.classify(data)
+	m.mu.Unlock()
+
+	if data = filterInput(filters, data); len(data) > 0 && m.inputHandler != nil {
+		m.inputHandler(kind)
+	}
+}
+
 // Wait waits for the process to exit
 func (m *Manager) Wait() error {
 	if m.ptyManager == nil {
@@ -89,8 +217,16 @@ func (m *Manager) Wait() error {
 	err := m.ptyManager.Wait()
 
 	m.mu.Lock()
-	if m.ptyManager.ProcessState() != nil {
-		m.exitCode = m.ptyManager.ProcessState().ExitCode()
+	if state := m.ptyManager.ProcessState(); state != nil {
+		m.exitCode = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			m.exitSignal = status.Signal()
+			// The OOM killer sends SIGKILL
+			if m.exitSignal == syscall.SIGKILL && !m.killed {
+				after, known := cgroupOOMKills()
+				m.oomKilled = (known && m.oomKillsKnown && after > m.oomKillsBefore) || kernelLogOOMKill(state.Pid())
+			}
+		}
 	}
 	m.mu.Unlock()
 
@@ -113,6 +249,148 @@ func (m *Manager) ExitCode() int {
 	return m.exitCode
 }
 
+// PTYError returns why the process runs with its output piped instead of
+// in a PTY, or nil if it has a PTY
+func (m *Manager) PTYError() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ptyError
+}
+
+// Pid returns the process ID of the wrapped process, or 0 before it started
+func (m *Manager) Pid() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ptyManager == nil || m.ptyManager.Process() == nil {
+		return 0
+	}
+	return m.ptyManager.Process().Pid
+}
+
+// ExitSignal returns the signal that killed the process, or 0 if it exited
+func (m *Manager) ExitSignal() syscall.Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.exitSignal
+}
+
+// OOMKilled reports whether the kernel's OOM killer killed the process, as
+// far as the cgroup or the kernel log tell (Linux only)
+func (m *Manager) OOMKilled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.oomKilled
+}
+
+// maxInjectedInputLength limits how much text a single remote reply can type
+const maxInjectedInputLength = 1024
+
+// InjectInput types text followed by Enter into the wrapped process's terminal.
+// Injection is opt-in via the remote_input config option. Control characters
+// are stripped so a reply cannot smuggle escape sequences or signals.
+func (m *Manager) InjectInput(text string) error {
+	if !m.config.RemoteInput {
+		return fmt.Errorf("remote input is disabled (set remote_input to enable)")
+	}
+
+	sanitized := sanitizeInput(text)
+	if len(sanitized) > maxInjectedInputLength {
+		return fmt.Errorf("input too long (%d bytes, max %d)", len(sanitized), maxInjectedInputLength)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ptyManager == nil || m.ptyManager.GetPTY() == nil {
+		// After the pipe fallback, or with --ci, there is no terminal to type into
+		if m.ptyManager != nil && m.ptyManager.Process() != nil {
+			return fmt.Errorf("remote input needs a terminal; running without a PTY")
+		}
+		return fmt.Errorf("process not started")
+	}
+
+	// Injected input counts as user interaction
+	if m.inputHandler != nil {
+		m.inputHandler(notification.InputRemote)
+	}
+
+	if _, err := m.ptyManager.GetPTY().Write([]byte(sanitized + "\r")); err != nil {
+		return fmt.Errorf("failed to write input: %w", err)
+	}
+
+	return nil
+}
+
+// SignalRemote sends a signal to the wrapped process on behalf of a remote
+// command. Remote signals are opt-in via the remote_signals config option.
+func (m *Manager) SignalRemote(sig os.Signal) error {
+	if !m.config.RemoteSignals {
+		return fmt.Errorf("remote signals are disabled (set remote_signals to enable)")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ptyManager == nil || m.ptyManager.Process() == nil {
+		return fmt.Errorf("process not started")
+	}
+
+	if err := m.ptyManager.Process().Signal(sig); err != nil {
+		return fmt.Errorf("failed to send %v: %w", sig, err)
+	}
+
+	return nil
+}
+
+// terminateGrace is how long a process has to exit after SIGTERM before
+// Terminate kills it
+const terminateGrace = 10 * time.Second
+
+// Terminate asks the wrapped process to exit with SIGTERM, and kills it if
+// it is still running after the grace period, leaving the cleanup to the
+// normal exit path
+func (m *Manager) Terminate() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ptyManager == nil || m.ptyManager.Process() == nil {
+		return fmt.Errorf("process not started")
+	}
+
+	process := m.ptyManager.Process()
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to terminate: %w", err)
+	}
+	go m.killAfter(process, m.terminateGrace)
+
+	return nil
+}
+
+// killAfter kills the process unless it exits within grace
+func (m *Manager) killAfter(process *os.Process, grace time.Duration) {
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-m.done:
+	case <-timer.C:
+		m.mu.Lock()
+		m.killed = true
+		m.mu.Unlock()
+		_ = process.Kill()
+	}
+}
+
+// sanitizeInput removes control characters from remotely supplied input
+func sanitizeInput(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
 // setupSignalForwarding sets up signal forwarding to the child process
 func (m *Manager) setupSignalForwarding() {
 	m.sigChan = make(chan os.Signal, 1)
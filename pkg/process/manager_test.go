@@ -0,0 +1,273 @@
+package process
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+)
+
+func TestManagerReplay(t *testing.T) {
+	var kinds []notification.InputKind
+	var output []byte
+	m := NewManager(nil, replayHandler(func(data []byte) { output = append(output, data...) }), func(kind notification.InputKind) {
+		kinds = append(kinds, kind)
+	})
+	// A filter that swallows a key chord
+	m.AddInputFilter(func(data []byte) []byte {
+		if string(data) == "\x01" {
+			return nil
+		}
+		return data
+	})
+
+	m.ReplayOutput([]byte("Hello\r\n"))
+	for _, input := range []string{"y", "\x01", "\x1b[200~pasted", " text\x1b[201~", "\r"} {
+		m.ReplayInput([]byte(input))
+	}
+
+	if string(output) != "Hello\r\n" {
+		t.Errorf("expected the output handled, got %q", output)
+	}
+	want := []notification.InputKind{notification.InputKeystroke, notification.InputBracketedPaste, notification.InputBracketedPaste, notification.InputEnter}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected kinds %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("expected kinds %v, got %v", want, kinds)
+			break
+		}
+	}
+}
+
+// replayHandler adapts a function to interfaces.DataHandler
+type replayHandler func([]byte)
+
+func (h replayHandler) HandleData(data []byte) { h(data) }
+func (h replayHandler) HandleLine(line string) { h([]byte(line + "\n")) }
+
+// pipePTY is a PTY whose terminal is the write end of a pipe, so tests can
+// read what is typed into it
+type pipePTY struct {
+	*DirectProcess
+	terminal *os.File
+}
+
+func (p pipePTY) GetPTY() *os.File {
+	return p.terminal
+}
+
+// newInjectManager returns a manager with a pipe for a terminal, the read
+// end of the pipe, and the input kinds it reports
+func newInjectManager(t *testing.T, remoteInput bool) (*Manager, *os.File, *[]notification.InputKind) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close(); _ = w.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.RemoteInput = remoteInput
+	var kinds []notification.InputKind
+	m := NewManager(cfg, nil, func(kind notification.InputKind) { kinds = append(kinds, kind) })
+	m.ptyManager = pipePTY{DirectProcess: NewDirectProcess(), terminal: w}
+	return m, r, &kinds
+}
+
+func TestSanitizeInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "yes, go ahead", "yes, go ahead"},
+		{"escape sequence", "\x1b[31mred\x1b[0m", "[31mred[0m"},
+		{"enter and newlines", "one\rtwo\nthree\r\n", "onetwothree"},
+		{"other C0 controls", "\x03\x04\x1a\x00tab\there", "tabhere"},
+		{"delete", "abc\x7f", "abc"},
+		{"C1 controls", "\u009b31m\u0085next\u009d", "31mnext"},
+		{"printable non-ASCII", "héllo ✓ 日本", "héllo ✓ 日本"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeInput(tt.input); got != tt.want {
+				t.Errorf("sanitizeInput(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInjectInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		remoteInput bool
+		input       string
+		// What is typed, or "" if the input is refused
+		want string
+	}{
+		{"disabled", false, "y", ""},
+		{"reply", true, "y", "y\r"},
+		{"control characters stripped", true, "y\r\x1b[A\nrm -rf /\x03", "y[Arm -rf /\r"},
+		{"at the limit", true, strings.Repeat("a", maxInjectedInputLength), strings.Repeat("a", maxInjectedInputLength) + "\r"},
+		{"over the limit", true, strings.Repeat("a", maxInjectedInputLength+1), ""},
+		{"limit counts sanitized text", true, strings.Repeat("a", maxInjectedInputLength) + "\x1b\x1b", strings.Repeat("a", maxInjectedInputLength) + "\r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, terminal, kinds := newInjectManager(t, tt.remoteInput)
+
+			err := m.InjectInput(tt.input)
+			if tt.want == "" {
+				if err == nil {
+					t.Error("expected the input to be refused")
+				}
+				if len(*kinds) != 0 {
+					t.Errorf("expected no input to be reported, got %v", *kinds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InjectInput failed: %v", err)
+			}
+
+			got := make([]byte, len(tt.want))
+			if _, err := io.ReadFull(terminal, got); err != nil {
+				t.Fatalf("failed to read the terminal: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %q to be typed, got %q", tt.want, got)
+			}
+			if len(*kinds) != 1 || (*kinds)[0] != notification.InputRemote {
+				t.Errorf("expected remote input to be reported once, got %v", *kinds)
+			}
+		})
+	}
+
+	t.Run("not started", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.RemoteInput = true
+		m := NewManager(cfg, nil, nil)
+		if err := m.InjectInput("y"); err == nil {
+			t.Error("expected an error before the process has started")
+		}
+	})
+
+	t.Run("without a PTY", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.RemoteInput = true
+		var kinds []notification.InputKind
+		m := NewManager(cfg, nil, func(kind notification.InputKind) { kinds = append(kinds, kind) })
+		process := NewPipeProcess()
+		m.ptyManager = process
+		if err := process.Start("/bin/sleep", []string{"10"}, nil); err != nil {
+			t.Fatalf("failed to start sleep: %v", err)
+		}
+		defer func() { _ = process.Process().Kill(); _ = process.Wait() }()
+
+		err := m.InjectInput("y")
+		if err == nil || !strings.Contains(err.Error(), "without a PTY") {
+			t.Errorf("expected an error naming the missing PTY, got %v", err)
+		}
+		if len(kinds) != 0 {
+			t.Errorf("expected no input to be reported, got %v", kinds)
+		}
+	})
+}
+
+func TestSignalRemote(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cfg := config.DefaultConfig()
+		cfg.RemoteSignals = enabled
+		m := NewManager(cfg, nil, nil)
+		if err := m.SignalRemote(syscall.SIGINT); err == nil {
+			t.Errorf("remote_signals %v: expected an error before the process has started", enabled)
+		}
+
+		process := NewDirectProcess()
+		m.ptyManager = process
+		if err := process.Start("/bin/sleep", []string{"10"}, nil); err != nil {
+			t.Fatalf("failed to start sleep: %v", err)
+		}
+
+		err := m.SignalRemote(syscall.SIGINT)
+		if !enabled {
+			if err == nil {
+				t.Error("expected remote signals to be refused")
+			}
+			_ = process.Process().Kill()
+			_ = process.Wait()
+			if status := process.ProcessState().Sys().(syscall.WaitStatus); status.Signal() != syscall.SIGKILL {
+				t.Errorf("expected the process to be untouched until killed, got %v", status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("SignalRemote failed: %v", err)
+		}
+		_ = process.Wait()
+		if status := process.ProcessState().Sys().(syscall.WaitStatus); status.Signal() != syscall.SIGINT {
+			t.Errorf("expected the process to be interrupted, got %v", status)
+		}
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	m := NewManager(config.DefaultConfig(), nil, nil)
+	if err := m.Terminate(); err == nil {
+		t.Error("expected an error before the process has started")
+	}
+
+	t.Run("exits on SIGTERM", func(t *testing.T) {
+		m := NewManager(config.DefaultConfig(), nil, nil)
+		m.ptyManager = NewDirectProcess()
+		if err := m.ptyManager.Start("/bin/sleep", []string{"10"}, nil); err != nil {
+			t.Fatalf("failed to start sleep: %v", err)
+		}
+		if err := m.Terminate(); err != nil {
+			t.Fatalf("Terminate failed: %v", err)
+		}
+		_ = m.Wait()
+		if m.ExitSignal() != syscall.SIGTERM {
+			t.Errorf("expected the process to exit on SIGTERM, got %v", m.ExitSignal())
+		}
+	})
+
+	t.Run("killed after the grace period", func(t *testing.T) {
+		ready := filepath.Join(t.TempDir(), "ready")
+		m := NewManager(config.DefaultConfig(), nil, nil)
+		m.ptyManager = NewDirectProcess()
+		m.terminateGrace = 50 * time.Millisecond
+		script := `trap "" TERM; : > "$1"; exec sleep 10`
+		if err := m.ptyManager.Start("/bin/sh", []string{"-c", script, "sh", ready}, nil); err != nil {
+			t.Fatalf("failed to start sh: %v", err)
+		}
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+			if _, err := os.Stat(ready); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("the process never ignored SIGTERM")
+			}
+		}
+
+		if err := m.Terminate(); err != nil {
+			t.Fatalf("Terminate failed: %v", err)
+		}
+		_ = m.Wait()
+		if m.ExitSignal() != syscall.SIGKILL {
+			t.Errorf("expected the process to be killed, got %v", m.ExitSignal())
+		}
+		if m.OOMKilled() {
+			t.Error("expected our kill not to be taken for the OOM killer")
+		}
+	})
+}
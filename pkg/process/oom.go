@@ -0,0 +1,53 @@
+package process
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// signalNames are the names of the signals a process commonly dies of
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// SignalName returns the name of a signal, e.g. "SIGKILL"
+func SignalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", int(sig))
+}
+
+// parseOOMKills returns the number of processes the OOM killer killed,
+// from the contents of a cgroup's memory.events
+func parseOOMKills(events string) (int, bool) {
+	for _, line := range strings.Split(events, "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok || key != "oom_kill" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// isOOMKillRecord reports whether a kernel log record says the OOM killer
+// killed pid, e.g. "6,1234,5678,-;Out of memory: Killed process 42 (node)"
+func isOOMKillRecord(record string, pid int) bool {
+	return strings.Contains(record, fmt.Sprintf("Killed process %d ", pid))
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxKernelLogRecords bounds how much of the kernel log is searched
+const maxKernelLogRecords = 100000
+
+// cgroupOOMKills returns the number of OOM kills in this process's cgroup
+// (v2), which the wrapped process shares
+func cgroupOOMKills() (int, bool) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// The v2 hierarchy is listed as "0::/path"
+		path, ok := strings.CutPrefix(line, "0::")
+		if !ok {
+			continue
+		}
+		events, err := os.ReadFile(filepath.Join("/sys/fs/cgroup", path, "memory.events"))
+		if err != nil {
+			return 0, false
+		}
+		return parseOOMKills(string(events))
+	}
+	return 0, false
+}
+
+// kernelLogOOMKill reports whether the kernel log says the OOM killer
+// killed pid. Reading it may need privileges (kernel.dmesg_restrict).
+func kernelLogOOMKill(pid int) bool {
+	// Read without blocking, so reading stops at the end of the log
+	fd, err := syscall.Open("/dev/kmsg", syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = syscall.Close(fd) }()
+
+	buf := make([]byte, 8192)
+	for range maxKernelLogRecords {
+		n, err := syscall.Read(fd, buf)
+		if errors.Is(err, syscall.EPIPE) {
+			// Records were overwritten while reading
+			continue
+		}
+		if err != nil || n <= 0 {
+			return false
+		}
+		if isOOMKillRecord(string(buf[:n]), pid) {
+			return true
+		}
+	}
+	return false
+}
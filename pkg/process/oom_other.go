@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package process
+
+// cgroupOOMKills is only available on Linux
+func cgroupOOMKills() (int, bool) {
+	return 0, false
+}
+
+// kernelLogOOMKill is only available on Linux
+func kernelLogOOMKill(pid int) bool {
+	return false
+}
@@ -0,0 +1,35 @@
+package process
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestSignalName(t *testing.T) {
+	if name := SignalName(syscall.SIGKILL); name != "SIGKILL" {
+		t.Errorf("expected SIGKILL, got %q", name)
+	}
+	if name := SignalName(syscall.Signal(99)); name != "signal 99" {
+		t.Errorf("expected signal 99, got %q", name)
+	}
+}
+
+func TestParseOOMKills(t *testing.T) {
+	events := "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 0\n"
+	if n, ok := parseOOMKills(events); !ok || n != 2 {
+		t.Errorf("expected 2 OOM kills, got %d, %v", n, ok)
+	}
+	if _, ok := parseOOMKills("low 0\n"); ok {
+		t.Error("expected no count without oom_kill")
+	}
+}
+
+func TestIsOOMKillRecord(t *testing.T) {
+	record := "3,1520,9512331,-;Out of memory: Killed process 4242 (node) total-vm:9012344kB, anon-rss:7812345kB"
+	if !isOOMKillRecord(record, 4242) {
+		t.Error("expected the OOM kill of 4242 to be recognized")
+	}
+	if isOOMKillRecord(record, 424) || isOOMKillRecord("6,1,2,-;Killed process group", 4242) {
+		t.Error("expected other records not to match")
+	}
+}
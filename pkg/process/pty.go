@@ -1,6 +1,7 @@
 package process
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"syscall"
 
 	"github.com/creack/pty"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
 )
 
 // PTYManager handles PTY-based process execution
@@ -20,8 +22,27 @@ type PTYManager struct {
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
 	restoreFunc func()
+	// Rows at the bottom of the terminal kept for the wrapper's own UI
+	reservedRows uint16
+	// Optional transformation applied to stdin before it reaches the child
+	inputFilter func([]byte) []byte
+	// Relay output with splice(2) where supported
+	zeroCopy bool
+	// Don't expect a terminal on stdin
+	headless bool
 }
 
+// headlessSize is the terminal size given to the child when there is no
+// terminal to copy it from
+var headlessSize = pty.Winsize{Rows: 40, Cols: 120}
+
+// ErrNoPTY means no PTY could be opened for the process, e.g. in a
+// container without /dev/ptmx
+var ErrNoPTY = errors.New("no PTY available")
+
+// errSpliceUnsupported means output can't be relayed with splice and must be copied
+var errSpliceUnsupported = errors.New("splice not supported")
+
 // Ensure PTYManager implements PTY
 var _ PTY = (*PTYManager)(nil)
 
@@ -45,17 +66,36 @@ func (p *PTYManager) Start(command string, args []string, env []string) error {
 	p.cmd = exec.Command(command, args...)
 	p.cmd.Env = env
 
+	// Tell a missing PTY apart from a command that fails to start, which
+	// running without a PTY wouldn't fix
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoPTY, err)
+	}
+	_ = ptmx.Close()
+	_ = tty.Close()
+
 	// Start the command with a PTY
-	var err error
 	p.pty, err = pty.Start(p.cmd)
 	if err != nil {
+		// Containers may not allow making the PTY the controlling terminal
+		if errors.Is(err, syscall.EPERM) {
+			return fmt.Errorf("%w: %v", ErrNoPTY, err)
+		}
 		return fmt.Errorf("failed to start PTY: %w", err)
 	}
 
+	if p.headless {
+		if err := pty.Setsize(p.pty, &headlessSize); err != nil {
+			return fmt.Errorf("failed to set PTY size: %w", err)
+		}
+		return nil
+	}
+
 	// Copy terminal size
 	if err := p.copyTerminalSize(); err != nil {
 		// Log but don't fail - some environments don't have a terminal
-		fmt.Fprintf(os.Stderr, "claude-code-ntfy: failed to copy terminal size: %v\n", err)
+		fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to copy terminal size: %v\n", err)
 	}
 
 	// Start monitoring for terminal size changes
@@ -126,6 +166,42 @@ func (p *PTYManager) Stop() error {
 	return nil
 }
 
+// SetReservedRows makes the child's terminal the given number of rows
+// shorter than the real one. Must be called before Start.
+func (p *PTYManager) SetReservedRows(rows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rows >= 0 {
+		p.reservedRows = uint16(rows) // #nosec G115 -- Small non-negative row count
+	}
+}
+
+// SetInputFilter sets a function that may inspect and remove bytes from
+// stdin before they reach the child. Must be called before CopyIO.
+func (p *PTYManager) SetInputFilter(filter func([]byte) []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inputFilter = filter
+}
+
+// SetZeroCopy makes CopyIO relay output to stdout with splice(2) on Linux,
+// when stdout is a file, and feed the output handler asynchronously from a
+// copy. Must be called before CopyIO.
+func (p *PTYManager) SetZeroCopy(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.zeroCopy = enabled
+}
+
+// SetHeadless gives the child a fixed terminal size and leaves stdin's
+// terminal settings alone, for running without a terminal. Must be called
+// before Start.
+func (p *PTYManager) SetHeadless(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.headless = enabled
+}
+
 // copyTerminalSize copies the terminal size from stdin to the PTY
 func (p *PTYManager) copyTerminalSize() error {
 	size, err := pty.GetsizeFull(os.Stdin)
@@ -133,6 +209,10 @@ func (p *PTYManager) copyTerminalSize() error {
 		return err
 	}
 
+	if size.Rows > p.reservedRows {
+		size.Rows -= p.reservedRows
+	}
+
 	return pty.Setsize(p.pty, size)
 }
 
@@ -151,7 +231,7 @@ func (p *PTYManager) monitorTerminalSize() {
 			p.mu.Lock()
 			if p.pty != nil {
 				if err := p.copyTerminalSize(); err != nil {
-					fmt.Fprintf(os.Stderr, "claude-code-ntfy: failed to resize PTY: %v\n", err)
+					fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: failed to resize PTY: %v\n", err)
 				}
 			}
 			p.mu.Unlock()
@@ -162,16 +242,17 @@ func (p *PTYManager) monitorTerminalSize() {
 }
 
 // CopyIO handles copying between PTY and standard streams
-func (p *PTYManager) CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHandler func([]byte), inputHandler func()) error {
+func (p *PTYManager) CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHandler func([]byte), inputHandler func(notification.InputKind)) error {
 	p.mu.Lock()
 	if p.pty == nil {
 		p.mu.Unlock()
 		return fmt.Errorf("PTY not initialized")
 	}
+	headless := p.headless
 	p.mu.Unlock()
 
 	// Store the restore function so we can call it from Stop()
-	if file, ok := stdin.(*os.File); ok {
+	if file, ok := stdin.(*os.File); ok && !headless {
 		if restore, err := setRawMode(int(file.Fd())); err == nil {
 			p.mu.Lock()
 			p.restoreFunc = restore
@@ -194,14 +275,20 @@ func (p *PTYManager) CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHan
 	errChan := make(chan error, 2)
 
 	// Copy from stdin to PTY
+	p.mu.Lock()
+	inputFilter := p.inputFilter
+	zeroCopy := p.zeroCopy
+	p.mu.Unlock()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if inputHandler != nil {
-			// Use an inputReader to detect stdin activity
+		if inputHandler != nil || inputFilter != nil {
+			// Use an inputReader to detect and filter stdin activity
 			reader := &inputReader{
 				reader:  stdin,
 				handler: inputHandler,
+				filter:  inputFilter,
 			}
 			if _, err := io.Copy(p.pty, reader); err != nil {
 				errChan <- fmt.Errorf("stdin copy error: %w", err)
@@ -219,18 +306,32 @@ func (p *PTYManager) CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHan
 	go func() {
 		defer wg.Done()
 
+		// Relay without copying through user space if possible
+		if dst, ok := stdout.(*os.File); ok && zeroCopy {
+			err := spliceOutput(dst, p.pty, outputHandler)
+			if !errors.Is(err, errSpliceUnsupported) {
+				if err != nil {
+					errChan <- fmt.Errorf("stdout splice error: %w", err)
+				}
+				return
+			}
+			if os.Getenv("GEMINI_NOTIFY_DEBUG") == "1" {
+				fmt.Fprintf(os.Stderr, "gemini-cli-ntfy: splice not supported, copying output\n")
+			}
+		}
+
 		if outputHandler != nil {
 			// Use a TeeReader to handle output
 			reader := &outputReader{
 				reader:  p.pty,
 				handler: outputHandler,
 			}
-			if _, err := io.Copy(stdout, reader); err != nil {
+			if _, err := io.Copy(stdout, reader); err != nil && !isPTYClosed(err) {
 				errChan <- fmt.Errorf("stdout copy error: %w", err)
 			}
 		} else {
 			// Direct copy without handling
-			if _, err := io.Copy(stdout, p.pty); err != nil {
+			if _, err := io.Copy(stdout, p.pty); err != nil && !isPTYClosed(err) {
 				errChan <- fmt.Errorf("stdout copy error: %w", err)
 			}
 		}
@@ -248,6 +349,12 @@ func (p *PTYManager) CopyIO(stdin io.Reader, stdout, stderr io.Writer, outputHan
 	}
 }
 
+// isPTYClosed reports whether a read error from the PTY only means that the
+// child has exited, which Linux reports as EIO once the other side is closed
+func isPTYClosed(err error) bool {
+	return errors.Is(err, syscall.EIO)
+}
+
 // outputReader wraps a reader and calls a handler for each chunk of data
 type outputReader struct {
 	reader  io.Reader
@@ -262,16 +369,46 @@ func (r *outputReader) Read(p []byte) (n int, err error) {
 	return n, err
 }
 
-// inputReader wraps a reader and calls a handler when input is detected
+// inputReader wraps a reader, optionally filters the input and calls a
+// handler with the kind of input when input is detected
 type inputReader struct {
-	reader  io.Reader
-	handler func()
+	reader     io.Reader
+	handler    func(notification.InputKind)
+	filter     func([]byte) []byte
+	classifier inputClassifier
+	// Filtered input that did not fit into the caller's buffer
+	pending []byte
 }
 
 func (r *inputReader) Read(p []byte) (n int, err error) {
-	n, err = r.reader.Read(p)
-	if n > 0 && r.handler != nil {
-		r.handler()
+	if len(r.pending) > 0 {
+		n = copy(p, r.pending)
+		r.pending = r.pending[n:]
+		return n, nil
+	}
+
+	for {
+		n, err = r.reader.Read(p)
+		// Classify what the user sent, before a filter removes any of it
+		var kind notification.InputKind
+		if n > 0 {
+			kind = r.classifier.classify(p[:n])
+		}
+		if n > 0 && r.filter != nil {
+			filtered := r.filter(p[:n])
+			n = copy(p, filtered)
+			if n < len(filtered) {
+				// A filter released held-back input; deliver the rest next time
+				r.pending = append(r.pending[:0], filtered[n:]...)
+			}
+			if n == 0 && err == nil {
+				// Everything was consumed; keep reading instead of returning 0, nil
+				continue
+			}
+		}
+		if n > 0 && r.handler != nil {
+			r.handler(kind)
+		}
+		return n, err
 	}
-	return n, err
 }
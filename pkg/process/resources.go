@@ -0,0 +1,128 @@
+package process
+
+import (
+	"sync"
+	"time"
+)
+
+// resourceCheckInterval is how often a ResourceWatchdog samples the process
+const resourceCheckInterval = 5 * time.Second
+
+// ResourceSample is the resource usage of a process and its descendants
+type ResourceSample struct {
+	// Resident memory in bytes
+	RSS uint64
+	// CPU time used so far, user and system
+	CPU time.Duration
+}
+
+// ResourceWatchdog samples the resource usage of the wrapped process and
+// the processes it started, e.g. a build Gemini runs, and warns once memory
+// use or CPU load goes over its thresholds. Each warning is given again only
+// after the usage has dropped below the threshold.
+type ResourceWatchdog struct {
+	sample     func() (ResourceSample, bool)
+	maxRSS     uint64
+	cpuPercent float64
+	cpuFor     time.Duration
+	onMemory   func(rss uint64)
+	onCPU      func(percent float64, busy time.Duration)
+
+	mu sync.Mutex
+	// Previous sample, to tell the CPU load since
+	last     ResourceSample
+	lastTime time.Time
+	peakRSS  uint64
+	// When the CPU load went over the threshold, or zero
+	busySince      time.Time
+	memoryReported bool
+	cpuReported    bool
+}
+
+// NewResourceWatchdog creates a watchdog for the process pid and its
+// descendants. onMemory is called once their resident memory exceeds maxRSS
+// bytes, and onCPU once they have used cpuPercent of a core or more for
+// cpuFor; 0 disables either.
+func NewResourceWatchdog(pid int, maxRSS uint64, cpuPercent int, cpuFor time.Duration,
+	onMemory func(rss uint64), onCPU func(percent float64, busy time.Duration)) *ResourceWatchdog {
+	return &ResourceWatchdog{
+		sample:     func() (ResourceSample, bool) { return sampleProcessTree(pid) },
+		maxRSS:     maxRSS,
+		cpuPercent: float64(cpuPercent),
+		cpuFor:     cpuFor,
+		onMemory:   onMemory,
+		onCPU:      onCPU,
+	}
+}
+
+// Run samples the processes until stop is closed
+func (rw *ResourceWatchdog) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(resourceCheckInterval)
+	defer ticker.Stop()
+	rw.check(time.Now())
+	for {
+		select {
+		case now := <-ticker.C:
+			rw.check(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// PeakRSS returns the most resident memory seen in a sample, in bytes
+func (rw *ResourceWatchdog) PeakRSS() uint64 {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.peakRSS
+}
+
+// CPUTime returns the CPU time used as of the latest sample
+func (rw *ResourceWatchdog) CPUTime() time.Duration {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.last.CPU
+}
+
+// check samples the processes and warns about usage over the thresholds
+func (rw *ResourceWatchdog) check(now time.Time) {
+	sample, ok := rw.sample()
+	if !ok {
+		return
+	}
+
+	rw.mu.Lock()
+	rw.peakRSS = max(rw.peakRSS, sample.RSS)
+
+	memory := false
+	if rw.maxRSS > 0 {
+		over := sample.RSS > rw.maxRSS
+		memory = over && !rw.memoryReported
+		rw.memoryReported = over
+	}
+
+	cpu, percent, busy := false, 0.0, time.Duration(0)
+	if rw.cpuFor > 0 && !rw.lastTime.IsZero() && now.After(rw.lastTime) {
+		percent = float64(sample.CPU-rw.last.CPU) / float64(now.Sub(rw.lastTime)) * 100
+		if percent >= rw.cpuPercent {
+			if rw.busySince.IsZero() {
+				rw.busySince = rw.lastTime
+			}
+			busy = now.Sub(rw.busySince)
+			cpu = busy >= rw.cpuFor && !rw.cpuReported
+			rw.cpuReported = rw.cpuReported || cpu
+		} else {
+			rw.busySince = time.Time{}
+			rw.cpuReported = false
+		}
+	}
+	rw.last, rw.lastTime = sample, now
+	rw.mu.Unlock()
+
+	if memory {
+		rw.onMemory(sample.RSS)
+	}
+	if cpu {
+		rw.onCPU(percent, busy)
+	}
+}
@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the unit of CPU times in /proc, USER_HZ, which is 100 on
+// every Linux architecture Go supports
+const clockTicks = 100
+
+// sampleProcessTree sums the resource usage of pid and its descendants from
+// /proc
+func sampleProcessTree(pid int) (ResourceSample, bool) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return ResourceSample{}, false
+	}
+	stats := make(map[int]procStat)
+	for _, entry := range entries {
+		p, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile("/proc/" + entry.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		if stat, ok := parseProcStat(string(data)); ok {
+			stats[p] = stat
+		}
+	}
+	if _, ok := stats[pid]; !ok {
+		return ResourceSample{}, false
+	}
+	return sumProcessTree(stats, pid, uint64(os.Getpagesize())), true
+}
+
+// procStat is what a sample needs of /proc/<pid>/stat
+type procStat struct {
+	ppid int
+	// CPU time in clock ticks, user and system
+	ticks uint64
+	// Resident memory in pages
+	rssPages uint64
+}
+
+// parseProcStat parses /proc/<pid>/stat. The command name in parentheses
+// may hold spaces and parentheses itself, so the fields after it are found
+// from the last closing parenthesis.
+func parseProcStat(data string) (procStat, bool) {
+	end := strings.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, false
+	}
+	// Fields from the state (field 3) on
+	fields := strings.Fields(data[end+1:])
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	utime, err2 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err3 := strconv.ParseUint(fields[12], 10, 64)
+	rss, err4 := strconv.ParseInt(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return procStat{}, false
+	}
+	return procStat{ppid: ppid, ticks: utime + stime, rssPages: uint64(max(rss, 0))}, true
+}
+
+// sumProcessTree sums the usage of pid and its descendants
+func sumProcessTree(stats map[int]procStat, pid int, pageSize uint64) ResourceSample {
+	children := make(map[int][]int)
+	for p, stat := range stats {
+		children[stat.ppid] = append(children[stat.ppid], p)
+	}
+	var sample ResourceSample
+	pending := []int{pid}
+	for len(pending) > 0 {
+		p := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		stat := stats[p]
+		sample.RSS += stat.rssPages * pageSize
+		sample.CPU += time.Duration(stat.ticks) * time.Second / clockTicks
+		pending = append(pending, children[p]...)
+	}
+	return sample
+}
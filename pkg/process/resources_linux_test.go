@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	data := "4242 (node (worker) 1) S 4200 4242 4200 34816 4242 4194304 51234 0 12 0 1500 250 0 0 20 0 11 0 123456 1234567890 25000 18446744073709551615 1 1 0 0 0 0 0 16781312 17922 0 0 0 17 3 0 0 0 0 0\n"
+	stat, ok := parseProcStat(data)
+	if !ok {
+		t.Fatal("expected the stat to parse")
+	}
+	if stat.ppid != 4200 || stat.ticks != 1750 || stat.rssPages != 25000 {
+		t.Errorf("unexpected stat %+v", stat)
+	}
+	if _, ok := parseProcStat("4242 (node"); ok {
+		t.Error("expected a truncated stat to be rejected")
+	}
+}
+
+func TestSumProcessTree(t *testing.T) {
+	stats := map[int]procStat{
+		1:  {ppid: 0, ticks: 1000, rssPages: 1000},
+		10: {ppid: 1, ticks: 100, rssPages: 10},
+		11: {ppid: 10, ticks: 200, rssPages: 20},
+		12: {ppid: 11, ticks: 300, rssPages: 30},
+		20: {ppid: 1, ticks: 400, rssPages: 40},
+	}
+	sample := sumProcessTree(stats, 10, 4096)
+	if sample.RSS != 60*4096 || sample.CPU != 6*time.Second {
+		t.Errorf("expected the usage of 10 and its descendants, got %+v", sample)
+	}
+}
+
+func TestSampleProcessTree(t *testing.T) {
+	sample, ok := sampleProcessTree(os.Getpid())
+	if !ok || sample.RSS == 0 {
+		t.Errorf("expected the test's own usage, got %+v, %v", sample, ok)
+	}
+}
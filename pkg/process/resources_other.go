@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package process
+
+// sampleProcessTree is only available on Linux
+func sampleProcessTree(pid int) (ResourceSample, bool) {
+	return ResourceSample{}, false
+}
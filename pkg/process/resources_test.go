@@ -0,0 +1,50 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResourceWatchdog(t *testing.T) {
+	var sample ResourceSample
+	var memory []uint64
+	var cpu []time.Duration
+	rw := &ResourceWatchdog{
+		sample:     func() (ResourceSample, bool) { return sample, true },
+		maxRSS:     4 << 30,
+		cpuPercent: 90,
+		cpuFor:     10 * time.Minute,
+		onMemory:   func(rss uint64) { memory = append(memory, rss) },
+		onCPU:      func(percent float64, busy time.Duration) { cpu = append(cpu, busy) },
+	}
+
+	start := time.Now()
+	rw.check(start)
+	// A core in use for the next 12 minutes, while memory grows
+	for minute := 1; minute <= 12; minute++ {
+		sample.CPU += time.Minute
+		sample.RSS = uint64(minute) << 29
+		rw.check(start.Add(time.Duration(minute) * time.Minute))
+	}
+	if len(memory) != 1 || memory[0] != 9<<29 {
+		t.Errorf("expected one memory warning at 4.5 GiB, got %v", memory)
+	}
+	if len(cpu) != 1 || cpu[0] != 10*time.Minute {
+		t.Errorf("expected one CPU warning after 10m, got %v", cpu)
+	}
+	if rw.PeakRSS() != 6<<30 {
+		t.Errorf("expected a peak of 6 GiB, got %d", rw.PeakRSS())
+	}
+
+	// Idle for a minute with less memory, then busy again
+	sample.RSS = 1 << 30
+	rw.check(start.Add(13 * time.Minute))
+	for minute := 14; minute <= 25; minute++ {
+		sample.CPU += time.Minute
+		sample.RSS = 5 << 30
+		rw.check(start.Add(time.Duration(minute) * time.Minute))
+	}
+	if len(memory) != 2 || len(cpu) != 2 {
+		t.Errorf("expected second warnings after the usage dropped, got %v and %v", memory, cpu)
+	}
+}
@@ -0,0 +1,201 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const (
+	spliceMove     = 0x1 // SPLICE_F_MOVE
+	spliceNonblock = 0x2 // SPLICE_F_NONBLOCK
+	fcntlSetPipeSz = 1031
+
+	// spliceChunk is the most output moved per splice call
+	spliceChunk = 64 * 1024
+	// monitorPipeSize lets the monitor fall behind a burst of output
+	// without slowing down the relay
+	monitorPipeSize = 1024 * 1024
+)
+
+// spliceOutput relays src to dst with splice(2), so output never passes
+// through user space. A copy is tee'd into a second pipe that feeds handler
+// from its own goroutine. If dst or src can't be spliced it returns
+// errSpliceUnsupported, after delivering any output already taken from src,
+// and the caller should continue with a plain copy.
+func spliceOutput(dst, src *os.File, handler func([]byte)) error {
+	srcConn, err := src.SyscallConn()
+	if err != nil {
+		return errSpliceUnsupported
+	}
+	dstConn, err := dst.SyscallConn()
+	if err != nil {
+		return errSpliceUnsupported
+	}
+
+	// Output moves src -> relay pipe -> dst, and is tee'd from the relay
+	// pipe into the monitor pipe
+	relayR, relayW, err := os.Pipe()
+	if err != nil {
+		return errSpliceUnsupported
+	}
+	defer func() { _ = relayR.Close() }()
+	defer func() { _ = relayW.Close() }()
+
+	var monitorW *os.File
+	var monitorDone chan struct{}
+	if handler != nil {
+		var monitorR *os.File
+		monitorR, monitorW, err = os.Pipe()
+		if err != nil {
+			return errSpliceUnsupported
+		}
+		setPipeSize(monitorW, monitorPipeSize)
+
+		monitorDone = make(chan struct{})
+		go func() {
+			defer close(monitorDone)
+			defer func() { _ = monitorR.Close() }()
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := monitorR.Read(buf)
+				if n > 0 {
+					handler(buf[:n])
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		// Let the monitor finish the output it was given before returning
+		defer func() {
+			_ = monitorW.Close()
+			<-monitorDone
+		}()
+	}
+
+	relayRFd, relayWFd := fileFd(relayR), fileFd(relayW)
+	first := true
+	for {
+		// Move the next chunk of output into the relay pipe
+		var n int64
+		var serr error
+		err := srcConn.Read(func(fd uintptr) bool {
+			n, serr = syscall.Splice(int(fd), nil, relayWFd, nil, spliceChunk, spliceMove|spliceNonblock)
+			return serr != syscall.EAGAIN
+		})
+		if err == nil {
+			err = serr
+		}
+		switch {
+		case err == syscall.EINVAL && first:
+			return errSpliceUnsupported
+		case err == syscall.EIO || (err == nil && n == 0):
+			// The child closed the terminal
+			return nil
+		case err != nil:
+			return err
+		}
+		first = false
+
+		// Pass it on, in as many pieces as tee manages to copy
+		for n > 0 {
+			chunk := n
+			if monitorW != nil {
+				var t int64
+				var terr error
+				err := rawWrite(monitorW, func(fd uintptr) bool {
+					t, terr = syscall.Tee(relayRFd, int(fd), int(n), spliceNonblock)
+					return terr != syscall.EAGAIN
+				})
+				if err == nil {
+					err = terr
+				}
+				if err != nil {
+					return err
+				}
+				chunk = t
+			}
+
+			if err := spliceTo(dstConn, relayRFd, chunk); err != nil {
+				if err != syscall.EINVAL {
+					return err
+				}
+				// dst can't be spliced to: copy what is already in the
+				// relay pipe, handing the part not tee'd yet to the monitor
+				return drainRelay(dst, relayR, monitorW, n, chunk)
+			}
+			n -= chunk
+		}
+	}
+}
+
+// spliceTo moves exactly n bytes from the relay pipe to dst
+func spliceTo(dstConn syscall.RawConn, relayRFd int, n int64) error {
+	for n > 0 {
+		var w int64
+		var werr error
+		err := dstConn.Write(func(fd uintptr) bool {
+			w, werr = syscall.Splice(relayRFd, nil, int(fd), nil, int(n), spliceMove|spliceNonblock)
+			return werr != syscall.EAGAIN
+		})
+		if err == nil {
+			err = werr
+		}
+		if err != nil {
+			return err
+		}
+		n -= w
+	}
+	return nil
+}
+
+// drainRelay copies the n bytes left in the relay pipe to dst after splicing
+// to dst failed. The first teed bytes were already copied to the monitor.
+func drainRelay(dst, relayR, monitorW *os.File, n, teed int64) error {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(relayR, buf); err != nil {
+		return err
+	}
+	if _, err := dst.Write(buf); err != nil {
+		return err
+	}
+	if monitorW != nil && teed < n {
+		if _, err := monitorW.Write(buf[teed:]); err != nil {
+			return err
+		}
+	}
+	return errSpliceUnsupported
+}
+
+// rawWrite runs fn on f's descriptor once it is writable
+func rawWrite(f *os.File, fn func(fd uintptr) bool) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	return conn.Write(fn)
+}
+
+// fileFd returns f's descriptor without switching it to blocking mode, as
+// f.Fd() would
+func fileFd(f *os.File) int {
+	fd := -1
+	if conn, err := f.SyscallConn(); err == nil {
+		_ = conn.Control(func(d uintptr) { fd = int(d) })
+	}
+	return fd
+}
+
+// setPipeSize grows a pipe's buffer; failure just leaves the default size
+func setPipeSize(f *os.File, size int) {
+	if conn, err := f.SyscallConn(); err == nil {
+		_ = conn.Control(func(fd uintptr) {
+			_, _, _ = syscall.Syscall(syscall.SYS_FCNTL, fd, fcntlSetPipeSz, uintptr(size))
+		})
+	}
+}
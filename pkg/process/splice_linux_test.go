@@ -0,0 +1,96 @@
+//go:build linux
+// +build linux
+
+package process
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// spliceSource returns a pipe that yields data and then EOF
+func spliceSource(t *testing.T, data string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		// Write in pieces so the relay sees several chunks
+		for i := 0; i < len(data); i += 1000 {
+			end := min(i+1000, len(data))
+			_, _ = w.WriteString(data[i:end])
+		}
+		_ = w.Close()
+	}()
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestSpliceOutput(t *testing.T) {
+	data := strings.Repeat("output line \033[32mgreen\033[0m\n", 5000)
+
+	t.Run("relays and tees output", func(t *testing.T) {
+		dstR, dstW, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var relayed bytes.Buffer
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = io.Copy(&relayed, dstR)
+		}()
+
+		var monitored bytes.Buffer
+		err = spliceOutput(dstW, spliceSource(t, data), func(b []byte) { monitored.Write(b) })
+		if err != nil {
+			t.Fatalf("spliceOutput failed: %v", err)
+		}
+		_ = dstW.Close()
+		wg.Wait()
+
+		if relayed.String() != data {
+			t.Errorf("relayed %d bytes, want %d", relayed.Len(), len(data))
+		}
+		if monitored.String() != data {
+			t.Errorf("monitored %d bytes, want %d", monitored.Len(), len(data))
+		}
+	})
+
+	t.Run("falls back when destination can't be spliced", func(t *testing.T) {
+		// splice(2) refuses files opened for appending
+		path := filepath.Join(t.TempDir(), "out")
+		dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = dst.Close() }()
+
+		src := spliceSource(t, data)
+		var monitored bytes.Buffer
+		handler := func(b []byte) { monitored.Write(b) }
+		if err := spliceOutput(dst, src, handler); err != errSpliceUnsupported {
+			t.Fatalf("expected errSpliceUnsupported, got %v", err)
+		}
+
+		// The caller copies the rest, as CopyIO does
+		if _, err := io.Copy(dst, &outputReader{reader: src, handler: handler}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, _ := os.ReadFile(path)
+		if string(got) != data {
+			t.Errorf("destination has %d bytes, want %d", len(got), len(data))
+		}
+		if monitored.String() != data {
+			t.Errorf("monitored %d bytes, want %d", monitored.Len(), len(data))
+		}
+	})
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package process
+
+import "os"
+
+// spliceOutput is only implemented on Linux; elsewhere output is always copied
+func spliceOutput(dst, src *os.File, handler func([]byte)) error {
+	return errSpliceUnsupported
+}
@@ -39,3 +39,11 @@ func setRawMode(fd int) (func(), error) {
 		_, _, _ = syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), ioctlWriteTermios, uintptr(unsafe.Pointer(&oldState)), 0, 0, 0) // #nosec G103 -- Required for terminal operations
 	}, nil
 }
+
+// IsTerminal reports whether fd refers to a terminal
+func IsTerminal(fd int) bool {
+	var state syscall.Termios
+	// #nosec G103 -- Required for terminal operations
+	_, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd), ioctlReadTermios, uintptr(unsafe.Pointer(&state)), 0, 0, 0)
+	return err == 0
+}
@@ -0,0 +1,86 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Kinds of events in a recording
+const (
+	// CastOutput is output the recorded program printed
+	CastOutput = "o"
+	// CastInput is input the user typed, recorded with asciinema's --stdin
+	CastInput = "i"
+)
+
+// CastEvent is one event of an asciinema recording
+type CastEvent struct {
+	// Time since the recording started
+	Time time.Duration
+	// Kind of event, e.g. CastOutput
+	Kind string
+	Data string
+}
+
+// ReadCast reads the events of an asciinema recording, in version 2 (times
+// since the start) or 3 (times since the previous event) of the format.
+// Events other than output and input are skipped.
+func ReadCast(r io.Reader) ([]CastEvent, error) {
+	reader := bufio.NewReader(r)
+
+	header, err := reader.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(bytes.TrimSpace(header)) == 0) {
+		return nil, fmt.Errorf("failed to read recording header: %w", err)
+	}
+	var meta struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(header, &meta); err != nil {
+		return nil, fmt.Errorf("invalid recording header: %w", err)
+	}
+	if meta.Version != 2 && meta.Version != 3 {
+		return nil, fmt.Errorf("unsupported recording version %d (want 2 or 3)", meta.Version)
+	}
+
+	var events []CastEvent
+	var elapsed float64
+	for lineNumber := 2; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 && !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			var fields []json.RawMessage
+			if err := json.Unmarshal(line, &fields); err != nil || len(fields) < 3 {
+				return nil, fmt.Errorf("invalid event on line %d", lineNumber)
+			}
+			var at float64
+			var kind, data string
+			if json.Unmarshal(fields[0], &at) != nil || json.Unmarshal(fields[1], &kind) != nil {
+				return nil, fmt.Errorf("invalid event on line %d", lineNumber)
+			}
+			if meta.Version == 3 {
+				at += elapsed
+			}
+			elapsed = at
+			if kind == CastOutput || kind == CastInput {
+				if json.Unmarshal(fields[2], &data) != nil {
+					return nil, fmt.Errorf("invalid event on line %d", lineNumber)
+				}
+				events = append(events, CastEvent{
+					Time: time.Duration(at * float64(time.Second)),
+					Kind: kind,
+					Data: data,
+				})
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+	}
+}
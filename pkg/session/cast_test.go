@@ -0,0 +1,63 @@
+package session
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCast(t *testing.T) {
+	t.Run("version 2", func(t *testing.T) {
+		recording := `{"version": 2, "width": 80, "height": 24}
+[0.5, "o", "\u001b[1mHello\u001b[0m\r\n"]
+[1.25, "i", "fix it\r"]
+[1.5, "r", "100x30"]
+[2, "o", "Done"]`
+		events, err := ReadCast(strings.NewReader(recording))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []CastEvent{
+			{500 * time.Millisecond, CastOutput, "\x1b[1mHello\x1b[0m\r\n"},
+			{1250 * time.Millisecond, CastInput, "fix it\r"},
+			{2 * time.Second, CastOutput, "Done"},
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %q, got %q", want, events)
+		}
+	})
+
+	t.Run("version 3 times are intervals", func(t *testing.T) {
+		recording := `{"version": 3, "term": {"cols": 80, "rows": 24}}
+# a comment
+[0.5, "o", "a"]
+[0.25, "m", "marker"]
+[1.0, "o", "b"]
+[0.1, "x", "0"]
+`
+		events, err := ReadCast(strings.NewReader(recording))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []CastEvent{
+			{500 * time.Millisecond, CastOutput, "a"},
+			{1750 * time.Millisecond, CastOutput, "b"},
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %q, got %q", want, events)
+		}
+	})
+
+	t.Run("rejects other formats", func(t *testing.T) {
+		for _, recording := range []string{
+			`{"version": 1, "stdout": []}`,
+			"plain text\n",
+			"{\"version\": 2}\n[0.5, \"o\"]\n",
+		} {
+			if _, err := ReadCast(strings.NewReader(recording)); err == nil {
+				t.Errorf("expected an error for %q", recording)
+			}
+		}
+	})
+}
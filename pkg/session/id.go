@@ -0,0 +1,50 @@
+package session
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxIDLength keeps a topic with the identifier appended short; ntfy allows
+// at most 64 characters
+const maxIDLength = 16
+
+// maxTopicLength is the longest topic ntfy accepts
+const maxTopicLength = 64
+
+// ID returns a short identifier for a session: its name if it has one, or
+// else the name of its working directory, so a project keeps its identifier
+// across restarts. Only the characters ntfy allows in topics are kept.
+func ID(name, dir string) string {
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if b.Len() == maxIDLength {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '.':
+			b.WriteRune('-')
+		}
+	}
+
+	id := strings.Trim(b.String(), "-_")
+	if id == "" {
+		return "session"
+	}
+	return id
+}
+
+// Topic returns the topic of a session: the configured topic with the
+// session's identifier appended
+func Topic(topic, id string) string {
+	if len(topic)+1+len(id) > maxTopicLength {
+		topic = topic[:maxTopicLength-1-len(id)]
+	}
+	return topic + "-" + id
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		session  string
+		dir      string
+		expected string
+	}{
+		{"directory name", "", "/home/me/src/my-api", "my-api"},
+		{"session name wins", "Frontend", "/home/me/src/web", "frontend"},
+		{"unsafe characters", "", "/home/me/My Project.v2", "my-project-v2"},
+		{"long names are cut", "", "/src/a-very-long-project-name", "a-very-long-proj"},
+		{"nothing usable", "", "/", "session"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id := ID(tt.session, tt.dir); id != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestTopic(t *testing.T) {
+	if topic := Topic("gemini-abc", "my-api"); topic != "gemini-abc-my-api" {
+		t.Errorf("expected the identifier appended, got %q", topic)
+	}
+
+	long := strings.Repeat("t", 60)
+	if topic := Topic(long, "my-api"); len(topic) != maxTopicLength || !strings.HasSuffix(topic, "-my-api") {
+		t.Errorf("expected a topic cut to %d characters keeping the identifier, got %q", maxTopicLength, topic)
+	}
+}
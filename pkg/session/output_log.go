@@ -0,0 +1,155 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/systemd"
+)
+
+// Output log formats
+const (
+	// LogRaw keeps the output byte for byte, for replaying with cat or less -R
+	LogRaw = "raw"
+	// LogPlain keeps the text as plain lines, without escape sequences
+	LogPlain = "plain"
+	// LogBoth writes both files
+	LogBoth = "both"
+)
+
+// DefaultLogDir returns the directory holding output logs
+func DefaultLogDir() string {
+	return filepath.Join(StateDir(), "logs")
+}
+
+// OutputLog writes a session's output to a log file, as raw output, as
+// plain text, or both
+type OutputLog struct {
+	mu    sync.Mutex
+	files []*os.File
+	paths []string
+	raw   io.Writer
+	plain *systemd.JournalWriter
+	path  string
+	// The first write error; logging stops after it
+	err error
+}
+
+// OpenOutputLog creates the log files of a session in dir, named after the
+// start time, the session's identifier and the pid, e.g.
+// 20261016-091200-my-api-4242.log for plain text and .raw.log for raw output
+func OpenOutputLog(dir, id string, pid int, format string) (*OutputLog, error) {
+	// Logs hold everything the session showed, so only the owner may read them
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s-%d", time.Now().Format("20060102-150405"), id, pid))
+
+	ol := &OutputLog{}
+	if format == LogRaw || format == LogBoth {
+		f, err := ol.create(base+".raw.log", os.O_EXCL)
+		if err != nil {
+			return nil, err
+		}
+		ol.raw = f
+		ol.path = f.Name()
+	}
+	if format == LogPlain || format == LogBoth {
+		f, err := ol.create(base+".log", os.O_EXCL)
+		if err != nil {
+			_ = ol.Close()
+			return nil, err
+		}
+		ol.plain = systemd.NewJournalWriter(f)
+		// The plain text is the one worth reading
+		ol.path = f.Name()
+	}
+	if len(ol.files) == 0 {
+		return nil, fmt.Errorf("unknown output log format %q", format)
+	}
+	return ol, nil
+}
+
+// OpenTee creates or truncates the file at path for a copy of a session's
+// output, as plain text unless raw is set, like tee(1) with the escape
+// sequences removed
+func OpenTee(path string, raw bool) (*OutputLog, error) {
+	ol := &OutputLog{}
+	f, err := ol.create(path, os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+	if raw {
+		ol.raw = f
+	} else {
+		ol.plain = systemd.NewJournalWriter(f)
+	}
+	ol.path = f.Name()
+	return ol, nil
+}
+
+// create opens a log file with the extra open flag and remembers it for
+// Close
+func (ol *OutputLog) create(path string, flag int) (*os.File, error) {
+	// #nosec G304 -- The path is built from the state directory or given by the user
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output log: %w", err)
+	}
+	ol.files = append(ol.files, f)
+	ol.paths = append(ol.paths, path)
+	return f, nil
+}
+
+// Path returns the log file to point the user at: the plain text one if it
+// is written
+func (ol *OutputLog) Path() string {
+	return ol.path
+}
+
+// Write implements io.Writer. Errors are remembered rather than returned, so
+// a full disk never disturbs the session; logging stops at the first one.
+func (ol *OutputLog) Write(p []byte) (int, error) {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if ol.err != nil {
+		return len(p), nil
+	}
+	if ol.raw != nil {
+		if _, err := ol.raw.Write(p); err != nil {
+			ol.err = err
+		}
+	}
+	if ol.plain != nil && ol.err == nil {
+		if _, err := ol.plain.Write(p); err != nil {
+			ol.err = err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes any incomplete last line and closes the files. It returns
+// the first error logging ran into.
+func (ol *OutputLog) Close() error {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if ol.plain != nil && ol.err == nil {
+		ol.err = ol.plain.Flush()
+	}
+	for _, f := range ol.files {
+		if err := f.Close(); err != nil && ol.err == nil {
+			ol.err = err
+		}
+	}
+	ol.files = nil
+	if ol.err != nil {
+		return fmt.Errorf("failed to write output log: %w", ol.err)
+	}
+	return nil
+}
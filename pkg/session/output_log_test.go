@@ -0,0 +1,106 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputLog(t *testing.T) {
+	output := "\x1b[1mHello\x1b[0m\r\nProgress 1%\rProgress 100%\r\nDone"
+
+	t.Run("both", func(t *testing.T) {
+		dir := t.TempDir()
+		ol, err := OpenOutputLog(dir, "my-api", 4242, LogBoth)
+		if err != nil {
+			t.Fatalf("OpenOutputLog failed: %v", err)
+		}
+		// Output arrives in arbitrary chunks
+		_, _ = ol.Write([]byte(output[:9]))
+		_, _ = ol.Write([]byte(output[9:]))
+		if err := ol.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		if !strings.HasSuffix(ol.Path(), "-my-api-4242.log") || filepath.Dir(ol.Path()) != dir {
+			t.Errorf("unexpected path %q", ol.Path())
+		}
+		plain, err := os.ReadFile(ol.Path())
+		if err != nil {
+			t.Fatalf("failed to read plain log: %v", err)
+		}
+		if string(plain) != "Hello\nProgress 100%\nDone\n" {
+			t.Errorf("unexpected plain log %q", plain)
+		}
+		raw, err := os.ReadFile(strings.TrimSuffix(ol.Path(), ".log") + ".raw.log")
+		if err != nil {
+			t.Fatalf("failed to read raw log: %v", err)
+		}
+		if string(raw) != output {
+			t.Errorf("unexpected raw log %q", raw)
+		}
+
+		info, err := os.Stat(ol.Path())
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("expected a private log file, got %v, %v", info, err)
+		}
+	})
+
+	t.Run("raw only", func(t *testing.T) {
+		ol, err := OpenOutputLog(t.TempDir(), "my-api", 4242, LogRaw)
+		if err != nil {
+			t.Fatalf("OpenOutputLog failed: %v", err)
+		}
+		defer func() { _ = ol.Close() }()
+		if !strings.HasSuffix(ol.Path(), ".raw.log") {
+			t.Errorf("expected the raw log's path, got %q", ol.Path())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := OpenOutputLog(t.TempDir(), "my-api", 4242, "html"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestOpenTee(t *testing.T) {
+	output := "\x1b[32m✓\x1b[0m Tests passed\r\nSpinner |\rSpinner /\rDone\r\n"
+	path := filepath.Join(t.TempDir(), "gemini.txt")
+	// An existing file is replaced, as tee does
+	if err := os.WriteFile(path, []byte("an earlier session\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		raw  bool
+		want string
+	}{
+		{false, "✓ Tests passed\nDone\n"},
+		{true, output},
+	} {
+		tee, err := OpenTee(path, tt.raw)
+		if err != nil {
+			t.Fatalf("OpenTee failed: %v", err)
+		}
+		_, _ = tee.Write([]byte(output))
+		if err := tee.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if tee.Path() != path {
+			t.Errorf("expected path %q, got %q", path, tee.Path())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read tee file: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("raw=%v: expected %q, got %q", tt.raw, tt.want, data)
+		}
+	}
+
+	if _, err := OpenTee(filepath.Join(t.TempDir(), "missing", "gemini.txt"), false); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
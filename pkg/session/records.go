@@ -0,0 +1,156 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Record summarizes a finished session, for the daily digest
+type Record struct {
+	ID       string    `json:"id"`
+	Dir      string    `json:"dir"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+	ExitCode int       `json:"exit_code"`
+	// Notifications sent, by type
+	Notifications map[string]int `json:"notifications,omitempty"`
+}
+
+// DefaultRecordsPath returns the file finished sessions are recorded in
+func DefaultRecordsPath() string {
+	return filepath.Join(StateDir(), "records.jsonl")
+}
+
+// DefaultDigestDir returns the directory marking the days a digest was sent
+func DefaultDigestDir() string {
+	return filepath.Join(StateDir(), "digests")
+}
+
+// AppendRecord adds a finished session to the records file, one JSON object
+// per line
+func AppendRecord(path string, record Record) error {
+	// Records name the projects worked on, so only the owner may read them
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+
+	// A single append of a short line isn't interleaved with other sessions'
+	// #nosec G304 -- The path is built from the state directory
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open records: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadRecords returns the sessions that ended after since, oldest first.
+// Lines that can't be read, e.g. from an interrupted write, are skipped.
+func ReadRecords(path string, since time.Time) ([]Record, error) {
+	// #nosec G304 -- The path is built from the state directory
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open records: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if record.Ended.After(since) {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return records, nil
+}
+
+// PruneRecords drops the sessions that ended before a time from the records
+// file, so it doesn't grow forever
+func PruneRecords(path string, before time.Time) error {
+	records, err := ReadRecords(path, before)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	// Write a temporary file and rename it, so appends never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".records-*")
+	if err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return nil
+}
+
+// ClaimDigest reports whether the digest of a day is still to be sent, and
+// marks it as sent, so only one of the sessions running at the digest time
+// sends it. Markers of earlier days are removed.
+func ClaimDigest(dir string, day time.Time) (bool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create digest directory: %w", err)
+	}
+	name := day.Format("2006-01-02")
+
+	// #nosec G304 -- The path is built from the state directory
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark digest as sent: %w", err)
+	}
+	_ = f.Close()
+
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if entry.Name() < name {
+				_ = os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "records.jsonl")
+	now := time.Now()
+
+	records, err := ReadRecords(path, now.Add(-24*time.Hour))
+	if err != nil || len(records) != 0 {
+		t.Fatalf("expected no records before the file exists, got %v, %v", records, err)
+	}
+
+	old := Record{ID: "old", Started: now.Add(-50 * time.Hour), Ended: now.Add(-48 * time.Hour)}
+	recent := Record{
+		ID:            "api",
+		Dir:           "/src/api",
+		Started:       now.Add(-2 * time.Hour),
+		Ended:         now.Add(-time.Hour),
+		ExitCode:      1,
+		Notifications: map[string]int{"backstop": 2, "crash": 1},
+	}
+	for _, r := range []Record{old, recent} {
+		if err := AppendRecord(path, r); err != nil {
+			t.Fatalf("AppendRecord failed: %v", err)
+		}
+	}
+
+	t.Run("reads the records since a time", func(t *testing.T) {
+		records, err := ReadRecords(path, now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("ReadRecords failed: %v", err)
+		}
+		if len(records) != 1 || records[0].ID != "api" || records[0].ExitCode != 1 || records[0].Notifications["backstop"] != 2 {
+			t.Errorf("expected the recent record, got %+v", records)
+		}
+	})
+
+	t.Run("skips damaged lines", func(t *testing.T) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString("{\"id\": \"cut of\n")
+		_ = f.Close()
+
+		records, err := ReadRecords(path, time.Time{})
+		if err != nil || len(records) != 2 {
+			t.Errorf("expected 2 records, got %+v, %v", records, err)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		if err := PruneRecords(path, now.Add(-24*time.Hour)); err != nil {
+			t.Fatalf("PruneRecords failed: %v", err)
+		}
+		records, err := ReadRecords(path, time.Time{})
+		if err != nil || len(records) != 1 || records[0].ID != "api" {
+			t.Errorf("expected only the recent record to be kept, got %+v, %v", records, err)
+		}
+	})
+}
+
+func TestClaimDigest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "digests")
+	day := time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+
+	if claimed, err := ClaimDigest(dir, day.AddDate(0, 0, -1)); err != nil || !claimed {
+		t.Fatalf("expected to claim the previous day, got %v, %v", claimed, err)
+	}
+	if claimed, err := ClaimDigest(dir, day); err != nil || !claimed {
+		t.Fatalf("expected to claim the day, got %v, %v", claimed, err)
+	}
+	if claimed, err := ClaimDigest(dir, day); err != nil || claimed {
+		t.Errorf("expected the day to be claimed once, got %v, %v", claimed, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || entries[0].Name() != "2026-10-16" {
+		t.Errorf("expected only the day's marker to be kept, got %v, %v", entries, err)
+	}
+}
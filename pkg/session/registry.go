@@ -0,0 +1,164 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Info describes a running wrapped session
+type Info struct {
+	PID int `json:"pid"`
+	// Short identifier, see ID
+	ID      string    `json:"id"`
+	Started time.Time `json:"started"`
+	Dir     string    `json:"dir"`
+	Topic   string    `json:"topic,omitempty"`
+	Profile string    `json:"profile,omitempty"`
+	// Control socket of the session, if it has one
+	Socket string `json:"socket,omitempty"`
+	// Time of the wrapped CLI's last output, refreshed while it runs
+	LastOutput time.Time `json:"last_output"`
+	// Whether notifications are silenced
+	Quiet bool `json:"quiet,omitempty"`
+	// File the session's output is logged to, if any
+	OutputLog string `json:"output_log,omitempty"`
+}
+
+// IdleFor returns how long the session has produced no output
+func (i Info) IdleFor(now time.Time) time.Duration {
+	if i.LastOutput.IsZero() {
+		return now.Sub(i.Started)
+	}
+	return now.Sub(i.LastOutput)
+}
+
+// StateDir returns the wrapper's directory under the XDG state directory
+func StateDir() string {
+	if stateDir := os.Getenv("XDG_STATE_HOME"); stateDir != "" {
+		return filepath.Join(stateDir, "gemini-cli-ntfy")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "gemini-cli-ntfy")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gemini-cli-ntfy-%d", os.Getuid()))
+}
+
+// DefaultDir returns the directory holding the registry
+func DefaultDir() string {
+	return filepath.Join(StateDir(), "sessions")
+}
+
+// Registry keeps one file per running session, named after its pid, so
+// other commands can find the sessions of the current user
+type Registry struct {
+	dir string
+}
+
+// NewRegistry creates a registry in the given directory
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// path returns the file of the session with the given pid
+func (r *Registry) path(pid int) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%d.json", pid))
+}
+
+// Save records or updates a session
+func (r *Registry) Save(info Info) error {
+	// Sessions reveal the topic, so only the owning user may read them
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	// Write a temporary file and rename it, so readers never see half a file
+	tmp, err := os.CreateTemp(r.dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path(info.PID)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the session with the given pid
+func (r *Registry) Remove(pid int) error {
+	if err := os.Remove(r.path(pid)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove session: %w", err)
+	}
+	return nil
+}
+
+// List returns the running sessions, oldest first. Sessions whose wrapper
+// is gone, e.g. because it was killed, are removed.
+func (r *Registry) List() ([]Info, error) {
+	entries, err := os.ReadDir(r.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	var sessions []Info
+	for _, entry := range entries {
+		name := entry.Name()
+		pid, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+		if err != nil || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if !alive(pid) {
+			_ = r.Remove(pid)
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			continue
+		}
+		var info Info
+		if err := json.Unmarshal(data, &info); err != nil || info.PID != pid {
+			continue
+		}
+		sessions = append(sessions, info)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+	return sessions, nil
+}
+
+// alive reports whether a process with the given pid exists
+func alive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Signal 0 only checks the process; EPERM means it belongs to someone else
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	registry := NewRegistry(dir)
+
+	sessions, err := registry.List()
+	if err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no sessions before the directory exists, got %v, %v", sessions, err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	self := Info{PID: os.Getpid(), Started: start, Dir: "/src/app", Topic: "gemini-abc"}
+	if err := registry.Save(self); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		self.LastOutput = start.Add(30 * time.Minute)
+		if err := registry.Save(self); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		sessions, err := registry.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(sessions) != 1 || !sessions[0].LastOutput.Equal(self.LastOutput) || sessions[0].Dir != "/src/app" {
+			t.Errorf("expected the updated session, got %+v", sessions)
+		}
+	})
+
+	t.Run("stale sessions are removed", func(t *testing.T) {
+		// No process has a pid this large
+		stale := Info{PID: 1 << 30, Started: start}
+		if err := registry.Save(stale); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		sessions, err := registry.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].PID != os.Getpid() {
+			t.Errorf("expected only the live session, got %+v", sessions)
+		}
+		if _, err := os.Stat(registry.path(stale.PID)); !os.IsNotExist(err) {
+			t.Errorf("expected the stale session's file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if err := registry.Remove(self.PID); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+		if err := registry.Remove(self.PID); err != nil {
+			t.Errorf("removing twice should not fail: %v", err)
+		}
+		if sessions, _ := registry.List(); len(sessions) != 0 {
+			t.Errorf("expected no sessions, got %+v", sessions)
+		}
+	})
+
+	info, err := os.Stat(dir)
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("expected a private session directory, got %v, %v", info, err)
+	}
+}
+
+func TestInfoIdleFor(t *testing.T) {
+	start := time.Now()
+	info := Info{Started: start}
+	if idle := info.IdleFor(start.Add(time.Minute)); idle != time.Minute {
+		t.Errorf("expected idle since start, got %v", idle)
+	}
+	info.LastOutput = start.Add(time.Minute)
+	if idle := info.IdleFor(start.Add(3 * time.Minute)); idle != 2*time.Minute {
+		t.Errorf("expected idle since last output, got %v", idle)
+	}
+}
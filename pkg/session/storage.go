@@ -0,0 +1,199 @@
+package session
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compress gzips the closed log files, replacing each with a .gz file, and
+// points Path at the compressed copy
+func (ol *OutputLog) Compress() error {
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	for _, path := range ol.paths {
+		compressed, err := compressFile(path)
+		if err != nil {
+			return err
+		}
+		if path == ol.path {
+			ol.path = compressed
+		}
+	}
+	return nil
+}
+
+// compressFile gzips a file next to it, keeping its modification time so
+// the oldest logs are still evicted first, and removes the original
+func compressFile(path string) (string, error) {
+	// #nosec G304 -- The path is a log file in the state directory
+	in, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress log: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+	info, err := in.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to compress log: %w", err)
+	}
+
+	compressed := path + ".gz"
+	// #nosec G304 -- The path is a log file in the state directory
+	out, err := os.OpenFile(compressed, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress log: %w", err)
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(compressed)
+		return "", fmt.Errorf("failed to compress log: %w", err)
+	}
+
+	_ = os.Chtimes(compressed, info.ModTime(), info.ModTime())
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to compress log: %w", err)
+	}
+	return compressed, nil
+}
+
+// logFile is a log in the log directory
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	// Whether the session that wrote it is still running
+	running bool
+}
+
+// TidyLogs looks after the log directory dir when a session ends: with
+// compress set, it gzips the logs left uncompressed by sessions that are no
+// longer running, e.g. because their wrapper was killed; with maxBytes set,
+// it then removes logs, oldest first, until all of stateDir takes up at most
+// maxBytes. Logs of running sessions are kept either way.
+func TidyLogs(dir, stateDir string, compress bool, maxBytes int64) error {
+	logs, err := readLogs(dir)
+	if err != nil {
+		return err
+	}
+
+	if compress {
+		for i, log := range logs {
+			if log.running || !strings.HasSuffix(log.path, ".log") {
+				continue
+			}
+			compressed, err := compressFile(log.path)
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(compressed); err == nil {
+				logs[i].path = compressed
+				logs[i].size = info.Size()
+			}
+		}
+	}
+
+	if maxBytes <= 0 {
+		return nil
+	}
+	total, err := dirSize(stateDir)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].modTime.Before(logs[j].modTime)
+	})
+	for _, log := range logs {
+		if total <= maxBytes {
+			break
+		}
+		if log.running {
+			continue
+		}
+		if err := os.Remove(log.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log: %w", err)
+		}
+		total -= log.size
+	}
+	return nil
+}
+
+// readLogs returns the logs in dir, plain, raw or compressed
+func readLogs(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var logs []logFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, logFile{
+			path:    filepath.Join(dir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			running: logRunning(name),
+		})
+	}
+	return logs, nil
+}
+
+// logRunning reports whether the session that wrote a log is running, from
+// the pid that ends the log's name
+func logRunning(name string) bool {
+	base := strings.TrimSuffix(name, ".gz")
+	base = strings.TrimSuffix(base, ".log")
+	base = strings.TrimSuffix(base, ".raw")
+	pid, err := strconv.Atoi(base[strings.LastIndex(base, "-")+1:])
+	return err == nil && pid > 0 && alive(pid)
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may go away while other sessions tidy up
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure state directory: %w", err)
+	}
+	return total, nil
+}
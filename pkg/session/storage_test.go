@@ -0,0 +1,168 @@
+package session
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readGzip returns the uncompressed content of a gzip file
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestOutputLogCompress(t *testing.T) {
+	dir := t.TempDir()
+	ol, err := OpenOutputLog(dir, "my-api", 4242, LogBoth)
+	if err != nil {
+		t.Fatalf("OpenOutputLog failed: %v", err)
+	}
+	_, _ = ol.Write([]byte("\x1b[1mHello\x1b[0m\r\n"))
+	if err := ol.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	plain := ol.Path()
+	if err := ol.Compress(); err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+
+	if ol.Path() != plain+".gz" {
+		t.Errorf("expected the path of the compressed plain log, got %q", ol.Path())
+	}
+	if got := readGzip(t, ol.Path()); got != "Hello\n" {
+		t.Errorf("unexpected plain log %q", got)
+	}
+	if got := readGzip(t, strings.TrimSuffix(plain, ".log")+".raw.log.gz"); got != "\x1b[1mHello\x1b[0m\r\n" {
+		t.Errorf("unexpected raw log %q", got)
+	}
+	if _, err := os.Stat(plain); !os.IsNotExist(err) {
+		t.Errorf("expected the uncompressed log to be removed, got %v", err)
+	}
+	info, err := os.Stat(ol.Path())
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected a private compressed log, got %v, %v", info, err)
+	}
+}
+
+func TestTidyLogs(t *testing.T) {
+	// A pid no process has, and the test's own for a running session
+	const finished = 999999
+	running := os.Getpid()
+
+	setup := func(t *testing.T) (string, string) {
+		stateDir := t.TempDir()
+		dir := filepath.Join(stateDir, "logs")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now().Add(-time.Hour)
+		logs := []struct {
+			name string
+			pid  int
+		}{
+			{"20261016-090000-old.log.gz", finished},
+			{"20261016-091000-killed.log", finished},
+			{"20261016-092000-running.log", running},
+			{"20261016-093000-killed.raw.log", finished},
+		}
+		for i, log := range logs {
+			name := strings.Replace(log.name, ".", fmt.Sprintf("-%d.", log.pid), 1)
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(strings.Repeat("x", 1000)), 0600); err != nil {
+				t.Fatal(err)
+			}
+			modTime := start.Add(time.Duration(i) * time.Minute)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir, stateDir
+	}
+	names := func(t *testing.T, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("compress", func(t *testing.T) {
+		dir, stateDir := setup(t)
+		if err := TidyLogs(dir, stateDir, true, 0); err != nil {
+			t.Fatalf("TidyLogs failed: %v", err)
+		}
+		want := []string{
+			"20261016-090000-old-999999.log.gz",
+			"20261016-091000-killed-999999.log.gz",
+			fmt.Sprintf("20261016-092000-running-%d.log", running),
+			"20261016-093000-killed-999999.raw.log.gz",
+		}
+		if got := names(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+		if got := readGzip(t, filepath.Join(dir, want[1])); got != strings.Repeat("x", 1000) {
+			t.Errorf("unexpected compressed log %q", got)
+		}
+	})
+
+	t.Run("size cap", func(t *testing.T) {
+		dir, stateDir := setup(t)
+		// Other state counts towards the cap but is never removed
+		if err := os.WriteFile(filepath.Join(stateDir, "records.jsonl"), []byte(strings.Repeat("r", 500)), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := TidyLogs(dir, stateDir, false, 2500); err != nil {
+			t.Fatalf("TidyLogs failed: %v", err)
+		}
+		want := []string{
+			fmt.Sprintf("20261016-092000-running-%d.log", running),
+			"20261016-093000-killed-999999.raw.log",
+		}
+		if got := names(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+
+		// A running session's log is kept even over the cap
+		if err := TidyLogs(dir, stateDir, false, 1); err != nil {
+			t.Fatalf("TidyLogs failed: %v", err)
+		}
+		if got := names(t, dir); len(got) != 1 || got[0] != want[0] {
+			t.Errorf("expected only the running session's log, got %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(stateDir, "records.jsonl")); err != nil {
+			t.Errorf("expected other state to be kept, got %v", err)
+		}
+	})
+
+	t.Run("no log directory", func(t *testing.T) {
+		stateDir := t.TempDir()
+		if err := TidyLogs(filepath.Join(stateDir, "logs"), stateDir, true, 1); err != nil {
+			t.Errorf("expected nothing to do, got %v", err)
+		}
+	})
+}
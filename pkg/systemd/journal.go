@@ -0,0 +1,107 @@
+package systemd
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
+)
+
+// maxLogLine is the longest line written before it is split
+const maxLogLine = 4096
+
+// JournalWriter turns raw terminal output into plain log lines: escape
+// sequences are removed, lines redrawn with a carriage return keep only their
+// final text, and blank lines and immediate repeats, which full-screen
+// redraws produce, are dropped.
+type JournalWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	parser *ansi.Parser
+	// The line being collected, and the last line written
+	line []byte
+	last []byte
+	// A carriage return was seen; the line is cleared unless a newline follows
+	pendingCR bool
+	err       error
+}
+
+// NewJournalWriter creates a writer that logs complete lines to out
+func NewJournalWriter(out io.Writer) *JournalWriter {
+	return &JournalWriter{
+		out:    out,
+		parser: ansi.NewParser(),
+	}
+}
+
+// Write implements io.Writer. It only fails if writing to the log has failed.
+func (jw *JournalWriter) Write(p []byte) (int, error) {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+
+	jw.parser.Feed(p, (*journalHandler)(jw))
+	if jw.err != nil {
+		return 0, jw.err
+	}
+	return len(p), nil
+}
+
+// Flush writes any incomplete last line
+func (jw *JournalWriter) Flush() error {
+	jw.mu.Lock()
+	defer jw.mu.Unlock()
+
+	jw.pendingCR = false
+	(*journalHandler)(jw).emit()
+	return jw.err
+}
+
+// journalHandler receives the parsed output of a JournalWriter
+type journalHandler JournalWriter
+
+// Print implements ansi.Handler
+func (jw *journalHandler) Print(text []byte) {
+	if jw.pendingCR {
+		jw.pendingCR = false
+		jw.line = jw.line[:0]
+	}
+	for len(text) > 0 {
+		n := min(len(text), maxLogLine-len(jw.line))
+		jw.line = append(jw.line, text[:n]...)
+		text = text[n:]
+		if len(jw.line) >= maxLogLine {
+			jw.emit()
+		}
+	}
+}
+
+// Execute implements ansi.Handler
+func (jw *journalHandler) Execute(c byte) {
+	switch c {
+	case '\n':
+		jw.pendingCR = false
+		jw.emit()
+	case '\r':
+		jw.pendingCR = true
+	case '\t':
+		jw.Print([]byte{'\t'})
+	}
+}
+
+// Dispatch implements ansi.Handler
+func (jw *journalHandler) Dispatch(*ansi.Sequence) {}
+
+// emit writes the collected line unless it is blank or a repeat
+func (jw *journalHandler) emit() {
+	line := bytes.TrimRight(jw.line, " \t")
+	jw.line = jw.line[:0]
+	if len(line) == 0 || bytes.Equal(line, jw.last) || jw.err != nil {
+		return
+	}
+
+	jw.last = append(jw.last[:0], line...)
+	if _, err := jw.out.Write(append(line, '\n')); err != nil {
+		jw.err = err
+	}
+}
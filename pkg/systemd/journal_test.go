@@ -0,0 +1,52 @@
+package systemd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJournalWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"plain lines", []string{"one\ntwo\n"}, "one\ntwo\n"},
+		{"strips escape sequences", []string{"\x1b[1;32mgreen\x1b[0m\r\n"}, "green\n"},
+		{"keeps the last redraw", []string{"50%\r75%\r100%\n"}, "100%\n"},
+		{"carriage return split from newline", []string{"done\r", "\n"}, "done\n"},
+		{"drops blank lines and repeats", []string{"a\n\n   \na\nb\n"}, "a\nb\n"},
+		{"line split across writes", []string{"hel", "\x1b[", "0mlo\n"}, "hello\n"},
+		{"flushes the last line", []string{"no newline"}, "no newline\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			jw := NewJournalWriter(&out)
+			for _, w := range tt.writes {
+				if _, err := jw.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := jw.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestJournalWriterSplitsLongLines(t *testing.T) {
+	var out bytes.Buffer
+	jw := NewJournalWriter(&out)
+	_, _ = jw.Write(bytes.Repeat([]byte("x"), maxLogLine+10))
+	_ = jw.Flush()
+
+	lines := bytes.Split(bytes.TrimSuffix(out.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 || len(lines[0]) != maxLogLine || len(lines[1]) != 10 {
+		t.Errorf("expected a %d and a 10 character line, got %d lines", maxLogLine, len(lines))
+	}
+}
@@ -0,0 +1,74 @@
+// Package systemd supports running the wrapper as a systemd service: it
+// reports readiness and watchdog pings with the sd_notify protocol and turns
+// terminal output into plain lines for the journal.
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// States sent to the service manager
+const (
+	// Ready tells a Type=notify service that startup has finished
+	Ready = "READY=1"
+	// Stopping tells the service manager that the service is shutting down
+	Stopping = "STOPPING=1"
+	// Watchdog keeps a service with WatchdogSec= from being restarted
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends a state to the service manager through $NOTIFY_SOCKET. It
+// does nothing when not started by systemd.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// Names starting with @ are abstract sockets, which net handles itself
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// WatchdogInterval returns the watchdog timeout systemd set for this process,
+// or 0 if the watchdog is not enabled
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	// The watchdog may be meant for another process of the service
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog pings the watchdog at half its timeout, as systemd recommends,
+// until stop is closed. It returns at once if the watchdog is not enabled.
+func RunWatchdog(stop <-chan struct{}) {
+	interval := WatchdogInterval()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = Notify(Watchdog)
+		case <-stop:
+			return
+		}
+	}
+}
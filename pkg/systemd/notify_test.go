@@ -0,0 +1,68 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Run("sends state to the socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notify.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = conn.Close() }()
+		t.Setenv("NOTIFY_SOCKET", path)
+
+		if err := Notify(Ready); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+
+		buf := make([]byte, 64)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != "READY=1" {
+			t.Errorf("expected READY=1, got %q", got)
+		}
+	})
+
+	t.Run("does nothing outside systemd", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		if err := Notify(Ready); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{"not enabled", "", "", 0},
+		{"enabled", "30000000", "", 30 * time.Second},
+		{"enabled for us", "2000000", strconv.Itoa(os.Getpid()), 2 * time.Second},
+		{"enabled for another process", "2000000", "1", 0},
+		{"invalid", "soon", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			if got := WatchdogInterval(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package terminal
+
+import (
+	"sync"
+	"time"
+)
+
+// Flash holds a short-lived message, such as the confirmation of a hotkey,
+// for UI elements to show until it expires
+type Flash struct {
+	mu      sync.Mutex
+	text    string
+	expires time.Time
+	onShow  []func()
+}
+
+// NewFlash creates an empty flash message
+func NewFlash() *Flash {
+	return &Flash{}
+}
+
+// OnShow registers a function to run whenever a new message is shown, e.g.
+// to redraw immediately instead of on the next refresh
+func (f *Flash) OnShow(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onShow = append(f.onShow, fn)
+}
+
+// Show displays text for the given duration
+func (f *Flash) Show(text string, d time.Duration) {
+	f.mu.Lock()
+	f.text = text
+	f.expires = time.Now().Add(d)
+	hooks := f.onShow
+	f.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// Text returns the current message, or "" once it has expired
+func (f *Flash) Text() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Now().After(f.expires) {
+		return ""
+	}
+	return f.text
+}
@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ParseKey parses a key name such as "ctrl-\", "ctrl-a" or "s" into the
+// byte the terminal sends for it in raw mode
+func ParseKey(name string) (byte, error) {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "ctrl-") || strings.HasPrefix(lower, "c-") {
+		key := lower[strings.Index(lower, "-")+1:]
+		if len(key) != 1 {
+			return 0, fmt.Errorf("invalid key %q", name)
+		}
+		switch c := key[0]; {
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 1, nil
+		case c >= '[' && c <= '_':
+			// ctrl-[ (escape), ctrl-\, ctrl-], ctrl-^, ctrl-_
+			return c - '@', nil
+		case c == '@' || c == ' ':
+			return 0, nil
+		}
+		return 0, fmt.Errorf("invalid key %q", name)
+	}
+
+	if len(name) == 1 && name[0] >= 0x20 && name[0] < 0x7f {
+		return name[0], nil
+	}
+
+	return 0, fmt.Errorf("invalid key %q", name)
+}
+
+// KeyChords intercepts two-key chords on the input: a prefix key followed by
+// a bound command key, like tmux. Input that is not a bound chord is passed
+// through unchanged. A lone prefix key is held back until the next input
+// arrives, then delivered together with it.
+type KeyChords struct {
+	prefix byte
+
+	mu       sync.Mutex
+	bindings map[byte]func()
+	pending  bool
+}
+
+// NewKeyChords creates a chord interceptor for the given prefix key
+func NewKeyChords(prefix byte) *KeyChords {
+	return &KeyChords{
+		prefix:   prefix,
+		bindings: make(map[byte]func()),
+	}
+}
+
+// Bind runs action when the prefix key is followed by key
+func (kc *KeyChords) Bind(key byte, action func()) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	kc.bindings[key] = action
+}
+
+// FilterInput is an input filter that removes bound chords from the input
+// and runs their actions
+func (kc *KeyChords) FilterInput(data []byte) []byte {
+	kc.mu.Lock()
+
+	var actions []func()
+	result := make([]byte, 0, len(data)+1)
+
+	for _, b := range data {
+		if kc.pending {
+			kc.pending = false
+			if action, ok := kc.bindings[b]; ok {
+				actions = append(actions, action)
+				continue
+			}
+			// Not a chord: deliver the held prefix and the key as typed
+			result = append(result, kc.prefix, b)
+			continue
+		}
+
+		if b == kc.prefix {
+			kc.pending = true
+			continue
+		}
+		result = append(result, b)
+	}
+
+	kc.mu.Unlock()
+
+	// Run actions outside the lock; they may draw on the terminal
+	for _, action := range actions {
+		action()
+	}
+
+	return result
+}
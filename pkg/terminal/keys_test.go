@@ -0,0 +1,125 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    byte
+		wantErr bool
+	}{
+		{`ctrl-\`, 0x1c, false},
+		{"ctrl-a", 0x01, false},
+		{"C-g", 0x07, false},
+		{"ctrl-]", 0x1d, false},
+		{"s", 's', false},
+		{"ctrl-", 0, true},
+		{"ctrl-ab", 0, true},
+		{"enter", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKey(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKey(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKey(%q) = %#x, want %#x", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyChords(t *testing.T) {
+	const prefix = 0x1c
+
+	t.Run("bound chord runs action and is removed", func(t *testing.T) {
+		kc := NewKeyChords(prefix)
+		ran := 0
+		kc.Bind('s', func() { ran++ })
+
+		got := kc.FilterInput([]byte{'a', prefix, 's', 'b'})
+		if string(got) != "ab" {
+			t.Errorf("FilterInput() = %q, want %q", got, "ab")
+		}
+		if ran != 1 {
+			t.Errorf("action ran %d times, want 1", ran)
+		}
+	})
+
+	t.Run("chord split across reads", func(t *testing.T) {
+		kc := NewKeyChords(prefix)
+		ran := 0
+		kc.Bind('s', func() { ran++ })
+
+		if got := kc.FilterInput([]byte{prefix}); len(got) != 0 {
+			t.Errorf("prefix alone should be held back, got %q", got)
+		}
+		if got := kc.FilterInput([]byte{'s'}); len(got) != 0 {
+			t.Errorf("chord key should be consumed, got %q", got)
+		}
+		if ran != 1 {
+			t.Errorf("action ran %d times, want 1", ran)
+		}
+	})
+
+	t.Run("unbound key releases prefix", func(t *testing.T) {
+		kc := NewKeyChords(prefix)
+		kc.Bind('s', func() {})
+
+		kc.FilterInput([]byte{prefix})
+		got := kc.FilterInput([]byte{'x', 'y'})
+		if !bytes.Equal(got, []byte{prefix, 'x', 'y'}) {
+			t.Errorf("FilterInput() = %q, want prefix followed by input", got)
+		}
+	})
+}
+
+func TestOverlay(t *testing.T) {
+	buf := &lockedBuffer{}
+	output := NewOutput(buf)
+
+	var keys []byte
+	ov := NewOverlay(output, func() []string { return []string{"settings"} }, func(key byte) bool {
+		keys = append(keys, key)
+		return key == '\r'
+	})
+
+	if got := ov.FilterInput([]byte("hi")); string(got) != "hi" {
+		t.Errorf("closed overlay should pass input through, got %q", got)
+	}
+
+	ov.Open()
+	if !strings.Contains(buf.String(), "\033[?1049h") || !strings.Contains(buf.String(), "settings") {
+		t.Errorf("overlay not drawn on the alternate screen: %q", buf.String())
+	}
+
+	// Output from the process is held while the overlay is open
+	_, _ = output.Write([]byte("child output"))
+	if strings.Contains(buf.String(), "child output") {
+		t.Error("process output should be held while the overlay is open")
+	}
+
+	if got := ov.FilterInput([]byte("q")); len(got) != 0 {
+		t.Errorf("open overlay should consume input, got %q", got)
+	}
+	ov.FilterInput([]byte("\r"))
+	if ov.IsOpen() {
+		t.Fatal("overlay should close on Enter")
+	}
+	if string(keys) != "q\r" {
+		t.Errorf("overlay saw keys %q, want %q", keys, "q\r")
+	}
+
+	out := buf.String()
+	leave := strings.Index(out, "\033[?1049l")
+	held := strings.Index(out, "child output")
+	if leave < 0 || held < leave {
+		t.Errorf("held output should be written after leaving the alternate screen: %q", out)
+	}
+}
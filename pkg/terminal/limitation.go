@@ -0,0 +1,27 @@
+package terminal
+
+// Limitation describes a terminal the wrapper can't fully use
+type Limitation struct {
+	// Reason names what was detected, e.g. "TERM=dumb"
+	Reason string
+	// Headless is set when there is no terminal to put in raw mode or take
+	// the size of, as opposed to one that only shouldn't get escape
+	// sequences
+	Headless bool
+}
+
+// DetectLimitation checks for a terminal that shows escape sequences as
+// garbage or isn't there at all, e.g. under script or an IDE task runner:
+// stdin that is not a terminal, TERM=dumb, or NO_COLOR set. getenv looks up
+// environment variables.
+func DetectLimitation(getenv func(string) string, stdinIsTerminal bool) (Limitation, bool) {
+	switch {
+	case !stdinIsTerminal:
+		return Limitation{Reason: "stdin is not a terminal", Headless: true}, true
+	case getenv("TERM") == "dumb":
+		return Limitation{Reason: "TERM=dumb", Headless: true}, true
+	case getenv("NO_COLOR") != "":
+		return Limitation{Reason: "NO_COLOR is set"}, true
+	}
+	return Limitation{}, false
+}
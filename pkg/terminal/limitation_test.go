@@ -0,0 +1,27 @@
+package terminal
+
+import "testing"
+
+func TestDetectLimitation(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		terminal bool
+		expected Limitation
+		limited  bool
+	}{
+		{"full terminal", map[string]string{"TERM": "xterm-256color"}, true, Limitation{}, false},
+		{"no terminal", map[string]string{"TERM": "xterm-256color"}, false, Limitation{Reason: "stdin is not a terminal", Headless: true}, true},
+		{"dumb", map[string]string{"TERM": "dumb"}, true, Limitation{Reason: "TERM=dumb", Headless: true}, true},
+		{"no color", map[string]string{"TERM": "xterm", "NO_COLOR": "1"}, true, Limitation{Reason: "NO_COLOR is set"}, true},
+		{"empty no color", map[string]string{"TERM": "xterm", "NO_COLOR": ""}, true, Limitation{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limitation, limited := DetectLimitation(func(name string) string { return tt.env[name] }, tt.terminal)
+			if limitation != tt.expected || limited != tt.limited {
+				t.Errorf("expected %+v, %v; got %+v, %v", tt.expected, tt.limited, limitation, limited)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"io"
+	"sync"
+)
+
+// Output serializes writes to the real terminal between the wrapped
+// process's output and the wrapper's own UI elements, so that neither
+// interrupts the other halfway through an escape sequence
+type Output struct {
+	mu    sync.Mutex
+	out   io.Writer
+	hooks []func(w io.Writer)
+
+	// While held, the wrapped process's output is buffered instead of shown
+	held   bool
+	buffer []byte
+}
+
+// maxHeldOutput bounds the output buffered while the terminal is held
+const maxHeldOutput = 4 << 20
+
+// NewOutput creates a serialized terminal output
+func NewOutput(out io.Writer) *Output {
+	return &Output{
+		out: out,
+	}
+}
+
+// Write copies a chunk of the wrapped process's output to the terminal and
+// then runs the after-write hooks
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.held {
+		// Keep the newest output; the screen is redrawn by the child anyway
+		o.buffer = append(o.buffer, p...)
+		if len(o.buffer) > maxHeldOutput {
+			o.buffer = o.buffer[len(o.buffer)-maxHeldOutput:]
+		}
+		return len(p), nil
+	}
+
+	n, err := o.out.Write(p)
+	for _, hook := range o.hooks {
+		hook(o.out)
+	}
+	return n, err
+}
+
+// AfterWrite registers a function that runs after every chunk of output,
+// with exclusive access to the terminal
+func (o *Output) AfterWrite(hook func(w io.Writer)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.hooks = append(o.hooks, hook)
+}
+
+// Do runs fn with exclusive access to the terminal
+func (o *Output) Do(fn func(w io.Writer)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fn(o.out)
+}
+
+// Hold runs fn with exclusive access to the terminal and then buffers the
+// wrapped process's output until Release, e.g. while an overlay is shown
+func (o *Output) Hold(fn func(w io.Writer)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.held = true
+	fn(o.out)
+}
+
+// Release runs fn with exclusive access to the terminal and then writes the
+// output buffered since Hold
+func (o *Output) Release(fn func(w io.Writer)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	fn(o.out)
+	if !o.held {
+		return
+	}
+	o.held = false
+	if len(o.buffer) > 0 {
+		_, _ = o.out.Write(o.buffer)
+		o.buffer = nil
+		for _, hook := range o.hooks {
+			hook(o.out)
+		}
+	}
+}
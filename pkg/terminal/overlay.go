@@ -0,0 +1,120 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Overlay is a full-screen panel drawn on the terminal's alternate screen on
+// top of the wrapped process. While it is open the process's output is held
+// back and all keyboard input goes to the overlay; closing it returns to the
+// main screen exactly as the process left it.
+type Overlay struct {
+	output    *Output
+	render    func() []string
+	handleKey func(key byte) bool
+	onClose   func()
+
+	mu   sync.Mutex
+	open bool
+}
+
+// NewOverlay creates an overlay that draws the lines returned by render.
+// handleKey is called for every key pressed while the overlay is open and
+// returns true to close it.
+func NewOverlay(output *Output, render func() []string, handleKey func(key byte) bool) *Overlay {
+	return &Overlay{
+		output:    output,
+		render:    render,
+		handleKey: handleKey,
+	}
+}
+
+// OnClose registers a function to run after the overlay is closed, e.g. to
+// redraw other UI elements
+func (ov *Overlay) OnClose(fn func()) {
+	ov.onClose = fn
+}
+
+// Open shows the overlay
+func (ov *Overlay) Open() {
+	ov.mu.Lock()
+	if ov.open {
+		ov.mu.Unlock()
+		return
+	}
+	ov.open = true
+	ov.mu.Unlock()
+
+	ov.output.Hold(func(w io.Writer) {
+		// Switch to the alternate screen so the process's screen is preserved
+		_, _ = io.WriteString(w, "\033[?1049h")
+		ov.draw(w)
+	})
+}
+
+// Close hides the overlay and releases the output held while it was open
+func (ov *Overlay) Close() {
+	ov.mu.Lock()
+	if !ov.open {
+		ov.mu.Unlock()
+		return
+	}
+	ov.open = false
+	ov.mu.Unlock()
+
+	ov.output.Release(func(w io.Writer) {
+		_, _ = io.WriteString(w, "\033[?1049l")
+	})
+
+	if ov.onClose != nil {
+		ov.onClose()
+	}
+}
+
+// IsOpen returns whether the overlay is shown
+func (ov *Overlay) IsOpen() bool {
+	ov.mu.Lock()
+	defer ov.mu.Unlock()
+	return ov.open
+}
+
+// Redraw re-renders the overlay if it is open
+func (ov *Overlay) Redraw() {
+	if !ov.IsOpen() {
+		return
+	}
+	ov.output.Do(ov.draw)
+}
+
+// FilterInput is an input filter that hands every key to the overlay while
+// it is open, so none of it reaches the wrapped process
+func (ov *Overlay) FilterInput(data []byte) []byte {
+	if !ov.IsOpen() {
+		return data
+	}
+
+	for _, b := range data {
+		if ov.handleKey(b) {
+			// The rest of the chunk may be the tail of an escape sequence
+			// such as an arrow key, which the process should not see
+			ov.Close()
+			return nil
+		}
+	}
+	ov.Redraw()
+	return nil
+}
+
+// draw clears the screen and renders the overlay's lines
+func (ov *Overlay) draw(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	for _, line := range ov.render() {
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	_, _ = fmt.Fprint(w, b.String())
+}
@@ -0,0 +1,40 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QRCode renders text as a QR code drawn with block characters, two rows of
+// modules per line. The code is drawn black on white whatever the terminal's
+// colors, since phone cameras don't read inverted codes reliably.
+func QRCode(text string) (string, error) {
+	code, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	bitmap := code.Bitmap()
+
+	var b strings.Builder
+	for y := 0; y < len(bitmap); y += 2 {
+		b.WriteString("\033[30;47m")
+		for x := range bitmap[y] {
+			top := bitmap[y][x]
+			bottom := y+1 < len(bitmap) && bitmap[y+1][x]
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\033[0m\n")
+	}
+	return b.String(), nil
+}
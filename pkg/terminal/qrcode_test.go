@@ -0,0 +1,35 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/ansi"
+)
+
+func TestQRCode(t *testing.T) {
+	code, err := QRCode("https://ntfy.sh/gemini-0123456789abcdef01234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	width := utf8.RuneCountInString(string(ansi.Strip([]byte(lines[0]))))
+	// Two rows of modules per line, rounded up
+	if want := (width + 1) / 2; len(lines) != want {
+		t.Errorf("expected %d lines for a code %d modules wide, got %d", want, width, len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "\033[30;47m") || !strings.HasSuffix(line, "\033[0m") {
+			t.Errorf("line %d is not drawn black on white: %q", i, line)
+		}
+		if got := utf8.RuneCountInString(string(ansi.Strip([]byte(line)))); got != width {
+			t.Errorf("line %d is %d wide, expected %d", i, got, width)
+		}
+	}
+	// The quiet zone around the code is blank
+	if first := string(ansi.Strip([]byte(lines[0]))); strings.TrimSpace(first) != "" {
+		t.Errorf("expected a blank first line, got %q", first)
+	}
+}
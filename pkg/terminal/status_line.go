@@ -0,0 +1,211 @@
+// Package terminal provides local terminal UI elements drawn around the
+// wrapped process's output.
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/creack/pty"
+)
+
+// StatusLine draws a one-line status bar on the bottom row of the terminal.
+// The row is protected from the child's output with a scroll region, and the
+// child's PTY is sized one row shorter so it never draws there.
+type StatusLine struct {
+	output   *Output
+	sizeFrom *os.File
+	text     func() string
+	interval time.Duration
+
+	// Guarded by mu; always acquired after the output lock
+	mu      sync.Mutex
+	rows    int
+	cols    int
+	dirty   bool
+	active  bool
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewStatusLine creates a status line that draws on output, reads the
+// terminal size from sizeFrom and renders the text returned by text
+func NewStatusLine(output *Output, sizeFrom *os.File, text func() string) *StatusLine {
+	s := &StatusLine{
+		output:   output,
+		sizeFrom: sizeFrom,
+		text:     text,
+		interval: time.Second,
+	}
+	output.AfterWrite(s.afterWrite)
+	return s
+}
+
+// Start reserves the bottom row and begins refreshing the status line
+func (s *StatusLine) Start() error {
+	rows, cols, err := pty.Getsize(s.sizeFrom)
+	if err != nil {
+		return fmt.Errorf("failed to get terminal size: %w", err)
+	}
+	if rows < 3 {
+		return fmt.Errorf("terminal too small for status line (%d rows)", rows)
+	}
+
+	s.output.Do(func(w io.Writer) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.rows, s.cols = rows, cols
+		s.active = true
+		s.stop = make(chan struct{})
+		s.stopped = make(chan struct{})
+
+		// Make room for the bar: scroll if the cursor sits on the last row,
+		// then restrict scrolling to the rows above it
+		_, _ = fmt.Fprintf(w, "\n\0337\033[1;%dr\0338\033[1A", s.rows-1)
+		s.drawLocked(w)
+	})
+
+	go s.refresh()
+
+	return nil
+}
+
+// afterWrite redraws the status line after output that may have disturbed it
+func (s *StatusLine) afterWrite(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dirty && s.active {
+		s.applyScrollRegionLocked(w)
+		s.drawLocked(w)
+		s.dirty = false
+	}
+}
+
+// Invalidate marks the status line for redrawing after the next output
+// write, e.g. when a screen clear was seen in the output
+func (s *StatusLine) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirty = true
+}
+
+// Redraw immediately redraws the status line
+func (s *StatusLine) Redraw() {
+	s.output.Do(func(w io.Writer) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.active {
+			s.drawLocked(w)
+		}
+	})
+}
+
+// refresh redraws periodically and after terminal resizes
+func (s *StatusLine) refresh() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGWINCH)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Redraw()
+		case <-sigChan:
+			s.resize()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// resize re-reads the terminal size and re-reserves the bottom row
+func (s *StatusLine) resize() {
+	rows, cols, err := pty.Getsize(s.sizeFrom)
+	if err != nil || rows < 3 {
+		return
+	}
+
+	s.output.Do(func(w io.Writer) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !s.active {
+			return
+		}
+		s.rows, s.cols = rows, cols
+		s.applyScrollRegionLocked(w)
+		s.drawLocked(w)
+	})
+}
+
+// applyScrollRegionLocked restricts scrolling to the rows above the bar.
+// Caller must hold s.mu.
+func (s *StatusLine) applyScrollRegionLocked(w io.Writer) {
+	// DECSTBM homes the cursor, so save and restore around it
+	_, _ = fmt.Fprintf(w, "\0337\033[1;%dr\0338", s.rows-1)
+}
+
+// drawLocked renders the bar on the bottom row. Caller must hold s.mu.
+func (s *StatusLine) drawLocked(w io.Writer) {
+	text := ""
+	if s.text != nil {
+		text = truncate(" "+s.text(), s.cols)
+	}
+	padding := s.cols - utf8.RuneCountInString(text)
+	if padding < 0 {
+		padding = 0
+	}
+
+	// Save cursor, jump to the last row, draw in reverse video, restore
+	_, _ = fmt.Fprintf(w, "\0337\033[%d;1H\033[2K\033[7m%s%*s\033[0m\0338",
+		s.rows, text, padding, "")
+}
+
+// Close clears the status line and gives the whole terminal back
+func (s *StatusLine) Close() error {
+	var stopped chan struct{}
+
+	s.output.Do(func(w io.Writer) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !s.active {
+			return
+		}
+		s.active = false
+		close(s.stop)
+		stopped = s.stopped
+
+		// Reset the scroll region and clear the bar
+		_, _ = fmt.Fprintf(w, "\0337\033[r\033[%d;1H\033[2K\0338", s.rows)
+	})
+
+	if stopped != nil {
+		<-stopped
+	}
+	return nil
+}
+
+// truncate shortens s to at most width runes
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
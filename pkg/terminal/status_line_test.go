@@ -0,0 +1,91 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatusLine(t *testing.T) {
+	newActive := func(buf *bytes.Buffer) *StatusLine {
+		s := NewStatusLine(NewOutput(buf), nil, func() string { return "notify: on" })
+		s.rows, s.cols, s.active = 24, 20, true
+		return s
+	}
+
+	t.Run("passes output through", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := newActive(&buf)
+
+		if _, err := s.output.Write([]byte("hello")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if buf.String() != "hello" {
+			t.Errorf("expected plain passthrough, got %q", buf.String())
+		}
+	})
+
+	t.Run("redraws after invalidate", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := newActive(&buf)
+
+		s.Invalidate()
+		_, _ = s.output.Write([]byte("\033[2J"))
+
+		out := buf.String()
+		if !strings.HasPrefix(out, "\033[2J") {
+			t.Errorf("expected output before redraw, got %q", out)
+		}
+		if !strings.Contains(out, "\033[1;23r") {
+			t.Errorf("expected scroll region to be re-applied, got %q", out)
+		}
+		if !strings.Contains(out, "\033[24;1H") || !strings.Contains(out, "notify: on") {
+			t.Errorf("expected status drawn on last row, got %q", out)
+		}
+
+		buf.Reset()
+		_, _ = s.output.Write([]byte("x"))
+		if buf.String() != "x" {
+			t.Errorf("expected no redraw once clean, got %q", buf.String())
+		}
+	})
+
+	t.Run("close restores terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := newActive(&buf)
+		s.stop = make(chan struct{})
+		s.stopped = make(chan struct{})
+		close(s.stopped)
+
+		_ = s.Close()
+		if !strings.Contains(buf.String(), "\033[r") {
+			t.Errorf("expected scroll region reset, got %q", buf.String())
+		}
+
+		buf.Reset()
+		s.Invalidate()
+		_, _ = s.output.Write([]byte("x"))
+		if buf.String() != "x" {
+			t.Errorf("expected no drawing after close, got %q", buf.String())
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in       string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"much too long", 8, "much to…"},
+		{"ab", 1, "a"},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.width); got != tt.expected {
+			t.Errorf("truncate(%q, %d) = %q, expected %q", tt.in, tt.width, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TitleMarker prefixes the terminal title with a marker, e.g. while the
+// wrapped process is idle, so tab and window lists show which sessions are
+// waiting
+type TitleMarker struct {
+	output   *Output
+	title    func() string
+	marker   func() string
+	interval time.Duration
+
+	// Guarded by mu; always acquired after the output lock
+	mu          sync.Mutex
+	current     string
+	markedTitle string
+	stop        chan struct{}
+	stopped     chan struct{}
+}
+
+// NewTitleMarker creates a title marker. title returns the title most
+// recently set by the wrapped process and marker the prefix to show right
+// now, or "" for none.
+func NewTitleMarker(output *Output, title func() string, marker func() string) *TitleMarker {
+	tm := &TitleMarker{
+		output:   output,
+		title:    title,
+		marker:   marker,
+		interval: time.Second,
+	}
+	output.AfterWrite(tm.afterWrite)
+	return tm
+}
+
+// IdleMarker returns a marker function that shows marker once lastOutput
+// is at least threshold ago
+func IdleMarker(marker string, lastOutput func() time.Time, threshold time.Duration) func() string {
+	return func() string {
+		if time.Since(lastOutput()) >= threshold {
+			return marker
+		}
+		return ""
+	}
+}
+
+// Start begins updating the title
+func (tm *TitleMarker) Start() {
+	tm.mu.Lock()
+	if tm.stop != nil {
+		tm.mu.Unlock()
+		return
+	}
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	tm.stop, tm.stopped = stop, stopped
+	tm.mu.Unlock()
+
+	go tm.watch(stop, stopped)
+}
+
+// watch applies the marker as it changes
+func (tm *TitleMarker) watch(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(tm.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			tm.Update()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Update applies the current marker right away instead of on the next tick
+func (tm *TitleMarker) Update() {
+	marker := tm.marker()
+	tm.output.Do(func(w io.Writer) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		if marker == tm.current {
+			return
+		}
+		if marker == "" {
+			tm.unmarkLocked(w)
+		} else {
+			tm.markLocked(w, marker)
+		}
+	})
+}
+
+// afterWrite re-applies the marker if the process changed the title while marked
+func (tm *TitleMarker) afterWrite(w io.Writer) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.current != "" && tm.title() != tm.markedTitle {
+		tm.markLocked(w, tm.current)
+	}
+}
+
+// markLocked writes the marked title. Caller must hold tm.mu.
+func (tm *TitleMarker) markLocked(w io.Writer, marker string) {
+	tm.markedTitle = tm.title()
+	tm.current = marker
+	writeTitle(w, marker+tm.markedTitle)
+}
+
+// unmarkLocked restores the process's own title. Caller must hold tm.mu.
+func (tm *TitleMarker) unmarkLocked(w io.Writer) {
+	tm.current = ""
+	writeTitle(w, tm.title())
+}
+
+// IsMarked returns whether a marker is currently shown
+func (tm *TitleMarker) IsMarked() bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return tm.current != ""
+}
+
+// Close stops updating and restores the original title
+func (tm *TitleMarker) Close() error {
+	tm.mu.Lock()
+	stop, stopped := tm.stop, tm.stopped
+	tm.stop = nil
+	tm.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	<-stopped
+
+	tm.output.Do(func(w io.Writer) {
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		if tm.current != "" {
+			tm.unmarkLocked(w)
+		}
+	})
+	return nil
+}
+
+// writeTitle sets the terminal title with an OSC 0 sequence
+func writeTitle(w io.Writer, title string) {
+	// Control characters would terminate the sequence early
+	title = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, title)
+	_, _ = fmt.Fprintf(w, "\033]0;%s\007", title)
+}
@@ -0,0 +1,107 @@
+package terminal
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTitleMarker(t *testing.T) {
+	var mu sync.Mutex
+	title := "Gemini - project"
+	lastOutput := time.Now().Add(-time.Minute)
+	getTitle := func() string { mu.Lock(); defer mu.Unlock(); return title }
+	getLastOutput := func() time.Time { mu.Lock(); defer mu.Unlock(); return lastOutput }
+
+	buf := &lockedBuffer{}
+	output := NewOutput(buf)
+	tm := NewTitleMarker(output, getTitle, IdleMarker("⏳ ", getLastOutput, 30*time.Second))
+	tm.interval = 5 * time.Millisecond
+	tm.Start()
+
+	waitFor := func(cond func() bool, what string) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s (output %q)", what, buf.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitFor(tm.IsMarked, "marker")
+	if !bytes.Contains([]byte(buf.String()), []byte("\033]0;⏳ Gemini - project\007")) {
+		t.Errorf("expected marked title, got %q", buf.String())
+	}
+
+	// A title change from the process is re-marked after it is written
+	mu.Lock()
+	title = "New title"
+	mu.Unlock()
+	_, _ = output.Write([]byte("\033]0;New title\007"))
+	if !bytes.Contains([]byte(buf.String()), []byte("\033]0;⏳ New title\007")) {
+		t.Errorf("expected re-marked title, got %q", buf.String())
+	}
+
+	// Activity restores the plain title
+	mu.Lock()
+	lastOutput = time.Now().Add(time.Hour)
+	mu.Unlock()
+	waitFor(func() bool { return !tm.IsMarked() }, "unmark")
+
+	if err := tm.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestTitleMarkerChangesMarker(t *testing.T) {
+	var mu sync.Mutex
+	marker := "⚠ "
+	getMarker := func() string { mu.Lock(); defer mu.Unlock(); return marker }
+
+	buf := &lockedBuffer{}
+	tm := NewTitleMarker(NewOutput(buf), func() string { return "Gemini" }, getMarker)
+	tm.interval = 5 * time.Millisecond
+	tm.Start()
+	defer func() { _ = tm.Close() }()
+
+	waitForOutput := func(want string) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !bytes.Contains([]byte(buf.String()), []byte(want)) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q (output %q)", want, buf.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitForOutput("\033]0;⚠ Gemini\007")
+
+	// Switching markers rewrites the title without unmarking in between
+	mu.Lock()
+	marker = "⏳ "
+	mu.Unlock()
+	waitForOutput("\033]0;⏳ Gemini\007")
+	if bytes.Contains([]byte(buf.String()), []byte("\033]0;Gemini\007")) {
+		t.Errorf("expected no plain title between markers, got %q", buf.String())
+	}
+}
@@ -0,0 +1,187 @@
+// Package wrapper runs an AI CLI in a pseudo-terminal and reports when it
+// needs attention. It is the core of gemini-cli-ntfy for programs that want
+// to embed it, e.g. a custom supervisor:
+//
+//	code, err := wrapper.Wrap(ctx, "/usr/local/bin/gemini", wrapper.Options{
+//		Notifier: notification.NewNtfyClient("https://ntfy.sh", "my-topic"),
+//		OnEvent: func(e wrapper.Event) {
+//			log.Printf("%s %s", e.Type, e.Notification.Title)
+//		},
+//	})
+//
+// The wrapped process takes over the calling process's terminal until it exits.
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/monitor"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/notification"
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/process"
+)
+
+// EventType identifies what happened in a wrapped session
+type EventType string
+
+const (
+	// EventStart is sent once the process has started
+	EventStart EventType = "start"
+	// EventNotification is sent for every notification, e.g. the backstop
+	// notification after inactivity, before it is passed to the Notifier
+	EventNotification EventType = "notification"
+	// EventBell is sent when the process rings the terminal bell
+	EventBell EventType = "bell"
+	// EventScreenClear is sent when the process clears the screen
+	EventScreenClear EventType = "screen_clear"
+	// EventTitle is sent when the process changes the terminal title
+	EventTitle EventType = "title"
+	// EventInput is sent whenever input from the user reaches the process
+	EventInput EventType = "input"
+	// EventExit is sent once the process has exited
+	EventExit EventType = "exit"
+)
+
+// Event describes something that happened in a wrapped session
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Notification is set for EventNotification
+	Notification notification.Notification
+	// Title is set for EventTitle
+	Title string
+	// ExitCode is set for EventExit
+	ExitCode int
+	// Input is the kind of input for EventInput
+	Input notification.InputKind
+}
+
+// Options configures a wrapped session
+type Options struct {
+	// Args are passed to the command
+	Args []string
+	// Config tunes the session, e.g. the backstop timeout; only the settings
+	// used by the monitor and process manager apply. Defaults to
+	// config.DefaultConfig().
+	Config *config.Config
+	// Notifier receives the session's notifications. Without one they are
+	// only reported as events.
+	Notifier notification.Notifier
+	// OnEvent is called for every event, from the goroutine that observed it.
+	// It must not block.
+	OnEvent func(Event)
+	// Output receives the process's output (default os.Stdout)
+	Output io.Writer
+}
+
+// Wrap runs command in a pseudo-terminal attached to the calling process's
+// terminal and waits for it to exit, returning its exit code. Cancelling ctx
+// stops the process.
+func Wrap(ctx context.Context, command string, opts Options) (int, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	s := &session{onEvent: opts.OnEvent}
+
+	// Every notification is reported, then passed on to the caller's notifier
+	backstop := notification.NewBackstopNotifier(&eventNotifier{session: s, underlying: opts.Notifier}, cfg.BackstopTimeout)
+	defer func() { _ = backstop.Close() }()
+
+	outputMonitor := monitor.NewOutputMonitor(cfg, backstop)
+	outputMonitor.SetScreenEventHandler(&screenEvents{OutputMonitor: outputMonitor, session: s})
+	outputMonitor.SetBellHook(func() { s.emit(Event{Type: EventBell}) })
+
+	backstop.SetInteractionInputs(cfg.InteractionInputKinds())
+	inputHandler := func(kind notification.InputKind) {
+		outputMonitor.MarkInput()
+		backstop.HandleInput(kind)
+		s.emit(Event{Type: EventInput, Input: kind})
+	}
+
+	manager := process.NewManager(cfg, outputMonitor, inputHandler)
+	if opts.Output != nil {
+		manager.SetOutput(opts.Output)
+	}
+
+	if err := manager.Start(command, opts.Args); err != nil {
+		return -1, err
+	}
+	s.emit(Event{Type: EventStart})
+
+	// Stop the process if the caller gives up on it
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = manager.Stop()
+		case <-done:
+		}
+	}()
+
+	err := manager.Wait()
+	code := manager.ExitCode()
+	s.emit(Event{Type: EventExit, ExitCode: code})
+
+	// A non-zero exit is reported through the exit code
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && err == nil {
+		err = ctxErr
+	}
+	return code, err
+}
+
+// session delivers events to the caller
+type session struct {
+	onEvent func(Event)
+}
+
+// emit reports an event, stamping it with the current time
+func (s *session) emit(e Event) {
+	if s.onEvent == nil {
+		return
+	}
+	e.Time = time.Now()
+	s.onEvent(e)
+}
+
+// eventNotifier reports notifications as events before sending them
+type eventNotifier struct {
+	session    *session
+	underlying notification.Notifier
+}
+
+// Send implements the Notifier interface
+func (en *eventNotifier) Send(n notification.Notification) error {
+	en.session.emit(Event{Type: EventNotification, Notification: n})
+	if en.underlying == nil {
+		return nil
+	}
+	return en.underlying.Send(n)
+}
+
+// screenEvents reports screen events while the monitor handles them as usual
+type screenEvents struct {
+	*monitor.OutputMonitor
+	session *session
+}
+
+// HandleScreenClear implements ScreenEventHandler
+func (se *screenEvents) HandleScreenClear() {
+	se.OutputMonitor.HandleScreenClear()
+	se.session.emit(Event{Type: EventScreenClear})
+}
+
+// HandleTitleChange implements ScreenEventHandler
+func (se *screenEvents) HandleTitleChange(title string) {
+	se.OutputMonitor.HandleTitleChange(title)
+	se.session.emit(Event{Type: EventTitle, Title: title})
+}
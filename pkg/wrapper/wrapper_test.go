@@ -0,0 +1,96 @@
+package wrapper
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nakkulla/gemini-cli-ntfy/pkg/config"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWrap(t *testing.T) {
+	t.Setenv("GEMINI_CLI_NTFY_WRAPPED", "")
+
+	t.Run("reports events and exit code", func(t *testing.T) {
+		var mu sync.Mutex
+		var events []Event
+		output := &syncBuffer{}
+
+		cfg := config.DefaultConfig()
+		cfg.BackstopTimeout = 0
+		code, err := Wrap(context.Background(), "/bin/sh", Options{
+			Args:   []string{"-c", `printf '\033]0;Build\007hello\007\n'; exit 3`},
+			Config: cfg,
+			Output: output,
+			OnEvent: func(e Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, e)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Wrap failed: %v", err)
+		}
+		if code != 3 {
+			t.Errorf("expected exit code 3, got %d", code)
+		}
+		if !bytes.Contains([]byte(output.String()), []byte("hello")) {
+			t.Errorf("expected output to be relayed, got %q", output.String())
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		seen := map[EventType]Event{}
+		for _, e := range events {
+			seen[e.Type] = e
+		}
+		for _, typ := range []EventType{EventStart, EventTitle, EventBell, EventExit} {
+			if _, ok := seen[typ]; !ok {
+				t.Errorf("expected a %s event, got %v", typ, events)
+			}
+		}
+		if seen[EventTitle].Title != "Build" {
+			t.Errorf("expected title %q, got %q", "Build", seen[EventTitle].Title)
+		}
+		if events[len(events)-1].Type != EventExit || events[len(events)-1].ExitCode != 3 {
+			t.Errorf("expected exit event last with code 3, got %+v", events[len(events)-1])
+		}
+	})
+
+	t.Run("cancelling the context stops the process", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := Wrap(ctx, "/bin/sh", Options{
+			Args:   []string{"-c", "sleep 10"},
+			Output: &syncBuffer{},
+		})
+		if err != context.DeadlineExceeded {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("process was not stopped, took %v", elapsed)
+		}
+	})
+}